@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"net/http"
 	"os"
 	"time"
@@ -10,16 +12,83 @@ import (
 
 	appmiddleware "recipe-app/internal/appmiddleware"
 	"recipe-app/internal/handlers"
+	"recipe-app/internal/importer"
 	"recipe-app/internal/logger"
+	"recipe-app/internal/media"
+	"recipe-app/internal/storage"
 )
 
 func main() {
 	log := logger.New()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(log, os.Args[2:]); err != nil {
+			log.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	autoMigrate := flag.Bool("auto-migrate", os.Getenv("AUTO_MIGRATE") == "true", "apply pending database migrations before serving")
+	flag.Parse()
+
+	var store storage.Store
+	if connString := os.Getenv("DATABASE_URL"); connString != "" {
+		db, err := storage.NewDB(connString)
+		if err != nil {
+			log.Error("Database connection failed", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		if err := prepareSchema(context.Background(), log, db, *autoMigrate); err != nil {
+			log.Error("Database schema is not ready, run migrations or start with -auto-migrate", "error", err)
+			os.Exit(1)
+		}
+		store = db
+	} else {
+		log.Info("DATABASE_URL not set, running in demo mode with in-memory storage")
+		memory := storage.NewMemory()
+		if err := storage.SeedDemoRecipes(context.Background(), memory); err != nil {
+			log.Error("Failed to seed demo data", "error", err)
+			os.Exit(1)
+		}
+		store = memory
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "data/media"
+	}
+	blobs, err := media.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Error("Media storage is not available", "error", err)
+		os.Exit(1)
+	}
+
+	authService := appmiddleware.NewAuthService(os.Getenv("JWT_SECRET"))
+
+	apiHandler := handlers.NewAPIHandler(store)
+	apiHandler.SetCursorSecret(os.Getenv("JWT_SECRET"))
+	apiHandler.SetUserRepository(store)
+	apiHandler.SetBlobStore(blobs)
+	authHandler := handlers.NewAuthHandler(authService, store, store)
+	userHandler := handlers.NewUserHandler(store)
+	collectionHandler := handlers.NewCollectionHandler(store, store)
+	ratingHandler := handlers.NewRatingHandler(store, store)
+	nutritionHandler := handlers.NewNutritionHandler(store, store)
+	mediaHandler := handlers.NewMediaHandler(blobs, store)
+	importHandler := handlers.NewImportHandler(importer.NewHTTPFetcher(), store)
+	mealPlanHandler := handlers.NewMealPlanHandler(store, store)
+	shoppingListHandler := handlers.NewShoppingListHandler(store, store, store)
+	pantryHandler := handlers.NewPantryHandler(store)
+	catalogHandler := handlers.NewCatalogHandler(store)
+	recipePages := handlers.NewWebHandler()
+	recipePages.SetRecipeRepository(store)
+
 	r := chi.NewRouter()
 
 	rateLimiter := appmiddleware.NewRateLimiter(100, time.Minute)
-	authService := appmiddleware.NewAuthService(os.Getenv("JWT_SECRET"))
 
 	r.Use(chiMiddleware.RequestID)
 	r.Use(chiMiddleware.Recoverer)
@@ -33,34 +102,105 @@ func main() {
 
 	r.Route("/api", func(r chi.Router) {
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", handlers.NewAuthHandler(authService).HandleRegister)
-			r.Post("/login", handlers.NewAuthHandler(authService).HandleLogin)
-			r.Post("/refresh", handlers.NewAuthHandler(authService).HandleRefresh)
+			r.Post("/register", authHandler.HandleRegister)
+			r.Post("/login", authHandler.HandleLogin)
+			r.Post("/refresh", authHandler.HandleRefresh)
+			r.Post("/logout", authHandler.HandleLogout)
 		})
 
 		r.Route("/recipes", func(r chi.Router) {
-			r.With(authService.OptionalAuthMiddleware).Get("/", handlers.NewAPIHandler().HandleRecipes)
-			r.With(authService.AuthMiddleware).Post("/", handlers.NewAPIHandler().HandleCreateRecipe)
+			r.With(authService.OptionalAuthMiddleware).Get("/", apiHandler.HandleRecipes)
+			r.With(authService.AuthMiddleware).Post("/", apiHandler.HandleCreateRecipe)
+			r.With(authService.AuthMiddleware).Post("/import", importHandler.HandleImport)
+			r.With(authService.AuthMiddleware).Get("/export", importHandler.HandleExport)
 			r.Route("/{id}", func(r chi.Router) {
-				r.With(authService.OptionalAuthMiddleware).Get("/", handlers.NewAPIHandler().HandleRecipe)
-				r.With(authService.AuthMiddleware).Put("/", handlers.NewAPIHandler().HandleUpdateRecipe)
-				r.With(authService.AuthMiddleware).Delete("/", handlers.NewAPIHandler().HandleDeleteRecipe)
+				r.With(authService.OptionalAuthMiddleware).Get("/", apiHandler.HandleRecipe)
+				r.With(authService.AuthMiddleware).Put("/", apiHandler.HandleUpdateRecipe)
+				r.With(authService.AuthMiddleware).Delete("/", apiHandler.HandleDeleteRecipe)
+				r.With(authService.OptionalAuthMiddleware).Get("/ratings", ratingHandler.HandleListRatings)
+				r.With(authService.OptionalAuthMiddleware).Get("/ratings/{userID}", ratingHandler.HandleGetRating)
+				r.With(authService.AuthMiddleware).Put("/ratings/{userID}", ratingHandler.HandleUpsertRating)
+				r.With(authService.AuthMiddleware).Delete("/ratings/{userID}", ratingHandler.HandleDeleteRating)
+				r.Get("/nutrition", nutritionHandler.HandleGetNutrition)
+				r.With(authService.AuthMiddleware).Put("/nutrition", nutritionHandler.HandleSetNutrition)
+				r.With(authService.AuthMiddleware).Delete("/nutrition", nutritionHandler.HandleClearNutrition)
+				r.With(authService.AuthMiddleware).Post("/image", mediaHandler.HandleUploadRecipeImage)
+				r.With(authService.AuthMiddleware).Delete("/image", mediaHandler.HandleDeleteRecipeImage)
+				r.With(authService.AuthMiddleware).Post("/instructions/{position}/images", mediaHandler.HandleUploadInstructionImage)
+				r.With(authService.AuthMiddleware).Delete("/instructions/{position}/images/{n}", mediaHandler.HandleDeleteInstructionImage)
 			})
 		})
 
-		r.With(authService.AuthMiddleware).Get("/users/profile", handlers.NewUserHandler().HandleProfile)
-		r.With(authService.AuthMiddleware).Put("/users/profile", handlers.NewUserHandler().HandleUpdateProfile)
+		r.Route("/collections", func(r chi.Router) {
+			r.With(authService.OptionalAuthMiddleware).Get("/", collectionHandler.HandleListCollections)
+			r.With(authService.AuthMiddleware).Post("/", collectionHandler.HandleCreateCollection)
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(authService.OptionalAuthMiddleware).Get("/", collectionHandler.HandleCollection)
+				r.With(authService.AuthMiddleware).Put("/", collectionHandler.HandleUpdateCollection)
+				r.With(authService.AuthMiddleware).Delete("/", collectionHandler.HandleDeleteCollection)
+				r.With(authService.AuthMiddleware).Post("/recipes", collectionHandler.HandleAddCollectionRecipe)
+				r.With(authService.AuthMiddleware).Put("/recipes/{recipeID}", collectionHandler.HandleMoveCollectionRecipe)
+				r.With(authService.AuthMiddleware).Delete("/recipes/{recipeID}", collectionHandler.HandleRemoveCollectionRecipe)
+			})
+		})
+
+		r.Route("/meal-plan", func(r chi.Router) {
+			r.Use(authService.AuthMiddleware)
+			r.Get("/", mealPlanHandler.HandleGetMealPlan)
+			r.Post("/", mealPlanHandler.HandleCreateMealPlanEntry)
+			r.Delete("/", mealPlanHandler.HandleClearMealPlan)
+			r.Post("/copy", mealPlanHandler.HandleCopyMealPlan)
+			r.Put("/{id}", mealPlanHandler.HandleUpdateMealPlanEntry)
+			r.Delete("/{id}", mealPlanHandler.HandleDeleteMealPlanEntry)
+		})
+
+		r.Route("/shopping-lists", func(r chi.Router) {
+			r.Use(authService.AuthMiddleware)
+			r.Get("/", shoppingListHandler.HandleListShoppingLists)
+			r.Post("/", shoppingListHandler.HandleCreateShoppingList)
+			r.Get("/{id}", shoppingListHandler.HandleShoppingList)
+			r.Delete("/{id}", shoppingListHandler.HandleDeleteShoppingList)
+			r.Put("/{id}/items/{itemID}", shoppingListHandler.HandleCheckShoppingListItem)
+		})
+
+		r.Route("/pantry", func(r chi.Router) {
+			r.Use(authService.AuthMiddleware)
+			r.Get("/", pantryHandler.HandleListPantry)
+			r.Post("/", pantryHandler.HandleAddPantryItems)
+			r.Get("/recipes", apiHandler.HandlePantryRecipes)
+			r.Delete("/{name}", pantryHandler.HandleRemovePantryItem)
+		})
+
+		r.Route("/admin/ingredients", func(r chi.Router) {
+			r.Use(authService.AuthMiddleware)
+			r.Use(appmiddleware.RequireAdmin)
+			r.Get("/", catalogHandler.HandleListCatalog)
+			r.Post("/", catalogHandler.HandleCreateCatalogIngredient)
+			r.Get("/unmatched", catalogHandler.HandleUnmatchedIngredients)
+			r.Post("/relink", catalogHandler.HandleRelinkIngredients)
+			r.Put("/links/{ingredientID}", catalogHandler.HandleLinkIngredient)
+			r.Delete("/links/{ingredientID}", catalogHandler.HandleResetIngredientLink)
+			r.Get("/{id}", catalogHandler.HandleCatalogIngredient)
+			r.Put("/{id}", catalogHandler.HandleUpdateCatalogIngredient)
+			r.Delete("/{id}", catalogHandler.HandleDeleteCatalogIngredient)
+		})
+
+		r.With(authService.AuthMiddleware).Get("/users/profile", userHandler.HandleProfile)
+		r.With(authService.AuthMiddleware).Put("/users/profile", userHandler.HandleUpdateProfile)
 	})
 
 	r.Route("/recipes", func(r chi.Router) {
 		r.Get("/", handlers.NewWebHandler().HandleRecipes)
 		r.With(authService.AuthMiddleware).Get("/new", handlers.NewWebHandler().HandleNewRecipe)
-		r.Get("/{id}", handlers.NewWebHandler().HandleRecipeDetail)
+		r.Get("/{id}", recipePages.HandleRecipeDetail)
 	})
 
+	r.With(authService.AuthMiddleware).Get("/meal-plan", handlers.NewWebHandler().HandleMealPlan)
+
 	// Serve static files
 	fileServer := http.FileServer(http.Dir("web/static/"))
 	r.Handle("/static/*", http.StripPrefix("/static/", fileServer))
+	r.Get("/media/*", mediaHandler.HandleMedia)
 
 	log.Info("Server starting on :8080")
 	if err := http.ListenAndServe(":8080", r); err != nil {
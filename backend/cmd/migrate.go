@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"recipe-app/internal/logger"
+	"recipe-app/internal/storage"
+)
+
+// runMigrate implements `main migrate [up|down|status]`.
+func runMigrate(log *logger.Logger, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert with down")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main migrate [up|down|status] [-steps N]")
+		fs.PrintDefaults()
+	}
+
+	command := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	connString := os.Getenv("DATABASE_URL")
+	if connString == "" {
+		return fmt.Errorf("DATABASE_URL must be set to run migrations")
+	}
+
+	db, err := storage.NewDB(connString)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := storage.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Info("Schema is up to date")
+		}
+		return backfillNutrition(ctx, log, db)
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			log.Info("Reverted migration", "version", m.Version, "name", m.Name)
+		}
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("Migration status", "current", status.Current, "latest", status.Latest, "pending", len(status.Pending))
+		for _, m := range status.Pending {
+			log.Info("Pending migration", "version", m.Version, "name", m.Name)
+		}
+		return nil
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+// prepareSchema applies pending migrations when autoMigrate is set and
+// otherwise refuses to continue with an outdated schema.
+func prepareSchema(ctx context.Context, log *logger.Logger, db *storage.DB, autoMigrate bool) error {
+	migrator, err := storage.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	if !autoMigrate {
+		if err := migrator.CheckCurrent(ctx); err != nil {
+			return err
+		}
+		return backfillNutrition(ctx, log, db)
+	}
+
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		log.Info("Applied migration", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	return backfillNutrition(ctx, log, db)
+}
+
+// backfillNutrition stores computed nutrition facts for the recipes written
+// before they were computed, so that recipe reads never compute them.
+func backfillNutrition(ctx context.Context, log *logger.Logger, db *storage.DB) error {
+	n, err := db.BackfillNutrition(ctx)
+	if n > 0 {
+		log.Info("Computed nutrition for existing recipes", "recipes", n)
+	}
+	return err
+}
@@ -2,6 +2,10 @@ package appmiddleware
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"net/http"
 	"strings"
 	"time"
@@ -11,7 +15,7 @@ import (
 )
 
 type Claims struct {
-	UserID  int    `json:"user_id"`
+	UserID  string `json:"user_id"`
 	Email   string `json:"email"`
 	IsAdmin bool   `json:"is_admin"`
 	jwt.RegisteredClaims
@@ -31,7 +35,7 @@ func NewAuthService(secret string) *AuthService {
 	}
 }
 
-func (a *AuthService) GenerateToken(userID int, email string, isAdmin bool) (string, error) {
+func (a *AuthService) GenerateToken(userID string, email string, isAdmin bool) (string, error) {
 	claims := &Claims{
 		UserID:  userID,
 		Email:   email,
@@ -47,6 +51,23 @@ func (a *AuthService) GenerateToken(userID int, email string, isAdmin bool) (str
 	return token.SignedString(a.JWTSecret)
 }
 
+// NewRefreshToken returns a random opaque refresh token and the hash under
+// which it is stored. The token itself is only ever given to the client.
+func NewRefreshToken() (token string, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 of a refresh token.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func (a *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return a.JWTSecret, nil
@@ -119,8 +140,8 @@ func (a *AuthService) OptionalAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func GetUserID(ctx context.Context) (int, bool) {
-	userID, ok := ctx.Value(UserIDKey).(int)
+func GetUserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
 }
 
@@ -14,12 +14,15 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 	Code    string `json:"code,omitempty"`
+	// Field names the offending request field for validation errors.
+	Field string `json:"field,omitempty"`
 }
 
 type AppError struct {
 	StatusCode int
 	Message    string
 	Code       string
+	Field      string
 	Err        error
 }
 
@@ -39,6 +42,31 @@ func NewAppError(statusCode int, message, code string, err error) *AppError {
 	}
 }
 
+// NewValidationError returns a 400 AppError with code VALIDATION_ERROR
+// naming the invalid field.
+func NewValidationError(field, message string, err error) *AppError {
+	return &AppError{
+		StatusCode: http.StatusBadRequest,
+		Message:    message,
+		Code:       "VALIDATION_ERROR",
+		Field:      field,
+		Err:        err,
+	}
+}
+
+// WriteError writes err as a JSON ErrorResponse. ErrorHandler passes such
+// responses through untouched instead of replacing them with a generic body.
+func WriteError(w http.ResponseWriter, err *AppError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   http.StatusText(err.StatusCode),
+		Message: err.Message,
+		Code:    err.Code,
+		Field:   err.Field,
+	})
+}
+
 func ErrorHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
@@ -63,11 +91,14 @@ func ErrorHandler(next http.Handler) http.Handler {
 
 type errorResponseWriter struct {
 	http.ResponseWriter
-	r *http.Request
+	r           *http.Request
+	intercepted bool
 }
 
 func (erw *errorResponseWriter) WriteHeader(statusCode int) {
-	if statusCode >= 400 {
+	if statusCode >= 400 && erw.Header().Get("Content-Type") != "application/json" {
+		erw.intercepted = true
+
 		ctx := erw.r.Context()
 		logger.LogError(ctx, nil, "HTTP error response")
 
@@ -87,6 +118,14 @@ func (erw *errorResponseWriter) WriteHeader(statusCode int) {
 	erw.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Write drops the handler's own body once an error response has replaced it.
+func (erw *errorResponseWriter) Write(b []byte) (int, error) {
+	if erw.intercepted {
+		return len(b), nil
+	}
+	return erw.ResponseWriter.Write(b)
+}
+
 func getErrorMessage(statusCode int) string {
 	messages := map[int]string{
 		http.StatusBadRequest:          "Invalid request parameters",
@@ -94,6 +133,7 @@ func getErrorMessage(statusCode int) string {
 		http.StatusForbidden:           "Access forbidden",
 		http.StatusNotFound:            "Resource not found",
 		http.StatusMethodNotAllowed:    "Method not allowed",
+		http.StatusConflict:            "Resource already exists",
 		http.StatusTooManyRequests:     "Rate limit exceeded",
 		http.StatusInternalServerError: "Internal server error",
 		http.StatusBadGateway:          "Service unavailable",
@@ -113,6 +153,7 @@ func getErrorCode(statusCode int) string {
 		http.StatusForbidden:           "FORBIDDEN",
 		http.StatusNotFound:            "NOT_FOUND",
 		http.StatusMethodNotAllowed:    "METHOD_NOT_ALLOWED",
+		http.StatusConflict:            "CONFLICT",
 		http.StatusTooManyRequests:     "RATE_LIMIT_EXCEEDED",
 		http.StatusInternalServerError: "INTERNAL_ERROR",
 		http.StatusBadGateway:          "BAD_GATEWAY",
@@ -0,0 +1,67 @@
+package appmiddleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandler_ReplacesPlainErrors(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal details", http.StatusNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	ErrorHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Expected a single JSON body, got %q: %v", w.Body.String(), err)
+	}
+	if response.Code != "NOT_FOUND" {
+		t.Errorf("Expected code NOT_FOUND, got %s", response.Code)
+	}
+}
+
+func TestErrorHandler_PassesThroughAppErrors(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteError(w, NewAppError(http.StatusConflict, "Email is already registered", "EMAIL_TAKEN", nil))
+	})
+
+	w := httptest.NewRecorder()
+	ErrorHandler(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Code != "EMAIL_TAKEN" || response.Message != "Email is already registered" {
+		t.Errorf("Unexpected error response: %+v", response)
+	}
+}
+
+func TestWriteError_ValidationField(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteError(w, NewValidationError("max_cook_time", "max_cook_time must be a whole number", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Code != "VALIDATION_ERROR" || response.Field != "max_cook_time" {
+		t.Errorf("Unexpected error response: %+v", response)
+	}
+}
@@ -1,28 +1,70 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
 	"recipe-app/internal/logger"
+	"recipe-app/internal/media"
+	"recipe-app/internal/models"
+	"recipe-app/internal/nutrition"
+	"recipe-app/internal/schemaorg"
+	"recipe-app/internal/storage"
+	"recipe-app/internal/units"
 )
 
 type APIHandler struct {
 	templates *template.Template
+	recipes   storage.RecipeRepository
+	users     storage.UserRepository
+	blobs     media.BlobStore
+	cursors   *cursorSigner
 }
 
-func NewAPIHandler() *APIHandler {
-	templates, err := template.ParseFiles("web/templates/recipe-cards.html", "web/templates/recipe-detail-content.html")
+func NewAPIHandler(recipes storage.RecipeRepository) *APIHandler {
+	templates, err := template.ParseFiles(
+		"web/templates/recipe-cards.html",
+		"web/templates/recipe-facets.html",
+		"web/templates/recipe-detail-content.html",
+	)
 	if err != nil {
 		// Templates not found, create empty template for tests
 		templates = template.New("")
 	}
 	return &APIHandler{
 		templates: templates,
+		recipes:   recipes,
+		cursors:   newCursorSigner(""),
 	}
 }
 
+// SetCursorSecret derives the key that signs paging cursors from secret, so
+// that cursors stay valid across restarts and between server instances.
+func (h *APIHandler) SetCursorSecret(secret string) {
+	h.cursors = newCursorSigner(secret)
+}
+
+// SetUserRepository lets recipe reads honor the signed-in user's preferred
+// measuring units when no units parameter is given.
+func (h *APIHandler) SetUserRepository(users storage.UserRepository) {
+	h.users = users
+}
+
+// SetBlobStore lets recipe updates and deletion remove the uploaded images
+// a recipe no longer uses.
+func (h *APIHandler) SetBlobStore(blobs media.BlobStore) {
+	h.blobs = blobs
+}
+
 func (h *APIHandler) HandleRecipes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -36,6 +78,17 @@ func (h *APIHandler) HandleRecipes(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// HandlePantryRecipes lists the recipes the signed-in user can cook from
+// their pantry, ranked by how many ingredients are on hand. It takes the
+// same parameters as GET /api/recipes with pantry=true.
+func (h *APIHandler) HandlePantryRecipes(w http.ResponseWriter, r *http.Request) {
+	r = r.Clone(r.Context())
+	values := r.URL.Query()
+	values.Set("pantry", "true")
+	r.URL.RawQuery = values.Encode()
+	h.getRecipes(w, r, r.Context())
+}
+
 func (h *APIHandler) HandleRecipe(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -63,14 +116,57 @@ func (h *APIHandler) HandleDeleteRecipe(w http.ResponseWriter, r *http.Request)
 	h.deleteRecipe(w, r, r.Context())
 }
 
-func (h *APIHandler) getRecipes(w http.ResponseWriter, r *http.Request, ctx interface{}) {
-	recipes := []map[string]interface{}{
-		{"id": "1", "title": "Spaghetti Bolognese", "description": "Classic Italian pasta dish with rich meat sauce", "cook_time": 30, "difficulty": "medium"},
-		{"id": "2", "title": "Chicken Curry", "description": "Spicy and aromatic Indian curry with tender chicken", "cook_time": 45, "difficulty": "hard"},
-		{"id": "3", "title": "Caesar Salad", "description": "Fresh romaine lettuce with creamy Caesar dressing", "cook_time": 15, "difficulty": "easy"},
-		{"id": "4", "title": "Beef Tacos", "description": "Mexican-style tacos with seasoned ground beef", "cook_time": 25, "difficulty": "medium"},
-		{"id": "5", "title": "Chocolate Cake", "description": "Rich and moist chocolate cake with fudge frosting", "cook_time": 60, "difficulty": "hard"},
-		{"id": "6", "title": "Greek Salad", "description": "Mediterranean salad with feta cheese and olives", "cook_time": 10, "difficulty": "easy"},
+func (h *APIHandler) getRecipes(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	query, err := bindRecipeQuery(r)
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	user := h.signedInUser(r)
+	if err := applyDietaryPreferences(r, user, &query.Filter); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if query.Cursor != "" {
+		var ok bool
+		if query.Cursor, ok = h.cursors.verify(query.Cursor); !ok {
+			writeFieldError(w, errInvalidCursor)
+			return
+		}
+	}
+	withFacets, err := boolParam(r.URL.Query(), "facets")
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	system, err := unitSystem(r, user)
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	result, err := h.recipes.ListRecipes(ctx, query)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		writeFieldError(w, errInvalidCursor)
+		return
+	}
+	if err != nil {
+		h.handleStorageError(w, r, err, "Failed to list recipes")
+		return
+	}
+	result.NextCursor = h.cursors.sign(result.NextCursor)
+	result.PrevCursor = h.cursors.sign(result.PrevCursor)
+	if system != "" {
+		for i := range result.Recipes {
+			result.Recipes[i] = *result.Recipes[i].ConvertUnits(system, nutrition.GramsPerML)
+		}
+	}
+	setLinkHeader(w, r, result.NextCursor, result.PrevCursor)
+	if withFacets {
+		if result.Facets, err = h.recipes.RecipeFacets(ctx, query); err != nil {
+			h.handleStorageError(w, r, err, "Failed to count facets")
+			return
+		}
 	}
 
 	// Check if this is an HTMX request
@@ -78,8 +174,16 @@ func (h *APIHandler) getRecipes(w http.ResponseWriter, r *http.Request, ctx inte
 		w.Header().Set("Content-Type", "text/html")
 		tmpl := h.templates.Lookup("recipe-cards.html")
 		if tmpl != nil {
-			data := map[string]interface{}{"recipes": recipes}
+			data := map[string]interface{}{
+				"recipes":    result.Recipes,
+				"highlights": result.Highlights,
+				"pantry":     result.Pantry,
+				"query":      query.Search,
+			}
 			err := tmpl.Execute(w, data)
+			if err == nil && result.Facets != nil {
+				err = h.renderFacets(w, query.Filter, result.Facets)
+			}
 			if err != nil {
 				http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
 			}
@@ -93,45 +197,102 @@ func (h *APIHandler) getRecipes(w http.ResponseWriter, r *http.Request, ctx inte
 
 	// Default JSON response
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(recipes)
+	json.NewEncoder(w).Encode(result)
 }
 
-func (h *APIHandler) createRecipe(w http.ResponseWriter, r *http.Request, ctx interface{}) {
-	logger.FromContext(r.Context()).Info("Creating new recipe")
+// renderFacets writes the filter sidebar with live counts as an
+// out-of-band HTMX swap, marking the active filter values.
+func (h *APIHandler) renderFacets(w http.ResponseWriter, filter models.RecipeFilter, facets *models.SearchFacets) error {
+	tmpl := h.templates.Lookup("recipe-facets.html")
+	if tmpl == nil {
+		return nil
+	}
+
+	selectedTags := make(map[string]bool, len(filter.Tags))
+	for _, tag := range filter.Tags {
+		selectedTags[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+	cookTime := ""
+	if filter.MaxCookTime > 0 {
+		cookTime = strconv.Itoa(filter.MaxCookTime)
+	}
+
+	return tmpl.Execute(w, map[string]interface{}{
+		"facets":       facets,
+		"filter":       filter,
+		"selectedTags": selectedTags,
+		"cookTime":     cookTime,
+	})
+}
+
+func (h *APIHandler) createRecipe(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	logger.FromContext(ctx).Info("Creating new recipe")
+
+	var recipe models.Recipe
+	if err := json.NewDecoder(r.Body).Decode(&recipe); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	recipe.NormalizeUnits()
+	if err := validateRecipe(&recipe); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	recipe.AuthorID = userID
+
+	if err := h.recipes.CreateRecipe(ctx, &recipe); err != nil {
+		h.handleStorageError(w, r, err, "Failed to create recipe")
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Recipe created successfully",
-		"id":      "3",
+		"id":      recipe.ID,
 	})
 }
 
-func (h *APIHandler) getRecipe(w http.ResponseWriter, r *http.Request, ctx interface{}) {
-	recipe := map[string]interface{}{
-		"id":          "1",
-		"title":       "Spaghetti Bolognese",
-		"description": "Classic Italian pasta dish with rich meat sauce",
-		"prep_time":   30,
-		"cook_time":   45,
-		"servings":    4,
-		"difficulty":  "medium",
-		"ingredients": []map[string]interface{}{
-			{"name": "spaghetti", "quantity": "400g", "unit": "grams"},
-			{"name": "ground beef", "quantity": "500g", "unit": "grams"},
-			{"name": "tomato sauce", "quantity": "800ml", "unit": "milliliters"},
-			{"name": "onion", "quantity": "1", "unit": "large"},
-			{"name": "garlic", "quantity": "3", "unit": "cloves"},
-			{"name": "olive oil", "quantity": "2", "unit": "tablespoons"},
-		},
-		"instructions": []string{
-			"Bring a large pot of salted water to boil and cook spaghetti according to package directions.",
-			"Heat olive oil in a large pan over medium heat. Add chopped onion and cook until translucent.",
-			"Add minced garlic and cook for another minute until fragrant.",
-			"Add ground beef and cook until browned, breaking it up with a wooden spoon.",
-			"Pour in tomato sauce and simmer for 15-20 minutes, stirring occasionally.",
-			"Season with salt, pepper, and Italian herbs to taste.",
-			"Drain pasta and toss with the bolognese sauce. Serve hot with grated Parmesan cheese.",
-		},
+func (h *APIHandler) getRecipe(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	// servings optionally scales the ingredients for display.
+	servings, err := intParam(r.URL.Query(), "servings")
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if servings < 0 || servings > models.MaxScaledServings || (servings == 0 && r.URL.Query().Get("servings") != "") {
+		writeFieldError(w, &models.FieldError{Field: "servings", Message: fmt.Sprintf("servings must be between 1 and %d", models.MaxScaledServings)})
+		return
+	}
+	system, err := unitSystem(r, h.signedInUser(r))
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	recipe, err := h.recipes.GetRecipe(ctx, chi.URLParam(r, "id"))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.handleStorageError(w, r, err, "Failed to load recipe")
+		return
+	}
+	if recipe != nil && servings > 0 {
+		recipe = recipe.ScaleTo(servings)
+	}
+	if recipe != nil && system != "" {
+		recipe = recipe.ConvertUnits(system, nutrition.GramsPerML)
+	}
+	w.Header().Add("Vary", "Accept")
+
+	if recipe == nil {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
 	}
 
 	// Check if this is an HTMX request
@@ -139,19 +300,53 @@ func (h *APIHandler) getRecipe(w http.ResponseWriter, r *http.Request, ctx inter
 		w.Header().Set("Content-Type", "text/html")
 		tmpl := h.templates.Lookup("recipe-detail-content.html")
 		if tmpl != nil {
-			data := map[string]interface{}{"recipe": recipe}
-			tmpl.Execute(w, data)
+			data := map[string]interface{}{"recipe": recipe, "units": system}
+			if err := tmpl.Execute(w, data); err != nil {
+				http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
+			}
 			return
 		}
 	}
 
+	if wantsJSONLD(r) {
+		w.Header().Set("Content-Type", schemaorg.ContentType)
+		json.NewEncoder(w).Encode(schemaorg.FromRecipe(recipe, requestBaseURL(r)))
+		return
+	}
+
 	// Default JSON response
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(recipe)
 }
 
-func (h *APIHandler) updateRecipe(w http.ResponseWriter, r *http.Request, ctx interface{}) {
-	logger.FromContext(r.Context()).Info("Updating recipe")
+func (h *APIHandler) updateRecipe(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	logger.FromContext(ctx).Info("Updating recipe")
+
+	var recipe models.Recipe
+	if err := json.NewDecoder(r.Body).Decode(&recipe); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	recipe.ID = chi.URLParam(r, "id")
+
+	previous, ok := authorizeRecipeChange(w, r, h.recipes, recipe.ID, "Only the recipe's author or an admin can change it")
+	if !ok {
+		return
+	}
+
+	recipe.NormalizeUnits()
+	if err := validateRecipe(&recipe); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.recipes.UpdateRecipe(ctx, &recipe); err != nil {
+		h.handleStorageError(w, r, err, "Failed to update recipe")
+		return
+	}
+	if h.blobs != nil {
+		deleteOrphanedImages(ctx, h.blobs, previous, &recipe)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -159,11 +354,145 @@ func (h *APIHandler) updateRecipe(w http.ResponseWriter, r *http.Request, ctx in
 	})
 }
 
-func (h *APIHandler) deleteRecipe(w http.ResponseWriter, r *http.Request, ctx interface{}) {
-	logger.FromContext(r.Context()).Info("Deleting recipe")
+func (h *APIHandler) deleteRecipe(w http.ResponseWriter, r *http.Request, ctx context.Context) {
+	logger.FromContext(ctx).Info("Deleting recipe")
+
+	id := chi.URLParam(r, "id")
+	if _, ok := authorizeRecipeChange(w, r, h.recipes, id, "Only the recipe's author or an admin can change it"); !ok {
+		return
+	}
+
+	if err := h.recipes.DeleteRecipe(ctx, id); err != nil {
+		h.handleStorageError(w, r, err, "Failed to delete recipe")
+		return
+	}
+	if h.blobs != nil {
+		deleteMedia(ctx, h.blobs, recipeMediaPrefix(id))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"message": "Recipe deleted successfully",
 	})
 }
+
+// authorizeRecipeChange loads the recipe and checks that the authenticated
+// user may change it, writing the error response, with forbidden as the
+// message when they may not, and returning false otherwise. Every handler
+// that changes a recipe or what belongs to it goes through it.
+func authorizeRecipeChange(w http.ResponseWriter, r *http.Request, recipes storage.RecipeRepository, id, forbidden string) (*models.Recipe, bool) {
+	ctx := r.Context()
+
+	claims, ok := appmiddleware.GetUserClaims(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	recipe, err := recipes.GetRecipe(ctx, id)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		logger.LogError(ctx, err, "Failed to load recipe")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !recipe.CanBeModifiedBy(claims.UserID, claims.IsAdmin) {
+		logger.FromContext(ctx).Warn("Recipe change forbidden", "recipe_id", id, "user_id", claims.UserID)
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusForbidden, forbidden, "FORBIDDEN", nil))
+		return nil, false
+	}
+	return recipe, true
+}
+
+// signedInUser loads the signed-in user for their preferences, returning
+// nil for anonymous requests. Reads fall back to no preferences rather than
+// failing when the user cannot be loaded.
+func (h *APIHandler) signedInUser(r *http.Request) *models.User {
+	userID, ok := appmiddleware.GetUserID(r.Context())
+	if !ok || h.users == nil {
+		return nil
+	}
+	user, err := h.users.GetUserByID(r.Context(), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Warn("Failed to load user preferences", "user_id", userID, "error", err)
+		return nil
+	}
+	return user
+}
+
+// unitSystem returns the measuring system recipes should be shown in: the
+// units query parameter, else the preference of user, the signed-in user
+// or nil, else "" to show them as written.
+func unitSystem(r *http.Request, user *models.User) (string, error) {
+	if system := strings.TrimSpace(r.URL.Query().Get("units")); system != "" {
+		if !units.ValidSystem(system) {
+			return "", &models.FieldError{Field: "units", Message: "units must be metric or imperial"}
+		}
+		return system, nil
+	}
+	if user == nil {
+		return "", nil
+	}
+	return user.PreferredUnits, nil
+}
+
+// applyDietaryPreferences fills in the diets user, the signed-in user or
+// nil, follows and the allergens they avoid when the request sets no
+// dietary criteria of its own and does not pass preferences=false.
+func applyDietaryPreferences(r *http.Request, user *models.User, filter *models.RecipeFilter) error {
+	use := true
+	if r.URL.Query().Get("preferences") != "" {
+		var err error
+		if use, err = boolParam(r.URL.Query(), "preferences"); err != nil {
+			return err
+		}
+	}
+	if !use || user == nil || len(filter.Diets)+len(filter.ExcludeDiets)+len(filter.Allergens)+len(filter.ExcludeAllergens) > 0 {
+		return nil
+	}
+	filter.Diets = user.Diets
+	filter.ExcludeAllergens = user.AvoidAllergens
+	return nil
+}
+
+func (h *APIHandler) handleStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+
+	logger.LogError(r.Context(), err, msg)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+// writeFieldError reports a *models.FieldError as a structured 400 naming
+// the field, and any other error as a plain 400.
+func writeFieldError(w http.ResponseWriter, err error) {
+	var fieldErr *models.FieldError
+	if errors.As(err, &fieldErr) {
+		appmiddleware.WriteError(w, appmiddleware.NewValidationError(fieldErr.Field, fieldErr.Message, err))
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+func validateRecipe(recipe *models.Recipe) error {
+	if err := recipe.Validate(); err != nil {
+		return err
+	}
+	for i := range recipe.Ingredients {
+		if err := recipe.Ingredients[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for i := range recipe.Instructions {
+		if err := recipe.Instructions[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,45 +3,69 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
 )
 
+// newTestAPIHandler returns a handler backed by an in-memory store seeded
+// with the demo recipes, newest first in the returned slice.
+func newTestAPIHandler(t *testing.T) (*APIHandler, []models.Recipe) {
+	t.Helper()
+
+	store := storage.NewMemory()
+	if err := storage.SeedDemoRecipes(context.Background(), store); err != nil {
+		t.Fatalf("failed to seed recipes: %v", err)
+	}
+	result, err := store.ListRecipes(context.Background(), storage.RecipeQuery{})
+	if err != nil {
+		t.Fatalf("failed to list seeded recipes: %v", err)
+	}
+	return NewAPIHandler(store), result.Recipes
+}
+
+func withRecipeID(req *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
 func TestAPIHandler_GetRecipes(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, seeded := newTestAPIHandler(t)
 
 	tests := []struct {
 		name           string
 		method         string
+		body           string
 		expectedStatus int
-		expectedBody   []map[string]interface{}
+		expectedBody   []models.Recipe
 	}{
 		{
 			name:           "GET recipes returns 200",
 			method:         http.MethodGet,
 			expectedStatus: http.StatusOK,
-			expectedBody: []map[string]interface{}{
-				{"id": "1", "title": "Spaghetti Bolognese", "description": "Classic Italian pasta dish with rich meat sauce", "cook_time": 30, "difficulty": "medium"},
-				{"id": "2", "title": "Chicken Curry", "description": "Spicy and aromatic Indian curry with tender chicken", "cook_time": 45, "difficulty": "hard"},
-				{"id": "3", "title": "Caesar Salad", "description": "Fresh romaine lettuce with creamy Caesar dressing", "cook_time": 15, "difficulty": "easy"},
-				{"id": "4", "title": "Beef Tacos", "description": "Mexican-style tacos with seasoned ground beef", "cook_time": 25, "difficulty": "medium"},
-				{"id": "5", "title": "Chocolate Cake", "description": "Rich and moist chocolate cake with fudge frosting", "cook_time": 60, "difficulty": "hard"},
-				{"id": "6", "title": "Greek Salad", "description": "Mediterranean salad with feta cheese and olives", "cook_time": 10, "difficulty": "easy"},
-			},
+			expectedBody:   seeded,
 		},
 		{
-			name:           "POST to recipes endpoint handled by getRecipes method",
+			name:           "POST to recipes endpoint creates a recipe",
 			method:         http.MethodPost,
-			expectedStatus: http.StatusOK,
+			body:           `{"title": "Pancakes"}`,
+			expectedStatus: http.StatusCreated,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(tt.method, "/api/recipes", nil)
+			req := httptest.NewRequest(tt.method, "/api/recipes", strings.NewReader(tt.body))
+			req = withUser(req, "cook", false)
 			w := httptest.NewRecorder()
 
 			handler.HandleRecipes(w, req)
@@ -51,22 +75,25 @@ func TestAPIHandler_GetRecipes(t *testing.T) {
 			}
 
 			if tt.method == http.MethodGet {
-				var response []map[string]interface{}
+				var response models.SearchResult
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				if err != nil {
 					t.Errorf("Failed to unmarshal response: %v", err)
 				}
 
-				if len(response) != len(tt.expectedBody) {
-					t.Errorf("Expected %d recipes, got %d", len(tt.expectedBody), len(response))
+				if len(response.Recipes) != len(tt.expectedBody) || response.Total != len(tt.expectedBody) {
+					t.Errorf("Expected %d recipes, got %d (total %d)", len(tt.expectedBody), len(response.Recipes), response.Total)
+				}
+				if response.Page != 1 || response.PerPage != storage.DefaultPerPage {
+					t.Errorf("Expected page 1 of %d, got page %d of %d", storage.DefaultPerPage, response.Page, response.PerPage)
 				}
 
 				for i, expected := range tt.expectedBody {
-					if response[i]["id"] != expected["id"] {
-						t.Errorf("Expected recipe ID %s, got %s", expected["id"], response[i]["id"])
+					if response.Recipes[i].ID != expected.ID {
+						t.Errorf("Expected recipe ID %s, got %s", expected.ID, response.Recipes[i].ID)
 					}
-					if response[i]["title"] != expected["title"] {
-						t.Errorf("Expected recipe title %s, got %s", expected["title"], response[i]["title"])
+					if response.Recipes[i].Title != expected.Title {
+						t.Errorf("Expected recipe title %s, got %s", expected.Title, response.Recipes[i].Title)
 					}
 				}
 			}
@@ -74,16 +101,321 @@ func TestAPIHandler_GetRecipes(t *testing.T) {
 	}
 }
 
+func TestAPIHandler_SearchRecipes(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes?q=salad+veg&per_page=1", nil)
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response models.SearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Total != 2 || len(response.Recipes) != 1 || response.PerPage != 1 {
+		t.Fatalf("Expected 1 of 2 salads, got %d of %d", len(response.Recipes), response.Total)
+	}
+	hl, ok := response.Highlights[response.Recipes[0].ID]
+	if !ok || !strings.Contains(string(hl.Title), "<mark>Salad</mark>") {
+		t.Errorf("Expected a highlighted title, got %+v", response.Highlights)
+	}
+}
+
+func TestAPIHandler_RecipeCursors(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+
+	get := func(rawQuery string) (*httptest.ResponseRecorder, models.SearchResult) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.HandleRecipes(w, httptest.NewRequest(http.MethodGet, "/api/recipes?"+rawQuery, nil))
+		var response models.SearchResult
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w, response
+	}
+
+	w, first := get("limit=4")
+	if len(first.Recipes) != 4 || first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("Expected 4 recipes and only a next cursor, got %d, next %q, prev %q", len(first.Recipes), first.NextCursor, first.PrevCursor)
+	}
+	link := w.Header().Get("Link")
+	if !strings.Contains(link, `rel="next"`) || !strings.Contains(link, "cursor=") || strings.Contains(link, `rel="prev"`) {
+		t.Errorf("Expected a next link, got %q", link)
+	}
+
+	w, second := get("limit=4&cursor=" + first.NextCursor)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(second.Recipes) != 2 || second.Recipes[1].ID != seeded[len(seeded)-1].ID || second.NextCursor != "" || second.PrevCursor == "" {
+		t.Errorf("Expected the last 2 recipes with a prev cursor, got %d, next %q, prev %q", len(second.Recipes), second.NextCursor, second.PrevCursor)
+	}
+	if !strings.Contains(w.Header().Get("Link"), `rel="prev"`) {
+		t.Errorf("Expected a prev link, got %q", w.Header().Get("Link"))
+	}
+
+	_, back := get("limit=4&cursor=" + second.PrevCursor)
+	if len(back.Recipes) != 4 || back.Recipes[0].ID != first.Recipes[0].ID {
+		t.Errorf("Expected the prev cursor to return the first page, got %d recipes", len(back.Recipes))
+	}
+
+	tampered := strings.Replace(first.NextCursor, ".", "x.", 1)
+	for _, rawQuery := range []string{"cursor=" + tampered, "sort=title&cursor=" + first.NextCursor} {
+		w, _ := get(rawQuery)
+		var response appmiddleware.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if w.Code != http.StatusBadRequest || response.Field != "cursor" {
+			t.Errorf("%s: expected a cursor validation error, got %d %s", rawQuery, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAPIHandler_FilterRecipes(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		expected []string
+	}{
+		{"Difficulty and cook time", "difficulty=medium&cook_time=30", []string{"Beef Tacos", "Spaghetti Bolognese"}},
+		{"All tags", "tag=vegetarian&tag=quick&sort=title", []string{"Caesar Salad", "Greek Salad"}},
+		{"Any tag", "tags=spicy,baking&tag_match=any&sort=title", []string{"Chicken Curry", "Chocolate Cake"}},
+		{"Search with filter", "q=salad&cuisine=greek", []string{"Greek Salad"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/recipes?"+tt.rawQuery, nil)
+			w := httptest.NewRecorder()
+			handler.HandleRecipes(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var response models.SearchResult
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			var titles []string
+			for _, recipe := range response.Recipes {
+				titles = append(titles, recipe.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, titles)
+			}
+		})
+	}
+}
+
+func TestAPIHandler_FilterRecipesInvalid(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes?max_servings=lots", nil)
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	var response appmiddleware.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Code != "VALIDATION_ERROR" || response.Field != "max_servings" {
+		t.Errorf("Unexpected error response: %+v", response)
+	}
+}
+
+// countingUsers counts the users loaded through it.
+type countingUsers struct {
+	storage.UserRepository
+	loads int
+}
+
+func (u *countingUsers) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	u.loads++
+	return u.UserRepository.GetUserByID(ctx, id)
+}
+
+func TestAPIHandler_DietaryPreferences(t *testing.T) {
+	store := storage.NewMemory()
+	ctx := context.Background()
+	for _, entry := range []models.CatalogIngredient{
+		{Name: "tofu", Allergens: []string{"soy"}, Diets: []string{"vegan"}, AllergensReviewed: true},
+		{Name: "rice", Diets: []string{"vegan"}, AllergensReviewed: true},
+		{Name: "shrimp", Allergens: []string{"shellfish"}, Diets: []string{"pescatarian"}, AllergensReviewed: true},
+	} {
+		if err := store.CreateCatalogIngredient(ctx, &entry); err != nil {
+			t.Fatalf("CreateCatalogIngredient() error = %v", err)
+		}
+	}
+	for _, recipe := range []models.Recipe{
+		{Title: "Tofu Bowl", Ingredients: []models.Ingredient{{Name: "tofu", Amount: "1"}, {Name: "rice", Amount: "1"}}},
+		{Title: "Shrimp Rice", Ingredients: []models.Ingredient{{Name: "shrimp", Amount: "1"}, {Name: "rice", Amount: "1"}}},
+	} {
+		if err := store.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+	}
+	user := models.User{Email: "vegan@example.com", Username: "vegan", Password: "hash", Diets: []string{"vegan"}, PreferredUnits: "metric"}
+	if err := store.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	users := &countingUsers{UserRepository: store}
+	handler := NewAPIHandler(store)
+	handler.SetUserRepository(users)
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		signedIn bool
+		expected string
+	}{
+		{"Anonymous", "sort=title", false, "Shrimp Rice,Tofu Bowl"},
+		{"Preferences applied", "sort=title", true, "Tofu Bowl"},
+		{"Criteria override preferences", "sort=title&exclude_allergen=soy", true, "Shrimp Rice"},
+		{"Preferences turned off", "sort=title&preferences=false", true, "Shrimp Rice,Tofu Bowl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/recipes?"+tt.rawQuery, nil)
+			if tt.signedIn {
+				req = withUser(req, user.ID, false)
+			}
+			w := httptest.NewRecorder()
+			handler.HandleRecipes(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var response models.SearchResult
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			var titles []string
+			for _, recipe := range response.Recipes {
+				titles = append(titles, recipe.Title)
+			}
+			if strings.Join(titles, ",") != tt.expected {
+				t.Errorf("Expected %s, got %v", tt.expected, titles)
+			}
+		})
+	}
+	if want := 3; users.loads != want {
+		t.Errorf("Expected the user loaded once per signed-in request, got %d loads for %d requests", users.loads, want)
+	}
+}
+
+func TestAPIHandler_RecipeFacets(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes?facets=true&category=lunch", nil)
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response models.SearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Facets == nil {
+		t.Fatal("Expected facets in the response")
+	}
+	if len(response.Facets.Category) != 3 || response.Facets.Category[0] != (models.FacetCount{Value: "dinner", Count: 3}) {
+		t.Errorf("Expected category counts over every recipe, got %+v", response.Facets.Category)
+	}
+	if len(response.Facets.Difficulty) != 1 || response.Facets.Difficulty[0] != (models.FacetCount{Value: "easy", Count: 2}) {
+		t.Errorf("Expected difficulty counts for lunch recipes, got %+v", response.Facets.Difficulty)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, httptest.NewRequest(http.MethodGet, "/api/recipes", nil))
+	if strings.Contains(w.Body.String(), `"facets"`) {
+		t.Error("Expected no facets unless requested")
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, httptest.NewRequest(http.MethodGet, "/api/recipes?facets=maybe", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid facets flag, got %d", w.Code)
+	}
+}
+
+func TestAPIHandler_RecipeFacetsHTMX(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+	handler.templates = template.Must(template.ParseFiles(
+		"../../web/templates/recipe-cards.html",
+		"../../web/templates/recipe-facets.html",
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes?facets=true&tag=quick&cook_time=30", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `id="recipe-facets" hx-swap-oob="true"`) {
+		t.Fatalf("Expected an out-of-band facets sidebar, got %s", body)
+	}
+	if !strings.Contains(body, `<option value="30" selected>Under 30 min (3)</option>`) {
+		t.Errorf("Expected the active cook time bucket with its count, got %s", body)
+	}
+	if !strings.Contains(body, `value="quick" checked`) {
+		t.Errorf("Expected the active tag to stay checked, got %s", body)
+	}
+}
+
+func TestAPIHandler_SearchRecipesHTMX(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/recipe-cards.html"))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recipes?q=cocoa", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Chocolate Cake") || strings.Contains(body, "Greek Salad") {
+		t.Errorf("Expected only the matching recipe card, got %s", body)
+	}
+	if !strings.Contains(body, "<mark>cocoa</mark>") {
+		t.Errorf("Expected the ingredient match to be highlighted, got %s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/recipes?q=sushi", nil)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+	if !strings.Contains(w.Body.String(), "No recipes match") {
+		t.Errorf("Expected a no-results message, got %s", w.Body.String())
+	}
+}
+
 func TestAPIHandler_CreateRecipe(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, _ := newTestAPIHandler(t)
 
-	req := httptest.NewRequest(http.MethodPost, "/api/recipes", nil)
+	body := `{"title": "Pancakes", "servings": 4, "ingredients": [{"name": "flour", "amount": "2", "unit": "cups"}], "instructions": [{"text": "Mix"}]}`
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/recipes", strings.NewReader(body)), "cook", false)
 	w := httptest.NewRecorder()
 
 	handler.HandleCreateRecipe(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", w.Code)
 	}
 
 	var response map[string]interface{}
@@ -96,18 +428,59 @@ func TestAPIHandler_CreateRecipe(t *testing.T) {
 		t.Errorf("Expected message 'Recipe created successfully', got %s", response["message"])
 	}
 
-	if response["id"] != "3" {
-		t.Errorf("Expected id '3', got %s", response["id"])
+	id, _ := response["id"].(string)
+	if id == "" {
+		t.Fatal("Expected a recipe id in the response")
+	}
+
+	req = withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/"+id, nil), id)
+	w = httptest.NewRecorder()
+	handler.HandleRecipe(w, req)
+
+	var created models.Recipe
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal created recipe: %v", err)
+	}
+	if created.Title != "Pancakes" || len(created.Ingredients) != 1 || len(created.Instructions) != 1 {
+		t.Errorf("Created recipe was not persisted: %+v", created)
+	}
+	if created.AuthorID != "cook" {
+		t.Errorf("Expected author cook, got %q", created.AuthorID)
+	}
+}
+
+func TestAPIHandler_CreateRecipeValidation(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Malformed JSON", `{"title":`},
+		{"Missing title", `{"servings": 2}`},
+		{"Invalid ingredient", `{"title": "Soup", "ingredients": [{"amount": "1"}]}`},
+		{"Invalid instruction", `{"title": "Soup", "instructions": [{"text": ""}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/recipes", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.HandleCreateRecipe(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
 	}
 }
 
 func TestAPIHandler_GetRecipe(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, seeded := newTestAPIHandler(t)
+	bolognese := seeded[len(seeded)-1]
 
-	req := httptest.NewRequest(http.MethodGet, "/api/recipes/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req := withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/"+bolognese.ID, nil), bolognese.ID)
 
 	w := httptest.NewRecorder()
 	handler.HandleRecipe(w, req)
@@ -122,21 +495,201 @@ func TestAPIHandler_GetRecipe(t *testing.T) {
 		t.Errorf("Failed to unmarshal response: %v", err)
 	}
 
-	if response["id"] != "1" {
-		t.Errorf("Expected recipe ID '1', got %s", response["id"])
+	if response["id"] != bolognese.ID {
+		t.Errorf("Expected recipe ID %s, got %s", bolognese.ID, response["id"])
 	}
 	if response["title"] != "Spaghetti Bolognese" {
 		t.Errorf("Expected recipe title 'Spaghetti Bolognese', got %s", response["title"])
 	}
 }
 
+func TestAPIHandler_GetRecipeScaled(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+	bolognese := seeded[len(seeded)-1]
+
+	req := withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/"+bolognese.ID+"?servings=6", nil), bolognese.ID)
+	w := httptest.NewRecorder()
+	handler.HandleRecipe(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var recipe models.Recipe
+	if err := json.Unmarshal(w.Body.Bytes(), &recipe); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if recipe.Servings != 6 || recipe.ScaledFrom != 4 {
+		t.Errorf("Expected 6 servings scaled from 4, got %d from %d", recipe.Servings, recipe.ScaledFrom)
+	}
+	var amounts []string
+	for _, ing := range recipe.Ingredients {
+		amounts = append(amounts, ing.Amount)
+	}
+	if got, want := strings.Join(amounts, ","), "600,750,1200,1 1/2,4 1/2,3"; got != want {
+		t.Errorf("Expected amounts %s, got %s", want, got)
+	}
+
+	stored, err := handler.recipes.GetRecipe(context.Background(), bolognese.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if stored.Servings != 4 || stored.Ingredients[0].Amount != "400" {
+		t.Errorf("Expected the stored recipe to be unchanged, got %d servings and %s", stored.Servings, stored.Ingredients[0].Amount)
+	}
+
+	for _, servings := range []string{"0", "101", "two"} {
+		req := withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/"+bolognese.ID+"?servings="+servings, nil), bolognese.ID)
+		w := httptest.NewRecorder()
+		handler.HandleRecipe(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"servings"`) {
+			t.Errorf("Expected a servings validation error for %q, got %d: %s", servings, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestAPIHandler_GetRecipeScaledHTMX(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/recipe-detail-content.html"))
+	brownies := seeded[1]
+
+	req := withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/"+brownies.ID+"?servings=4", nil), brownies.ID)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleRecipe(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"Scaled from 8 servings.", `name="servings" value="4"`, "3/8 cup cocoa powder", "3/4 cup sugar"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected detail view to contain %q, got %s", want, body)
+		}
+	}
+}
+
+func TestAPIHandler_GetRecipeUnits(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+	brownies := seeded[1]
+
+	get := func(req *http.Request) (*httptest.ResponseRecorder, models.Recipe) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.HandleRecipe(w, withRecipeID(req, brownies.ID))
+		var recipe models.Recipe
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &recipe); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+		}
+		return w, recipe
+	}
+
+	_, recipe := get(httptest.NewRequest(http.MethodGet, "/api/recipes/"+brownies.ID+"?units=metric", nil))
+	if ing := recipe.Ingredients[0]; ing.Amount != "250" || ing.Unit != "g" {
+		t.Errorf("Expected 250 g of flour, got %s %s", ing.Amount, ing.Unit)
+	}
+	if inst := recipe.Instructions[2]; inst.Temperature != 175 || inst.TemperatureUnit != "C" {
+		t.Errorf("Expected baking at 175°C, got %d°%s", inst.Temperature, inst.TemperatureUnit)
+	}
+
+	if w, _ := get(httptest.NewRequest(http.MethodGet, "/api/recipes/"+brownies.ID+"?units=kelvin", nil)); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"units"`) {
+		t.Errorf("Expected a units validation error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	store := handler.recipes.(*storage.Memory)
+	user := models.User{Email: "metric@example.com", Username: "metric", Password: "hash", PreferredUnits: "metric"}
+	if err := store.CreateUser(context.Background(), &user); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	handler.SetUserRepository(store)
+
+	_, recipe = get(withUser(httptest.NewRequest(http.MethodGet, "/api/recipes/"+brownies.ID, nil), user.ID, false))
+	if ing := recipe.Ingredients[0]; ing.Unit != "g" {
+		t.Errorf("Expected the user's metric preference to apply, got %s %s", ing.Amount, ing.Unit)
+	}
+	_, recipe = get(withUser(httptest.NewRequest(http.MethodGet, "/api/recipes/"+brownies.ID+"?units=imperial", nil), user.ID, false))
+	if ing := recipe.Ingredients[0]; ing.Unit != "cups" {
+		t.Errorf("Expected the units parameter to override the preference, got %s %s", ing.Amount, ing.Unit)
+	}
+}
+
+func TestAPIHandler_GetRecipeJSONLD(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+	bolognese := seeded[len(seeded)-1]
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := withRecipeID(httptest.NewRequest(http.MethodGet, "http://recipes.example.com/api/recipes/"+bolognese.ID+"?servings=8", nil), bolognese.ID)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.HandleRecipe(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Accept %q: expected status 200, got %d: %s", accept, w.Code, w.Body.String())
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", accept, vary)
+		}
+		return w
+	}
+
+	w := get("application/ld+json, application/json;q=0.5")
+	if ct := w.Header().Get("Content-Type"); ct != "application/ld+json" {
+		t.Errorf("Content-Type = %q, want application/ld+json", ct)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if doc["@type"] != "Recipe" || doc["name"] != "Spaghetti Bolognese" || doc["recipeYield"] != "8" {
+		t.Errorf("JSON-LD = %v", doc)
+	}
+	if doc["url"] != "http://recipes.example.com/recipes/"+bolognese.ID {
+		t.Errorf("url = %v", doc["url"])
+	}
+
+	for _, accept := range []string{"", "application/json", "application/ld+json;q=0, application/json"} {
+		if ct := get(accept).Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Accept %q: Content-Type = %q, want application/json", accept, ct)
+		}
+	}
+}
+
+func TestAPIHandler_CreateRecipeNormalizesUnits(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	body := `{"title": "Tea", "ingredients": [{"name": "sugar", "amount": "2", "unit": "Teaspoons"}],
+		"instructions": [{"text": "Boil", "temperature": 100, "temperature_unit": "C"}]}`
+	w := httptest.NewRecorder()
+	handler.HandleRecipes(w, withUser(httptest.NewRequest(http.MethodPost, "/api/recipes", strings.NewReader(body)), "cook", false))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	created, err := handler.recipes.GetRecipe(context.Background(), response.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if unit := created.Ingredients[0].Unit; unit != "tsp" {
+		t.Errorf("Expected unit tsp, got %q", unit)
+	}
+
+	body = `{"title": "Tea", "instructions": [{"text": "Boil", "temperature": 100}]}`
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, withUser(httptest.NewRequest(http.MethodPost, "/api/recipes", strings.NewReader(body)), "cook", false))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a temperature without a unit, got %d", w.Code)
+	}
+}
+
 func TestAPIHandler_UpdateRecipe(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, seeded := newTestAPIHandler(t)
+	id := seeded[0].ID
 
-	req := httptest.NewRequest(http.MethodPut, "/api/recipes/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req := httptest.NewRequest(http.MethodPut, "/api/recipes/"+id, strings.NewReader(`{"title": "Spaghetti Carbonara"}`))
+	req = withUser(withRecipeID(req, id), "admin", true)
 
 	w := httptest.NewRecorder()
 	handler.HandleUpdateRecipe(w, req)
@@ -157,12 +710,11 @@ func TestAPIHandler_UpdateRecipe(t *testing.T) {
 }
 
 func TestAPIHandler_DeleteRecipe(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, seeded := newTestAPIHandler(t)
+	id := seeded[0].ID
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/recipes/1", nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("id", "1")
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req := withRecipeID(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+id, nil), id)
+	req = withUser(req, "admin", true)
 
 	w := httptest.NewRecorder()
 	handler.HandleDeleteRecipe(w, req)
@@ -182,8 +734,135 @@ func TestAPIHandler_DeleteRecipe(t *testing.T) {
 	}
 }
 
+func TestAPIHandler_RecipeOwnership(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/recipes", strings.NewReader(`{"title": "Pancakes"}`)), "owner", false)
+	w := httptest.NewRecorder()
+	handler.HandleCreateRecipe(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	var created map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	owned, _ := created["id"].(string)
+
+	tests := []struct {
+		name           string
+		recipeID       string
+		userID         string
+		isAdmin        bool
+		expectedStatus int
+	}{
+		{"Author can update", owned, "owner", false, http.StatusOK},
+		{"Other user cannot update", owned, "intruder", false, http.StatusForbidden},
+		{"Admin can update", owned, "admin", true, http.StatusOK},
+		{"Unowned recipe needs admin", seeded[0].ID, "owner", false, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/recipes/"+tt.recipeID, strings.NewReader(`{"title": "Crepes"}`))
+			req = withUser(withRecipeID(req, tt.recipeID), tt.userID, tt.isAdmin)
+			w := httptest.NewRecorder()
+			handler.HandleUpdateRecipe(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusForbidden {
+				var response appmiddleware.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+				if response.Code != "FORBIDDEN" {
+					t.Errorf("Expected code FORBIDDEN, got %s", response.Code)
+				}
+			}
+		})
+	}
+
+	t.Run("Other user cannot probe validation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/recipes/"+owned, strings.NewReader(`{"title": ""}`))
+		w := httptest.NewRecorder()
+		handler.HandleUpdateRecipe(w, withUser(withRecipeID(req, owned), "intruder", false))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 for an invalid body from another user, got %d", w.Code)
+		}
+	})
+
+	t.Run("Other user cannot delete", func(t *testing.T) {
+		req := withUser(withRecipeID(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+owned, nil), owned), "intruder", false)
+		w := httptest.NewRecorder()
+		handler.HandleDeleteRecipe(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("Author can delete", func(t *testing.T) {
+		req := withUser(withRecipeID(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+owned, nil), owned), "owner", false)
+		w := httptest.NewRecorder()
+		handler.HandleDeleteRecipe(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		req := withRecipeID(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+seeded[0].ID, nil), seeded[0].ID)
+		w := httptest.NewRecorder()
+		handler.HandleDeleteRecipe(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestAPIHandler_RecipeNotFound(t *testing.T) {
+	handler, _ := newTestAPIHandler(t)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		serve  func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"GET missing recipe", http.MethodGet, "", handler.HandleRecipe},
+		{"PUT missing recipe", http.MethodPut, `{"title": "Ghost"}`, handler.HandleUpdateRecipe},
+		{"DELETE missing recipe", http.MethodDelete, "", handler.HandleDeleteRecipe},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/recipes/404", strings.NewReader(tt.body))
+			req = withUser(withRecipeID(req, "404"), "admin", true)
+
+			w := httptest.NewRecorder()
+			tt.serve(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404, got %d", w.Code)
+			}
+		})
+	}
+
+	t.Run("HTMX GET missing recipe", func(t *testing.T) {
+		handler.templates = template.Must(template.ParseFiles("../../web/templates/recipe-detail-content.html"))
+		req := withRecipeID(httptest.NewRequest(http.MethodGet, "/api/recipes/404", nil), "404")
+		req.Header.Set("HX-Request", "true")
+
+		w := httptest.NewRecorder()
+		handler.HandleRecipe(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
 func TestAPIHandler_InvalidMethod(t *testing.T) {
-	handler := NewAPIHandler()
+	handler, _ := newTestAPIHandler(t)
 
 	tests := []struct {
 		name     string
@@ -203,10 +882,7 @@ func TestAPIHandler_InvalidMethod(t *testing.T) {
 			if tt.endpoint == "/api/recipes" {
 				handler.HandleRecipes(w, req)
 			} else {
-				rctx := chi.NewRouteContext()
-				rctx.URLParams.Add("id", "1")
-				req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-				handler.HandleRecipe(w, req)
+				handler.HandleRecipe(w, withRecipeID(req, "1"))
 			}
 
 			if w.Code != http.StatusMethodNotAllowed {
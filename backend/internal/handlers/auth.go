@@ -2,21 +2,41 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	appmiddleware "recipe-app/internal/appmiddleware"
 	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// dummyPasswordHash is compared against when a login email is unknown so
+// that response timing does not reveal which emails are registered.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("recipe-app-dummy-password"), bcrypt.DefaultCost)
+
+// refreshCookieName holds the refresh token for browser clients. The cookie
+// is scoped to the auth endpoints so it is not sent with every request.
+const (
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/api/auth"
 )
 
 type AuthHandler struct {
 	authService *appmiddleware.AuthService
+	users       storage.UserRepository
+	tokens      storage.RefreshTokenRepository
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Username string `json:"username"`
 	Name     string `json:"name"`
 }
 
@@ -25,24 +45,28 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
-type AuthResponse struct {
-	Token     string `json:"token"`
-	User      User   `json:"user"`
-	ExpiresIn int64  `json:"expires_in"`
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
-type User struct {
-	ID    int    `json:"id"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+type AuthResponse struct {
+	Token        string      `json:"token"`
+	RefreshToken string      `json:"refresh_token"`
+	User         models.User `json:"user"`
+	ExpiresIn    int64       `json:"expires_in"`
 }
 
-func NewAuthHandler(authService *appmiddleware.AuthService) *AuthHandler {
+func NewAuthHandler(authService *appmiddleware.AuthService, users storage.UserRepository, tokens storage.RefreshTokenRepository) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
+		users:       users,
+		tokens:      tokens,
 	}
 }
 
+// maxPasswordBytes is the longest password bcrypt can hash.
+const maxPasswordBytes = 72
+
 func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -52,39 +76,42 @@ func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(req.Email) == 0 || len(req.Password) < 8 {
+	req.Email = strings.TrimSpace(req.Email)
+	if !strings.Contains(req.Email, "@") || len(req.Password) < 8 {
 		http.Error(w, "Invalid email or password (min 8 chars)", http.StatusBadRequest)
 		return
 	}
+	if len(req.Password) > maxPasswordBytes {
+		writeFieldError(w, &models.FieldError{Field: "password", Message: fmt.Sprintf("password must be at most %d bytes", maxPasswordBytes)})
+		return
+	}
 
-	_, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		logger.LogError(ctx, err, "Password hashing failed")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	user := User{
-		ID:    1,
-		Email: req.Email,
-		Name:  req.Name,
+	user := models.User{
+		Email:    req.Email,
+		Username: strings.TrimSpace(req.Username),
+		Password: string(hash),
 	}
-
-	token, err := h.authService.GenerateToken(user.ID, user.Email, false)
-	if err != nil {
-		logger.LogError(ctx, err, "Token generation failed")
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	if user.Username == "" {
+		user.Username = strings.SplitN(req.Email, "@", 2)[0]
+	}
+	if names := strings.Fields(req.Name); len(names) > 0 {
+		user.FirstName = names[0]
+		user.LastName = strings.Join(names[1:], " ")
 	}
 
-	response := AuthResponse{
-		Token:     token,
-		User:      user,
-		ExpiresIn: 86400,
+	if err := h.users.CreateUser(ctx, &user); err != nil {
+		writeUserStorageError(w, r, err, "User registration failed")
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	h.startSession(w, r, &user, http.StatusCreated)
 }
 
 func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
@@ -101,72 +128,199 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := User{
-		ID:    1,
-		Email: req.Email,
-		Name:  "Test User",
+	user, err := h.users.GetUserByEmail(ctx, req.Email)
+	if errors.Is(err, storage.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.LogError(ctx, err, "User lookup failed")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte("$2a$10$example.hash"), []byte(req.Password)); err != nil {
-		if strings.Contains(req.Password, "password") {
-			user.Name = "Demo User"
-		} else {
-			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-			return
-		}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+
+	h.startSession(w, r, user, http.StatusOK)
+}
+
+// HandleRefresh exchanges a refresh token for a new access token and a new
+// refresh token. The presented token is read from the JSON body or, failing
+// that, the refresh token cookie.
+func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	presented := readRefreshToken(r)
+	if presented == "" {
+		http.Error(w, "Refresh token required", http.StatusUnauthorized)
+		return
 	}
 
-	token, err := h.authService.GenerateToken(user.ID, user.Email, false)
+	plain, next, err := h.newRefreshToken()
 	if err != nil {
-		logger.LogError(ctx, err, "Token generation failed")
+		logger.LogError(ctx, err, "Refresh token generation failed")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := AuthResponse{
-		Token:     token,
-		User:      user,
-		ExpiresIn: 86400,
+	err = h.tokens.RotateRefreshToken(ctx, appmiddleware.HashRefreshToken(presented), next)
+	switch {
+	case errors.Is(err, storage.ErrTokenReused):
+		logger.FromContext(ctx).Warn("Refresh token reuse detected, revoking token family")
+		clearRefreshCookie(w, r)
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusUnauthorized, "Refresh token has already been used", "REFRESH_TOKEN_REUSED", err))
+		return
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrTokenExpired), errors.Is(err, storage.ErrTokenRevoked):
+		clearRefreshCookie(w, r)
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusUnauthorized, "Invalid refresh token", "INVALID_REFRESH_TOKEN", err))
+		return
+	case err != nil:
+		logger.LogError(ctx, err, "Refresh token rotation failed")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := h.users.GetUserByID(ctx, next.UserID)
+	if err != nil {
+		writeUserStorageError(w, r, err, "User lookup failed")
+		return
 	}
 
+	h.writeAuthResponse(w, r, user, plain, http.StatusOK)
+}
+
+// HandleLogout revokes the presented refresh token's family so that neither
+// it nor any token rotated from it can be used again. Unknown tokens are
+// ignored so that logging out is idempotent.
+func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if presented := readRefreshToken(r); presented != "" {
+		token, err := h.tokens.GetRefreshToken(ctx, appmiddleware.HashRefreshToken(presented))
+		if err == nil {
+			err = h.tokens.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+		}
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			logger.LogError(ctx, err, "Refresh token revocation failed")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	clearRefreshCookie(w, r)
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/")
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
 }
 
-func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+// startSession issues a refresh token in a new family for user and writes
+// the auth response.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *models.User, status int) {
 	ctx := r.Context()
 
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+	plain, token, err := h.newRefreshToken()
+	if err != nil {
+		logger.LogError(ctx, err, "Refresh token generation failed")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	token.UserID = user.ID
+	token.FamilyID = uuid.NewString()
 
-	tokenParts := strings.Split(authHeader, " ")
-	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-		http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+	if err := h.tokens.CreateRefreshToken(ctx, token); err != nil {
+		logger.LogError(ctx, err, "Refresh token storage failed")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	claims, err := h.authService.ValidateToken(tokenParts[1])
+	h.writeAuthResponse(w, r, user, plain, status)
+}
+
+func (h *AuthHandler) newRefreshToken() (string, *models.RefreshToken, error) {
+	plain, hash, err := appmiddleware.NewRefreshToken()
 	if err != nil {
-		logger.LogError(ctx, err, "Token validation failed")
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
-		return
+		return "", nil, err
 	}
+	return plain, &models.RefreshToken{
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(h.authService.RefreshExpiry),
+	}, nil
+}
 
-	newToken, err := h.authService.GenerateToken(claims.UserID, claims.Email, claims.IsAdmin)
+func (h *AuthHandler) writeAuthResponse(w http.ResponseWriter, r *http.Request, user *models.User, refreshToken string, status int) {
+	token, err := h.authService.GenerateToken(user.ID, user.Email, user.IsAdmin)
 	if err != nil {
-		logger.LogError(ctx, err, "Token generation failed")
+		logger.LogError(r.Context(), err, "Token generation failed")
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	response := map[string]interface{}{
-		"token":      newToken,
-		"expires_in": 86400,
+	response := AuthResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         *user,
+		ExpiresIn:    int64(h.authService.TokenExpiry.Seconds()),
 	}
 
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    refreshToken,
+		Path:     refreshCookiePath,
+		MaxAge:   int(h.authService.RefreshExpiry.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
+
+// readRefreshToken returns the refresh token from a JSON request body, or
+// from the refresh token cookie when the body does not carry one.
+func readRefreshToken(r *http.Request) string {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.RefreshToken != "" {
+			return req.RefreshToken
+		}
+	}
+	if cookie, err := r.Cookie(refreshCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+func clearRefreshCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// writeUserStorageError maps user repository errors to HTTP responses,
+// reporting duplicate emails and usernames as 409 Conflict.
+func writeUserStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrEmailTaken):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusConflict, "Email is already registered", "EMAIL_TAKEN", err))
+	case errors.Is(err, storage.ErrUsernameTaken):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusConflict, "Username is already taken", "USERNAME_TAKEN", err))
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "User not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/storage"
+)
+
+func newTestAuthHandler() (*AuthHandler, *appmiddleware.AuthService) {
+	authService := appmiddleware.NewAuthService("test-secret-key")
+	store := storage.NewMemory()
+	return NewAuthHandler(authService, store, store), authService
+}
+
+func postJSON(handler http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w
+}
+
+func TestAuthHandler_RegisterAndLogin(t *testing.T) {
+	handler, authService := newTestAuthHandler()
+
+	w := postJSON(handler.HandleRegister, "/api/auth/register",
+		`{"email": "Cook@Example.com", "password": "s3cret-pass", "username": "cook", "name": "Julia Child"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var registered AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &registered); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if registered.User.ID == "" || registered.User.Email != "cook@example.com" || registered.User.FirstName != "Julia" || registered.User.LastName != "Child" {
+		t.Errorf("Unexpected registered user: %+v", registered.User)
+	}
+	if strings.Contains(w.Body.String(), "s3cret-pass") || strings.Contains(w.Body.String(), "$2a$") {
+		t.Error("Response must not expose the password or its hash")
+	}
+
+	claims, err := authService.ValidateToken(registered.Token)
+	if err != nil {
+		t.Fatalf("Register token validation failed: %v", err)
+	}
+	if claims.UserID != registered.User.ID {
+		t.Errorf("Expected token user ID %s, got %s", registered.User.ID, claims.UserID)
+	}
+
+	w = postJSON(handler.HandleLogin, "/api/auth/login", `{"email": "cook@example.com", "password": "s3cret-pass"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var loggedIn AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &loggedIn); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if loggedIn.User.ID != registered.User.ID {
+		t.Errorf("Expected login user ID %s, got %s", registered.User.ID, loggedIn.User.ID)
+	}
+}
+
+func TestAuthHandler_RegisterDefaultsUsername(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+
+	w := postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "baker@example.com", "password": "password123"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	var response AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.User.Username != "baker" {
+		t.Errorf("Expected username baker, got %s", response.User.Username)
+	}
+}
+
+func TestAuthHandler_RegisterConflicts(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+
+	w := postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "cook@example.com", "password": "password123", "username": "cook"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"Duplicate email", `{"email": "COOK@example.com", "password": "password123", "username": "other"}`, "EMAIL_TAKEN"},
+		{"Duplicate username", `{"email": "other@example.com", "password": "password123", "username": "cook"}`, "USERNAME_TAKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postJSON(handler.HandleRegister, "/api/auth/register", tt.body)
+			if w.Code != http.StatusConflict {
+				t.Fatalf("Expected status 409, got %d", w.Code)
+			}
+
+			var response appmiddleware.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, response.Code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RegisterValidation(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Malformed JSON", `{"email":`},
+		{"Missing email", `{"password": "password123"}`},
+		{"Invalid email", `{"email": "not-an-email", "password": "password123"}`},
+		{"Short password", `{"email": "cook@example.com", "password": "short"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postJSON(handler.HandleRegister, "/api/auth/register", tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAuthHandler_RegisterRejectsLongPassword(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+
+	w := postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "cook@example.com", "password": "`+strings.Repeat("p", 73)+`"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"password"`) {
+		t.Errorf("Expected a password validation error, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "cook@example.com", "password": "`+strings.Repeat("p", 72)+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected a 72-byte password accepted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthHandler_LoginRejectsBadCredentials(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+
+	postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "cook@example.com", "password": "password123"}`)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Wrong password", `{"email": "cook@example.com", "password": "password1234"}`, http.StatusUnauthorized},
+		{"Password containing the old demo bypass", `{"email": "cook@example.com", "password": "mypassword"}`, http.StatusUnauthorized},
+		{"Unknown email", `{"email": "nobody@example.com", "password": "password123"}`, http.StatusUnauthorized},
+		{"Missing password", `{"email": "cook@example.com"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postJSON(handler.HandleLogin, "/api/auth/login", tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func registerForTokens(t *testing.T, handler *AuthHandler) AuthResponse {
+	t.Helper()
+
+	w := postJSON(handler.HandleRegister, "/api/auth/register", `{"email": "cook@example.com", "password": "password123"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.RefreshToken == "" {
+		t.Fatal("Expected a refresh token in the auth response")
+	}
+	return response
+}
+
+func refreshWith(handler *AuthHandler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", strings.NewReader(`{"refresh_token": "`+token+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.HandleRefresh(w, req)
+	return w
+}
+
+func TestAuthHandler_RefreshRotatesToken(t *testing.T) {
+	handler, authService := newTestAuthHandler()
+	registered := registerForTokens(t, handler)
+
+	w := refreshWith(handler, registered.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var refreshed AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &refreshed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == registered.RefreshToken {
+		t.Errorf("Expected a new refresh token, got %q", refreshed.RefreshToken)
+	}
+	claims, err := authService.ValidateToken(refreshed.Token)
+	if err != nil {
+		t.Fatalf("Refreshed token validation failed: %v", err)
+	}
+	if claims.UserID != registered.User.ID {
+		t.Errorf("Expected token user ID %s, got %s", registered.User.ID, claims.UserID)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != refreshed.RefreshToken || !cookies[0].HttpOnly {
+		t.Errorf("Expected an HttpOnly refresh cookie with the new token, got %+v", cookies)
+	}
+}
+
+func TestAuthHandler_RefreshReuseRevokesFamily(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+	registered := registerForTokens(t, handler)
+
+	w := refreshWith(handler, registered.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var rotated AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &rotated)
+
+	w = refreshWith(handler, registered.RefreshToken)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for a reused token, got %d", w.Code)
+	}
+	var response appmiddleware.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Code != "REFRESH_TOKEN_REUSED" {
+		t.Errorf("Expected code REFRESH_TOKEN_REUSED, got %s", response.Code)
+	}
+
+	if w := refreshWith(handler, rotated.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected the rest of the family to be revoked, got status %d", w.Code)
+	}
+}
+
+func TestAuthHandler_RefreshRejectsInvalidTokens(t *testing.T) {
+	handler, authService := newTestAuthHandler()
+	registered := registerForTokens(t, handler)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"Unknown token", "not-a-refresh-token"},
+		{"Access token", registered.Token},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := refreshWith(handler, tt.token); w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401, got %d", w.Code)
+			}
+		})
+	}
+
+	t.Run("Missing token", func(t *testing.T) {
+		w := postJSON(handler.HandleRefresh, "/api/auth/refresh", `{}`)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		authService.RefreshExpiry = -time.Minute
+		defer func() { authService.RefreshExpiry = 7 * 24 * time.Hour }()
+
+		w := postJSON(handler.HandleLogin, "/api/auth/login", `{"email": "cook@example.com", "password": "password123"}`)
+		var expired AuthResponse
+		json.Unmarshal(w.Body.Bytes(), &expired)
+
+		if w := refreshWith(handler, expired.RefreshToken); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	handler, _ := newTestAuthHandler()
+	registered := registerForTokens(t, handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	req.Header.Set("HX-Request", "true")
+	req.AddCookie(&http.Cookie{Name: "refresh_token", Value: registered.RefreshToken})
+	w := httptest.NewRecorder()
+	handler.HandleLogout(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if w.Header().Get("HX-Redirect") != "/" {
+		t.Errorf("Expected HTMX redirect to /, got %q", w.Header().Get("HX-Redirect"))
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("Expected the refresh cookie to be cleared, got %+v", cookies)
+	}
+
+	if w := refreshWith(handler, registered.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected logged out token to be rejected, got status %d", w.Code)
+	}
+
+	// Logging out again, or without a token, still succeeds.
+	if w := postJSON(handler.HandleLogout, "/api/auth/logout", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// DefaultUnmatchedLimit is how many unmatched ingredient names are listed
+// when the request does not say.
+const DefaultUnmatchedLimit = 50
+
+// CatalogHandler serves the admin API for the canonical ingredient catalog
+// and recipe ingredients' links to it. Routes are expected to sit behind
+// appmiddleware.RequireAdmin.
+type CatalogHandler struct {
+	catalog storage.CatalogRepository
+}
+
+// CatalogIngredientRequest creates or replaces a catalog entry.
+type CatalogIngredientRequest struct {
+	Name     string   `json:"name"`
+	Plural   string   `json:"plural"`
+	Category string   `json:"category"`
+	Synonyms []string `json:"synonyms"`
+	// Allergens and Diets tag the entry; see models.Allergens and
+	// models.Diets for the accepted labels.
+	Allergens []string `json:"allergens"`
+	Diets     []string `json:"diets"`
+	// AllergensReviewed vouches that Allergens lists every allergen the
+	// entry contains.
+	AllergensReviewed bool `json:"allergens_reviewed"`
+}
+
+// IngredientLinkRequest links a recipe ingredient to a catalog entry by
+// hand. An empty CatalogID leaves it unlinked.
+type IngredientLinkRequest struct {
+	CatalogID string `json:"catalog_id"`
+}
+
+func NewCatalogHandler(catalog storage.CatalogRepository) *CatalogHandler {
+	return &CatalogHandler{catalog: catalog}
+}
+
+// HandleListCatalog lists catalog entries, only those with a name, plural
+// or synonym containing the q parameter when it is set.
+func (h *CatalogHandler) HandleListCatalog(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.catalog.ListCatalog(r.Context(), r.URL.Query().Get("q"))
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to list ingredient catalog")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ingredients": entries})
+}
+
+func (h *CatalogHandler) HandleCatalogIngredient(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.catalog.GetCatalogIngredient(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to get catalog ingredient")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// HandleCreateCatalogIngredient adds an entry to the catalog and links the
+// recipe ingredients it matches.
+func (h *CatalogHandler) HandleCreateCatalogIngredient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CatalogIngredientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	entry := req.entry()
+	if err := entry.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.catalog.CreateCatalogIngredient(ctx, entry); err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to create catalog ingredient")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Catalog ingredient created", "catalog_id", entry.ID, "name", entry.Name)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// HandleUpdateCatalogIngredient replaces an entry's names, category and
+// dietary tags and rematches the recipe ingredients they affect.
+func (h *CatalogHandler) HandleUpdateCatalogIngredient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CatalogIngredientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	entry := req.entry()
+	entry.ID = chi.URLParam(r, "id")
+	if err := entry.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.catalog.UpdateCatalogIngredient(ctx, entry); err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to update catalog ingredient")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Catalog ingredient updated", "catalog_id", entry.ID, "name", entry.Name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// HandleDeleteCatalogIngredient removes an entry, returning the recipe
+// ingredients linked to it to automatic matching.
+func (h *CatalogHandler) HandleDeleteCatalogIngredient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := chi.URLParam(r, "id")
+	if err := h.catalog.DeleteCatalogIngredient(ctx, id); err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to delete catalog ingredient")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Catalog ingredient deleted", "catalog_id", id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Catalog ingredient deleted successfully",
+	})
+}
+
+// HandleUnmatchedIngredients lists the most common ingredient names no
+// catalog entry matches, up to the limit parameter.
+func (h *CatalogHandler) HandleUnmatchedIngredients(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r.URL.Query(), "limit")
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if limit < 1 {
+		limit = DefaultUnmatchedLimit
+	}
+	if limit > storage.MaxPerPage {
+		limit = storage.MaxPerPage
+	}
+
+	counts, err := h.catalog.UnmatchedIngredients(r.Context(), limit)
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to list unmatched ingredients")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ingredients": counts})
+}
+
+// HandleRelinkIngredients rematches every recipe ingredient not linked by
+// hand and reports how many links changed.
+func (h *CatalogHandler) HandleRelinkIngredients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	n, err := h.catalog.RelinkIngredients(ctx)
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to relink ingredients")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Ingredients relinked", "changed", n)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"relinked": n})
+}
+
+// HandleLinkIngredient links a recipe ingredient to a catalog entry by
+// hand, overriding the automatic match until the link is reset.
+func (h *CatalogHandler) HandleLinkIngredient(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req IngredientLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ingredientID := chi.URLParam(r, "ingredientID")
+	ing, err := h.catalog.LinkIngredient(ctx, ingredientID, req.CatalogID)
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to link ingredient")
+		return
+	}
+
+	userID, _ := appmiddleware.GetUserID(ctx)
+	logger.FromContext(ctx).Info("Ingredient linked by hand", "ingredient_id", ingredientID, "catalog_id", req.CatalogID, "user_id", userID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ing)
+}
+
+// HandleResetIngredientLink drops a manual link and matches the recipe
+// ingredient automatically again.
+func (h *CatalogHandler) HandleResetIngredientLink(w http.ResponseWriter, r *http.Request) {
+	ing, err := h.catalog.ResetIngredientLink(r.Context(), chi.URLParam(r, "ingredientID"))
+	if err != nil {
+		writeCatalogStorageError(w, r, err, "Failed to reset ingredient link")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ing)
+}
+
+func (req CatalogIngredientRequest) entry() *models.CatalogIngredient {
+	return &models.CatalogIngredient{
+		Name:      req.Name,
+		Plural:    req.Plural,
+		Category:  req.Category,
+		Synonyms:  req.Synonyms,
+		Allergens: req.Allergens,
+		Diets:     req.Diets,
+
+		AllergensReviewed: req.AllergensReviewed,
+	}
+}
+
+func writeCatalogStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrCatalogTermTaken):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusConflict,
+			"A name, plural or synonym already belongs to another catalog ingredient", "CATALOG_TERM_TAKEN", err))
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Catalog ingredient or recipe ingredient not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
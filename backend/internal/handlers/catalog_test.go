@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+func TestCatalogHandler_Lifecycle(t *testing.T) {
+	store := storage.NewMemory()
+	ctx := context.Background()
+	recipe := models.Recipe{Title: "Noodles", Ingredients: []models.Ingredient{
+		{Name: "scallions", Amount: "2"}, {Name: "noodles", Amount: "200", Unit: "g"}, {Name: "noodles", Amount: "1"},
+	}}
+	if err := store.CreateRecipe(ctx, &recipe); err != nil {
+		t.Fatalf("failed to create recipe: %v", err)
+	}
+	handler := NewCatalogHandler(store)
+
+	w := serveAs(t, http.MethodPost, "/api/admin/ingredients", `{"name": "green onion", "category": "produce", "synonyms": ["scallion"]}`, "admin", nil, handler.HandleCreateCatalogIngredient)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var onion models.CatalogIngredient
+	if err := json.Unmarshal(w.Body.Bytes(), &onion); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	stored, _ := store.GetRecipe(ctx, recipe.ID)
+	if stored.Ingredients[0].CatalogID != onion.ID {
+		t.Errorf("Expected scallions to be linked to %s, got %+v", onion.ID, stored.Ingredients[0])
+	}
+
+	w = serveAs(t, http.MethodPost, "/api/admin/ingredients", `{"name": "Scallions"}`, "admin", nil, handler.HandleCreateCatalogIngredient)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "CATALOG_TERM_TAKEN") {
+		t.Errorf("Expected status 409 for a taken synonym, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveAs(t, http.MethodPost, "/api/admin/ingredients", `{"name": "", "synonyms": ["x"]}`, "admin", nil, handler.HandleCreateCatalogIngredient)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"name"`) {
+		t.Errorf("Expected a name validation error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAs(t, http.MethodGet, "/api/admin/ingredients?q=scal", "", "admin", nil, handler.HandleListCatalog)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"green onion"`) {
+		t.Errorf("Expected the entry listed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAs(t, http.MethodGet, "/api/admin/ingredients/unmatched?limit=5", "", "admin", nil, handler.HandleUnmatchedIngredients)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `{"name":"noodles","count":2}`) {
+		t.Errorf("Expected noodles unmatched twice, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAs(t, http.MethodPut, "/api/admin/ingredients/"+onion.ID, `{"name": "green onion", "synonyms": ["scallion", "noodle"], "allergens_reviewed": true}`, "admin",
+		map[string]string{"id": onion.ID}, handler.HandleUpdateCatalogIngredient)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"noodle"`) || !strings.Contains(w.Body.String(), `"allergens_reviewed":true`) {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := store.GetRecipe(ctx, recipe.ID); !stored.Dietary.Complete {
+		t.Errorf("Expected the recipe's labels complete once its entry is reviewed, got %+v", stored.Dietary)
+	}
+
+	noodlesID := stored.Ingredients[1].ID
+	w = serveAs(t, http.MethodPut, "/api/admin/ingredients/links/"+noodlesID, `{"catalog_id": ""}`, "admin",
+		map[string]string{"ingredientID": noodlesID}, handler.HandleLinkIngredient)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"catalog_match":"manual"`) || strings.Contains(w.Body.String(), "catalog_id") {
+		t.Errorf("Expected the ingredient unlinked by hand, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveAs(t, http.MethodPut, "/api/admin/ingredients/links/"+noodlesID, `{"catalog_id": "missing"}`, "admin",
+		map[string]string{"ingredientID": noodlesID}, handler.HandleLinkIngredient)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing entry, got %d", w.Code)
+	}
+	w = serveAs(t, http.MethodDelete, "/api/admin/ingredients/links/"+noodlesID, "", "admin",
+		map[string]string{"ingredientID": noodlesID}, handler.HandleResetIngredientLink)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"catalog_name":"green onion"`) {
+		t.Errorf("Expected the ingredient matched again, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAs(t, http.MethodPost, "/api/admin/ingredients/relink", "", "admin", nil, handler.HandleRelinkIngredients)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"relinked":0`) {
+		t.Errorf("Expected nothing to relink, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveAs(t, http.MethodDelete, "/api/admin/ingredients/"+onion.ID, "", "admin", map[string]string{"id": onion.ID}, handler.HandleDeleteCatalogIngredient)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveAs(t, http.MethodGet, "/api/admin/ingredients/"+onion.ID, "", "admin", map[string]string{"id": onion.ID}, handler.HandleCatalogIngredient)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestCatalogHandler_RequiresAdmin(t *testing.T) {
+	handler := appmiddleware.RequireAdmin(http.HandlerFunc(NewCatalogHandler(storage.NewMemory()).HandleRelinkIngredients))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, withUser(httptest.NewRequest(http.MethodPost, "/api/admin/ingredients/relink", nil), "cook", false))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a non-admin, got %d", w.Code)
+	}
+}
@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+type CollectionHandler struct {
+	templates   *template.Template
+	collections storage.CollectionRepository
+	recipes     storage.RecipeRepository
+}
+
+// CollectionRequest creates a collection or, on update, changes only the
+// fields that are set. RecipeID optionally adds a recipe to a new
+// collection.
+type CollectionRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	IsPublic    *bool   `json:"is_public"`
+	RecipeID    string  `json:"recipe_id"`
+}
+
+// CollectionRecipeRequest adds or moves a recipe. Position is 1-based;
+// 0 appends.
+type CollectionRecipeRequest struct {
+	RecipeID string `json:"recipe_id"`
+	Position int    `json:"position"`
+}
+
+// CollectionResponse is a collection with its recipes in order.
+type CollectionResponse struct {
+	models.RecipeCollection
+	Recipes []models.Recipe `json:"recipes"`
+}
+
+func NewCollectionHandler(collections storage.CollectionRepository, recipes storage.RecipeRepository) *CollectionHandler {
+	templates, err := template.ParseFiles("web/templates/collection-picker.html")
+	if err != nil {
+		// Templates not found, create empty template for tests
+		templates = template.New("")
+	}
+	return &CollectionHandler{
+		templates:   templates,
+		collections: collections,
+		recipes:     recipes,
+	}
+}
+
+// HandleListCollections lists the signed-in user's collections, or only the
+// public collections of the user named by user_id. HTMX requests with a
+// recipe_id get the collection picker for that recipe instead.
+func (h *CollectionHandler) HandleListCollections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewerID, _ := appmiddleware.GetUserID(ctx)
+
+	ownerID := strings.TrimSpace(r.URL.Query().Get("user_id"))
+	if ownerID == "" {
+		ownerID = viewerID
+	}
+	if ownerID == "" {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	if recipeID := r.URL.Query().Get("recipe_id"); r.Header.Get("HX-Request") == "true" && recipeID != "" {
+		h.renderPicker(w, r, recipeID)
+		return
+	}
+
+	collections, err := h.collections.ListCollections(ctx, ownerID, ownerID == viewerID)
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to list collections")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"collections": collections})
+}
+
+func (h *CollectionHandler) HandleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req CollectionRequest
+	if err := decodeCollectionRequest(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	collection := models.RecipeCollection{UserID: userID}
+	req.apply(&collection)
+	if err := collection.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if req.RecipeID != "" {
+		collection.RecipeIDs = []string{req.RecipeID}
+	}
+
+	if err := h.collections.CreateCollection(ctx, &collection); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to create collection")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Collection created", "collection_id", collection.ID, "user_id", userID)
+	if r.Header.Get("HX-Request") == "true" && req.RecipeID != "" {
+		h.renderPicker(w, r, req.RecipeID)
+		return
+	}
+	h.writeCollection(w, r, collection.ID, http.StatusCreated)
+}
+
+// HandleCollection returns a collection with its recipes. Private
+// collections are reported as not found to everyone but their owner.
+func (h *CollectionHandler) HandleCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	viewerID, _ := appmiddleware.GetUserID(ctx)
+
+	collection, err := h.collections.GetCollection(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to load collection")
+		return
+	}
+	if !collection.CanBeViewedBy(viewerID) {
+		http.Error(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+
+	h.writeCollection(w, r, collection.ID, http.StatusOK)
+}
+
+func (h *CollectionHandler) HandleUpdateCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, ok := h.authorizeCollectionChange(w, r, ctx)
+	if !ok {
+		return
+	}
+
+	var req CollectionRequest
+	if err := decodeCollectionRequest(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.apply(collection)
+	if err := collection.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.collections.UpdateCollection(ctx, collection); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to update collection")
+		return
+	}
+
+	h.writeCollection(w, r, collection.ID, http.StatusOK)
+}
+
+func (h *CollectionHandler) HandleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, ok := h.authorizeCollectionChange(w, r, ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.collections.DeleteCollection(ctx, collection.ID); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to delete collection")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Collection deleted successfully",
+	})
+}
+
+// HandleAddCollectionRecipe adds recipe_id at position, appending when it
+// is 0. HTMX requests get the refreshed collection picker.
+func (h *CollectionHandler) HandleAddCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, ok := h.authorizeCollectionChange(w, r, ctx)
+	if !ok {
+		return
+	}
+
+	var req CollectionRecipeRequest
+	if err := decodeCollectionRecipeRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if req.RecipeID == "" {
+		writeFieldError(w, &models.FieldError{Field: "recipe_id", Message: "recipe_id is required"})
+		return
+	}
+
+	if err := h.collections.AddCollectionRecipe(ctx, collection.ID, req.RecipeID, req.Position); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to add recipe to collection")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderPicker(w, r, req.RecipeID)
+		return
+	}
+	h.writeCollection(w, r, collection.ID, http.StatusCreated)
+}
+
+// HandleMoveCollectionRecipe moves a recipe to the given position.
+func (h *CollectionHandler) HandleMoveCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, ok := h.authorizeCollectionChange(w, r, ctx)
+	if !ok {
+		return
+	}
+
+	var req CollectionRecipeRequest
+	if err := decodeCollectionRecipeRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if req.Position < 1 {
+		writeFieldError(w, &models.FieldError{Field: "position", Message: "position must be at least 1"})
+		return
+	}
+
+	if err := h.collections.MoveCollectionRecipe(ctx, collection.ID, chi.URLParam(r, "recipeID"), req.Position); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to move recipe in collection")
+		return
+	}
+
+	h.writeCollection(w, r, collection.ID, http.StatusOK)
+}
+
+func (h *CollectionHandler) HandleRemoveCollectionRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collection, ok := h.authorizeCollectionChange(w, r, ctx)
+	if !ok {
+		return
+	}
+
+	recipeID := chi.URLParam(r, "recipeID")
+	if err := h.collections.RemoveCollectionRecipe(ctx, collection.ID, recipeID); err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to remove recipe from collection")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderPicker(w, r, recipeID)
+		return
+	}
+	h.writeCollection(w, r, collection.ID, http.StatusOK)
+}
+
+// authorizeCollectionChange loads the collection named in the URL and
+// checks that the authenticated user owns it, writing the error response
+// and returning false otherwise. Collections the user cannot see are
+// reported as not found.
+func (h *CollectionHandler) authorizeCollectionChange(w http.ResponseWriter, r *http.Request, ctx context.Context) (*models.RecipeCollection, bool) {
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	collection, err := h.collections.GetCollection(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to load collection")
+		return nil, false
+	}
+
+	if collection.UserID != userID {
+		if !collection.CanBeViewedBy(userID) {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return nil, false
+		}
+		logger.FromContext(ctx).Warn("Collection change forbidden", "collection_id", collection.ID, "user_id", userID)
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusForbidden, "Only the collection's owner can change it", "FORBIDDEN", nil))
+		return nil, false
+	}
+	return collection, true
+}
+
+// writeCollection reloads a collection and writes it with its recipes.
+func (h *CollectionHandler) writeCollection(w http.ResponseWriter, r *http.Request, id string, status int) {
+	ctx := r.Context()
+
+	collection, err := h.collections.GetCollection(ctx, id)
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to load collection")
+		return
+	}
+
+	recipes, err := h.recipes.GetRecipes(ctx, collection.RecipeIDs)
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to load collection recipes")
+		return
+	}
+	response := CollectionResponse{RecipeCollection: *collection, Recipes: recipes}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// renderPicker writes the "save to collection" partial for a recipe,
+// listing the signed-in user's collections.
+func (h *CollectionHandler) renderPicker(w http.ResponseWriter, r *http.Request, recipeID string) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	collections, err := h.collections.ListCollections(ctx, userID, true)
+	if err != nil {
+		writeCollectionStorageError(w, r, err, "Failed to list collections")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	tmpl := h.templates.Lookup("collection-picker.html")
+	if tmpl == nil {
+		http.Error(w, "Template collection-picker.html not found", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"recipeID":    recipeID,
+		"collections": collections,
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (req *CollectionRequest) apply(collection *models.RecipeCollection) {
+	if req.Name != nil {
+		collection.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		collection.Description = *req.Description
+	}
+	if req.IsPublic != nil {
+		collection.IsPublic = *req.IsPublic
+	}
+}
+
+// isFormRequest reports whether the body is form encoded, as HTMX sends it.
+func isFormRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+}
+
+func decodeCollectionRequest(r *http.Request, req *CollectionRequest) error {
+	if !isFormRequest(r) {
+		return json.NewDecoder(r.Body).Decode(req)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	if r.PostForm.Has("name") {
+		name := r.PostForm.Get("name")
+		req.Name = &name
+	}
+	if r.PostForm.Has("description") {
+		description := r.PostForm.Get("description")
+		req.Description = &description
+	}
+	if r.PostForm.Has("is_public") {
+		isPublic, err := strconv.ParseBool(r.PostForm.Get("is_public"))
+		if err != nil {
+			return err
+		}
+		req.IsPublic = &isPublic
+	}
+	req.RecipeID = r.PostForm.Get("recipe_id")
+	return nil
+}
+
+// decodeCollectionRecipeRequest returns a *models.FieldError for an
+// invalid position.
+func decodeCollectionRecipeRequest(r *http.Request, req *CollectionRecipeRequest) error {
+	if !isFormRequest(r) {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return errors.New("Invalid request body")
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return errors.New("Invalid request body")
+		}
+		req.RecipeID = r.PostForm.Get("recipe_id")
+		var err error
+		if req.Position, err = intParam(r.PostForm, "position"); err != nil {
+			return err
+		}
+	}
+	if req.Position < 0 {
+		return &models.FieldError{Field: "position", Message: "position cannot be negative"}
+	}
+	return nil
+}
+
+// writeCollectionStorageError maps collection repository errors to HTTP
+// responses, reporting a recipe added twice as 409 Conflict.
+func writeCollectionStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrAlreadyInCollection):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusConflict, "Recipe is already in the collection", "ALREADY_IN_COLLECTION", err))
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Collection or recipe not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
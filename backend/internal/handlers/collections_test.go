@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+// newTestCollectionHandler returns a handler over the seeded demo recipes
+// and the IDs of two registered users.
+func newTestCollectionHandler(t *testing.T) (*CollectionHandler, []models.Recipe, string, string) {
+	t.Helper()
+	store, recipes, owner, other := newSeededStore(t)
+	return NewCollectionHandler(store, store), recipes, owner, other
+}
+
+func TestCollectionHandler_Lifecycle(t *testing.T) {
+	handler, recipes, owner, other := newTestCollectionHandler(t)
+
+	w := httptest.NewRecorder()
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/collections", strings.NewReader(`{"name": " Favourites ", "recipe_id": "`+recipes[0].ID+`"}`)), owner, false)
+	handler.HandleCreateCollection(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created CollectionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if created.Name != "Favourites" || created.IsPublic || len(created.Recipes) != 1 || created.Recipes[0].ID != recipes[0].ID {
+		t.Fatalf("Unexpected collection: %+v", created)
+	}
+	id := created.ID
+
+	w = httptest.NewRecorder()
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/collections", strings.NewReader(`{"name": "Lost", "recipe_id": "missing"}`)), owner, false)
+	handler.HandleCreateCollection(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing recipe, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.HandleListCollections(w, withUser(httptest.NewRequest(http.MethodGet, "/api/collections", nil), owner, false))
+	if strings.Contains(w.Body.String(), "Lost") {
+		t.Errorf("Expected no collection saved for a missing recipe, got %s", w.Body.String())
+	}
+
+	serve := func(method, target, body, userID string, params map[string]string, fn http.HandlerFunc) (*httptest.ResponseRecorder, CollectionResponse) {
+		t.Helper()
+		w := serveAs(t, method, target, body, userID, params, fn)
+		var response CollectionResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	params := map[string]string{"id": id}
+
+	w, _ = serve(http.MethodPost, "/api/collections/"+id+"/recipes", `{"recipe_id": "`+recipes[1].ID+`", "position": 1}`, owner, params, handler.HandleAddCollectionRecipe)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 adding a recipe, got %d: %s", w.Code, w.Body.String())
+	}
+	w, _ = serve(http.MethodPost, "/api/collections/"+id+"/recipes", `{"recipe_id": "`+recipes[1].ID+`"}`, owner, params, handler.HandleAddCollectionRecipe)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 adding a recipe twice, got %d", w.Code)
+	}
+
+	moveParams := map[string]string{"id": id, "recipeID": recipes[1].ID}
+	w, moved := serve(http.MethodPut, "/api/collections/"+id+"/recipes/"+recipes[1].ID, `{"position": 2}`, owner, moveParams, handler.HandleMoveCollectionRecipe)
+	if w.Code != http.StatusOK || len(moved.RecipeIDs) != 2 || moved.RecipeIDs[1] != recipes[1].ID {
+		t.Errorf("Expected the recipe moved to position 2, got %d: %+v", w.Code, moved.RecipeIDs)
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		expected int
+	}{
+		{"Owner sees private collection", owner, http.StatusOK},
+		{"Other user cannot see it", other, http.StatusNotFound},
+		{"Anonymous cannot see it", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := serve(http.MethodGet, "/api/collections/"+id, "", tt.userID, params, handler.HandleCollection)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+
+	w, _ = serve(http.MethodPut, "/api/collections/"+id, `{"is_public": true}`, other, params, handler.HandleUpdateCollection)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 changing another user's private collection, got %d", w.Code)
+	}
+	w, updated := serve(http.MethodPut, "/api/collections/"+id, `{"name": "Best", "is_public": true}`, owner, params, handler.HandleUpdateCollection)
+	if w.Code != http.StatusOK || updated.Name != "Best" || !updated.IsPublic || len(updated.Recipes) != 2 {
+		t.Errorf("Expected a renamed public collection, got %d: %+v", w.Code, updated)
+	}
+	w, _ = serve(http.MethodPut, "/api/collections/"+id, `{"name": " "}`, owner, params, handler.HandleUpdateCollection)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty name, got %d", w.Code)
+	}
+
+	w, _ = serve(http.MethodGet, "/api/collections/"+id, "", "", params, handler.HandleCollection)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected a public collection to be visible anonymously, got %d", w.Code)
+	}
+	w, _ = serve(http.MethodDelete, "/api/collections/"+id+"/recipes/"+recipes[0].ID, "", other, map[string]string{"id": id, "recipeID": recipes[0].ID}, handler.HandleRemoveCollectionRecipe)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 changing another user's public collection, got %d", w.Code)
+	}
+
+	w, _ = serve(http.MethodDelete, "/api/collections/"+id, "", owner, params, handler.HandleDeleteCollection)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 deleting, got %d", w.Code)
+	}
+	w, _ = serve(http.MethodGet, "/api/collections/"+id, "", owner, params, handler.HandleCollection)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestCollectionHandler_List(t *testing.T) {
+	handler, _, owner, other := newTestCollectionHandler(t)
+
+	for _, body := range []string{`{"name": "Private"}`, `{"name": "Shared", "is_public": true}`} {
+		w := httptest.NewRecorder()
+		handler.HandleCreateCollection(w, withUser(httptest.NewRequest(http.MethodPost, "/api/collections", strings.NewReader(body)), owner, false))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		userID   string
+		expected string
+	}{
+		{"Own collections", "/api/collections", owner, "Private,Shared"},
+		{"Own collections by user_id", "/api/collections?user_id=" + owner, owner, "Private,Shared"},
+		{"Another user's collections", "/api/collections?user_id=" + owner, other, "Shared"},
+		{"Anonymous by user_id", "/api/collections?user_id=" + owner, "", "Shared"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.userID != "" {
+				req = withUser(req, tt.userID, false)
+			}
+			w := httptest.NewRecorder()
+			handler.HandleListCollections(w, req)
+
+			var response struct {
+				Collections []models.RecipeCollection `json:"collections"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			var names []string
+			for _, c := range response.Collections {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, ",") != tt.expected {
+				t.Errorf("Expected %s, got %v", tt.expected, names)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleListCollections(w, httptest.NewRequest(http.MethodGet, "/api/collections", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 listing anonymously, got %d", w.Code)
+	}
+}
+
+func TestCollectionHandler_PickerHTMX(t *testing.T) {
+	handler, recipes, owner, _ := newTestCollectionHandler(t)
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/collection-picker.html"))
+	recipeID := recipes[0].ID
+
+	form := url.Values{"name": {"Desserts"}, "recipe_id": {recipeID}}
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/collections", strings.NewReader(form.Encode())), owner, false)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleCreateCollection(w, req)
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `id="collection-picker"`) {
+		t.Fatalf("Expected the collection picker, got %d: %s", w.Code, body)
+	}
+	if !strings.Contains(body, "Desserts") || !strings.Contains(body, "✓ Saved") {
+		t.Errorf("Expected the new collection marked as containing the recipe, got %s", body)
+	}
+
+	req = withUser(httptest.NewRequest(http.MethodGet, "/api/collections?recipe_id="+recipes[1].ID, nil), owner, false)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleListCollections(w, req)
+	if !strings.Contains(w.Body.String(), `hx-post="/api/collections/`) {
+		t.Errorf("Expected an add button for a recipe not yet saved, got %s", w.Body.String())
+	}
+}
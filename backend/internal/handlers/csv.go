@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/recipecsv"
+	"recipe-app/internal/storage"
+)
+
+// CSVImportResponse reports a bulk CSV import. Recipes lists the rows that
+// were, or in a dry run would be, created. When any row has errors nothing
+// is created and Errors lists them.
+type CSVImportResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Created int                 `json:"created"`
+	Recipes []CSVImportedRecipe `json:"recipes"`
+	Errors  []CSVRowError       `json:"errors,omitempty"`
+}
+
+// CSVImportedRecipe is a recipe read from a CSV row. ID is empty in a dry
+// run.
+type CSVImportedRecipe struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title"`
+}
+
+// CSVRowError lists the problems with one CSV row. Line is the row's line
+// in the file, counting the header as line 1.
+type CSVRowError struct {
+	Line   int      `json:"line"`
+	Title  string   `json:"title,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+// importUploadedCSV imports the "csv" field of a parsed multipart form.
+func (h *ImportHandler) importUploadedCSV(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("csv")
+	if err != nil {
+		writeFieldError(w, &models.FieldError{Field: "csv", Message: "a CSV file is required"})
+		return
+	}
+	defer file.Close()
+	h.importCSV(w, r, file)
+}
+
+// importCSV creates the current user's recipes from a CSV file laid out as
+// described in package recipecsv. Every row is validated first and the
+// file is only imported when all of them are valid. With dry_run=true the
+// rows are validated and reported without creating anything.
+func (h *ImportHandler) importCSV(w http.ResponseWriter, r *http.Request, body io.Reader) {
+	ctx := r.Context()
+
+	dryRun, err := boolParam(r.URL.Query(), "dry_run")
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := recipecsv.Read(body)
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusRequestEntityTooLarge, "The CSV file is too large to import", "PAYLOAD_TOO_LARGE", err))
+		return
+	case err != nil:
+		writeFieldError(w, &models.FieldError{Field: "csv", Message: err.Error()})
+		return
+	case len(rows) == 0:
+		writeFieldError(w, &models.FieldError{Field: "csv", Message: "the CSV file has no recipes"})
+		return
+	}
+
+	response := CSVImportResponse{DryRun: dryRun, Recipes: []CSVImportedRecipe{}}
+	for _, row := range rows {
+		if len(row.Errors) == 0 {
+			continue
+		}
+		rowErr := CSVRowError{Line: row.Line, Errors: row.Errors}
+		if row.Recipe != nil {
+			rowErr.Title = row.Recipe.Title
+		}
+		response.Errors = append(response.Errors, rowErr)
+	}
+	if len(response.Errors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	for _, row := range rows {
+		imported := CSVImportedRecipe{Line: row.Line, Title: row.Recipe.Title}
+		if !dryRun {
+			row.Recipe.AuthorID = userID
+			if err := h.recipes.CreateRecipe(ctx, row.Recipe); err != nil {
+				logger.LogError(ctx, err, fmt.Sprintf("Failed to import CSV line %d", row.Line))
+				http.Error(w, fmt.Sprintf("Failed to import recipes: %d of %d were created", response.Created, len(rows)), http.StatusInternalServerError)
+				return
+			}
+			imported.ID = row.Recipe.ID
+			response.Created++
+		}
+		response.Recipes = append(response.Recipes, imported)
+	}
+
+	logger.FromContext(ctx).Info("Recipes imported from CSV", "rows", len(rows), "created", response.Created, "dry_run", dryRun)
+	w.Header().Set("Content-Type", "application/json")
+	if !dryRun {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleExport streams the current user's recipes, oldest first, as a CSV
+// file that POST /api/recipes/import reads back.
+func (h *ImportHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	query := storage.RecipeQuery{
+		Filter:  models.RecipeFilter{AuthorID: userID},
+		Sort:    storage.SortOldest,
+		PerPage: storage.MaxPerPage,
+	}
+	result, err := h.recipes.ListRecipes(ctx, query)
+	if err != nil {
+		logger.LogError(ctx, err, "Failed to export recipes")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="recipes.csv"`)
+	writer := recipecsv.NewWriter(w)
+	if err := writer.WriteHeader(); err != nil {
+		return
+	}
+
+	exported := 0
+	for {
+		for i := range result.Recipes {
+			if err := writer.Write(&result.Recipes[i]); err != nil {
+				return
+			}
+			exported++
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+		if result.NextCursor == "" {
+			break
+		}
+
+		// Keyset paging keeps the export consistent while recipes are
+		// added. A failure now can only cut the file short.
+		query.Cursor = result.NextCursor
+		if result, err = h.recipes.ListRecipes(ctx, query); err != nil {
+			logger.LogError(ctx, err, fmt.Sprintf("Recipe export stopped after %d recipes", exported))
+			return
+		}
+	}
+
+	logger.FromContext(ctx).Info("Recipes exported to CSV", "count", exported)
+}
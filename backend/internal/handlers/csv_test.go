@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+const importCSV = "title,servings,tags,ingredients,instructions\n" +
+	"Lemonade,4,\"drinks, summer\",\"4 lemons, juiced\n1 | cup | sugar\",Stir everything together.\n" +
+	"Iced Tea,2,drinks,2 | | tea bags,\"Steep | 5 |\nChill\"\n"
+
+func decodeCSVImport(t *testing.T, w *httptest.ResponseRecorder) CSVImportResponse {
+	t.Helper()
+	var response CSVImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v: %s", err, w.Body.String())
+	}
+	return response
+}
+
+func postCSV(handler *ImportHandler, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := withUser(httptest.NewRequest(http.MethodPost, target, strings.NewReader(body)), "cook", false)
+	req.Header.Set("Content-Type", "text/csv")
+	handler.HandleImport(w, req)
+	return w
+}
+
+func TestImportHandler_CSV(t *testing.T) {
+	store := storage.NewMemory()
+	handler := NewImportHandler(stubFetcher{}, store)
+	ctx := context.Background()
+
+	w := postCSV(handler, "/api/recipes/import?dry_run=true", importCSV)
+	if w.Code != http.StatusOK {
+		t.Fatalf("dry run status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	response := decodeCSVImport(t, w)
+	if !response.DryRun || response.Created != 0 || len(response.Recipes) != 2 || response.Recipes[0].ID != "" {
+		t.Errorf("dry run response = %+v", response)
+	}
+	if result, _ := store.ListRecipes(ctx, storage.RecipeQuery{}); result.Total != 0 {
+		t.Errorf("dry run created %d recipes", result.Total)
+	}
+
+	w = postCSV(handler, "/api/recipes/import", importCSV)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("import status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	response = decodeCSVImport(t, w)
+	if response.Created != 2 || response.Recipes[1].Line != 4 || response.Recipes[1].Title != "Iced Tea" {
+		t.Errorf("import response = %+v", response)
+	}
+
+	recipe, err := store.GetRecipe(ctx, response.Recipes[0].ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if recipe.AuthorID != "cook" || recipe.Servings != 4 || len(recipe.Ingredients) != 2 || recipe.Ingredients[0].Notes != "juiced" {
+		t.Errorf("imported recipe = %+v", recipe)
+	}
+}
+
+func TestImportHandler_CSVRowErrors(t *testing.T) {
+	store := storage.NewMemory()
+	handler := NewImportHandler(stubFetcher{}, store)
+
+	w := postCSV(handler, "/api/recipes/import", "title,prep_time\nSoup,-5\nStew,10\n,abc\n")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+	}
+	response := decodeCSVImport(t, w)
+	if len(response.Errors) != 2 || response.Errors[0].Line != 2 || response.Errors[0].Title != "Soup" || response.Errors[1].Line != 4 {
+		t.Errorf("errors = %+v", response.Errors)
+	}
+	if len(response.Errors) == 2 && len(response.Errors[1].Errors) != 2 {
+		t.Errorf("line 4 errors = %v, want 2", response.Errors[1].Errors)
+	}
+	if result, _ := store.ListRecipes(context.Background(), storage.RecipeQuery{}); result.Total != 0 {
+		t.Errorf("import with errors created %d recipes", result.Total)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"Unknown column", "/api/recipes/import", "title,rating\nSoup,5\n"},
+		{"No recipes", "/api/recipes/import", "title\n"},
+		{"Bad dry run", "/api/recipes/import?dry_run=maybe", importCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postCSV(handler, tt.target, tt.body); w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestImportHandler_CSVUpload(t *testing.T) {
+	handler := NewImportHandler(stubFetcher{}, storage.NewMemory())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("csv", "recipes.csv")
+	part.Write([]byte(importCSV))
+	form.Close()
+
+	w := httptest.NewRecorder()
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/recipes/import", &body), "cook", false)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	handler.HandleImport(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if response := decodeCSVImport(t, w); response.Created != 2 {
+		t.Errorf("created = %d, want 2", response.Created)
+	}
+}
+
+func TestImportHandler_Export(t *testing.T) {
+	store := storage.NewMemory()
+	handler := NewImportHandler(stubFetcher{}, store)
+	ctx := context.Background()
+
+	if err := storage.SeedDemoRecipes(ctx, store); err != nil {
+		t.Fatalf("failed to seed recipes: %v", err)
+	}
+	for i := 0; i < storage.MaxPerPage+1; i++ {
+		recipe := models.Recipe{Title: "Soup", AuthorID: "cook"}
+		if err := store.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+	}
+	if w := postCSV(handler, "/api/recipes/import", importCSV); w.Code != http.StatusCreated {
+		t.Fatalf("import status = %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/recipes/export", nil), "cook", false)
+	handler.HandleExport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	exported := w.Body.String()
+	if strings.Contains(exported, "Spaghetti Bolognese") {
+		t.Error("export includes other users' recipes")
+	}
+	if !strings.HasSuffix(exported, "\"Steep | 5 | \nChill\",\n") {
+		t.Errorf("export does not end with the imported iced tea:\n%s", exported[len(exported)-200:])
+	}
+
+	// The export imports back as the same recipes.
+	other := NewImportHandler(stubFetcher{}, storage.NewMemory())
+	w = postCSV(other, "/api/recipes/import?dry_run=true", exported)
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-import status = %d: %s", w.Code, w.Body.String())
+	}
+	if response := decodeCSVImport(t, w); len(response.Recipes) != storage.MaxPerPage+3 {
+		t.Errorf("re-import read %d recipes, want %d", len(response.Recipes), storage.MaxPerPage+3)
+	}
+}
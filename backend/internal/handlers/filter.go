@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// bindRecipeQuery builds a validated RecipeQuery from the URL query string.
+// Errors are *models.FieldError naming the offending parameter.
+//
+// Supported parameters are q, sort, page or cursor, per_page (limit is
+// accepted as an alias), category, cuisine, difficulty, tag (repeatable)
+// or tags (comma-separated), tag_match (all or any), max_prep_time,
+// max_cook_time (cook_time is accepted as an alias, as sent by the recipes
+// page), min_servings, max_servings, diet, exclude_diet, allergen and
+// exclude_allergen (each repeatable or comma-separated), and pantry with
+// max_missing to search by the signed-in user's pantry.
+// Empty values are ignored so that "any" options in forms can send "".
+func bindRecipeQuery(r *http.Request) (storage.RecipeQuery, error) {
+	values := r.URL.Query()
+
+	query := storage.RecipeQuery{
+		Search: values.Get("q"),
+		Sort:   storage.RecipeSort(strings.ToLower(strings.TrimSpace(values.Get("sort")))),
+	}
+	switch query.Sort {
+	case "", storage.SortNewest, storage.SortOldest, storage.SortTitle, storage.SortRating, storage.SortRelevance, storage.SortPantry:
+	default:
+		return query, &models.FieldError{Field: "sort", Message: "sort must be newest, oldest, title, rating, relevance, or pantry"}
+	}
+
+	filter, err := bindRecipeFilter(values)
+	if err != nil {
+		return query, err
+	}
+	query.Filter = filter
+
+	pantry, err := boolParam(values, "pantry")
+	if err != nil {
+		return query, err
+	}
+	if pantry {
+		userID, ok := appmiddleware.GetUserID(r.Context())
+		if !ok {
+			return query, &models.FieldError{Field: "pantry", Message: "pantry search requires signing in"}
+		}
+		query.Filter.PantryUserID = userID
+	} else if query.Filter.MaxMissing != nil {
+		return query, &models.FieldError{Field: "max_missing", Message: "max_missing requires pantry=true"}
+	} else if query.Sort == storage.SortPantry {
+		return query, &models.FieldError{Field: "sort", Message: "sort=pantry requires pantry=true"}
+	}
+
+	if query.Page, err = intParam(values, "page"); err != nil {
+		return query, err
+	}
+	for _, name := range []string{"per_page", "limit"} {
+		if values.Get(name) == "" {
+			continue
+		}
+		if query.PerPage, err = intParam(values, name); err != nil {
+			return query, err
+		}
+		break
+	}
+
+	query.Cursor = strings.TrimSpace(values.Get("cursor"))
+	if query.Cursor != "" && query.Page != 0 {
+		return query, &models.FieldError{Field: "cursor", Message: "cursor cannot be combined with page"}
+	}
+	return query, nil
+}
+
+// errInvalidCursor reports a cursor that was tampered with or belongs to a
+// different search, filter or sort.
+var errInvalidCursor = &models.FieldError{Field: "cursor", Message: "cursor is invalid for this query"}
+
+func bindRecipeFilter(values url.Values) (models.RecipeFilter, error) {
+	filter := models.RecipeFilter{
+		Category:   strings.TrimSpace(values.Get("category")),
+		Cuisine:    strings.TrimSpace(values.Get("cuisine")),
+		Difficulty: strings.ToLower(strings.TrimSpace(values.Get("difficulty"))),
+		TagMatch:   strings.ToLower(strings.TrimSpace(values.Get("tag_match"))),
+		AuthorID:   strings.TrimSpace(values.Get("author_id")),
+	}
+
+	filter.Tags = append(filter.Tags, values["tag"]...)
+	for _, list := range values["tags"] {
+		filter.Tags = append(filter.Tags, strings.Split(list, ",")...)
+	}
+	filter.Diets = listParam(values, "diet")
+	filter.ExcludeDiets = listParam(values, "exclude_diet")
+	filter.Allergens = listParam(values, "allergen")
+	filter.ExcludeAllergens = listParam(values, "exclude_allergen")
+
+	var err error
+	ints := []struct {
+		dest  *int
+		names []string
+	}{
+		{&filter.MaxPrepTime, []string{"max_prep_time"}},
+		{&filter.MaxCookTime, []string{"max_cook_time", "cook_time"}},
+		{&filter.MinServings, []string{"min_servings"}},
+		{&filter.MaxServings, []string{"max_servings"}},
+	}
+	for _, p := range ints {
+		for _, name := range p.names {
+			if values.Get(name) == "" {
+				continue
+			}
+			if *p.dest, err = intParam(values, name); err != nil {
+				return filter, err
+			}
+			break
+		}
+	}
+
+	if values.Get("max_missing") != "" {
+		maxMissing, err := intParam(values, "max_missing")
+		if err != nil {
+			return filter, err
+		}
+		filter.MaxMissing = &maxMissing
+	}
+
+	if err := filter.Validate(); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+// listParam returns the values of a repeatable query parameter, splitting
+// each on commas and dropping empty items.
+func listParam(values url.Values, name string) []string {
+	var items []string
+	for _, list := range values[name] {
+		for _, item := range strings.Split(list, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// intParam parses an optional integer query parameter, returning 0 when it
+// is absent or empty.
+func intParam(values url.Values, name string) (int, error) {
+	raw := strings.TrimSpace(values.Get(name))
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, &models.FieldError{Field: name, Message: name + " must be a whole number"}
+	}
+	return n, nil
+}
+
+// boolParam parses an optional boolean query parameter, returning false
+// when it is absent or empty.
+func boolParam(values url.Values, name string) (bool, error) {
+	raw := strings.TrimSpace(values.Get(name))
+	if raw == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, &models.FieldError{Field: name, Message: name + " must be true or false"}
+	}
+	return b, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+func TestBindRecipeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		expected storage.RecipeQuery
+	}{
+		{
+			name:     "Empty",
+			rawQuery: "",
+			expected: storage.RecipeQuery{},
+		},
+		{
+			name:     "All filters",
+			rawQuery: "q=pasta&sort=title&page=2&per_page=10&category=dinner&cuisine=italian&difficulty=Easy&tag=quick&tags=pasta,spicy&tag_match=ANY&max_prep_time=15&max_cook_time=30&min_servings=2&max_servings=6",
+			expected: storage.RecipeQuery{
+				Search:  "pasta",
+				Sort:    storage.SortTitle,
+				Page:    2,
+				PerPage: 10,
+				Filter: models.RecipeFilter{
+					Category:    "dinner",
+					Cuisine:     "italian",
+					Difficulty:  "easy",
+					Tags:        []string{"quick", "pasta", "spicy"},
+					TagMatch:    models.TagMatchAny,
+					MaxPrepTime: 15,
+					MaxCookTime: 30,
+					MinServings: 2,
+					MaxServings: 6,
+				},
+			},
+		},
+		{
+			name:     "Recipes page form",
+			rawQuery: "q=&cook_time=60&difficulty=",
+			expected: storage.RecipeQuery{Filter: models.RecipeFilter{MaxCookTime: 60}},
+		},
+		{
+			name:     "Cursor with limit",
+			rawQuery: "cursor=abc.def&limit=6",
+			expected: storage.RecipeQuery{PerPage: 6, Cursor: "abc.def"},
+		},
+		{
+			name:     "Dietary criteria",
+			rawQuery: "diet=vegan&diet=&exclude_diet=pescatarian&allergen=nuts&exclude_allergen=gluten,%20Dairy",
+			expected: storage.RecipeQuery{Filter: models.RecipeFilter{
+				Diets:            []string{"vegan"},
+				ExcludeDiets:     []string{"pescatarian"},
+				Allergens:        []string{"nuts"},
+				ExcludeAllergens: []string{"gluten", "Dairy"},
+			}},
+		},
+		{
+			name:     "max_cook_time wins over cook_time",
+			rawQuery: "cook_time=60&max_cook_time=20",
+			expected: storage.RecipeQuery{Filter: models.RecipeFilter{MaxCookTime: 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/recipes?"+tt.rawQuery, nil)
+			got, err := bindRecipeQuery(req)
+			if err != nil {
+				t.Fatalf("bindRecipeQuery() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("bindRecipeQuery() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBindRecipeQuery_Errors(t *testing.T) {
+	tests := []struct {
+		rawQuery string
+		field    string
+	}{
+		{"max_prep_time=ten", "max_prep_time"},
+		{"cook_time=1.5", "cook_time"},
+		{"min_servings=-1", "min_servings"},
+		{"min_servings=6&max_servings=2", "min_servings"},
+		{"difficulty=extreme", "difficulty"},
+		{"tag=quick&tag_match=some", "tag_match"},
+		{"sort=popularity", "sort"},
+		{"page=first", "page"},
+		{"limit=six", "limit"},
+		{"page=2&cursor=abc", "cursor"},
+		{"pantry=yes", "pantry"},
+		{"pantry=true", "pantry"},
+		{"max_missing=2", "max_missing"},
+		{"pantry=true&max_missing=-1", "max_missing"},
+		{"sort=pantry", "sort"},
+		{"diet=paleo", "diet"},
+		{"exclude_allergen=gluten,celery", "exclude_allergen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rawQuery, func(t *testing.T) {
+			_, err := bindRecipeQuery(httptest.NewRequest("GET", "/api/recipes?"+tt.rawQuery, nil))
+
+			var fieldErr *models.FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("bindRecipeQuery() error = %v, want a FieldError", err)
+			}
+			if fieldErr.Field != tt.field {
+				t.Errorf("Field = %q, want %q", fieldErr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestBindRecipeQuery_Pantry(t *testing.T) {
+	req := withUser(httptest.NewRequest("GET", "/api/recipes?pantry=true&max_missing=2&sort=pantry", nil), "user-1", false)
+	got, err := bindRecipeQuery(req)
+	if err != nil {
+		t.Fatalf("bindRecipeQuery() error = %v", err)
+	}
+	if got.Sort != storage.SortPantry || got.Filter.PantryUserID != "user-1" || got.Filter.MaxMissing == nil || *got.Filter.MaxMissing != 2 {
+		t.Errorf("bindRecipeQuery() = %+v, want a pantry search for user-1", got)
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// newSeededStore returns an in-memory store seeded with the demo recipes,
+// newest first in the returned slice, and the IDs of two registered users:
+// one who owns what a test creates and one who should not see or change it.
+func newSeededStore(t *testing.T) (*storage.Memory, []models.Recipe, string, string) {
+	t.Helper()
+
+	store := storage.NewMemory()
+	ctx := context.Background()
+	if err := storage.SeedDemoRecipes(ctx, store); err != nil {
+		t.Fatalf("failed to seed recipes: %v", err)
+	}
+	result, err := store.ListRecipes(ctx, storage.RecipeQuery{})
+	if err != nil {
+		t.Fatalf("failed to list seeded recipes: %v", err)
+	}
+
+	var ids []string
+	for _, name := range []string{"owner", "other"} {
+		user := models.User{Email: name + "@example.com", Username: name, Password: "hash"}
+		if err := store.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	return store, result.Recipes, ids[0], ids[1]
+}
+
+// serveAs calls fn with a request signed in as userID, or anonymous when
+// it is empty, carrying the given route parameters.
+func serveAs(t *testing.T, method, target, body, userID string, params map[string]string, fn http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if userID != "" {
+		req = withUser(req, userID, false)
+	}
+	w := httptest.NewRecorder()
+	fn(w, withURLParams(req, params))
+	return w
+}
+
+// withUser authenticates req as AuthMiddleware would.
+func withUser(req *http.Request, userID string, isAdmin bool) *http.Request {
+	claims := &appmiddleware.Claims{UserID: userID, Email: userID + "@example.com", IsAdmin: isAdmin}
+	ctx := context.WithValue(req.Context(), appmiddleware.UserClaimsKey, claims)
+	ctx = context.WithValue(ctx, appmiddleware.UserIDKey, userID)
+	return req.WithContext(ctx)
+}
+
+func withURLParams(req *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
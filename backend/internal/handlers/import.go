@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/importer"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+type ImportHandler struct {
+	fetcher importer.Fetcher
+	recipes storage.RecipeRepository
+}
+
+// ImportRequest names the page to import a recipe from.
+type ImportRequest struct {
+	URL string `json:"url"`
+}
+
+// ImportResponse is an imported recipe for the user to review and save
+// with POST /api/recipes. Warnings name what must be fixed before the
+// draft can be saved.
+type ImportResponse struct {
+	Recipe    *models.Recipe `json:"recipe"`
+	SourceURL string         `json:"source_url,omitempty"`
+	Warnings  []string       `json:"warnings,omitempty"`
+}
+
+func NewImportHandler(fetcher importer.Fetcher, recipes storage.RecipeRepository) *ImportHandler {
+	return &ImportHandler{fetcher: fetcher, recipes: recipes}
+}
+
+// HandleImport reads a schema.org Recipe from an HTML page and returns it
+// as an unsaved draft. The page is fetched from the url of a JSON body,
+// uploaded as the "file" field of a multipart form, or sent as a text/html
+// body. A CSV file, sent as a text/csv body or as the "csv" field of a
+// multipart form, is instead imported in bulk by importCSV.
+func (h *ImportHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	r.Body = http.MaxBytesReader(w, r.Body, importer.MaxDocumentSize+1<<20)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var doc []byte
+	var sourceURL string
+	var err error
+	switch mediaType {
+	case "multipart/form-data":
+		if err = r.ParseMultipartForm(1 << 20); err == nil && r.MultipartForm.File["csv"] != nil {
+			h.importUploadedCSV(w, r)
+			return
+		}
+		if err == nil {
+			doc, sourceURL, err = readUploadedDocument(r)
+		}
+	case "text/csv":
+		h.importCSV(w, r, r.Body)
+		return
+	case "text/html", "application/xhtml+xml":
+		doc, err = io.ReadAll(r.Body)
+	default:
+		var req ImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		sourceURL = strings.TrimSpace(req.URL)
+		if err := importer.ValidateURL(sourceURL); err != nil {
+			writeFieldError(w, &models.FieldError{Field: "url", Message: err.Error()})
+			return
+		}
+		doc, err = h.fetcher.Fetch(ctx, sourceURL)
+		switch {
+		case errors.Is(err, importer.ErrInvalidURL), errors.Is(err, importer.ErrForbiddenAddress):
+			writeFieldError(w, &models.FieldError{Field: "url", Message: err.Error()})
+			return
+		case errors.Is(err, importer.ErrTooLarge):
+			appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusBadGateway, "The page is too large to import", "FETCH_FAILED", err))
+			return
+		case err != nil:
+			logger.FromContext(ctx).Warn("Recipe import fetch failed", "url", sourceURL, "error", err)
+			appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusBadGateway, "The page could not be fetched", "FETCH_FAILED", err))
+			return
+		}
+	}
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusRequestEntityTooLarge, "The document is too large to import", "PAYLOAD_TOO_LARGE", err))
+		return
+	case err != nil:
+		writeFieldError(w, err)
+		return
+	}
+
+	draft, err := importer.Parse(doc, sourceURL)
+	if errors.Is(err, importer.ErrNoRecipe) {
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusUnprocessableEntity, err.Error(), "NO_RECIPE", err))
+		return
+	}
+	if err != nil {
+		logger.LogError(ctx, err, "Failed to import recipe")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	response := ImportResponse{Recipe: draft, SourceURL: sourceURL}
+	if err := validateRecipe(draft); err != nil {
+		response.Warnings = append(response.Warnings, err.Error())
+	}
+	if len(draft.Ingredients) == 0 {
+		response.Warnings = append(response.Warnings, "no ingredients were found")
+	}
+	if len(draft.Instructions) == 0 {
+		response.Warnings = append(response.Warnings, "no instructions were found")
+	}
+
+	logger.FromContext(ctx).Info("Recipe imported", "url", sourceURL, "title", draft.Title)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// readUploadedDocument reads the "file" field of a multipart form and the
+// optional "url" it was saved from.
+func readUploadedDocument(r *http.Request) ([]byte, string, error) {
+	file, _, err := r.FormFile("file")
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", &models.FieldError{Field: "file", Message: "an HTML file is required"}
+	}
+	defer file.Close()
+
+	doc, err := io.ReadAll(io.LimitReader(file, importer.MaxDocumentSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(doc) > importer.MaxDocumentSize {
+		return nil, "", &http.MaxBytesError{Limit: importer.MaxDocumentSize}
+	}
+
+	sourceURL := strings.TrimSpace(r.FormValue("url"))
+	if sourceURL != "" && importer.ValidateURL(sourceURL) != nil {
+		return nil, "", &models.FieldError{Field: "url", Message: importer.ErrInvalidURL.Error()}
+	}
+	return doc, sourceURL, nil
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/importer"
+	"recipe-app/internal/storage"
+)
+
+// stubFetcher serves pages from memory.
+type stubFetcher map[string]string
+
+func (f stubFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	page, ok := f[rawURL]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return []byte(page), nil
+}
+
+const importPage = `<html><head><script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "Recipe", "name": "Lemonade",
+ "prepTime": "PT5M", "recipeYield": "4 glasses",
+ "recipeIngredient": ["4 lemons, juiced", "1 cup sugar"],
+ "recipeInstructions": [{"@type": "HowToStep", "text": "Stir everything together."}]}
+</script></head></html>`
+
+func decodeImport(t *testing.T, w *httptest.ResponseRecorder) ImportResponse {
+	t.Helper()
+	var response ImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v: %s", err, w.Body.String())
+	}
+	return response
+}
+
+func TestImportHandler_URL(t *testing.T) {
+	handler := NewImportHandler(stubFetcher{
+		"https://example.com/lemonade": importPage,
+		"https://example.com/article":  `<html><body>No recipe</body></html>`,
+	}, storage.NewMemory())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		req := withUser(httptest.NewRequest(http.MethodPost, "/api/recipes/import", strings.NewReader(body)), "cook", false)
+		req.Header.Set("Content-Type", "application/json")
+		handler.HandleImport(w, req)
+		return w
+	}
+
+	w := post(`{"url": "https://example.com/lemonade"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeImport(t, w)
+	recipe := response.Recipe
+	if recipe.Title != "Lemonade" || recipe.PrepTime != 5 || recipe.Servings != 4 || recipe.ID != "" {
+		t.Errorf("Unexpected draft: %+v", recipe)
+	}
+	if len(recipe.Ingredients) != 2 || recipe.Ingredients[1].Unit != "cup" || len(recipe.Instructions) != 1 {
+		t.Errorf("Unexpected draft contents: %+v, %+v", recipe.Ingredients, recipe.Instructions)
+	}
+	if response.SourceURL != "https://example.com/lemonade" || len(response.Warnings) != 0 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	tests := []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"url": "https://example.com/article"}`, http.StatusUnprocessableEntity, "NO_RECIPE"},
+		{`{"url": "https://example.com/missing"}`, http.StatusBadGateway, "FETCH_FAILED"},
+		{`{"url": "file:///etc/passwd"}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+		{`{}`, http.StatusBadRequest, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		w := post(tt.body)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.code) {
+			t.Errorf("%s: expected %d %s, got %d: %s", tt.body, tt.status, tt.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestImportHandler_Upload(t *testing.T) {
+	handler := NewImportHandler(stubFetcher{}, storage.NewMemory())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "lemonade.html")
+	part.Write([]byte(strings.Replace(importPage, `"name": "Lemonade",`, "", 1)))
+	form.WriteField("url", "https://example.com/lemonade")
+	form.Close()
+
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/recipes/import", &body), "cook", false)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler.HandleImport(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeImport(t, w)
+	if response.SourceURL != "https://example.com/lemonade" || len(response.Recipe.Ingredients) != 2 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	if len(response.Warnings) != 1 || response.Warnings[0] != "title is required" {
+		t.Errorf("Expected a warning about the missing title, got %v", response.Warnings)
+	}
+
+	// Raw HTML bodies work too.
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/recipes/import", strings.NewReader(importPage)), "cook", false)
+	req.Header.Set("Content-Type", "text/html; charset=utf-8")
+	w = httptest.NewRecorder()
+	handler.HandleImport(w, req)
+	if w.Code != http.StatusOK || decodeImport(t, w).Recipe.Title != "Lemonade" {
+		t.Errorf("Expected the HTML body to import, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/recipes/import", strings.NewReader(strings.Repeat("a", importer.MaxDocumentSize+2<<20))), "cook", false)
+	req.Header.Set("Content-Type", "text/html")
+	w = httptest.NewRecorder()
+	handler.HandleImport(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for a huge document, got %d", w.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"recipe-app/internal/schemaorg"
+)
+
+// wantsJSONLD reports whether the Accept header lists JSON-LD, as crawlers
+// and linked-data tools send it, without refusing it with q=0.
+func wantsJSONLD(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil || mediaType != schemaorg.ContentType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// requestBaseURL returns the scheme and host the request was made to, for
+// absolute links in JSON-LD.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,554 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// Meal plan views.
+const (
+	MealPlanWeek  = "week"
+	MealPlanMonth = "month"
+)
+
+type MealPlanHandler struct {
+	templates *template.Template
+	plans     storage.MealPlanRepository
+	recipes   storage.RecipeRepository
+}
+
+// MealPlanRequest plans a recipe or, on update, changes only the fields
+// that are set. A recipe stays on its entry; plan another one instead.
+type MealPlanRequest struct {
+	Date     *string `json:"date"`
+	Slot     *string `json:"slot"`
+	RecipeID string  `json:"recipe_id"`
+	Servings *int    `json:"servings"`
+}
+
+// MealPlanCopyRequest copies the week holding From onto the week holding
+// To, one week later when To is empty.
+type MealPlanCopyRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MealPlanResponse is the signed-in user's plan for a week or month.
+type MealPlanResponse struct {
+	View    string                 `json:"view"`
+	Start   string                 `json:"start"`
+	End     string                 `json:"end"`
+	Entries []models.MealPlanEntry `json:"entries"`
+}
+
+// mealPlanCalendar is the data for meal-plan-calendar.html.
+type mealPlanCalendar struct {
+	View    string
+	Date    string
+	Start   string
+	End     string
+	Prev    string
+	Next    string
+	Slots   []string
+	Weeks   [][]mealPlanDay
+	Recipes []models.Recipe
+}
+
+type mealPlanDay struct {
+	Date    string
+	Label   string
+	InRange bool
+	Meals   []mealPlanMeal
+}
+
+type mealPlanMeal struct {
+	Slot    string
+	Entries []models.MealPlanEntry
+}
+
+func NewMealPlanHandler(plans storage.MealPlanRepository, recipes storage.RecipeRepository) *MealPlanHandler {
+	templates, err := template.ParseFiles("web/templates/meal-plan-calendar.html")
+	if err != nil {
+		// Templates not found, create empty template for tests
+		templates = template.New("")
+	}
+	return &MealPlanHandler{
+		templates: templates,
+		plans:     plans,
+		recipes:   recipes,
+	}
+}
+
+// HandleGetMealPlan returns the week (Monday to Sunday) or month holding
+// date, today by default. HTMX requests get the calendar.
+func (h *MealPlanHandler) HandleGetMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	view, date, err := mealPlanView(r.URL.Query(), "")
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderCalendar(w, r, userID, view, date)
+		return
+	}
+
+	start, end := mealPlanRange(view, date)
+	entries, err := h.plans.ListMealPlan(ctx, userID, formatDate(start), formatDate(end))
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to list meal plan")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(MealPlanResponse{
+		View:    view,
+		Start:   formatDate(start),
+		End:     formatDate(end),
+		Entries: entries,
+	})
+}
+
+// HandleCreateMealPlanEntry plans recipe_id for a date and slot.
+func (h *MealPlanHandler) HandleCreateMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req MealPlanRequest
+	if err := decodeMealPlanRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	entry := models.MealPlanEntry{UserID: userID, RecipeID: strings.TrimSpace(req.RecipeID)}
+	req.apply(&entry)
+	if err := entry.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.plans.CreateMealPlanEntry(ctx, &entry); err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to plan meal")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Meal planned", "entry_id", entry.ID, "user_id", userID, "date", entry.Date, "slot", entry.Slot)
+	h.writeEntry(w, r, entry.ID, http.StatusCreated)
+}
+
+// HandleUpdateMealPlanEntry moves an entry to another date or slot or
+// changes its servings.
+func (h *MealPlanHandler) HandleUpdateMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	entry, ok := h.authorizeEntryChange(w, r)
+	if !ok {
+		return
+	}
+
+	var req MealPlanRequest
+	if err := decodeMealPlanRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	req.apply(entry)
+	if err := entry.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.plans.UpdateMealPlanEntry(ctx, entry); err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to update meal plan entry")
+		return
+	}
+
+	h.writeEntry(w, r, entry.ID, http.StatusOK)
+}
+
+func (h *MealPlanHandler) HandleDeleteMealPlanEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	entry, ok := h.authorizeEntryChange(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.plans.DeleteMealPlanEntry(ctx, entry.ID); err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to delete meal plan entry")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderCalendarAfterChange(w, r, entry.UserID, entry.Date)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Meal plan entry deleted successfully",
+	})
+}
+
+// HandleCopyMealPlan copies every entry of one week onto another, keeping
+// each entry's weekday and slot. Meals that already hold the recipe are
+// skipped.
+func (h *MealPlanHandler) HandleCopyMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req MealPlanCopyRequest
+	if err := decodeMealPlanCopyRequest(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	from, err := models.ParseDate(strings.TrimSpace(req.From))
+	if err != nil {
+		writeFieldError(w, &models.FieldError{Field: "from", Message: err.Error()})
+		return
+	}
+	from = models.WeekStart(from)
+	to := from.AddDate(0, 0, 7)
+	if req.To = strings.TrimSpace(req.To); req.To != "" {
+		if to, err = models.ParseDate(req.To); err != nil {
+			writeFieldError(w, &models.FieldError{Field: "to", Message: err.Error()})
+			return
+		}
+		to = models.WeekStart(to)
+	}
+	if to.Equal(from) {
+		writeFieldError(w, &models.FieldError{Field: "to", Message: "to must be in a different week than from"})
+		return
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	copied, err := h.plans.CopyMealPlan(ctx, userID, formatDate(from), formatDate(from.AddDate(0, 0, 6)), days)
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to copy meal plan")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Meal plan copied", "user_id", userID, "from", formatDate(from), "to", formatDate(to), "copied", copied)
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderCalendarAfterChange(w, r, userID, formatDate(to))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"copied": copied})
+}
+
+// HandleClearMealPlan deletes the entries planned from start to end,
+// inclusive.
+func (h *MealPlanHandler) HandleClearMealPlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	start, err := models.ParseDate(query.Get("start"))
+	if err != nil {
+		writeFieldError(w, &models.FieldError{Field: "start", Message: err.Error()})
+		return
+	}
+	end, err := models.ParseDate(query.Get("end"))
+	if err != nil {
+		writeFieldError(w, &models.FieldError{Field: "end", Message: err.Error()})
+		return
+	}
+	if end.Before(start) {
+		writeFieldError(w, &models.FieldError{Field: "end", Message: "end cannot be before start"})
+		return
+	}
+	if end.After(start.AddDate(0, 0, models.MaxMealPlanDays-1)) {
+		writeFieldError(w, &models.FieldError{Field: "end", Message: "a meal plan range cannot span more than a year"})
+		return
+	}
+
+	deleted, err := h.plans.ClearMealPlan(ctx, userID, formatDate(start), formatDate(end))
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to clear meal plan")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Meal plan cleared", "user_id", userID, "start", formatDate(start), "end", formatDate(end), "deleted", deleted)
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderCalendarAfterChange(w, r, userID, formatDate(start))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"deleted": deleted})
+}
+
+// authorizeEntryChange loads the entry named in the URL and checks that
+// the authenticated user planned it, writing the error response and
+// returning false otherwise. Other users' entries are reported as not
+// found.
+func (h *MealPlanHandler) authorizeEntryChange(w http.ResponseWriter, r *http.Request) (*models.MealPlanEntry, bool) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	entry, err := h.plans.GetMealPlanEntry(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to load meal plan entry")
+		return nil, false
+	}
+	if entry.UserID != userID {
+		http.Error(w, "Meal plan entry not found", http.StatusNotFound)
+		return nil, false
+	}
+	return entry, true
+}
+
+// writeEntry reloads an entry and writes it with its recipe's title, or
+// the refreshed calendar for HTMX requests.
+func (h *MealPlanHandler) writeEntry(w http.ResponseWriter, r *http.Request, id string, status int) {
+	entry, err := h.plans.GetMealPlanEntry(r.Context(), id)
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to load meal plan entry")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderCalendarAfterChange(w, r, entry.UserID, entry.Date)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// renderCalendarAfterChange renders the calendar the change was made from,
+// as named by the view and date query parameters, or the week holding date.
+func (h *MealPlanHandler) renderCalendarAfterChange(w http.ResponseWriter, r *http.Request, userID, date string) {
+	view, day, err := mealPlanView(r.URL.Query(), date)
+	if err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	h.renderCalendar(w, r, userID, view, day)
+}
+
+// renderCalendar writes the meal-plan-calendar.html partial. Month views
+// are padded to whole weeks, so they show the neighbouring months' days
+// too.
+func (h *MealPlanHandler) renderCalendar(w http.ResponseWriter, r *http.Request, userID, view string, date time.Time) {
+	ctx := r.Context()
+
+	start, end := mealPlanRange(view, date)
+	first := models.WeekStart(start)
+	last := models.WeekStart(end).AddDate(0, 0, 6)
+	entries, err := h.plans.ListMealPlan(ctx, userID, formatDate(first), formatDate(last))
+	if err != nil {
+		writeMealPlanStorageError(w, r, err, "Failed to list meal plan")
+		return
+	}
+	byDay := make(map[string][]models.MealPlanEntry)
+	for _, entry := range entries {
+		byDay[entry.Date] = append(byDay[entry.Date], entry)
+	}
+
+	data := mealPlanCalendar{
+		View:  view,
+		Date:  formatDate(date),
+		Start: formatDate(start),
+		End:   formatDate(end),
+		Slots: models.MealSlots,
+	}
+	if view == MealPlanMonth {
+		data.Prev = formatDate(start.AddDate(0, -1, 0))
+		data.Next = formatDate(start.AddDate(0, 1, 0))
+	} else {
+		data.Prev = formatDate(start.AddDate(0, 0, -7))
+		data.Next = formatDate(start.AddDate(0, 0, 7))
+	}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Monday {
+			data.Weeks = append(data.Weeks, nil)
+		}
+		d := mealPlanDay{
+			Date:    formatDate(day),
+			Label:   day.Format("Mon 2 Jan"),
+			InRange: !day.Before(start) && !day.After(end),
+		}
+		for _, slot := range models.MealSlots {
+			meal := mealPlanMeal{Slot: slot}
+			for _, entry := range byDay[d.Date] {
+				if entry.Slot == slot {
+					meal.Entries = append(meal.Entries, entry)
+				}
+			}
+			d.Meals = append(d.Meals, meal)
+		}
+		week := len(data.Weeks) - 1
+		data.Weeks[week] = append(data.Weeks[week], d)
+	}
+
+	if h.recipes != nil {
+		result, err := h.recipes.ListRecipes(ctx, storage.RecipeQuery{Sort: storage.SortTitle, PerPage: storage.MaxPerPage})
+		if err != nil {
+			logger.LogError(ctx, err, "Failed to list recipes for meal plan")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data.Recipes = result.Recipes
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	tmpl := h.templates.Lookup("meal-plan-calendar.html")
+	if tmpl == nil {
+		http.Error(w, "Template meal-plan-calendar.html not found", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// mealPlanView reads the view and date query parameters. The view defaults
+// to a week and the date to fallback, or today when that is empty.
+func mealPlanView(values url.Values, fallback string) (string, time.Time, error) {
+	view := strings.TrimSpace(values.Get("view"))
+	switch view {
+	case "":
+		view = MealPlanWeek
+	case MealPlanWeek, MealPlanMonth:
+	default:
+		return "", time.Time{}, &models.FieldError{Field: "view", Message: "view must be week or month"}
+	}
+
+	raw := strings.TrimSpace(values.Get("date"))
+	if raw == "" {
+		raw = fallback
+	}
+	if raw == "" {
+		return view, truncateToDay(time.Now()), nil
+	}
+	date, err := models.ParseDate(raw)
+	if err != nil {
+		return "", time.Time{}, &models.FieldError{Field: "date", Message: err.Error()}
+	}
+	return view, date, nil
+}
+
+// mealPlanRange returns the first and last day of the view holding date.
+func mealPlanRange(view string, date time.Time) (time.Time, time.Time) {
+	if view == MealPlanMonth {
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	}
+	start := models.WeekStart(date)
+	return start, start.AddDate(0, 0, 6)
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func formatDate(t time.Time) string {
+	return t.Format(models.DateLayout)
+}
+
+func (req *MealPlanRequest) apply(entry *models.MealPlanEntry) {
+	if req.Date != nil {
+		entry.Date = strings.TrimSpace(*req.Date)
+	}
+	if req.Slot != nil {
+		entry.Slot = strings.ToLower(strings.TrimSpace(*req.Slot))
+	}
+	if req.Servings != nil {
+		entry.Servings = *req.Servings
+	}
+}
+
+// decodeMealPlanRequest returns a *models.FieldError for an invalid body
+// or servings.
+func decodeMealPlanRequest(r *http.Request, req *MealPlanRequest) error {
+	if !isFormRequest(r) {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return errors.New("Invalid request body")
+		}
+		return nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return errors.New("Invalid request body")
+	}
+	for _, field := range []struct {
+		name string
+		dst  **string
+	}{{"date", &req.Date}, {"slot", &req.Slot}} {
+		if r.PostForm.Has(field.name) {
+			value := r.PostForm.Get(field.name)
+			*field.dst = &value
+		}
+	}
+	if r.PostForm.Has("servings") {
+		servings, err := intParam(r.PostForm, "servings")
+		if err != nil {
+			return err
+		}
+		req.Servings = &servings
+	}
+	req.RecipeID = r.PostForm.Get("recipe_id")
+	return nil
+}
+
+func decodeMealPlanCopyRequest(r *http.Request, req *MealPlanCopyRequest) error {
+	if !isFormRequest(r) {
+		return json.NewDecoder(r.Body).Decode(req)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	req.From = r.PostForm.Get("from")
+	req.To = r.PostForm.Get("to")
+	return nil
+}
+
+// writeMealPlanStorageError maps meal plan repository errors to HTTP
+// responses, reporting a recipe planned twice for a meal as 409 Conflict.
+func writeMealPlanStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrAlreadyPlanned):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusConflict, "Recipe is already planned for that meal", "ALREADY_PLANNED", err))
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Meal plan entry or recipe not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
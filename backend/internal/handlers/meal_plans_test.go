@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+// newTestMealPlanHandler returns a handler over the seeded demo recipes
+// and the IDs of two registered users.
+func newTestMealPlanHandler(t *testing.T) (*MealPlanHandler, []models.Recipe, string, string) {
+	t.Helper()
+	store, recipes, owner, other := newSeededStore(t)
+	return NewMealPlanHandler(store, store), recipes, owner, other
+}
+
+func TestMealPlanHandler_Lifecycle(t *testing.T) {
+	handler, recipes, planner, other := newTestMealPlanHandler(t)
+
+	plan := func(body string) models.MealPlanEntry {
+		t.Helper()
+		w := serveAs(t, http.MethodPost, "/api/meal-plan", body, planner, nil, handler.HandleCreateMealPlanEntry)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("POST %s: expected status 201, got %d: %s", body, w.Code, w.Body.String())
+		}
+		var entry models.MealPlanEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return entry
+	}
+	list := func(query string) MealPlanResponse {
+		t.Helper()
+		w := serveAs(t, http.MethodGet, "/api/meal-plan?"+query, "", planner, nil, handler.HandleGetMealPlan)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var response MealPlanResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	dinner := plan(`{"date": "2024-03-06", "slot": "Dinner", "recipe_id": "` + recipes[0].ID + `", "servings": 6}`)
+	if dinner.UserID != planner || dinner.Slot != models.MealDinner || dinner.Servings != 6 || dinner.RecipeTitle != recipes[0].Title {
+		t.Errorf("Unexpected entry: %+v", dinner)
+	}
+	plan(`{"date": "2024-03-04", "slot": "breakfast", "recipe_id": "` + recipes[1].ID + `"}`)
+	plan(`{"date": "2024-03-29", "slot": "lunch", "recipe_id": "` + recipes[1].ID + `"}`)
+
+	week := list("date=2024-03-06")
+	if week.View != MealPlanWeek || week.Start != "2024-03-04" || week.End != "2024-03-10" || len(week.Entries) != 2 || week.Entries[1].ID != dinner.ID {
+		t.Errorf("Unexpected week: %+v", week)
+	}
+	month := list("view=month&date=2024-03-06")
+	if month.Start != "2024-03-01" || month.End != "2024-03-31" || len(month.Entries) != 3 {
+		t.Errorf("Unexpected month: %+v", month)
+	}
+
+	w := serveAs(t, http.MethodPost, "/api/meal-plan", `{"date": "2024-03-06", "slot": "dinner", "recipe_id": "`+recipes[0].ID+`"}`, planner, nil, handler.HandleCreateMealPlanEntry)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Planning a recipe twice: expected status 409, got %d", w.Code)
+	}
+
+	for _, fn := range []http.HandlerFunc{handler.HandleUpdateMealPlanEntry, handler.HandleDeleteMealPlanEntry} {
+		if w := serveAs(t, http.MethodPut, "/api/meal-plan/"+dinner.ID, `{"servings": 2}`, other, map[string]string{"id": dinner.ID}, fn); w.Code != http.StatusNotFound {
+			t.Errorf("Changing another user's entry: expected status 404, got %d", w.Code)
+		}
+	}
+
+	w = serveAs(t, http.MethodPut, "/api/meal-plan/"+dinner.ID, `{"date": "2024-03-07", "servings": 2}`, planner, map[string]string{"id": dinner.ID}, handler.HandleUpdateMealPlanEntry)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var moved models.MealPlanEntry
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	if moved.Date != "2024-03-07" || moved.Slot != models.MealDinner || moved.Servings != 2 || moved.RecipeID != recipes[0].ID {
+		t.Errorf("Unexpected moved entry: %+v", moved)
+	}
+
+	w = serveAs(t, http.MethodPost, "/api/meal-plan/copy", `{"from": "2024-03-05"}`, planner, nil, handler.HandleCopyMealPlan)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"copied":2`) {
+		t.Fatalf("Copy: got %d: %s", w.Code, w.Body.String())
+	}
+	if next := list("date=2024-03-11"); len(next.Entries) != 2 || next.Entries[0].Date != "2024-03-11" || next.Entries[1].Date != "2024-03-14" {
+		t.Errorf("Unexpected copied week: %+v", next)
+	}
+
+	w = serveAs(t, http.MethodDelete, "/api/meal-plan?start=2024-03-01&end=2024-03-10", "", planner, nil, handler.HandleClearMealPlan)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"deleted":2`) {
+		t.Fatalf("Clear: got %d: %s", w.Code, w.Body.String())
+	}
+	if got := list("view=month&date=2024-03-01"); len(got.Entries) != 3 {
+		t.Errorf("Expected the copied week and the 29th to remain, got %+v", got.Entries)
+	}
+
+	w = serveAs(t, http.MethodDelete, "/api/meal-plan/"+dinner.ID, "", planner, map[string]string{"id": dinner.ID}, handler.HandleDeleteMealPlanEntry)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Deleting a cleared entry: expected status 404, got %d", w.Code)
+	}
+}
+
+func TestMealPlanHandler_Validation(t *testing.T) {
+	handler, recipes, planner, _ := newTestMealPlanHandler(t)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		fn        http.HandlerFunc
+		wantCode  int
+		wantField string
+	}{
+		{"unknown view", http.MethodGet, "/api/meal-plan?view=year", "", handler.HandleGetMealPlan, http.StatusBadRequest, "view"},
+		{"bad date", http.MethodGet, "/api/meal-plan?date=03/06/2024", "", handler.HandleGetMealPlan, http.StatusBadRequest, "date"},
+		{"missing date", http.MethodPost, "/api/meal-plan", `{"slot": "lunch", "recipe_id": "` + recipes[0].ID + `"}`, handler.HandleCreateMealPlanEntry, http.StatusBadRequest, "date"},
+		{"unknown slot", http.MethodPost, "/api/meal-plan", `{"date": "2024-03-06", "slot": "brunch", "recipe_id": "` + recipes[0].ID + `"}`, handler.HandleCreateMealPlanEntry, http.StatusBadRequest, "slot"},
+		{"negative servings", http.MethodPost, "/api/meal-plan", `{"date": "2024-03-06", "slot": "lunch", "recipe_id": "` + recipes[0].ID + `", "servings": -1}`, handler.HandleCreateMealPlanEntry, http.StatusBadRequest, "servings"},
+		{"missing recipe", http.MethodPost, "/api/meal-plan", `{"date": "2024-03-06", "slot": "lunch", "recipe_id": "00000000-0000-0000-0000-000000000000"}`, handler.HandleCreateMealPlanEntry, http.StatusNotFound, ""},
+		{"copy onto same week", http.MethodPost, "/api/meal-plan/copy", `{"from": "2024-03-04", "to": "2024-03-10"}`, handler.HandleCopyMealPlan, http.StatusBadRequest, "to"},
+		{"clear backwards", http.MethodDelete, "/api/meal-plan?start=2024-03-10&end=2024-03-04", "", handler.HandleClearMealPlan, http.StatusBadRequest, "end"},
+		{"clear too long", http.MethodDelete, "/api/meal-plan?start=2024-01-01&end=2025-01-01", "", handler.HandleClearMealPlan, http.StatusBadRequest, "end"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.fn(w, withUser(httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)), planner, false))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantField != "" && !strings.Contains(w.Body.String(), `"`+tt.wantField+`"`) {
+				t.Errorf("Expected an error for %s, got %s", tt.wantField, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleGetMealPlan(w, httptest.NewRequest(http.MethodGet, "/api/meal-plan", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestMealPlanHandler_HTMXCalendar(t *testing.T) {
+	handler, recipes, planner, _ := newTestMealPlanHandler(t)
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/meal-plan-calendar.html"))
+
+	form := url.Values{"date": {"2024-03-06"}, "slot": {"lunch"}, "recipe_id": {recipes[0].ID}, "servings": {"3"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/meal-plan?view=month&date=2024-03-01", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleCreateMealPlanEntry(w, withUser(req, planner, false))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`id="meal-plan-calendar"`,
+		"2024-03-01 &ndash; 2024-03-31",
+		"Mon 26 Feb", // the month is padded to whole weeks
+		"Sun 31 Mar",
+		template.HTMLEscapeString(recipes[0].Title),
+		"&times;3",
+		"view=month&date=2024-02-01",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Calendar does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, "Copy previous week") {
+		t.Error("Month view should not offer to copy the previous week")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/meal-plan?date=2024-03-06", nil)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleGetMealPlan(w, withUser(req, planner, false))
+	body = w.Body.String()
+	if !strings.Contains(body, "2024-03-04 &ndash; 2024-03-10") || !strings.Contains(body, "Copy previous week") || strings.Contains(body, "Sun 3 Mar") {
+		t.Errorf("Unexpected week calendar:\n%s", body)
+	}
+}
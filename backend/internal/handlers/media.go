@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/media"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// mediaCacheControl lets browsers and proxies keep media for a year. Every
+// upload is stored under a new key, so a stored blob never changes.
+const mediaCacheControl = "public, max-age=31536000, immutable"
+
+type MediaHandler struct {
+	blobs   media.BlobStore
+	recipes storage.RecipeRepository
+}
+
+// ImageResponse describes a recipe's uploaded image and its thumbnails.
+type ImageResponse struct {
+	ImageURL   string            `json:"image_url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+func NewMediaHandler(blobs media.BlobStore, recipes storage.RecipeRepository) *MediaHandler {
+	return &MediaHandler{
+		blobs:   blobs,
+		recipes: recipes,
+	}
+}
+
+// HandleUploadRecipeImage replaces a recipe's image with the one uploaded
+// in the "image" field of a multipart form.
+func (h *MediaHandler) HandleUploadRecipeImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its image")
+	if !ok {
+		return
+	}
+	imageURL, ok := h.storeUpload(w, r, recipe.ID)
+	if !ok {
+		return
+	}
+
+	previous, err := h.recipes.SetRecipeImage(ctx, recipe.ID, imageURL)
+	if err != nil {
+		deleteRecipeImage(ctx, h.blobs, recipe.ID, imageURL)
+		writeImageStorageError(w, r, err, "Failed to set recipe image")
+		return
+	}
+	recipe.ImageURL = imageURL
+	deleteUnreferencedImage(ctx, h.blobs, recipe, previous)
+
+	logger.FromContext(ctx).Info("Recipe image uploaded", "recipe_id", recipe.ID)
+	writeImage(w, imageURL)
+}
+
+// HandleUploadInstructionImage adds the image uploaded in the "image" field
+// of a multipart form to the photos of the recipe's step at the 1-based
+// position.
+func (h *MediaHandler) HandleUploadInstructionImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its image")
+	if !ok {
+		return
+	}
+	position, err := strconv.Atoi(chi.URLParam(r, "position"))
+	if err != nil || position < 1 || position > len(recipe.Instructions) {
+		http.Error(w, "Instruction not found", http.StatusNotFound)
+		return
+	}
+	if len(recipe.Instructions[position-1].Images) >= models.MaxInstructionImages {
+		msg := fmt.Sprintf("a step can have at most %d images", models.MaxInstructionImages)
+		writeFieldError(w, &models.FieldError{Field: "image", Message: msg})
+		return
+	}
+	imageURL, ok := h.storeUpload(w, r, recipe.ID)
+	if !ok {
+		return
+	}
+
+	if err := h.recipes.AddInstructionImage(ctx, recipe.ID, position, imageURL); err != nil {
+		deleteRecipeImage(ctx, h.blobs, recipe.ID, imageURL)
+		writeImageStorageError(w, r, err, "Failed to add instruction image")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Instruction image uploaded", "recipe_id", recipe.ID, "position", position)
+	writeImage(w, imageURL)
+}
+
+// HandleDeleteInstructionImage removes the nth photo, counting from 1, of
+// the recipe's step at the 1-based position.
+func (h *MediaHandler) HandleDeleteInstructionImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its image")
+	if !ok {
+		return
+	}
+	position, err := strconv.Atoi(chi.URLParam(r, "position"))
+	if err != nil || position < 1 || position > len(recipe.Instructions) {
+		http.Error(w, "Instruction not found", http.StatusNotFound)
+		return
+	}
+	n, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || n < 1 || n > len(recipe.Instructions[position-1].Images) {
+		http.Error(w, "Image not found", http.StatusNotFound)
+		return
+	}
+
+	removed, err := h.recipes.RemoveInstructionImage(ctx, recipe.ID, position, n)
+	if err != nil {
+		writeImageStorageError(w, r, err, "Failed to remove instruction image")
+		return
+	}
+	step := &recipe.Instructions[position-1]
+	step.Images = append(step.Images[:n-1:n-1], step.Images[n:]...)
+	deleteUnreferencedImage(ctx, h.blobs, recipe, removed)
+
+	logger.FromContext(ctx).Info("Instruction image removed", "recipe_id", recipe.ID, "position", position)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Instruction image removed successfully",
+	})
+}
+
+// storeUpload reads the image uploaded for a recipe in the "image" field of
+// a multipart form, checks it by its content and stores it without its
+// metadata, together with its thumbnails. It returns the image's URL, or
+// writes the error response and returns false.
+func (h *MediaHandler) storeUpload(w http.ResponseWriter, r *http.Request, recipeID string) (string, bool) {
+	ctx := r.Context()
+
+	// Leave room for the multipart framing around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, media.MaxUploadSize+1<<20)
+	file, _, err := r.FormFile("image")
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		writeImageTooLarge(w)
+		return "", false
+	case err != nil:
+		writeFieldError(w, &models.FieldError{Field: "image", Message: "an image file is required"})
+		return "", false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		logger.LogError(ctx, err, "Failed to read uploaded image")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+	if len(data) > media.MaxUploadSize {
+		writeImageTooLarge(w)
+		return "", false
+	}
+
+	img, err := media.Process(data)
+	switch {
+	case errors.Is(err, media.ErrUnsupportedType):
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusUnsupportedMediaType, err.Error(), "UNSUPPORTED_MEDIA_TYPE", err))
+		return "", false
+	case errors.Is(err, media.ErrTooManyPixels), errors.Is(err, media.ErrInvalidImage):
+		writeFieldError(w, &models.FieldError{Field: "image", Message: err.Error()})
+		return "", false
+	case err != nil:
+		logger.LogError(ctx, err, "Failed to process uploaded image")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return "", false
+	}
+
+	prefix := recipeMediaPrefix(recipeID) + uuid.NewString() + "/"
+	original := "original." + img.Ext
+	blobs := map[string][]byte{original: img.Original}
+	for name, thumb := range img.Thumbnails {
+		blobs[name+".jpg"] = thumb
+	}
+	for name, data := range blobs {
+		contentType := "image/jpeg"
+		if name == original {
+			contentType = img.ContentType
+		}
+		if err := h.blobs.Put(ctx, prefix+name, bytes.NewReader(data), contentType); err != nil {
+			deleteMedia(ctx, h.blobs, prefix)
+			logger.LogError(ctx, err, "Failed to store image")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return "", false
+		}
+	}
+	return models.MediaURLPrefix + prefix + original, true
+}
+
+// HandleDeleteRecipeImage removes a recipe's image.
+func (h *MediaHandler) HandleDeleteRecipeImage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its image")
+	if !ok {
+		return
+	}
+
+	previous, err := h.recipes.SetRecipeImage(ctx, recipe.ID, "")
+	if err != nil {
+		writeImageStorageError(w, r, err, "Failed to remove recipe image")
+		return
+	}
+	recipe.ImageURL = ""
+	deleteUnreferencedImage(ctx, h.blobs, recipe, previous)
+
+	logger.FromContext(ctx).Info("Recipe image removed", "recipe_id", recipe.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Recipe image removed successfully",
+	})
+}
+
+// HandleMedia serves stored blobs below /media/.
+func (h *MediaHandler) HandleMedia(w http.ResponseWriter, r *http.Request) {
+	blob, info, err := h.blobs.Open(r.Context(), chi.URLParam(r, "*"))
+	if errors.Is(err, media.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		logger.LogError(r.Context(), err, "Failed to open media")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Cache-Control", mediaCacheControl)
+	if seeker, ok := blob.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", info.ModTime, seeker)
+		return
+	}
+	if !info.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", info.ModTime.UTC().Format(http.TimeFormat))
+	}
+	io.Copy(w, blob)
+}
+
+func writeImage(w http.ResponseWriter, imageURL string) {
+	thumbnails := make(map[string]string, len(media.Variants))
+	for _, v := range media.Variants {
+		thumbnails[v.Name] = models.ImageVariantURL(imageURL, v.Name)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ImageResponse{ImageURL: imageURL, Thumbnails: thumbnails})
+}
+
+// deleteOrphanedImages removes the uploaded images that a recipe referred
+// to before a change and no longer does.
+func deleteOrphanedImages(ctx context.Context, blobs media.BlobStore, before, after *models.Recipe) {
+	kept := make(map[string]bool)
+	for _, url := range after.ImageURLs() {
+		kept[url] = true
+	}
+	for _, url := range before.ImageURLs() {
+		if !kept[url] {
+			deleteRecipeImage(ctx, blobs, before.ID, url)
+		}
+	}
+}
+
+// deleteUnreferencedImage removes an image that was dropped from a recipe,
+// unless the recipe, as it is after the change, still refers to it
+// elsewhere, such as a cover that is also a step photo.
+func deleteUnreferencedImage(ctx context.Context, blobs media.BlobStore, after *models.Recipe, imageURL string) {
+	for _, url := range after.ImageURLs() {
+		if url == imageURL {
+			return
+		}
+	}
+	deleteRecipeImage(ctx, blobs, after.ID, imageURL)
+}
+
+// deleteRecipeImage removes the blobs of an image that was uploaded for the
+// recipe, ignoring images hosted elsewhere. Image URLs can also be set
+// through recipe updates, so only a directory of the recipe's own is
+// removed.
+func deleteRecipeImage(ctx context.Context, blobs media.BlobStore, recipeID, imageURL string) {
+	dir := path.Dir(strings.TrimPrefix(imageURL, models.MediaURLPrefix))
+	if !strings.HasPrefix(imageURL, models.MediaURLPrefix) || path.Dir(dir)+"/" != recipeMediaPrefix(recipeID) {
+		return
+	}
+	deleteMedia(ctx, blobs, dir+"/")
+}
+
+// deleteMedia removes the blobs under prefix. Failures only leave unused
+// files behind, so they are logged rather than reported.
+func deleteMedia(ctx context.Context, blobs media.BlobStore, prefix string) {
+	if err := blobs.DeletePrefix(ctx, prefix); err != nil {
+		logger.LogError(ctx, err, "Failed to delete media "+prefix)
+	}
+}
+
+// recipeMediaPrefix is the blob key prefix of all of a recipe's images.
+func recipeMediaPrefix(recipeID string) string {
+	return "recipes/" + recipeID + "/"
+}
+
+// writeImageStorageError maps recipe repository errors to HTTP responses.
+func writeImageStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+	logger.LogError(r.Context(), err, msg)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+func writeImageTooLarge(w http.ResponseWriter) {
+	msg := fmt.Sprintf("image must be at most %d MB", media.MaxUploadSize>>20)
+	appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusRequestEntityTooLarge, msg, "PAYLOAD_TOO_LARGE", nil))
+}
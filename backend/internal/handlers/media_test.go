@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/media"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+func newTestMediaHandler(t *testing.T) (*MediaHandler, *storage.Memory, *media.LocalStore, *models.Recipe) {
+	t.Helper()
+
+	store := storage.NewMemory()
+	recipe := &models.Recipe{
+		Title:        "Toast",
+		AuthorID:     "cook",
+		Ingredients:  []models.Ingredient{{Name: "bread"}},
+		Instructions: []models.Instruction{{Text: "Slice the bread"}, {Text: "Toast it"}},
+	}
+	if err := store.CreateRecipe(context.Background(), recipe); err != nil {
+		t.Fatalf("failed to create recipe: %v", err)
+	}
+	blobs, err := media.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create blob store: %v", err)
+	}
+	return NewMediaHandler(blobs, store), store, blobs, recipe
+}
+
+// imageUpload builds a multipart request uploading data as the image field.
+func imageUpload(t *testing.T, recipeID, userID string, data []byte) *http.Request {
+	return upload(t, "/api/recipes/"+recipeID+"/image", map[string]string{"id": recipeID}, userID, data)
+}
+
+func upload(t *testing.T, target string, params map[string]string, userID string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "photo.png")
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return withURLParams(withUser(req, userID, false), params)
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMediaHandler_Upload(t *testing.T) {
+	handler, store, blobs, recipe := newTestMediaHandler(t)
+	ctx := context.Background()
+
+	upload := func() ImageResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.HandleUploadRecipeImage(w, imageUpload(t, recipe.ID, "cook", testPNG(t, 64, 48)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var response ImageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return response
+	}
+
+	first := upload()
+	prefix := "/media/recipes/" + recipe.ID + "/"
+	if !strings.HasPrefix(first.ImageURL, prefix) || !strings.HasSuffix(first.ImageURL, "/original.png") {
+		t.Errorf("Unexpected image URL %q", first.ImageURL)
+	}
+	if first.Thumbnails["card"] != strings.TrimSuffix(first.ImageURL, "original.png")+"card.jpg" || first.Thumbnails["detail"] == "" {
+		t.Errorf("Unexpected thumbnails %v", first.Thumbnails)
+	}
+	if got, _ := store.GetRecipe(ctx, recipe.ID); got.ImageURL != first.ImageURL {
+		t.Errorf("Expected the recipe to carry %q, got %q", first.ImageURL, got.ImageURL)
+	}
+
+	// Serve the thumbnail with long-lived cache headers.
+	w := httptest.NewRecorder()
+	key := strings.TrimPrefix(first.Thumbnails["card"], "/media/")
+	handler.HandleMedia(w, withURLParams(httptest.NewRequest(http.MethodGet, first.Thumbnails["card"], nil), map[string]string{"*": key}))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("Expected a 200 JPEG, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Cache-Control"), "max-age=31536000") {
+		t.Errorf("Expected a long-lived Cache-Control, got %q", w.Header().Get("Cache-Control"))
+	}
+
+	// Replacing the image deletes the previous one.
+	second := upload()
+	if second.ImageURL == first.ImageURL {
+		t.Fatal("Expected a new URL for the new image")
+	}
+	if _, _, err := blobs.Open(ctx, key); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("Expected the previous image to be deleted, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	req := withURLParams(withUser(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+recipe.ID+"/image", nil), "cook", false), map[string]string{"id": recipe.ID})
+	handler.HandleDeleteRecipeImage(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, _ := store.GetRecipe(ctx, recipe.ID); got.ImageURL != "" {
+		t.Errorf("Expected no image after delete, got %q", got.ImageURL)
+	}
+	blob, _, err := blobs.Open(ctx, strings.TrimPrefix(second.ImageURL, "/media/"))
+	if !errors.Is(err, media.ErrNotFound) {
+		if blob != nil {
+			blob.Close()
+		}
+		t.Errorf("Expected the image to be deleted, got %v", err)
+	}
+}
+
+func TestMediaHandler_ReplaceCoverKeepsStepPhoto(t *testing.T) {
+	handler, store, blobs, recipe := newTestMediaHandler(t)
+	ctx := context.Background()
+
+	w := httptest.NewRecorder()
+	handler.HandleUploadRecipeImage(w, imageUpload(t, recipe.ID, "cook", testPNG(t, 32, 32)))
+	var cover ImageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &cover); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	// Recipe updates can reuse the cover as a step photo.
+	if err := store.AddInstructionImage(ctx, recipe.ID, 1, cover.ImageURL); err != nil {
+		t.Fatalf("AddInstructionImage() error = %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleUploadRecipeImage(w, imageUpload(t, recipe.ID, "cook", testPNG(t, 32, 32)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serveAs(t, http.MethodDelete, "/api/recipes/"+recipe.ID+"/image", "", "cook", map[string]string{"id": recipe.ID}, handler.HandleDeleteRecipeImage)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	blob, _, err := blobs.Open(ctx, strings.TrimPrefix(cover.ImageURL, "/media/"))
+	if err != nil {
+		t.Fatalf("Expected the step photo to remain, got %v", err)
+	}
+	blob.Close()
+}
+
+func TestMediaHandler_UploadRejects(t *testing.T) {
+	handler, _, _, recipe := newTestMediaHandler(t)
+
+	tests := []struct {
+		name   string
+		userID string
+		data   []byte
+		status int
+	}{
+		{"not the author", "stranger", testPNG(t, 8, 8), http.StatusForbidden},
+		{"not an image", "cook", []byte("#!/bin/sh\necho hello\n"), http.StatusUnsupportedMediaType},
+		{"too large", "cook", bytes.Repeat([]byte{0}, media.MaxUploadSize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.HandleUploadRecipeImage(w, imageUpload(t, recipe.ID, tt.userID, tt.data))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+
+	// A form without the image field.
+	req := httptest.NewRequest(http.MethodPost, "/api/recipes/"+recipe.ID+"/image", strings.NewReader("title=toast"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.HandleUploadRecipeImage(w, withURLParams(withUser(req, "cook", false), map[string]string{"id": recipe.ID}))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"image"`) {
+		t.Errorf("Expected a 400 naming the image field, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMediaHandler_ServeMissing(t *testing.T) {
+	handler, _, _, _ := newTestMediaHandler(t)
+
+	for _, key := range []string{"recipes/none/card.jpg", "../secret", ""} {
+		w := httptest.NewRecorder()
+		handler.HandleMedia(w, withURLParams(httptest.NewRequest(http.MethodGet, "/media/x", nil), map[string]string{"*": key}))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %q, got %d", key, w.Code)
+		}
+	}
+}
+
+func TestMediaHandler_UploadInstructionImage(t *testing.T) {
+	handler, store, blobs, recipe := newTestMediaHandler(t)
+	ctx := context.Background()
+
+	uploadStep := func(position string) *httptest.ResponseRecorder {
+		t.Helper()
+		params := map[string]string{"id": recipe.ID, "position": position}
+		w := httptest.NewRecorder()
+		handler.HandleUploadInstructionImage(w, upload(t, "/api/recipes/"+recipe.ID+"/instructions/"+position+"/images", params, "cook", testPNG(t, 32, 32)))
+		return w
+	}
+
+	var urls []string
+	for range 2 {
+		w := uploadStep("2")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var response ImageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		urls = append(urls, response.ImageURL)
+	}
+	got, _ := store.GetRecipe(ctx, recipe.ID)
+	if strings.Join(got.Instructions[1].Images, ",") != strings.Join(urls, ",") {
+		t.Errorf("Expected step 2 to have %v, got %v", urls, got.Instructions[1].Images)
+	}
+
+	for _, position := range []string{"0", "3", "first"} {
+		if w := uploadStep(position); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for step %q, got %d", position, w.Code)
+		}
+	}
+
+	// Dropping the step through an update deletes its photos.
+	api := NewAPIHandler(store)
+	api.SetBlobStore(blobs)
+	body := `{"title": "Toast", "ingredients": [{"name": "bread"}], "instructions": [{"text": "Toast it", "images": ["` + urls[1] + `"]}]}`
+	req := withUser(withRecipeID(httptest.NewRequest(http.MethodPut, "/api/recipes/"+recipe.ID, strings.NewReader(body)), recipe.ID), "cook", false)
+	w := httptest.NewRecorder()
+	api.HandleUpdateRecipe(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, _, err := blobs.Open(ctx, strings.TrimPrefix(urls[0], "/media/")); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("Expected the dropped photo to be deleted, got %v", err)
+	}
+	blob, _, err := blobs.Open(ctx, strings.TrimPrefix(urls[1], "/media/"))
+	if err != nil {
+		t.Fatalf("Expected the kept photo to remain, got %v", err)
+	}
+	blob.Close()
+}
+
+func TestMediaHandler_DeleteInstructionImage(t *testing.T) {
+	handler, store, blobs, recipe := newTestMediaHandler(t)
+	ctx := context.Background()
+
+	var urls []string
+	for range 2 {
+		params := map[string]string{"id": recipe.ID, "position": "1"}
+		w := httptest.NewRecorder()
+		handler.HandleUploadInstructionImage(w, upload(t, "/api/recipes/"+recipe.ID+"/instructions/1/images", params, "cook", testPNG(t, 32, 32)))
+		var response ImageResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		urls = append(urls, response.ImageURL)
+	}
+
+	deleteStep := func(position, n, userID string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serveAs(t, http.MethodDelete, "/api/recipes/"+recipe.ID+"/instructions/"+position+"/images/"+n, "", userID,
+			map[string]string{"id": recipe.ID, "position": position, "n": n}, handler.HandleDeleteInstructionImage)
+	}
+
+	if w := deleteStep("1", "1", "stranger"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user, got %d", w.Code)
+	}
+	for _, tt := range [][2]string{{"0", "1"}, {"3", "1"}, {"1", "0"}, {"1", "3"}, {"2", "1"}, {"1", "first"}} {
+		if w := deleteStep(tt[0], tt[1], "cook"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for step %s image %s, got %d", tt[0], tt[1], w.Code)
+		}
+	}
+
+	if w := deleteStep("1", "1", "cook"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got, _ := store.GetRecipe(ctx, recipe.ID)
+	if strings.Join(got.Instructions[0].Images, ",") != urls[1] {
+		t.Errorf("Expected step 1 to keep %s, got %v", urls[1], got.Instructions[0].Images)
+	}
+	if _, _, err := blobs.Open(ctx, strings.TrimPrefix(urls[0], "/media/")); !errors.Is(err, media.ErrNotFound) {
+		t.Errorf("Expected the removed photo to be deleted, got %v", err)
+	}
+	blob, _, err := blobs.Open(ctx, strings.TrimPrefix(urls[1], "/media/"))
+	if err != nil {
+		t.Fatalf("Expected the other photo to remain, got %v", err)
+	}
+	blob.Close()
+
+	// A photo the cover or another step still uses is kept.
+	if err := store.AddInstructionImage(ctx, recipe.ID, 2, urls[1]); err != nil {
+		t.Fatalf("AddInstructionImage() error = %v", err)
+	}
+	if _, err := store.SetRecipeImage(ctx, recipe.ID, urls[1]); err != nil {
+		t.Fatalf("SetRecipeImage() error = %v", err)
+	}
+	for _, position := range []string{"1", "2"} {
+		if w := deleteStep(position, "1", "cook"); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	blob, _, err = blobs.Open(ctx, strings.TrimPrefix(urls[1], "/media/"))
+	if err != nil {
+		t.Fatalf("Expected the photo the cover uses to remain, got %v", err)
+	}
+	blob.Close()
+}
+
+func TestAPIHandler_DeleteRecipeDeletesImages(t *testing.T) {
+	handler, seeded := newTestAPIHandler(t)
+	blobs, err := media.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create blob store: %v", err)
+	}
+	handler.SetBlobStore(blobs)
+	id := seeded[0].ID
+	key := "recipes/" + id + "/image/card.jpg"
+	if err := blobs.Put(context.Background(), key, strings.NewReader("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleDeleteRecipe(w, withUser(withRecipeID(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+id, nil), id), "admin", true))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if blob, _, err := blobs.Open(context.Background(), key); !errors.Is(err, media.ErrNotFound) {
+		if blob != nil {
+			io.Copy(io.Discard, blob)
+			blob.Close()
+		}
+		t.Errorf("Expected the recipe's images to be deleted, got %v", err)
+	}
+}
+
+func TestRecipeTemplates_Image(t *testing.T) {
+	templates := template.Must(template.ParseFiles(
+		"../../web/templates/recipe-cards.html",
+		"../../web/templates/recipe-detail-content.html",
+	))
+	recipe := models.Recipe{
+		ID:           "r1",
+		Title:        "Toast",
+		ImageURL:     "/media/recipes/r1/i1/original.jpg",
+		Instructions: []models.Instruction{{Text: "Toast it", Position: 1, Images: []string{"/media/recipes/r1/i2/original.png"}}},
+	}
+
+	var cards bytes.Buffer
+	if err := templates.ExecuteTemplate(&cards, "recipe-cards.html", map[string]interface{}{"recipes": []models.Recipe{recipe}, "highlights": map[string]models.SearchHighlight{}}); err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if !strings.Contains(cards.String(), `src="/media/recipes/r1/i1/card.jpg"`) {
+		t.Errorf("Expected the card thumbnail, got %s", cards.String())
+	}
+
+	var detail bytes.Buffer
+	if err := templates.ExecuteTemplate(&detail, "recipe-detail-content.html", map[string]interface{}{"recipe": &recipe}); err != nil {
+		t.Fatalf("ExecuteTemplate() error = %v", err)
+	}
+	if !strings.Contains(detail.String(), `src="/media/recipes/r1/i1/detail.jpg"`) {
+		t.Errorf("Expected the detail image, got %s", detail.String())
+	}
+	if !strings.Contains(detail.String(), `src="/media/recipes/r1/i2/card.jpg" alt="Step 1"`) {
+		t.Errorf("Expected the step photo, got %s", detail.String())
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+type NutritionHandler struct {
+	nutrition storage.NutritionRepository
+	recipes   storage.RecipeRepository
+}
+
+// NutritionRequest sets a recipe's nutrition facts per serving.
+type NutritionRequest struct {
+	Calories    float64 `json:"calories"`
+	Protein     float64 `json:"protein"`
+	Carbs       float64 `json:"carbs"`
+	Fat         float64 `json:"fat"`
+	Fiber       float64 `json:"fiber"`
+	Sugar       float64 `json:"sugar"`
+	Sodium      float64 `json:"sodium"`
+	ServingSize string  `json:"serving_size"`
+}
+
+// NutritionResponse is a recipe's nutrition per serving and for the whole
+// recipe.
+type NutritionResponse struct {
+	PerServing models.NutritionInfo `json:"per_serving"`
+	PerRecipe  models.NutritionInfo `json:"per_recipe"`
+	Servings   int                  `json:"servings"`
+}
+
+func NewNutritionHandler(nutrition storage.NutritionRepository, recipes storage.RecipeRepository) *NutritionHandler {
+	return &NutritionHandler{
+		nutrition: nutrition,
+		recipes:   recipes,
+	}
+}
+
+// HandleGetNutrition returns a recipe's nutrition facts.
+func (h *NutritionHandler) HandleGetNutrition(w http.ResponseWriter, r *http.Request) {
+	recipe, err := h.recipes.GetRecipe(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeNutritionStorageError(w, r, err, "Failed to load recipe")
+		return
+	}
+	info := recipe.Nutrition
+	if info == nil {
+		// The recipe's facts have not been backfilled yet; the store
+		// estimates them without writing.
+		if info, err = h.nutrition.GetNutrition(r.Context(), recipe.ID); err != nil {
+			writeNutritionStorageError(w, r, err, "Failed to load nutrition")
+			return
+		}
+	}
+	writeNutrition(w, recipe, info)
+}
+
+// HandleSetNutrition replaces a recipe's computed facts with ones entered by
+// its author or an admin. They are kept until cleared, whatever later edits
+// to the ingredients.
+func (h *NutritionHandler) HandleSetNutrition(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its nutrition")
+	if !ok {
+		return
+	}
+
+	var req NutritionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	info := models.NutritionInfo{
+		RecipeID:    recipe.ID,
+		Calories:    req.Calories,
+		Protein:     req.Protein,
+		Carbs:       req.Carbs,
+		Fat:         req.Fat,
+		Fiber:       req.Fiber,
+		Sugar:       req.Sugar,
+		Sodium:      req.Sodium,
+		ServingSize: strings.TrimSpace(req.ServingSize),
+	}
+	if err := info.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if err := h.nutrition.SetNutrition(ctx, &info); err != nil {
+		writeNutritionStorageError(w, r, err, "Failed to save nutrition")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Nutrition facts set", "recipe_id", recipe.ID)
+	writeNutrition(w, recipe, &info)
+}
+
+// HandleClearNutrition drops a recipe's author-entered facts and returns
+// the estimate computed from its ingredients.
+func (h *NutritionHandler) HandleClearNutrition(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, ok := authorizeRecipeChange(w, r, h.recipes, chi.URLParam(r, "id"), "Only the recipe's author or an admin can change its nutrition")
+	if !ok {
+		return
+	}
+
+	info, err := h.nutrition.ClearNutrition(ctx, recipe.ID)
+	if err != nil {
+		writeNutritionStorageError(w, r, err, "Failed to clear nutrition")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Nutrition facts cleared", "recipe_id", recipe.ID)
+	writeNutrition(w, recipe, info)
+}
+
+func writeNutrition(w http.ResponseWriter, recipe *models.Recipe, info *models.NutritionInfo) {
+	servings := recipe.Servings
+	if servings < 1 {
+		servings = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NutritionResponse{
+		PerServing: *info,
+		PerRecipe:  info.Scaled(float64(servings)),
+		Servings:   servings,
+	})
+}
+
+// writeNutritionStorageError maps nutrition repository errors to HTTP
+// responses.
+func writeNutritionStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recipe not found", http.StatusNotFound)
+		return
+	}
+	logger.LogError(r.Context(), err, msg)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+func newTestNutritionHandler(t *testing.T) (*NutritionHandler, *models.Recipe) {
+	t.Helper()
+
+	store := storage.NewMemory()
+	recipe := &models.Recipe{
+		Title:    "Sweet eggs",
+		Servings: 2,
+		AuthorID: "cook",
+		Ingredients: []models.Ingredient{
+			{Name: "eggs", Amount: "4"},
+			{Name: "sugar", Amount: "50", Unit: "g"},
+			{Name: "saffron", Amount: "1", Unit: "pinch"},
+		},
+	}
+	if err := store.CreateRecipe(context.Background(), recipe); err != nil {
+		t.Fatalf("failed to create recipe: %v", err)
+	}
+	return NewNutritionHandler(store, store), recipe
+}
+
+func decodeNutrition(t *testing.T, w *httptest.ResponseRecorder) NutritionResponse {
+	t.Helper()
+	var response NutritionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v: %s", err, w.Body.String())
+	}
+	return response
+}
+
+func TestNutritionHandler_Get(t *testing.T) {
+	handler, recipe := newTestNutritionHandler(t)
+	params := map[string]string{"id": recipe.ID}
+
+	w := httptest.NewRecorder()
+	handler.HandleGetNutrition(w, withURLParams(httptest.NewRequest(http.MethodGet, "/api/recipes/"+recipe.ID+"/nutrition", nil), params))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeNutrition(t, w)
+	if response.Servings != 2 || response.PerServing.Calories != 239.75 || response.PerRecipe.Calories != 479.5 {
+		t.Errorf("Unexpected nutrition: %+v", response)
+	}
+	if response.PerServing.Source != models.NutritionSourceComputed || strings.Join(response.PerServing.Unmatched, ",") != "saffron" {
+		t.Errorf("Expected a computed estimate missing saffron, got %+v", response.PerServing)
+	}
+
+	missing := "00000000-0000-0000-0000-000000000000"
+	w = httptest.NewRecorder()
+	handler.HandleGetNutrition(w, withURLParams(httptest.NewRequest(http.MethodGet, "/api/recipes/"+missing+"/nutrition", nil), map[string]string{"id": missing}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a missing recipe, got %d", w.Code)
+	}
+}
+
+// unfilledRecipes loads recipes as the database does before their
+// nutrition facts are backfilled.
+type unfilledRecipes struct {
+	storage.RecipeRepository
+}
+
+func (u unfilledRecipes) GetRecipe(ctx context.Context, id string) (*models.Recipe, error) {
+	recipe, err := u.RecipeRepository.GetRecipe(ctx, id)
+	if recipe != nil {
+		recipe.Nutrition = nil
+	}
+	return recipe, err
+}
+
+func TestNutritionHandler_GetWithoutStoredFacts(t *testing.T) {
+	handler, recipe := newTestNutritionHandler(t)
+	handler.recipes = unfilledRecipes{handler.recipes}
+
+	w := httptest.NewRecorder()
+	handler.HandleGetNutrition(w, withURLParams(httptest.NewRequest(http.MethodGet, "/api/recipes/"+recipe.ID+"/nutrition", nil), map[string]string{"id": recipe.ID}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if response := decodeNutrition(t, w); response.PerServing.Source != models.NutritionSourceComputed || response.PerRecipe.Calories != 479.5 {
+		t.Errorf("Expected the computed estimate, got %+v", response)
+	}
+}
+
+func TestNutritionHandler_SetAndClear(t *testing.T) {
+	handler, recipe := newTestNutritionHandler(t)
+	params := map[string]string{"id": recipe.ID}
+
+	set := func(userID string, isAdmin bool, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := withUser(httptest.NewRequest(http.MethodPut, "/api/recipes/"+recipe.ID+"/nutrition", strings.NewReader(body)), userID, isAdmin)
+		w := httptest.NewRecorder()
+		handler.HandleSetNutrition(w, withURLParams(req, params))
+		return w
+	}
+
+	if w := set("someone", false, `{"calories": 100}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user, got %d", w.Code)
+	}
+	if w := set("cook", false, `{"calories": -1}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"calories"`) {
+		t.Errorf("Expected a calories validation error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := set("cook", false, `{"calories": 300, "protein": 12.5, "serving_size": " 1 bowl "}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response := decodeNutrition(t, w)
+	if response.PerServing.Source != models.NutritionSourceManual || response.PerServing.ServingSize != "1 bowl" || response.PerRecipe.Protein != 25 {
+		t.Errorf("Unexpected manual nutrition: %+v", response)
+	}
+
+	stored, err := handler.recipes.GetRecipe(context.Background(), recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if stored.Nutrition == nil || stored.Nutrition.Calories != 300 {
+		t.Errorf("Expected the recipe to embed the manual facts, got %+v", stored.Nutrition)
+	}
+
+	req := withUser(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+recipe.ID+"/nutrition", nil), "admin", true)
+	w = httptest.NewRecorder()
+	handler.HandleClearNutrition(w, withURLParams(req, params))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if response := decodeNutrition(t, w); response.PerServing.Source != models.NutritionSourceComputed || response.PerServing.Calories != 239.75 {
+		t.Errorf("Expected the computed estimate back, got %+v", response.PerServing)
+	}
+}
+
+func TestRecipeDetail_NutritionPanel(t *testing.T) {
+	_, recipe := newTestNutritionHandler(t)
+	tmpl := template.Must(template.ParseFiles("../../web/templates/recipe-detail-content.html"))
+
+	var body strings.Builder
+	if err := tmpl.Execute(&body, map[string]interface{}{"recipe": recipe}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	for _, want := range []string{"Nutrition per serving", "239.8 kcal", "Estimated from ingredients", "saffron"} {
+		if !strings.Contains(body.String(), want) {
+			t.Errorf("Expected detail view to contain %q, got %s", want, body.String())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// MaxPantryNames bounds the ingredients added to a pantry in one request.
+const MaxPantryNames = 100
+
+type PantryHandler struct {
+	pantry storage.PantryRepository
+}
+
+// PantryRequest adds ingredients to the pantry. Form posts send names as
+// one comma-separated field.
+type PantryRequest struct {
+	Names []string `json:"names"`
+}
+
+func NewPantryHandler(pantry storage.PantryRepository) *PantryHandler {
+	return &PantryHandler{pantry: pantry}
+}
+
+// HandleListPantry lists the ingredients the signed-in user has on hand.
+func (h *PantryHandler) HandleListPantry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := h.pantry.ListPantry(ctx, userID)
+	if err != nil {
+		writePantryStorageError(w, r, err, "Failed to list pantry")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// HandleAddPantryItems adds ingredients to the signed-in user's pantry and
+// returns them under their normalized names. Names already in the pantry
+// are kept as they are.
+func (h *PantryHandler) HandleAddPantryItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req PantryRequest
+	if err := decodePantryRequest(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	items, err := h.pantry.AddPantryItems(ctx, userID, req.Names)
+	if err != nil {
+		writePantryStorageError(w, r, err, "Failed to add pantry items")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Pantry items added", "user_id", userID, "items", len(items))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// HandleRemovePantryItem removes an ingredient, named in any spelling that
+// normalizes to its name, from the signed-in user's pantry.
+func (h *PantryHandler) HandleRemovePantryItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	if err := h.pantry.RemovePantryItem(ctx, userID, name); err != nil {
+		writePantryStorageError(w, r, err, "Failed to remove pantry item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Pantry item removed successfully",
+	})
+}
+
+// validate drops blank names and returns a *models.FieldError when none
+// are left or one is too long.
+func (req *PantryRequest) validate() error {
+	names := make([]string, 0, len(req.Names))
+	for _, name := range req.Names {
+		if normalized := models.NormalizeIngredientName(name); normalized != "" {
+			if utf8.RuneCountInString(normalized) > models.MaxPantryItemLength {
+				return &models.FieldError{Field: "names", Message: fmt.Sprintf("ingredient names must be at most %d characters", models.MaxPantryItemLength)}
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return &models.FieldError{Field: "names", Message: "at least one ingredient name is required"}
+	}
+	if len(names) > MaxPantryNames {
+		return &models.FieldError{Field: "names", Message: fmt.Sprintf("at most %d ingredients can be added at once", MaxPantryNames)}
+	}
+	req.Names = names
+	return nil
+}
+
+func decodePantryRequest(r *http.Request, req *PantryRequest) error {
+	if !isFormRequest(r) {
+		return json.NewDecoder(r.Body).Decode(req)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	for _, list := range r.PostForm["names"] {
+		req.Names = append(req.Names, strings.Split(list, ",")...)
+	}
+	return nil
+}
+
+func writePantryStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Pantry item not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+// newTestPantryHandlers returns pantry and recipe handlers over the seeded
+// store with three more recipes, which it returns, and the ID of a
+// registered user.
+func newTestPantryHandlers(t *testing.T) (*PantryHandler, *APIHandler, []models.Recipe, string) {
+	t.Helper()
+
+	store, _, owner, _ := newSeededStore(t)
+	ctx := context.Background()
+	recipes := []models.Recipe{
+		{Title: "Omelette", Ingredients: []models.Ingredient{{Name: "eggs", Amount: "3"}, {Name: "butter", Amount: "1", Unit: "tbsp"}}},
+		{Title: "Pancakes", Ingredients: []models.Ingredient{{Name: "flour", Amount: "200", Unit: "g"}, {Name: "egg", Amount: "1"}, {Name: "milk", Amount: "300", Unit: "ml"}}},
+		{Title: "Steak", Ingredients: []models.Ingredient{{Name: "sirloin steak", Amount: "1"}}},
+	}
+	for i := range recipes {
+		if err := store.CreateRecipe(ctx, &recipes[i]); err != nil {
+			t.Fatalf("failed to create recipe: %v", err)
+		}
+	}
+	return NewPantryHandler(store), NewAPIHandler(store), recipes, owner
+}
+
+func TestPantryHandler_Lifecycle(t *testing.T) {
+	handler, _, _, userID := newTestPantryHandlers(t)
+
+	list := func() string {
+		t.Helper()
+		w := serveAs(t, http.MethodGet, "/api/pantry", "", userID, nil, handler.HandleListPantry)
+		var response struct{ Items []models.PantryItem }
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		names := make([]string, len(response.Items))
+		for i, item := range response.Items {
+			names[i] = item.Name
+		}
+		return strings.Join(names, ", ")
+	}
+
+	w := serveAs(t, http.MethodPost, "/api/pantry", `{"names": ["Eggs", "Olive Oil", ""]}`, userID, nil, handler.HandleAddPantryItems)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"name":"egg"`) {
+		t.Fatalf("Expected status 201 with normalized names, got %d: %s", w.Code, w.Body.String())
+	}
+
+	form := url.Values{"names": {"flour, butter"}}
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/pantry", strings.NewReader(form.Encode())), userID, false)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.HandleAddPantryItems(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Form post: expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := list(); got != "butter, egg, flour, olive oil" {
+		t.Errorf("Pantry = %s", got)
+	}
+
+	if w := serveAs(t, http.MethodDelete, "/api/pantry/Olive%20Oil", "", userID, map[string]string{"name": "Olive%20Oil"}, handler.HandleRemovePantryItem); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveAs(t, http.MethodDelete, "/api/pantry/olive-oil", "", userID, map[string]string{"name": "olive-oil"}, handler.HandleRemovePantryItem); w.Code != http.StatusNotFound {
+		t.Errorf("Removing a missing item: expected status 404, got %d", w.Code)
+	}
+	if got := list(); got != "butter, egg, flour" {
+		t.Errorf("Pantry after removing = %s", got)
+	}
+
+	for _, body := range []string{`{"names": [" ", "!"]}`, `{"names": ["` + strings.Repeat("x", 256) + `"]}`} {
+		if w := serveAs(t, http.MethodPost, "/api/pantry", body, userID, nil, handler.HandleAddPantryItems); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"names"`) {
+			t.Errorf("POST %.20s: expected a names error, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleListPantry(w, httptest.NewRequest(http.MethodGet, "/api/pantry", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestAPIHandler_PantryRecipes(t *testing.T) {
+	pantry, handler, recipes, userID := newTestPantryHandlers(t)
+	if _, err := pantry.pantry.AddPantryItems(context.Background(), userID, []string{"eggs", "butter", "milk"}); err != nil {
+		t.Fatalf("failed to stock pantry: %v", err)
+	}
+
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/pantry/recipes", nil), userID, false)
+	w := httptest.NewRecorder()
+	handler.HandlePantryRecipes(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.SearchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(result.Recipes) < 2 || result.Recipes[0].ID != recipes[0].ID || result.Recipes[1].ID != recipes[1].ID {
+		t.Fatalf("Expected the omelette and then the pancakes first, got %+v", result.Recipes)
+	}
+	for _, recipe := range result.Recipes {
+		if recipe.ID == recipes[2].ID {
+			t.Errorf("Expected no steak without any of its ingredients, got %+v", result.Recipes)
+		}
+	}
+	if match := result.Pantry[recipes[1].ID]; match.Covered != 2 || match.Total != 3 || len(match.Missing) != 1 || match.Missing[0] != "flour" {
+		t.Errorf("Unexpected pancakes match: %+v", match)
+	}
+	if req.URL.Query().Get("pantry") != "" {
+		t.Error("HandlePantryRecipes should not change the caller's request")
+	}
+
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/recipe-cards.html"))
+	req = withUser(httptest.NewRequest(http.MethodGet, "/api/recipes?pantry=true&max_missing=0", nil), userID, false)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, req)
+	body := w.Body.String()
+	if !strings.Contains(body, "Omelette") || strings.Contains(body, "Pancakes") || !strings.Contains(body, "2 of 2 ingredients on hand") {
+		t.Errorf("Unexpected recipe cards:\n%s", body)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleRecipes(w, httptest.NewRequest(http.MethodGet, "/api/recipes?pantry=true", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Pantry search without a user: expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+type RatingHandler struct {
+	templates *template.Template
+	ratings   storage.RatingRepository
+	recipes   storage.RecipeRepository
+}
+
+// RatingRequest sets the signed-in user's score and optional review.
+type RatingRequest struct {
+	Score   int    `json:"score"`
+	Comment string `json:"comment"`
+}
+
+// RatingResponse is a rating with its recipe's updated rating summary.
+type RatingResponse struct {
+	models.Rating
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+}
+
+func NewRatingHandler(ratings storage.RatingRepository, recipes storage.RecipeRepository) *RatingHandler {
+	templates, err := template.ParseFiles("web/templates/recipe-reviews.html")
+	if err != nil {
+		// Templates not found, create empty template for tests
+		templates = template.New("")
+	}
+	return &RatingHandler{
+		templates: templates,
+		ratings:   ratings,
+		recipes:   recipes,
+	}
+}
+
+// HandleListRatings lists a recipe's ratings, most recently updated first,
+// paged with page and per_page. HTMX requests get the reviews partial.
+func (h *RatingHandler) HandleListRatings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recipe, err := h.recipes.GetRecipe(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeRatingStorageError(w, r, err, "Failed to load recipe")
+		return
+	}
+
+	query := storage.RatingQuery{RecipeID: recipe.ID}
+	if query.Page, err = intParam(r.URL.Query(), "page"); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if query.PerPage, err = intParam(r.URL.Query(), "per_page"); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	list, err := h.ratings.ListRatings(ctx, query)
+	if err != nil {
+		writeRatingStorageError(w, r, err, "Failed to list ratings")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		h.renderReviews(w, r, recipe, list)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleGetRating returns one user's rating of a recipe. The user ID "me"
+// names the signed-in user.
+func (h *RatingHandler) HandleGetRating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := ratingUserID(r)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	rating, err := h.ratings.GetRating(ctx, chi.URLParam(r, "id"), userID)
+	if err != nil {
+		writeRatingStorageError(w, r, err, "Failed to load rating")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rating)
+}
+
+// HandleUpsertRating creates or replaces the signed-in user's rating. Users
+// can only set their own rating.
+func (h *RatingHandler) HandleUpsertRating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	callerID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	if userID, _ := ratingUserID(r); userID != callerID {
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusForbidden, "You can only rate recipes as yourself", "FORBIDDEN", nil))
+		return
+	}
+
+	var req RatingRequest
+	if err := decodeRatingRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	rating := models.Rating{
+		RecipeID: chi.URLParam(r, "id"),
+		UserID:   callerID,
+		Score:    req.Score,
+		Comment:  strings.TrimSpace(req.Comment),
+	}
+	if err := rating.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if err := h.ratings.UpsertRating(ctx, &rating); err != nil {
+		writeRatingStorageError(w, r, err, "Failed to save rating")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Recipe rated", "recipe_id", rating.RecipeID, "user_id", callerID, "score", rating.Score)
+	h.writeRatingChange(w, r, &rating)
+}
+
+// HandleDeleteRating deletes a user's rating. Admins may delete anyone's,
+// for moderation; everyone else only their own.
+func (h *RatingHandler) HandleDeleteRating(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	claims, ok := appmiddleware.GetUserClaims(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	userID, _ := ratingUserID(r)
+	if userID != claims.UserID && !claims.IsAdmin {
+		appmiddleware.WriteError(w, appmiddleware.NewAppError(http.StatusForbidden, "You can only delete your own rating", "FORBIDDEN", nil))
+		return
+	}
+
+	rating := models.Rating{RecipeID: chi.URLParam(r, "id"), UserID: userID}
+	if err := h.ratings.DeleteRating(ctx, rating.RecipeID, userID); err != nil {
+		writeRatingStorageError(w, r, err, "Failed to delete rating")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Rating deleted", "recipe_id", rating.RecipeID, "user_id", userID, "deleted_by", claims.UserID)
+	if r.Header.Get("HX-Request") == "true" {
+		h.writeRatingChange(w, r, &rating)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRatingChange responds to a rating change with the rating and the
+// recipe's new summary, or with the refreshed reviews partial for HTMX.
+func (h *RatingHandler) writeRatingChange(w http.ResponseWriter, r *http.Request, rating *models.Rating) {
+	ctx := r.Context()
+
+	recipe, err := h.recipes.GetRecipe(ctx, rating.RecipeID)
+	if err != nil {
+		writeRatingStorageError(w, r, err, "Failed to load recipe")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		list, err := h.ratings.ListRatings(ctx, storage.RatingQuery{RecipeID: recipe.ID})
+		if err != nil {
+			writeRatingStorageError(w, r, err, "Failed to list ratings")
+			return
+		}
+		h.renderReviews(w, r, recipe, list)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RatingResponse{
+		Rating:        *rating,
+		RatingAverage: recipe.RatingAverage,
+		RatingCount:   recipe.RatingCount,
+	})
+}
+
+// renderReviews writes the reviews partial: the recipe's rating summary, a
+// page of reviews and, for signed-in users, their own rating form.
+func (h *RatingHandler) renderReviews(w http.ResponseWriter, r *http.Request, recipe *models.Recipe, list *models.RatingList) {
+	ctx := r.Context()
+
+	data := map[string]interface{}{
+		"recipe":  recipe,
+		"ratings": list,
+	}
+	if userID, ok := appmiddleware.GetUserID(ctx); ok {
+		data["signedIn"] = true
+		mine, err := h.ratings.GetRating(ctx, recipe.ID, userID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			writeRatingStorageError(w, r, err, "Failed to load rating")
+			return
+		}
+		data["mine"] = mine
+	}
+	if list.Page*list.PerPage < list.Total {
+		data["nextPage"] = list.Page + 1
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	tmpl := h.templates.Lookup("recipe-reviews.html")
+	if tmpl == nil {
+		http.Error(w, "Template recipe-reviews.html not found", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ratingUserID resolves the {userID} URL parameter, where "me" names the
+// signed-in user.
+func ratingUserID(r *http.Request) (string, bool) {
+	userID := chi.URLParam(r, "userID")
+	if userID == "me" {
+		return appmiddleware.GetUserID(r.Context())
+	}
+	return userID, userID != ""
+}
+
+func decodeRatingRequest(r *http.Request, req *RatingRequest) error {
+	if !isFormRequest(r) {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return errors.New("Invalid request body")
+		}
+		return nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return errors.New("Invalid request body")
+	}
+	var err error
+	if req.Score, err = intParam(r.PostForm, "score"); err != nil {
+		return err
+	}
+	req.Comment = r.PostForm.Get("comment")
+	return nil
+}
+
+// writeRatingStorageError maps rating repository errors to HTTP responses.
+func writeRatingStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Recipe or rating not found", http.StatusNotFound)
+		return
+	}
+	logger.LogError(r.Context(), err, msg)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+// newTestRatingHandler returns a handler over the seeded demo recipes and
+// the IDs of two registered users.
+func newTestRatingHandler(t *testing.T) (*RatingHandler, []models.Recipe, string, string) {
+	t.Helper()
+	store, recipes, owner, other := newSeededStore(t)
+	return NewRatingHandler(store, store), recipes, owner, other
+}
+
+func TestRatingHandler_Lifecycle(t *testing.T) {
+	handler, recipes, owner, other := newTestRatingHandler(t)
+	recipeID := recipes[0].ID
+
+	rate := func(userID, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		return serveAs(t, http.MethodPut, "/api/recipes/"+recipeID+"/ratings/"+target, body, userID,
+			map[string]string{"id": recipeID, "userID": target}, handler.HandleUpsertRating)
+	}
+
+	w := rate(owner, "me", `{"score": 4, "comment": " Lovely "}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response RatingResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Score != 4 || response.Comment != "Lovely" || response.RatingAverage != 4 || response.RatingCount != 1 {
+		t.Errorf("Unexpected rating response: %+v", response)
+	}
+
+	if w := rate(other, other, `{"score": 1}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 rating by user ID, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := rate(other, owner, `{"score": 1}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 rating as another user, got %d", w.Code)
+	}
+	if w := rate(other, "me", `{"score": 6}`); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"field":"score"`) {
+		t.Errorf("Expected a score validation error, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/recipes/"+recipeID+"/ratings?per_page=1", nil), map[string]string{"id": recipeID})
+	w = httptest.NewRecorder()
+	handler.HandleListRatings(w, req)
+	var list models.RatingList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to unmarshal ratings: %v", err)
+	}
+	if list.Total != 2 || len(list.Ratings) != 1 || list.Ratings[0].Username != "other" {
+		t.Errorf("Expected other's review first of 2, got %+v", list)
+	}
+
+	recipe, err := handler.recipes.GetRecipe(context.Background(), recipeID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if recipe.RatingAverage != 2.5 || recipe.RatingCount != 2 {
+		t.Errorf("Expected a 2.5 average over 2 ratings, got %v over %d", recipe.RatingAverage, recipe.RatingCount)
+	}
+
+	remove := func(userID string, isAdmin bool, target string) int {
+		req := withUser(httptest.NewRequest(http.MethodDelete, "/api/recipes/"+recipeID+"/ratings/"+target, nil), userID, isAdmin)
+		w := httptest.NewRecorder()
+		handler.HandleDeleteRating(w, withURLParams(req, map[string]string{"id": recipeID, "userID": target}))
+		return w.Code
+	}
+	if code := remove(other, false, owner); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 deleting another user's rating, got %d", code)
+	}
+	if code := remove(other, true, owner); code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for an admin deleting a rating, got %d", code)
+	}
+	if code := remove(other, false, "me"); code != http.StatusNoContent {
+		t.Errorf("Expected status 204 deleting one's own rating, got %d", code)
+	}
+	if code := remove(other, false, "me"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 deleting a missing rating, got %d", code)
+	}
+}
+
+func TestRatingHandler_NotFound(t *testing.T) {
+	handler, _, owner, _ := newTestRatingHandler(t)
+	missing := "00000000-0000-0000-0000-000000000000"
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/api/recipes/"+missing+"/ratings", nil), map[string]string{"id": missing})
+	w := httptest.NewRecorder()
+	handler.HandleListRatings(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 listing ratings of a missing recipe, got %d", w.Code)
+	}
+
+	req = withUser(httptest.NewRequest(http.MethodPut, "/api/recipes/"+missing+"/ratings/me", strings.NewReader(`{"score": 3}`)), owner, false)
+	w = httptest.NewRecorder()
+	handler.HandleUpsertRating(w, withURLParams(req, map[string]string{"id": missing, "userID": "me"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 rating a missing recipe, got %d", w.Code)
+	}
+}
+
+func TestRatingHandler_ReviewsHTMX(t *testing.T) {
+	handler, recipes, owner, _ := newTestRatingHandler(t)
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/recipe-reviews.html"))
+	recipeID := recipes[0].ID
+	params := map[string]string{"id": recipeID, "userID": "me"}
+
+	form := url.Values{"score": {"5"}, "comment": {"Best <b>ever</b>"}}
+	req := withUser(httptest.NewRequest(http.MethodPut, "/api/recipes/"+recipeID+"/ratings/me", strings.NewReader(form.Encode())), owner, false)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	handler.HandleUpsertRating(w, withURLParams(req, params))
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `id="recipe-reviews"`) {
+		t.Fatalf("Expected the reviews partial, got %d: %s", w.Code, body)
+	}
+	for _, want := range []string{"★ 5.0 · 1 rating", "owner", "Best &lt;b&gt;ever&lt;/b&gt;", "Update review"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected reviews to contain %q, got %s", want, body)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/recipes/"+recipeID+"/ratings", nil)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleListRatings(w, withURLParams(req, params))
+	if strings.Contains(w.Body.String(), "<form") {
+		t.Errorf("Expected no rating form for anonymous visitors, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"recipe-app/internal/appmiddleware"
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/shopping"
+	"recipe-app/internal/storage"
+)
+
+// MaxShoppingListRecipes bounds the recipe IDs a shopping list can be
+// generated from.
+const MaxShoppingListRecipes = 100
+
+type ShoppingListHandler struct {
+	templates *template.Template
+	lists     storage.ShoppingListRepository
+	recipes   storage.RecipeRepository
+	plans     storage.MealPlanRepository
+}
+
+// ShoppingListRequest generates a list from recipe_ids, the meals planned
+// from start to end, or both.
+type ShoppingListRequest struct {
+	Name      string   `json:"name"`
+	RecipeIDs []string `json:"recipe_ids"`
+	Start     string   `json:"start"`
+	End       string   `json:"end"`
+}
+
+// ShoppingListItemRequest checks an item off, or unchecks it.
+type ShoppingListItemRequest struct {
+	Checked *bool `json:"checked"`
+}
+
+func NewShoppingListHandler(lists storage.ShoppingListRepository, recipes storage.RecipeRepository, plans storage.MealPlanRepository) *ShoppingListHandler {
+	templates, err := template.ParseFiles("web/templates/shopping-list.html")
+	if err != nil {
+		// Templates not found, create empty template for tests
+		templates = template.New("")
+	}
+	return &ShoppingListHandler{
+		templates: templates,
+		lists:     lists,
+		recipes:   recipes,
+		plans:     plans,
+	}
+}
+
+// HandleListShoppingLists lists the signed-in user's shopping lists, newest
+// first.
+func (h *ShoppingListHandler) HandleListShoppingLists(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := h.lists.ListShoppingLists(ctx, userID)
+	if err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to list shopping lists")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"shopping_lists": lists})
+}
+
+// HandleCreateShoppingList generates and saves a shopping list. Every
+// planned meal counts, at its servings override, while each of recipe_ids
+// counts once at the recipe's own servings.
+func (h *ShoppingListHandler) HandleCreateShoppingList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var req ShoppingListRequest
+	if err := decodeShoppingListRequest(r, &req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	sources, err := h.sources(r, userID, &req)
+	if err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to load shopping list recipes")
+		return
+	}
+
+	list := models.ShoppingList{UserID: userID, Name: strings.TrimSpace(req.Name), Items: shopping.Build(sources)}
+	if list.Name == "" {
+		list.Name = "Shopping list"
+		if req.Start != "" {
+			list.Name = "Meal plan " + req.Start + " to " + req.End
+		}
+	}
+	if err := list.Validate(); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+
+	if err := h.lists.CreateShoppingList(ctx, &list); err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to create shopping list")
+		return
+	}
+
+	logger.FromContext(ctx).Info("Shopping list created", "list_id", list.ID, "user_id", userID, "items", len(list.Items))
+	h.writeList(w, r, &list, http.StatusCreated)
+}
+
+// HandleShoppingList returns a list as JSON or, with format=text or
+// format=markdown, as a file to download. HTMX requests get the list
+// partial.
+func (h *ShoppingListHandler) HandleShoppingList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.authorizeList(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		h.writeList(w, r, list, http.StatusOK)
+		return
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="shopping-list.txt"`)
+		err = shopping.WriteText(w, list)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="shopping-list.md"`)
+		err = shopping.WriteMarkdown(w, list)
+	default:
+		writeFieldError(w, &models.FieldError{Field: "format", Message: "format must be json, text or markdown"})
+		return
+	}
+	if err != nil {
+		logger.LogError(r.Context(), err, "Failed to write shopping list")
+	}
+}
+
+// HandleCheckShoppingListItem checks an item off the list, or unchecks it.
+func (h *ShoppingListHandler) HandleCheckShoppingListItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	list, ok := h.authorizeList(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShoppingListItemRequest
+	if err := decodeShoppingListItemRequest(r, &req); err != nil {
+		writeFieldError(w, err)
+		return
+	}
+	if req.Checked == nil {
+		writeFieldError(w, &models.FieldError{Field: "checked", Message: "checked is required"})
+		return
+	}
+
+	if err := h.lists.SetShoppingListItemChecked(ctx, list.ID, chi.URLParam(r, "itemID"), *req.Checked); err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to check shopping list item")
+		return
+	}
+
+	list, err := h.lists.GetShoppingList(ctx, list.ID)
+	if err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to load shopping list")
+		return
+	}
+	h.writeList(w, r, list, http.StatusOK)
+}
+
+func (h *ShoppingListHandler) HandleDeleteShoppingList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.authorizeList(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.lists.DeleteShoppingList(r.Context(), list.ID); err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to delete shopping list")
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<div id="shopping-list"></div>`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Shopping list deleted successfully",
+	})
+}
+
+// sources loads the recipes a list is generated from: each planned meal in
+// the request's range, then each of its recipe IDs.
+func (h *ShoppingListHandler) sources(r *http.Request, userID string, req *ShoppingListRequest) ([]shopping.Source, error) {
+	ctx := r.Context()
+
+	recipes := make(map[string]*models.Recipe)
+	load := func(id string) (*models.Recipe, error) {
+		if recipe, ok := recipes[id]; ok {
+			return recipe, nil
+		}
+		recipe, err := h.recipes.GetRecipe(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		recipes[id] = recipe
+		return recipe, nil
+	}
+
+	var sources []shopping.Source
+	if req.Start != "" {
+		entries, err := h.plans.ListMealPlan(ctx, userID, req.Start, req.End)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			recipe, err := load(entry.RecipeID)
+			if errors.Is(err, storage.ErrNotFound) {
+				// Deleted since it was listed.
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, shopping.Source{Recipe: recipe, Servings: entry.Servings})
+		}
+	}
+	for _, id := range req.RecipeIDs {
+		recipe, err := load(id)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, shopping.Source{Recipe: recipe})
+	}
+	return sources, nil
+}
+
+// authorizeList loads the list named in the URL and checks that the
+// authenticated user owns it, writing the error response and returning
+// false otherwise. Other users' lists are reported as not found.
+func (h *ShoppingListHandler) authorizeList(w http.ResponseWriter, r *http.Request) (*models.ShoppingList, bool) {
+	ctx := r.Context()
+
+	userID, ok := appmiddleware.GetUserID(ctx)
+	if !ok {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	list, err := h.lists.GetShoppingList(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		writeShoppingListStorageError(w, r, err, "Failed to load shopping list")
+		return nil, false
+	}
+	if list.UserID != userID {
+		http.Error(w, "Shopping list not found", http.StatusNotFound)
+		return nil, false
+	}
+	return list, true
+}
+
+// writeList writes a list as JSON, or the list partial for HTMX requests.
+func (h *ShoppingListHandler) writeList(w http.ResponseWriter, r *http.Request, list *models.ShoppingList, status int) {
+	if r.Header.Get("HX-Request") != "true" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(list)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	tmpl := h.templates.Lookup("shopping-list.html")
+	if tmpl == nil {
+		http.Error(w, "Template shopping-list.html not found", http.StatusInternalServerError)
+		return
+	}
+	data := map[string]interface{}{
+		"list":   list,
+		"groups": shopping.Group(list.Items),
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Template execution error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// validate trims and deduplicates the recipe IDs and checks the range,
+// returning a *models.FieldError.
+func (req *ShoppingListRequest) validate() error {
+	seen := make(map[string]bool)
+	ids := []string{}
+	for _, id := range req.RecipeIDs {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	req.RecipeIDs = ids
+	if len(ids) > MaxShoppingListRecipes {
+		return &models.FieldError{Field: "recipe_ids", Message: "a shopping list can be made from at most 100 recipes"}
+	}
+
+	req.Start, req.End = strings.TrimSpace(req.Start), strings.TrimSpace(req.End)
+	if req.Start == "" && req.End == "" {
+		if len(ids) == 0 {
+			return &models.FieldError{Field: "recipe_ids", Message: "recipe_ids or a start and end date are required"}
+		}
+		return nil
+	}
+	start, err := models.ParseDate(req.Start)
+	if err != nil {
+		return &models.FieldError{Field: "start", Message: err.Error()}
+	}
+	end, err := models.ParseDate(req.End)
+	if err != nil {
+		return &models.FieldError{Field: "end", Message: err.Error()}
+	}
+	if end.Before(start) {
+		return &models.FieldError{Field: "end", Message: "end cannot be before start"}
+	}
+	if end.After(start.AddDate(0, 0, models.MaxMealPlanDays-1)) {
+		return &models.FieldError{Field: "end", Message: "a meal plan range cannot span more than a year"}
+	}
+	return nil
+}
+
+func decodeShoppingListRequest(r *http.Request, req *ShoppingListRequest) error {
+	if !isFormRequest(r) {
+		return json.NewDecoder(r.Body).Decode(req)
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	req.Name = r.PostForm.Get("name")
+	req.RecipeIDs = r.PostForm["recipe_ids"]
+	req.Start = r.PostForm.Get("start")
+	req.End = r.PostForm.Get("end")
+	return nil
+}
+
+// decodeShoppingListItemRequest returns a *models.FieldError for an
+// invalid checked value.
+func decodeShoppingListItemRequest(r *http.Request, req *ShoppingListItemRequest) error {
+	if !isFormRequest(r) {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			return errors.New("Invalid request body")
+		}
+		return nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return errors.New("Invalid request body")
+	}
+	if r.PostForm.Has("checked") {
+		checked, err := boolParam(r.PostForm, "checked")
+		if err != nil {
+			return err
+		}
+		req.Checked = &checked
+	}
+	return nil
+}
+
+// writeShoppingListStorageError maps shopping list repository errors to
+// HTTP responses.
+func writeShoppingListStorageError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.Error(w, "Shopping list, item or recipe not found", http.StatusNotFound)
+	default:
+		logger.LogError(r.Context(), err, msg)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
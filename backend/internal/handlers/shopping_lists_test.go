@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+)
+
+// newTestShoppingListHandler returns a handler over the seeded store with
+// two more recipes that share ingredients, which it returns, and the IDs of
+// two registered users.
+func newTestShoppingListHandler(t *testing.T) (*ShoppingListHandler, *storage.Memory, []models.Recipe, string, string) {
+	t.Helper()
+
+	store, _, owner, other := newSeededStore(t)
+	ctx := context.Background()
+	recipes := []models.Recipe{
+		{
+			Title:    "Tomato Pasta",
+			Servings: 2,
+			Ingredients: []models.Ingredient{
+				{Name: "spaghetti", Amount: "200", Unit: "g"},
+				{Name: "tomatoes", Amount: "4"},
+				{Name: "olive oil", Amount: "2", Unit: "tbsp"},
+			},
+		},
+		{
+			Title:    "Tomato Salad",
+			Servings: 4,
+			Ingredients: []models.Ingredient{
+				{Name: "Tomato", Amount: "2"},
+				{Name: "feta", Amount: "100", Unit: "g"},
+				{Name: "olive oil", Amount: "1", Unit: "tbsp"},
+			},
+		},
+	}
+	for i := range recipes {
+		if err := store.CreateRecipe(ctx, &recipes[i]); err != nil {
+			t.Fatalf("failed to create recipe: %v", err)
+		}
+	}
+	return NewShoppingListHandler(store, store, store), store, recipes, owner, other
+}
+
+// shoppingLines describes a list's items as "aisle: line" entries.
+func shoppingLines(list models.ShoppingList) string {
+	lines := make([]string, len(list.Items))
+	for i, item := range list.Items {
+		lines[i] = item.Aisle + ": " + item.Line()
+	}
+	return strings.Join(lines, ", ")
+}
+
+func TestShoppingListHandler_Lifecycle(t *testing.T) {
+	handler, _, recipes, shopper, other := newTestShoppingListHandler(t)
+
+	body := `{"name": "Dinner party", "recipe_ids": ["` + recipes[0].ID + `", "` + recipes[1].ID + `", "` + recipes[0].ID + `"]}`
+	w := serveAs(t, http.MethodPost, "/api/shopping-lists", body, shopper, nil, handler.HandleCreateShoppingList)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var list models.ShoppingList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	want := "produce: 6 tomatoes, dairy & eggs: 100 g feta, pantry: 3 tbsp olive oil, pantry: 200 g spaghetti"
+	if list.Name != "Dinner party" || list.UserID != shopper || shoppingLines(list) != want {
+		t.Fatalf("Unexpected list %q: %s, want %s", list.Name, shoppingLines(list), want)
+	}
+	params := map[string]string{"id": list.ID, "itemID": list.Items[0].ID}
+
+	if w := serveAs(t, http.MethodGet, "/api/shopping-lists/"+list.ID, "", other, params, handler.HandleShoppingList); w.Code != http.StatusNotFound {
+		t.Errorf("Reading another user's list: expected status 404, got %d", w.Code)
+	}
+
+	w = serveAs(t, http.MethodPut, "/", `{"checked": true}`, shopper, params, handler.HandleCheckShoppingListItem)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var checked models.ShoppingList
+	json.Unmarshal(w.Body.Bytes(), &checked)
+	if !checked.Items[0].Checked || checked.Items[1].Checked {
+		t.Errorf("Unexpected items after checking: %+v", checked.Items)
+	}
+	if w := serveAs(t, http.MethodPut, "/", `{}`, shopper, params, handler.HandleCheckShoppingListItem); w.Code != http.StatusBadRequest {
+		t.Errorf("Checking without checked: expected status 400, got %d", w.Code)
+	}
+	missing := map[string]string{"id": list.ID, "itemID": "missing"}
+	if w := serveAs(t, http.MethodPut, "/", `{"checked": true}`, shopper, missing, handler.HandleCheckShoppingListItem); w.Code != http.StatusNotFound {
+		t.Errorf("Checking a missing item: expected status 404, got %d", w.Code)
+	}
+
+	w = serveAs(t, http.MethodGet, "/api/shopping-lists/"+list.ID+"?format=text", "", shopper, params, handler.HandleShoppingList)
+	wantText := "Dinner party\n\nProduce\n[x] 6 tomatoes\n\nDairy & Eggs\n[ ] 100 g feta\n\nPantry\n[ ] 3 tbsp olive oil\n[ ] 200 g spaghetti\n"
+	if w.Body.String() != wantText || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Text export = %q (%s), want %q", w.Body.String(), w.Header().Get("Content-Type"), wantText)
+	}
+	w = serveAs(t, http.MethodGet, "/api/shopping-lists/"+list.ID+"?format=markdown", "", shopper, params, handler.HandleShoppingList)
+	if !strings.Contains(w.Body.String(), "## Produce\n\n- [x] 6 tomatoes\n") || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") {
+		t.Errorf("Markdown export = %q", w.Body.String())
+	}
+	if w := serveAs(t, http.MethodGet, "/api/shopping-lists/"+list.ID+"?format=pdf", "", shopper, params, handler.HandleShoppingList); w.Code != http.StatusBadRequest {
+		t.Errorf("Unknown format: expected status 400, got %d", w.Code)
+	}
+
+	w = serveAs(t, http.MethodGet, "/api/shopping-lists", "", shopper, nil, handler.HandleListShoppingLists)
+	if !strings.Contains(w.Body.String(), list.ID) {
+		t.Errorf("Expected the list to be listed: %s", w.Body.String())
+	}
+
+	if w := serveAs(t, http.MethodDelete, "/", "", other, params, handler.HandleDeleteShoppingList); w.Code != http.StatusNotFound {
+		t.Errorf("Deleting another user's list: expected status 404, got %d", w.Code)
+	}
+	if w := serveAs(t, http.MethodDelete, "/", "", shopper, params, handler.HandleDeleteShoppingList); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := serveAs(t, http.MethodGet, "/", "", shopper, params, handler.HandleShoppingList); w.Code != http.StatusNotFound {
+		t.Errorf("Reading a deleted list: expected status 404, got %d", w.Code)
+	}
+}
+
+func TestShoppingListHandler_FromMealPlan(t *testing.T) {
+	handler, store, recipes, shopper, other := newTestShoppingListHandler(t)
+	ctx := context.Background()
+
+	entries := []models.MealPlanEntry{
+		{UserID: shopper, Date: "2024-03-04", Slot: models.MealDinner, RecipeID: recipes[0].ID, Servings: 4},
+		{UserID: shopper, Date: "2024-03-06", Slot: models.MealLunch, RecipeID: recipes[1].ID},
+		{UserID: shopper, Date: "2024-03-11", Slot: models.MealLunch, RecipeID: recipes[1].ID},
+		{UserID: other, Date: "2024-03-05", Slot: models.MealLunch, RecipeID: recipes[1].ID},
+	}
+	for i := range entries {
+		if err := store.CreateMealPlanEntry(ctx, &entries[i]); err != nil {
+			t.Fatalf("failed to plan meal: %v", err)
+		}
+	}
+
+	form := url.Values{"start": {"2024-03-04"}, "end": {"2024-03-10"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/shopping-lists", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler.HandleCreateShoppingList(w, withUser(req, shopper, false))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var list models.ShoppingList
+	json.Unmarshal(w.Body.Bytes(), &list)
+
+	// The pasta is planned for 4 servings, doubling it.
+	want := "produce: 10 tomatoes, dairy & eggs: 100 g feta, pantry: 5 tbsp olive oil, pantry: 400 g spaghetti"
+	if list.Name != "Meal plan 2024-03-04 to 2024-03-10" || shoppingLines(list) != want {
+		t.Errorf("Unexpected list %q: %s, want %s", list.Name, shoppingLines(list), want)
+	}
+
+	handler.templates = template.Must(template.ParseFiles("../../web/templates/shopping-list.html"))
+	req = httptest.NewRequest(http.MethodGet, "/api/shopping-lists/"+list.ID, nil)
+	req.Header.Set("HX-Request", "true")
+	w = httptest.NewRecorder()
+	handler.HandleShoppingList(w, withURLParams(withUser(req, shopper, false), map[string]string{"id": list.ID}))
+	body := w.Body.String()
+	for _, want := range []string{`id="shopping-list"`, "Dairy &amp; Eggs", "400 g spaghetti", `/api/shopping-lists/` + list.ID + `/items/` + list.Items[0].ID} {
+		if !strings.Contains(body, want) {
+			t.Errorf("List partial does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestShoppingListHandler_Validation(t *testing.T) {
+	handler, _, recipes, shopper, _ := newTestShoppingListHandler(t)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantField string
+	}{
+		{"nothing to shop for", `{"name": "Empty"}`, http.StatusBadRequest, "recipe_ids"},
+		{"start without end", `{"start": "2024-03-04"}`, http.StatusBadRequest, "end"},
+		{"bad start", `{"start": "March", "end": "2024-03-10"}`, http.StatusBadRequest, "start"},
+		{"backwards range", `{"start": "2024-03-10", "end": "2024-03-04"}`, http.StatusBadRequest, "end"},
+		{"long name", `{"name": "` + strings.Repeat("x", 256) + `", "recipe_ids": ["` + recipes[0].ID + `"]}`, http.StatusBadRequest, "name"},
+		{"missing recipe", `{"recipe_ids": ["00000000-0000-0000-0000-000000000000"]}`, http.StatusNotFound, ""},
+		{"invalid JSON", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.HandleCreateShoppingList(w, withUser(httptest.NewRequest(http.MethodPost, "/api/shopping-lists", strings.NewReader(tt.body)), shopper, false))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantField != "" && !strings.Contains(w.Body.String(), `"`+tt.wantField+`"`) {
+				t.Errorf("Expected an error for %s, got %s", tt.wantField, w.Body.String())
+			}
+		})
+	}
+}
@@ -3,20 +3,38 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"recipe-app/internal/appmiddleware"
 	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/storage"
+	"recipe-app/internal/units"
 )
 
-type UserHandler struct{}
+type UserHandler struct {
+	users storage.UserRepository
+}
 
+// ProfileUpdateRequest changes only the fields that are non-empty.
 type ProfileUpdateRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	AvatarURL string `json:"avatar_url"`
+	// PreferredUnits is "metric", "imperial" or, to show recipes as
+	// written, ""; it is left alone when omitted.
+	PreferredUnits *string `json:"preferred_units"`
+	// Diets and AvoidAllergens replace the user's dietary preferences,
+	// which recipe searches apply by default; each is left alone when
+	// omitted.
+	Diets          *[]string `json:"diets"`
+	AvoidAllergens *[]string `json:"avoid_allergens"`
 }
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+func NewUserHandler(users storage.UserRepository) *UserHandler {
+	return &UserHandler{users: users}
 }
 
 func (h *UserHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
@@ -28,10 +46,10 @@ func (h *UserHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := User{
-		ID:    userID,
-		Email: "user@example.com",
-		Name:  "Test User",
+	user, err := h.users.GetUserByID(ctx, userID)
+	if err != nil {
+		writeUserStorageError(w, r, err, "Failed to load profile")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -53,12 +71,59 @@ func (h *UserHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	logger.FromContext(ctx).Info("Profile update requested", "user_id", userID, "name", req.Name)
+	user, err := h.users.GetUserByID(ctx, userID)
+	if err != nil {
+		writeUserStorageError(w, r, err, "Failed to load profile")
+		return
+	}
 
-	user := User{
-		ID:    userID,
-		Email: req.Email,
-		Name:  req.Name,
+	if email := strings.TrimSpace(req.Email); email != "" {
+		if !strings.Contains(email, "@") {
+			http.Error(w, "Invalid email", http.StatusBadRequest)
+			return
+		}
+		user.Email = email
+	}
+	if username := strings.TrimSpace(req.Username); username != "" {
+		user.Username = username
+	}
+	if req.FirstName != "" {
+		user.FirstName = req.FirstName
+	}
+	if req.LastName != "" {
+		user.LastName = req.LastName
+	}
+	if req.AvatarURL != "" {
+		user.AvatarURL = req.AvatarURL
+	}
+	if req.PreferredUnits != nil {
+		system := strings.TrimSpace(*req.PreferredUnits)
+		if system != "" && !units.ValidSystem(system) {
+			writeFieldError(w, &models.FieldError{Field: "preferred_units", Message: "preferred units must be metric, imperial or empty"})
+			return
+		}
+		user.PreferredUnits = system
+	}
+	if req.Diets != nil {
+		if err := models.ValidateDietLabels("diets", *req.Diets, models.Diets); err != nil {
+			writeFieldError(w, err)
+			return
+		}
+		user.Diets = *req.Diets
+	}
+	if req.AvoidAllergens != nil {
+		if err := models.ValidateDietLabels("avoid_allergens", *req.AvoidAllergens, models.Allergens); err != nil {
+			writeFieldError(w, err)
+			return
+		}
+		user.AvoidAllergens = *req.AvoidAllergens
+	}
+
+	logger.FromContext(ctx).Info("Profile update requested", "user_id", userID)
+
+	if err := h.users.UpdateUser(ctx, user); err != nil {
+		writeUserStorageError(w, r, err, "Failed to update profile")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
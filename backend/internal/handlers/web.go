@@ -1,19 +1,28 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"html/template"
 	"net/http"
+
+	"recipe-app/internal/logger"
+	"recipe-app/internal/models"
+	"recipe-app/internal/schemaorg"
+	"recipe-app/internal/storage"
 )
 
 type WebHandler struct {
 	templates *template.Template
+	recipes   storage.RecipeRepository
 }
 
 type PageData struct {
 	Title    string
-	User     *User
+	User     *models.User
 	RecipeID string
+	// JSONLD is embedded in recipe pages for search engines.
+	JSONLD *schemaorg.Recipe
 }
 
 func NewWebHandler() *WebHandler {
@@ -28,6 +37,11 @@ func NewWebHandler() *WebHandler {
 	}
 }
 
+// SetRecipeRepository lets recipe pages embed the recipe as JSON-LD.
+func (h *WebHandler) SetRecipeRepository(recipes storage.RecipeRepository) {
+	h.recipes = recipes
+}
+
 func (h *WebHandler) renderTemplate(w http.ResponseWriter, templateName string, data PageData) {
 	// Simple approach: create a new template set each time
 	templates := template.Must(template.ParseFiles(
@@ -70,6 +84,15 @@ func (h *WebHandler) HandleNewRecipe(w http.ResponseWriter, r *http.Request) {
 	h.renderTemplate(w, "new-recipe.html", data)
 }
 
+func (h *WebHandler) HandleMealPlan(w http.ResponseWriter, r *http.Request) {
+	data := PageData{
+		Title: "Meal Plan - RecipeApp",
+		User:  h.getUserFromContext(r),
+	}
+
+	h.renderTemplate(w, "meal-plan.html", data)
+}
+
 func (h *WebHandler) HandleRecipeDetail(w http.ResponseWriter, r *http.Request) {
 	recipeID := chi.URLParam(r, "id")
 	data := PageData{
@@ -78,10 +101,22 @@ func (h *WebHandler) HandleRecipeDetail(w http.ResponseWriter, r *http.Request)
 		RecipeID: recipeID,
 	}
 
+	// The page loads the recipe itself; a missing one is reported there.
+	if h.recipes != nil {
+		recipe, err := h.recipes.GetRecipe(r.Context(), recipeID)
+		switch {
+		case err == nil:
+			data.Title = recipe.Title + " - RecipeApp"
+			data.JSONLD = schemaorg.FromRecipe(recipe, requestBaseURL(r))
+		case !errors.Is(err, storage.ErrNotFound):
+			logger.FromContext(r.Context()).Warn("Failed to load recipe for JSON-LD", "recipe_id", recipeID, "error", err)
+		}
+	}
+
 	h.renderTemplate(w, "recipe-detail.html", data)
 }
 
-func (h *WebHandler) getUserFromContext(r *http.Request) *User {
+func (h *WebHandler) getUserFromContext(r *http.Request) *models.User {
 	// This would get user from JWT token in request context
 	// For now, return nil (not authenticated)
 	return nil
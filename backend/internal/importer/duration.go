@@ -0,0 +1,37 @@
+package importer
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isoDuration matches ISO 8601 durations such as "PT1H30M" or "P1DT2H".
+// Years and months are not used for cooking times and are rejected.
+var isoDuration = regexp.MustCompile(`^P(?:(\d+(?:[.,]\d+)?)W)?(?:(\d+(?:[.,]\d+)?)D)?(?:T(?:(\d+(?:[.,]\d+)?)H)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// ParseDuration converts an ISO 8601 duration to whole minutes, rounding
+// seconds up. It returns false for anything else, including "P" and "PT"
+// alone.
+func ParseDuration(s string) (int, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	m := isoDuration.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, false
+	}
+
+	minutesPer := []float64{7 * 24 * 60, 24 * 60, 60, 1, 1.0 / 60}
+	var minutes float64
+	for i, part := range m[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.Replace(part, ",", ".", 1), 64)
+		if err != nil {
+			return 0, false
+		}
+		minutes += n * minutesPer[i]
+	}
+	return int(math.Ceil(minutes - 1e-9)), true
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// MaxDocumentSize bounds the HTML documents recipes are imported from.
+const MaxDocumentSize = 5 << 20
+
+var (
+	ErrInvalidURL       = errors.New("url must be an absolute http or https URL")
+	ErrForbiddenAddress = errors.New("url must not point to a private or local address")
+	ErrTooLarge         = errors.New("document is too large")
+)
+
+// Fetcher retrieves the HTML document at a URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) ([]byte, error)
+}
+
+// HTTPFetcher fetches documents over HTTP.
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+// NewHTTPFetcher returns a fetcher that refuses to connect to loopback,
+// private and link-local addresses, so that imports cannot be used to
+// reach the server's own network.
+func NewHTTPFetcher() *HTTPFetcher {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+	return &HTTPFetcher{
+		Client: &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				// No proxy: a proxy would make the connection on our behalf
+				// and bypass the address check.
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return ValidateURL(req.URL.String())
+			},
+		},
+	}
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// ValidateURL checks that rawURL is an absolute http or https URL.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	if err := ValidateURL(rawURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "RecipeApp-Importer/1.0")
+
+	resp, err := f.Client.Do(req)
+	if errors.Is(err, ErrForbiddenAddress) {
+		return nil, ErrForbiddenAddress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch document: %s", resp.Status)
+	}
+
+	doc, err := io.ReadAll(io.LimitReader(resp.Body, MaxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if len(doc) > MaxDocumentSize {
+		return nil, ErrTooLarge
+	}
+	return doc, nil
+}
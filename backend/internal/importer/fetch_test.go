@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/recipe":
+			w.Write([]byte(jsonLDPage))
+		case "/huge":
+			w.Write([]byte(strings.Repeat("a", MaxDocumentSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// The test server listens on loopback, which NewHTTPFetcher refuses.
+	if _, err := NewHTTPFetcher().Fetch(ctx, server.URL+"/recipe"); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("Fetch() of a loopback address error = %v, want ErrForbiddenAddress", err)
+	}
+
+	fetcher := &HTTPFetcher{Client: server.Client()}
+	doc, err := fetcher.Fetch(ctx, server.URL+"/recipe")
+	if err != nil || string(doc) != jsonLDPage {
+		t.Errorf("Fetch() = %d bytes, %v", len(doc), err)
+	}
+	if _, err := fetcher.Fetch(ctx, server.URL+"/huge"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Fetch() of a huge page error = %v, want ErrTooLarge", err)
+	}
+	if _, err := fetcher.Fetch(ctx, server.URL+"/missing"); err == nil {
+		t.Error("Fetch() of a missing page succeeded")
+	}
+	for _, rawURL := range []string{"file:///etc/passwd", "example.com/recipe", "ftp://example.com/"} {
+		if _, err := fetcher.Fetch(ctx, rawURL); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Fetch(%q) error = %v, want ErrInvalidURL", rawURL, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"html"
+	"strings"
+)
+
+// node is an element or, when tag is empty, a run of text in a parsed
+// HTML document.
+type node struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	parent   *node
+	children []*node
+}
+
+// voidElements never have content or an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements hold text up to their end tag rather than markup.
+var rawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+// siblingClosers end an open element of the same name when they start,
+// as in lists written without </li>.
+var siblingClosers = map[string]bool{
+	"li": true, "p": true, "option": true, "tr": true, "td": true, "th": true, "dt": true, "dd": true,
+}
+
+// parseHTML builds a tree from an HTML document. It is forgiving in the way
+// recipe pages need rather than a full HTML5 parser: unknown end tags are
+// ignored and unclosed elements end with their parent.
+func parseHTML(doc string) *node {
+	root := &node{tag: "#document"}
+	current := root
+
+	for i := 0; i < len(doc); {
+		if doc[i] != '<' {
+			end := strings.IndexByte(doc[i:], '<')
+			if end < 0 {
+				end = len(doc) - i
+			}
+			current.appendText(html.UnescapeString(doc[i : i+end]))
+			i += end
+			continue
+		}
+
+		rest := doc[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			i += skipPast(rest, "-->")
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			i += skipPast(rest, ">")
+		case strings.HasPrefix(rest, "</"):
+			name, _ := readName(rest[2:])
+			i += skipPast(rest, ">")
+			for n := current; n != root; n = n.parent {
+				if n.tag == name {
+					current = n.parent
+					break
+				}
+			}
+		case len(rest) > 1 && isLetter(rest[1]):
+			el, size, selfClosing := parseTag(rest)
+			i += size
+			if siblingClosers[el.tag] && current.tag == el.tag {
+				current = current.parent
+			}
+			el.parent = current
+			current.children = append(current.children, el)
+
+			switch {
+			case voidElements[el.tag] || selfClosing:
+			case rawTextElements[el.tag]:
+				end := indexFold(doc[i:], "</"+el.tag)
+				if end < 0 {
+					end = len(doc) - i
+				}
+				text := doc[i : i+end]
+				if el.tag == "title" || el.tag == "textarea" {
+					text = html.UnescapeString(text)
+				}
+				el.appendText(text)
+				i += end
+				i += skipPast(doc[i:], ">")
+			default:
+				current = el
+			}
+		default:
+			current.appendText("<")
+			i++
+		}
+	}
+	return root
+}
+
+func (n *node) appendText(text string) {
+	if text == "" {
+		return
+	}
+	if last := len(n.children) - 1; last >= 0 && n.children[last].tag == "" {
+		n.children[last].text += text
+		return
+	}
+	n.children = append(n.children, &node{text: text, parent: n})
+}
+
+// textContent returns the element's text with whitespace collapsed,
+// leaving out scripts and styles.
+func (n *node) textContent() string {
+	var b strings.Builder
+	var walk func(*node)
+	walk = func(n *node) {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			return
+		}
+		if n.tag == "script" || n.tag == "style" {
+			return
+		}
+		if n.tag == "br" {
+			b.WriteString(" ")
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		b.WriteString(" ")
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// walk calls fn for the element and its descendants in document order,
+// skipping the children of elements for which fn returns false.
+func (n *node) walk(fn func(*node) bool) {
+	if n.tag == "" || !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+// parseTag reads a start tag at the beginning of s, returning the element,
+// the tag's length and whether it was written self-closing.
+func parseTag(s string) (*node, int, bool) {
+	name, i := readName(s[1:])
+	i++
+	el := &node{tag: name, attrs: make(map[string]string)}
+	for i < len(s) {
+		switch c := s[i]; {
+		case c == '>':
+			return el, i + 1, false
+		case c == '/' && i+1 < len(s) && s[i+1] == '>':
+			return el, i + 2, true
+		case isSpace(c) || c == '/':
+			i++
+		default:
+			attr, size := readAttrName(s[i:])
+			i += size
+			for i < len(s) && isSpace(s[i]) {
+				i++
+			}
+			value := ""
+			if i < len(s) && s[i] == '=' {
+				i++
+				for i < len(s) && isSpace(s[i]) {
+					i++
+				}
+				var raw string
+				raw, size = readAttrValue(s[i:])
+				value = html.UnescapeString(raw)
+				i += size
+			}
+			if _, ok := el.attrs[attr]; !ok {
+				el.attrs[attr] = value
+			}
+		}
+	}
+	return el, len(s), false
+}
+
+// readName reads a lowercased tag name.
+func readName(s string) (string, int) {
+	i := 0
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	return strings.ToLower(s[:i]), i
+}
+
+func readAttrName(s string) (string, int) {
+	i := 1
+	for i < len(s) && !isSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	return strings.ToLower(s[:i]), i
+}
+
+func readAttrValue(s string) (string, int) {
+	if s == "" {
+		return "", 0
+	}
+	if q := s[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(s[1:], q)
+		if end < 0 {
+			return s[1:], len(s)
+		}
+		return s[1 : 1+end], end + 2
+	}
+	i := 0
+	for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+		i++
+	}
+	return s[:i], i
+}
+
+// skipPast returns the length of s up to and including the first marker,
+// or of all of s.
+func skipPast(s, marker string) int {
+	if i := strings.Index(s, marker); i >= 0 {
+		return i + len(marker)
+	}
+	return len(s)
+}
+
+// indexFold is strings.Index ignoring case.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
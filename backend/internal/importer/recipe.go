@@ -0,0 +1,303 @@
+// Package importer reads schema.org Recipe data, as JSON-LD or microdata,
+// from recipe web pages and maps it onto draft recipes.
+package importer
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/quantity"
+	"recipe-app/internal/units"
+)
+
+// ErrNoRecipe is returned for documents without a schema.org Recipe.
+var ErrNoRecipe = errors.New("no schema.org Recipe found in the document")
+
+// Column sizes that imported text is cut to.
+const (
+	maxTitle    = 255
+	maxCategory = 100
+	maxAmount   = 50
+	maxName     = 255
+	maxTags     = 20
+)
+
+// Parse extracts the first schema.org Recipe in an HTML document, from its
+// JSON-LD or else its microdata, as an unsaved draft. sourceURL, which may
+// be empty, resolves relative image links.
+func Parse(doc []byte, sourceURL string) (*models.Recipe, error) {
+	tree := parseHTML(string(doc))
+	data := jsonLDRecipe(tree)
+	if data == nil {
+		data = microdataRecipe(tree)
+	}
+	if data == nil {
+		return nil, ErrNoRecipe
+	}
+	return mapRecipe(data, sourceURL), nil
+}
+
+func mapRecipe(data map[string]any, sourceURL string) *models.Recipe {
+	recipe := &models.Recipe{
+		Title:       truncate(first(data["name"]), maxTitle),
+		Description: first(data["description"]),
+		Category:    truncate(first(data["recipeCategory"]), maxCategory),
+		Cuisine:     truncate(first(data["recipeCuisine"]), maxCategory),
+		Servings:    servings(data["recipeYield"]),
+		ImageURL:    imageURL(data["image"], sourceURL),
+	}
+	if recipe.Title == "" {
+		recipe.Title = truncate(first(data["headline"]), maxTitle)
+	}
+
+	prep, hasPrep := ParseDuration(first(data["prepTime"]))
+	cook, hasCook := ParseDuration(first(data["cookTime"]))
+	if total, ok := ParseDuration(first(data["totalTime"])); ok && !hasCook && total > prep {
+		cook, hasCook = total-prep, true
+	}
+	if hasPrep {
+		recipe.PrepTime = prep
+	}
+	if hasCook {
+		recipe.CookTime = cook
+	}
+
+	ingredients := data["recipeIngredient"]
+	if ingredients == nil {
+		ingredients = data["ingredients"]
+	}
+	for _, line := range strs(ingredients) {
+		if ing, ok := ParseIngredient(line); ok {
+			recipe.Ingredients = append(recipe.Ingredients, ing)
+		}
+	}
+
+	for _, inst := range steps(data["recipeInstructions"], sourceURL) {
+		inst.Temperature, inst.TemperatureUnit = temperature(inst.Text)
+		recipe.Instructions = append(recipe.Instructions, inst)
+	}
+
+	recipe.Tags = keywords(data["keywords"])
+	recipe.NormalizeUnits()
+	return recipe
+}
+
+// strs flattens a JSON-LD or microdata value into its texts.
+func strs(v any) []string {
+	switch v := v.(type) {
+	case string:
+		if s := cleanText(v); s != "" {
+			return []string{s}
+		}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []any:
+		var out []string
+		for _, item := range v {
+			out = append(out, strs(item)...)
+		}
+		return out
+	case map[string]any:
+		for _, key := range []string{"@value", "text", "name", "url", "@id"} {
+			if out := strs(v[key]); len(out) > 0 {
+				return out
+			}
+		}
+	}
+	return nil
+}
+
+// first returns the first text of a value.
+func first(v any) string {
+	if values := strs(v); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+var (
+	markup     = regexp.MustCompile(`<[^>]*>`)
+	leadingInt = regexp.MustCompile(`\d+`)
+)
+
+// cleanText strips markup and entities, which JSON-LD texts often carry,
+// and collapses whitespace.
+func cleanText(s string) string {
+	s = html.UnescapeString(markup.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
+
+// servings reads the first number of a yield such as "4 servings" or
+// ["4", "4 servings"].
+func servings(v any) int {
+	for _, s := range strs(v) {
+		if n, err := strconv.Atoi(leadingInt.FindString(s)); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// imageURL returns the first image's absolute URL.
+func imageURL(v any, sourceURL string) string {
+	if urls := imageURLs(v, sourceURL); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// imageURLs returns the absolute URLs of every web image in a value.
+func imageURLs(v any, sourceURL string) []string {
+	base, err := url.Parse(sourceURL)
+	if sourceURL == "" {
+		base, err = nil, nil
+	}
+	if err != nil {
+		return nil
+	}
+
+	var urls []string
+	for _, link := range strs(v) {
+		ref, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		if base != nil {
+			ref = base.ResolveReference(ref)
+		}
+		if ref.Scheme == "http" || ref.Scheme == "https" {
+			urls = append(urls, ref.String())
+		}
+	}
+	return urls
+}
+
+// steps flattens recipeInstructions, which may be a block of text, a list
+// of texts, HowToStep objects or HowToSection objects holding steps. A
+// HowToStep's timeRequired and images are kept with its text.
+func steps(v any, sourceURL string) []models.Instruction {
+	switch v := v.(type) {
+	case string:
+		var out []models.Instruction
+		for _, line := range strings.Split(markupBreaks.ReplaceAllString(v, "\n"), "\n") {
+			if line = cleanText(line); line != "" {
+				out = append(out, models.Instruction{Text: line})
+			}
+		}
+		return out
+	case []any:
+		var out []models.Instruction
+		for _, item := range v {
+			out = append(out, steps(item, sourceURL)...)
+		}
+		return out
+	case map[string]any:
+		if list, ok := v["itemListElement"]; ok {
+			return steps(list, sourceURL)
+		}
+		inst := models.Instruction{Text: first(v["text"])}
+		if inst.Text == "" {
+			inst.Text = first(v["name"])
+		}
+		if inst.Text == "" {
+			return nil
+		}
+		if minutes, ok := ParseDuration(first(v["timeRequired"])); ok {
+			inst.Duration = minutes
+		}
+		for _, image := range imageURLs(v["image"], sourceURL) {
+			if len(inst.Images) < models.MaxInstructionImages {
+				inst.Images = append(inst.Images, image)
+			}
+		}
+		return []models.Instruction{inst}
+	}
+	return nil
+}
+
+// markupBreaks are the tags that separate steps written as one HTML block.
+var markupBreaks = regexp.MustCompile(`(?i)<\s*(?:br|/p|/li)\s*/?>`)
+
+// ovenTemperature matches temperatures such as "350°F", "180 °C" or
+// "200 degrees Celsius".
+var ovenTemperature = regexp.MustCompile(`(?i)\b(\d{2,3})\s*(?:°|º|degrees?\s*)\s*(c|f)(?:elsius|ahrenheit)?\b`)
+
+// temperature returns the first oven temperature mentioned in a step.
+func temperature(text string) (int, string) {
+	m := ovenTemperature.FindStringSubmatch(text)
+	if m == nil {
+		return 0, ""
+	}
+	degrees, _ := strconv.Atoi(m[1])
+	return degrees, strings.ToUpper(m[2])
+}
+
+// keywords splits comma-separated keywords into tags.
+func keywords(v any) []string {
+	var tags []string
+	for _, s := range strs(v) {
+		for _, tag := range strings.Split(s, ",") {
+			if tag = truncate(strings.ToLower(strings.TrimSpace(tag)), maxCategory); tag != "" && len(tags) < maxTags {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// ParseIngredient splits a line such as "1 1/2 cups flour, sifted" into
+// its amount, unit, name and notes. Lines without a number keep their
+// whole text as the name.
+func ParseIngredient(line string) (models.Ingredient, bool) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return models.Ingredient{}, false
+	}
+
+	var ing models.Ingredient
+	n := 0
+	for k := min(4, len(words)); k >= 1; k-- {
+		if _, err := quantity.Parse(strings.Join(words[:k], " ")); err == nil {
+			n = k
+			break
+		}
+	}
+	if n > 0 {
+		ing.Amount = strings.Join(words[:n], " ")
+		if n+1 < len(words) {
+			if _, ok := units.Lookup(words[n] + " " + words[n+1]); ok {
+				ing.Unit = words[n] + " " + words[n+1]
+				n += 2
+			}
+		}
+		if ing.Unit == "" && n < len(words) {
+			if _, ok := units.Lookup(words[n]); ok {
+				ing.Unit = words[n]
+				n++
+			}
+		}
+	}
+
+	rest := strings.Join(words[n:], " ")
+	rest = strings.TrimPrefix(rest, "of ")
+	name, notes, _ := strings.Cut(rest, ", ")
+	ing.Name, ing.Notes = strings.TrimSpace(name), strings.TrimSpace(notes)
+	if ing.Name == "" || len(ing.Amount) > maxAmount || len(ing.Unit) > maxAmount {
+		ing = models.Ingredient{Name: line}
+	}
+	ing.Name = truncate(ing.Name, maxName)
+	return ing, true
+}
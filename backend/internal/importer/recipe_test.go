@@ -0,0 +1,199 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+const jsonLDPage = `<!DOCTYPE html>
+<html><head>
+<title>Best Pancakes &amp; More</title>
+<script type="application/ld+json">{"@context": "https://schema.org", "@type": "WebSite", "name": "Cooking"}</script>
+<script type="application/ld+json">
+{
+  "@context": "https://schema.org",
+  "@graph": [
+    {"@type": "BreadcrumbList", "itemListElement": []},
+    {
+      "@type": ["Recipe", "NewsArticle"],
+      "name": "Fluffy Pancakes",
+      "description": "Light and <b>fluffy</b> pancakes &amp; syrup.",
+      "image": [{"@type": "ImageObject", "url": "/images/pancakes.jpg"}],
+      "prepTime": "PT10M",
+      "totalTime": "PT1H5M",
+      "recipeYield": ["4", "4 servings"],
+      "recipeCategory": "Breakfast",
+      "recipeCuisine": ["American"],
+      "keywords": "pancakes, Breakfast , easy",
+      "recipeIngredient": [
+        "1 1/2 cups all-purpose flour, sifted",
+        "2 Tablespoons sugar",
+        "1 to 2 tsp baking powder",
+        "2 fl oz milk",
+        "Salt to taste",
+        "3 large eggs"
+      ],
+      "recipeInstructions": [
+        {"@type": "HowToSection", "name": "Batter", "itemListElement": [
+          {"@type": "HowToStep", "text": "Whisk the dry ingredients."},
+          {"@type": "HowToStep", "text": "Stir in the milk and eggs."}
+        ]},
+        {"@type": "HowToStep", "name": "Bake", "text": "Keep warm in a 200 °F oven."}
+      ]
+    }
+  ]
+}
+</script>
+</head><body><h1>Fluffy Pancakes</h1></body></html>`
+
+func TestParse_JSONLD(t *testing.T) {
+	recipe, err := Parse([]byte(jsonLDPage), "https://example.com/recipes/pancakes")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if recipe.Title != "Fluffy Pancakes" || recipe.Description != "Light and fluffy pancakes & syrup." {
+		t.Errorf("Parse() title, description = %q, %q", recipe.Title, recipe.Description)
+	}
+	if recipe.PrepTime != 10 || recipe.CookTime != 55 || recipe.Servings != 4 {
+		t.Errorf("Parse() prep, cook, servings = %d, %d, %d, want 10, 55, 4", recipe.PrepTime, recipe.CookTime, recipe.Servings)
+	}
+	if recipe.Category != "Breakfast" || recipe.Cuisine != "American" {
+		t.Errorf("Parse() category, cuisine = %q, %q", recipe.Category, recipe.Cuisine)
+	}
+	if recipe.ImageURL != "https://example.com/images/pancakes.jpg" {
+		t.Errorf("Parse() image = %q", recipe.ImageURL)
+	}
+	if got := strings.Join(recipe.Tags, ","); got != "pancakes,breakfast,easy" {
+		t.Errorf("Parse() tags = %s", got)
+	}
+
+	want := []models.Ingredient{
+		{Amount: "1 1/2", Unit: "cups", Name: "all-purpose flour", Notes: "sifted"},
+		{Amount: "2", Unit: "tbsp", Name: "sugar"},
+		{Amount: "1 to 2", Unit: "tsp", Name: "baking powder"},
+		{Amount: "2", Unit: "fl oz", Name: "milk"},
+		{Name: "Salt to taste"},
+		{Amount: "3", Name: "large eggs"},
+	}
+	if len(recipe.Ingredients) != len(want) {
+		t.Fatalf("Parse() ingredients = %+v", recipe.Ingredients)
+	}
+	for i, ing := range recipe.Ingredients {
+		if ing != want[i] {
+			t.Errorf("ingredient %d = %+v, want %+v", i, ing, want[i])
+		}
+	}
+
+	steps := []string{"Whisk the dry ingredients.", "Stir in the milk and eggs.", "Keep warm in a 200 °F oven."}
+	if len(recipe.Instructions) != len(steps) {
+		t.Fatalf("Parse() instructions = %+v", recipe.Instructions)
+	}
+	for i, inst := range recipe.Instructions {
+		if inst.Text != steps[i] {
+			t.Errorf("step %d = %q, want %q", i, inst.Text, steps[i])
+		}
+	}
+	if last := recipe.Instructions[2]; last.Temperature != 200 || last.TemperatureUnit != "F" {
+		t.Errorf("step 3 temperature = %d%s, want 200F", last.Temperature, last.TemperatureUnit)
+	}
+}
+
+const microdataPage = `<html><body>
+<div itemscope itemtype="http://schema.org/Recipe">
+  <h1 itemprop="name">Tomato Soup</h1>
+  <img itemprop="image" src="https://cdn.example.com/soup.png" alt="">
+  <p itemprop="description">A warming soup.</p>
+  <meta itemprop="prepTime" content="PT15M">
+  <time itemprop="cookTime" datetime="PT0.5H">30 minutes</time>
+  <span itemprop="recipeYield">Serves 6</span>
+  <div itemprop="author" itemscope itemtype="http://schema.org/Person"><span itemprop="name">Ann</span></div>
+  <ul>
+    <li itemprop="recipeIngredient">800 g tomatoes
+    <li itemprop="recipeIngredient">1 onion, chopped
+  </ul>
+  <ol itemprop="recipeInstructions">
+    <li>Roast the tomatoes at 200&deg;C.</li>
+  </ol>
+  <div itemprop="recipeInstructions" itemscope itemtype="http://schema.org/HowToStep">
+    <span itemprop="text">Blend until smooth.</span>
+  </div>
+</div>
+</body></html>`
+
+func TestParse_Microdata(t *testing.T) {
+	recipe, err := Parse([]byte(microdataPage), "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if recipe.Title != "Tomato Soup" || recipe.Description != "A warming soup." || recipe.ImageURL != "https://cdn.example.com/soup.png" {
+		t.Errorf("Parse() = %q, %q, %q", recipe.Title, recipe.Description, recipe.ImageURL)
+	}
+	if recipe.PrepTime != 15 || recipe.CookTime != 30 || recipe.Servings != 6 {
+		t.Errorf("Parse() prep, cook, servings = %d, %d, %d, want 15, 30, 6", recipe.PrepTime, recipe.CookTime, recipe.Servings)
+	}
+	if len(recipe.Ingredients) != 2 || recipe.Ingredients[0] != (models.Ingredient{Amount: "800", Unit: "g", Name: "tomatoes"}) ||
+		recipe.Ingredients[1] != (models.Ingredient{Amount: "1", Name: "onion", Notes: "chopped"}) {
+		t.Errorf("Parse() ingredients = %+v", recipe.Ingredients)
+	}
+	if len(recipe.Instructions) != 2 || recipe.Instructions[0].Text != "Roast the tomatoes at 200°C." || recipe.Instructions[1].Text != "Blend until smooth." {
+		t.Fatalf("Parse() instructions = %+v", recipe.Instructions)
+	}
+	if recipe.Instructions[0].Temperature != 200 || recipe.Instructions[0].TemperatureUnit != "C" {
+		t.Errorf("step 1 temperature = %d%s, want 200C", recipe.Instructions[0].Temperature, recipe.Instructions[0].TemperatureUnit)
+	}
+}
+
+func TestParse_InstructionText(t *testing.T) {
+	page := `<script type="application/ld+json">{"@type": "Recipe", "name": "Toast",
+		"recipeInstructions": "<p>Slice the bread.</p><p>Toast it at 180 degrees Celsius.</p>"}</script>`
+	recipe, err := Parse([]byte(page), "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(recipe.Instructions) != 2 || recipe.Instructions[1].Text != "Toast it at 180 degrees Celsius." || recipe.Instructions[1].Temperature != 180 {
+		t.Errorf("Parse() instructions = %+v", recipe.Instructions)
+	}
+}
+
+func TestParse_NoRecipe(t *testing.T) {
+	for _, page := range []string{
+		`<html><body><p>Nothing here</p></body></html>`,
+		`<script type="application/ld+json">{"@type": "Article", "name": "News"}</script>`,
+		`<script type="application/ld+json">{not json</script>`,
+		``,
+	} {
+		if _, err := Parse([]byte(page), ""); !errors.Is(err, ErrNoRecipe) {
+			t.Errorf("Parse(%q) error = %v, want ErrNoRecipe", page, err)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"PT30M", 30, true},
+		{"PT1H30M", 90, true},
+		{"pt2h", 120, true},
+		{"P1DT2H", 1560, true},
+		{"PT0.5H", 30, true},
+		{"PT90S", 2, true},
+		{"PT", 0, false},
+		{"P", 0, false},
+		{"P1Y", 0, false},
+		{"30 minutes", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDuration(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseDuration(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// jsonLDRecipe returns the first schema.org Recipe object in the
+// document's JSON-LD scripts.
+func jsonLDRecipe(doc *node) map[string]any {
+	var recipe map[string]any
+	doc.walk(func(n *node) bool {
+		if recipe != nil {
+			return false
+		}
+		if n.tag != "script" || !isJSONLD(n.attrs["type"]) || len(n.children) == 0 {
+			return true
+		}
+		// Pages often break JSON strings across lines; outside of strings
+		// the control characters are whitespace anyway.
+		text := strings.NewReplacer("\n", " ", "\r", " ", "\t", " ").Replace(n.children[0].text)
+		var data any
+		if err := json.Unmarshal([]byte(text), &data); err == nil {
+			recipe = findRecipe(data)
+		}
+		return false
+	})
+	return recipe
+}
+
+func isJSONLD(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/ld+json")
+}
+
+// findRecipe searches JSON-LD data for a Recipe, looking into lists, graphs
+// and the main entity of pages.
+func findRecipe(data any) map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if recipe := findRecipe(item); recipe != nil {
+				return recipe
+			}
+		}
+	case map[string]any:
+		if isRecipeType(v["@type"]) {
+			return v
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage", "itemListElement", "item"} {
+			if recipe := findRecipe(v[key]); recipe != nil {
+				return recipe
+			}
+		}
+	}
+	return nil
+}
+
+// isRecipeType reports whether a @type or itemtype names schema.org/Recipe,
+// either alone or among several types.
+func isRecipeType(t any) bool {
+	for _, name := range strings.Fields(strings.Join(strs(t), " ")) {
+		name = strings.TrimSuffix(name, "/")
+		if name == "Recipe" || strings.HasSuffix(name, "schema.org/Recipe") || name == "schema:Recipe" {
+			return true
+		}
+	}
+	return false
+}
+
+// microdataRecipe returns the first schema.org Recipe item in the
+// document's microdata, shaped like its JSON-LD would be.
+func microdataRecipe(doc *node) map[string]any {
+	var recipe map[string]any
+	doc.walk(func(n *node) bool {
+		if recipe != nil {
+			return false
+		}
+		if _, scope := n.attrs["itemscope"]; scope && isRecipeType(n.attrs["itemtype"]) {
+			recipe = microdataItem(n)
+			return false
+		}
+		return true
+	})
+	return recipe
+}
+
+// microdataItem collects the properties of an itemscope element. Values of
+// repeated properties become lists.
+func microdataItem(item *node) map[string]any {
+	props := make(map[string]any)
+	if t := item.attrs["itemtype"]; t != "" {
+		fields := strings.Fields(t)
+		props["@type"] = fields[0][strings.LastIndex(fields[0], "/")+1:]
+	}
+
+	add := func(name string, value any) {
+		switch existing := props[name].(type) {
+		case nil:
+			props[name] = value
+		case []any:
+			props[name] = append(existing, value)
+		default:
+			props[name] = []any{existing, value}
+		}
+	}
+
+	for _, child := range item.children {
+		child.walk(func(n *node) bool {
+			names := strings.Fields(n.attrs["itemprop"])
+			_, scope := n.attrs["itemscope"]
+			if len(names) == 0 {
+				// Items without a property belong to no one here.
+				return !scope
+			}
+			var value any
+			if scope {
+				value = microdataItem(n)
+			} else {
+				value = microdataValue(n)
+			}
+			for _, name := range names {
+				add(name, value)
+			}
+			// A nested item's properties are its own, but a plain property
+			// may still contain other properties of this item.
+			return !scope
+		})
+	}
+	return props
+}
+
+// microdataValue returns a property's value by the element's kind.
+func microdataValue(n *node) string {
+	switch n.tag {
+	case "meta":
+		return n.attrs["content"]
+	case "a", "area", "link":
+		return n.attrs["href"]
+	case "img", "audio", "video", "source", "iframe", "embed", "track":
+		return n.attrs["src"]
+	case "object":
+		return n.attrs["data"]
+	case "time":
+		if v, ok := n.attrs["datetime"]; ok {
+			return v
+		}
+	case "data", "meter":
+		if v, ok := n.attrs["value"]; ok {
+			return v
+		}
+	}
+	if v, ok := n.attrs["content"]; ok {
+		return v
+	}
+	return n.textContent()
+}
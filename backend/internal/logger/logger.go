@@ -73,6 +73,10 @@ func LogRequest(ctx context.Context, method, path string, duration time.Duration
 
 func LogError(ctx context.Context, err error, msg string) {
 	logger := FromContext(ctx)
+	if err == nil {
+		logger.Error(msg, "error_type", "application_error")
+		return
+	}
 	logger.Error(msg,
 		"error", err.Error(),
 		"error_type", "application_error",
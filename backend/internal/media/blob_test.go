@@ -0,0 +1,70 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	for _, key := range []string{"recipes/r1/i1/card.jpg", "recipes/r1/i2/card.jpg", "recipes/r2/i1/card.jpg"} {
+		if err := store.Put(ctx, key, strings.NewReader("data "+key), "image/jpeg"); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+
+	blob, info, err := store.Open(ctx, "recipes/r1/i1/card.jpg")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(data) != "data recipes/r1/i1/card.jpg" || info.ContentType != "image/jpeg" || info.Size != int64(len(data)) {
+		t.Errorf("Open() = %q, %+v", data, info)
+	}
+	if _, ok := blob.(io.ReadSeeker); !ok {
+		t.Error("Open() blob does not support seeking")
+	}
+
+	if err := store.DeletePrefix(ctx, "recipes/r1/"); err != nil {
+		t.Fatalf("DeletePrefix() error = %v", err)
+	}
+	if _, _, err := store.Open(ctx, "recipes/r1/i2/card.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() after DeletePrefix error = %v, want ErrNotFound", err)
+	}
+	if blob, _, err := store.Open(ctx, "recipes/r2/i1/card.jpg"); err != nil {
+		t.Errorf("Open() of another prefix error = %v", err)
+	} else {
+		blob.Close()
+	}
+}
+
+func TestLocalStore_InvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	for _, key := range []string{"", "../escape.jpg", "/abs.jpg", "recipes/../../x.jpg", `recipes\x.jpg`} {
+		if err := store.Put(ctx, key, strings.NewReader("x"), "image/jpeg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Put(%q) error = %v, want ErrNotFound", key, err)
+		}
+		if _, _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v, want ErrNotFound", key, err)
+		}
+	}
+	for _, prefix := range []string{"", "/", "./", "../"} {
+		if err := store.DeletePrefix(ctx, prefix); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeletePrefix(%q) error = %v, want ErrNotFound", prefix, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// MaxUploadSize bounds the size of an uploaded image file.
+const MaxUploadSize = 10 << 20
+
+// maxPixels bounds decoded images, so that a small file cannot claim a
+// huge canvas.
+const maxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("image must be a JPEG, PNG or GIF")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+	ErrInvalidImage    = errors.New("image could not be decoded")
+)
+
+// Variant is a resized copy of an image.
+type Variant struct {
+	Name   string
+	Width  int
+	Height int
+	// Crop fills exactly Width by Height, cutting off the longer side;
+	// otherwise the image is fitted within the box.
+	Crop bool
+}
+
+// Variants are the sizes generated for every upload: cropped cards for
+// the recipe grid and a larger view for the recipe page, both at twice the
+// CSS size for high-density screens.
+var Variants = []Variant{
+	{Name: "card", Width: 640, Height: 384, Crop: true},
+	{Name: "detail", Width: 1600, Height: 1024},
+}
+
+// Image is an upload ready to store.
+type Image struct {
+	// Original is the uploaded image re-encoded, which drops EXIF and any
+	// other metadata, in Ext's format.
+	Original    []byte
+	ContentType string
+	Ext         string
+	// Thumbnails holds a JPEG per entry of Variants, keyed by name.
+	Thumbnails map[string][]byte
+}
+
+// Process validates an uploaded image by its content rather than its
+// declared type, rotates it upright according to its EXIF orientation and
+// produces the stripped original and its thumbnails. GIFs are stored as
+// PNGs of their first frame.
+func Process(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooManyPixels
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if contentType == "image/jpeg" {
+		src = orient(src, exifOrientation(data))
+	}
+
+	result := &Image{ContentType: "image/png", Ext: "png", Thumbnails: make(map[string][]byte, len(Variants))}
+	var original bytes.Buffer
+	if contentType == "image/jpeg" {
+		result.ContentType, result.Ext = "image/jpeg", "jpg"
+		err = jpeg.Encode(&original, src, &jpeg.Options{Quality: 90})
+	} else {
+		err = png.Encode(&original, src)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Original = original.Bytes()
+
+	for _, v := range Variants {
+		var thumb bytes.Buffer
+		if err := jpeg.Encode(&thumb, flatten(resize(src, v)), &jpeg.Options{Quality: 82}); err != nil {
+			return nil, err
+		}
+		result.Thumbnails[v.Name] = thumb.Bytes()
+	}
+	return result, nil
+}
+
+// resize scales src down to the variant's box with an area-averaging
+// filter. Images smaller than the box are not enlarged.
+func resize(src image.Image, v Variant) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	// crop is the part of the source that is kept.
+	crop := b
+	dw, dh := v.Width, v.Height
+	if v.Crop {
+		if sw*v.Height > sh*v.Width {
+			w := sh * v.Width / v.Height
+			crop = image.Rect(b.Min.X+(sw-w)/2, b.Min.Y, b.Min.X+(sw-w)/2+w, b.Max.Y)
+		} else {
+			h := sw * v.Height / v.Width
+			crop = image.Rect(b.Min.X, b.Min.Y+(sh-h)/2, b.Max.X, b.Min.Y+(sh-h)/2+h)
+		}
+		if crop.Dx() < dw {
+			dw, dh = crop.Dx(), crop.Dy()
+		}
+	} else {
+		scale := min(float64(v.Width)/float64(sw), float64(v.Height)/float64(sh), 1)
+		dw, dh = max(int(float64(sw)*scale), 1), max(int(float64(sh)*scale), 1)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, crop.Min, draw.Src)
+	if dw == crop.Dx() && dh == crop.Dy() {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	cw, ch := crop.Dx(), crop.Dy()
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*ch/dh, max((y+1)*ch/dh, y*ch/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*cw/dw, max((x+1)*cw/dw, x*cw/dw+1)
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r, g, bl, a = r+uint32(p[0]), g+uint32(p[1]), bl+uint32(p[2]), a+uint32(p[3])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// flatten composes img over white, as JPEG has no transparency.
+func flatten(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
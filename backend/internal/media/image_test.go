@@ -0,0 +1,182 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halves returns a w by h image that is red on its left half and blue on
+// its right.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment holding the given orientation
+// right after the JPEG's start marker.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, 0xFF, 0xE1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0xC000 && b < 0x4000
+}
+
+func TestExifOrientation(t *testing.T) {
+	data := encodeJPEG(t, halves(8, 4))
+	if got := exifOrientation(data); got != 1 {
+		t.Errorf("exifOrientation() without EXIF = %d, want 1", got)
+	}
+	for o := uint16(1); o <= 8; o++ {
+		if got := exifOrientation(withOrientation(data, o)); got != int(o) {
+			t.Errorf("exifOrientation() = %d, want %d", got, o)
+		}
+	}
+	if got := exifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Errorf("exifOrientation() of garbage = %d, want 1", got)
+	}
+}
+
+func TestProcess_OrientsAndStripsExif(t *testing.T) {
+	data := withOrientation(encodeJPEG(t, halves(40, 20)), 6)
+
+	img, err := Process(data)
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if img.ContentType != "image/jpeg" || img.Ext != "jpg" {
+		t.Errorf("Process() type = %q, %q, want image/jpeg, jpg", img.ContentType, img.Ext)
+	}
+	if bytes.Contains(img.Original, []byte("Exif")) {
+		t.Error("Process() kept the EXIF segment")
+	}
+
+	// Orientation 6 is stored rotated a quarter turn anticlockwise, so the
+	// upright image is portrait with the left half on top.
+	original, err := jpeg.Decode(bytes.NewReader(img.Original))
+	if err != nil {
+		t.Fatalf("jpeg.Decode() error = %v", err)
+	}
+	if b := original.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+		t.Fatalf("Process() original is %dx%d, want 20x40", b.Dx(), b.Dy())
+	}
+	if !isRed(original.At(10, 5)) || isRed(original.At(10, 35)) {
+		t.Error("Process() did not rotate the image upright")
+	}
+
+	for _, v := range Variants {
+		if _, err := jpeg.Decode(bytes.NewReader(img.Thumbnails[v.Name])); err != nil {
+			t.Errorf("thumbnail %q is not a JPEG: %v", v.Name, err)
+		}
+	}
+}
+
+func TestProcess_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halves(1000, 500)); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if img.ContentType != "image/png" || img.Ext != "png" {
+		t.Errorf("Process() type = %q, %q, want image/png, png", img.ContentType, img.Ext)
+	}
+	card, err := jpeg.Decode(bytes.NewReader(img.Thumbnails["card"]))
+	if err != nil {
+		t.Fatalf("jpeg.Decode() error = %v", err)
+	}
+	if b := card.Bounds(); b.Dx() != 640 || b.Dy() != 384 {
+		t.Errorf("card thumbnail is %dx%d, want 640x384", b.Dx(), b.Dy())
+	}
+}
+
+func TestProcess_Rejects(t *testing.T) {
+	// A PNG header claiming a 10000x10000 canvas, with no pixel data.
+	ihdr := binary.BigEndian.AppendUint32([]byte("IHDR"), 10000)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, 10000)
+	ihdr = append(ihdr, 8, 6, 0, 0, 0)
+	huge := []byte("\x89PNG\r\n\x1a\n")
+	huge = binary.BigEndian.AppendUint32(huge, 13)
+	huge = append(huge, ihdr...)
+	huge = binary.BigEndian.AppendUint32(huge, crc32.ChecksumIEEE(ihdr))
+
+	truncated := encodeJPEG(t, halves(40, 20))
+	truncated = truncated[:len(truncated)/2]
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("hello, world"), ErrUnsupportedType},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ErrUnsupportedType},
+		{"too many pixels", huge, ErrTooManyPixels},
+		{"truncated", truncated, ErrInvalidImage},
+	}
+	for _, tt := range tests {
+		if _, err := Process(tt.data); !errors.Is(err, tt.want) {
+			t.Errorf("Process(%s) error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		variant      Variant
+		wantW, wantH int
+	}{
+		{3200, 1600, Variants[0], 640, 384},
+		{300, 1000, Variants[0], 300, 180},
+		{3200, 1600, Variants[1], 1600, 800},
+		{800, 4000, Variants[1], 204, 1024},
+		{400, 300, Variants[1], 400, 300},
+	}
+	for _, tt := range tests {
+		got := resize(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.variant).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("resize(%dx%d, %s) = %dx%d, want %dx%d", tt.w, tt.h, tt.variant.Name, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
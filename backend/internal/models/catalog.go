@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CatalogIngredient is a canonical ingredient that recipes' free-text
+// ingredients are linked to, so that "scallions" and "green onions" are
+// known to be the same thing.
+type CatalogIngredient struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	// Plural is only needed where adding an "s" or "es" does not make one,
+	// as in "leaves".
+	Plural   string   `json:"plural" db:"plural"`
+	Category string   `json:"category" db:"category"`
+	Synonyms []string `json:"synonyms" db:"synonyms"`
+	// Allergens and Diets tag the ingredient with the allergens it
+	// contains and the diets it fits, from Allergens and Diets.
+	Allergens []string `json:"allergens" db:"allergens"`
+	Diets     []string `json:"diets" db:"diets"`
+	// AllergensReviewed is set once an admin has checked Allergens, so
+	// that an allergen missing from them is known to be absent.
+	AllergensReviewed bool      `json:"allergens_reviewed" db:"allergens_reviewed"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Catalog links are made by the automatic matcher or by hand. Manual
+// links, including manually leaving an ingredient unlinked, are never
+// changed by the matcher.
+const (
+	CatalogMatchAuto   = "auto"
+	CatalogMatchManual = "manual"
+)
+
+const (
+	MaxCatalogNameLength     = 255
+	MaxCatalogCategoryLength = 50
+	MaxCatalogSynonyms       = 50
+)
+
+// IngredientCount is how many recipe ingredients share a name.
+type IngredientCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Validate returns a *FieldError naming the first invalid field.
+func (c *CatalogIngredient) Validate() error {
+	if NormalizeIngredientName(c.Name) == "" {
+		return &FieldError{Field: "name", Message: "ingredient name is required"}
+	}
+	if utf8.RuneCountInString(c.Name) > MaxCatalogNameLength {
+		return &FieldError{Field: "name", Message: fmt.Sprintf("ingredient name must be at most %d characters", MaxCatalogNameLength)}
+	}
+	if utf8.RuneCountInString(c.Plural) > MaxCatalogNameLength {
+		return &FieldError{Field: "plural", Message: fmt.Sprintf("plural must be at most %d characters", MaxCatalogNameLength)}
+	}
+	if utf8.RuneCountInString(c.Category) > MaxCatalogCategoryLength {
+		return &FieldError{Field: "category", Message: fmt.Sprintf("category must be at most %d characters", MaxCatalogCategoryLength)}
+	}
+	if len(c.Synonyms) > MaxCatalogSynonyms {
+		return &FieldError{Field: "synonyms", Message: fmt.Sprintf("an ingredient can have at most %d synonyms", MaxCatalogSynonyms)}
+	}
+	for _, synonym := range c.Synonyms {
+		if NormalizeIngredientName(synonym) == "" {
+			return &FieldError{Field: "synonyms", Message: "synonyms cannot be blank"}
+		}
+		if utf8.RuneCountInString(synonym) > MaxCatalogNameLength {
+			return &FieldError{Field: "synonyms", Message: fmt.Sprintf("synonyms must be at most %d characters", MaxCatalogNameLength)}
+		}
+	}
+	if err := ValidateDietLabels("allergens", c.Allergens, Allergens); err != nil {
+		return err
+	}
+	return ValidateDietLabels("diets", c.Diets, Diets)
+}
+
+// Terms returns the distinct normalized names the ingredient is known by:
+// its name, plural and synonyms.
+func (c *CatalogIngredient) Terms() []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, name := range append([]string{c.Name, c.Plural}, c.Synonyms...) {
+		term := NormalizeIngredientName(name)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// MatchCatalogTerm returns the catalog term an ingredient name is linked
+// by: of the runs of consecutive words in its normalized name that
+// isTerm accepts, the one with the most words, and of those the last, as
+// in "chicken stock". The ingredient_catalog_terms matcher in SQL follows
+// the same rules.
+func MatchCatalogTerm(name string, isTerm func(term string) bool) (string, bool) {
+	words := ingredientWords(name)
+	for size := len(words); size > 0; size-- {
+		for start := len(words) - size; start >= 0; start-- {
+			if phrase := strings.Join(words[start:start+size], " "); isTerm(phrase) {
+				return phrase, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCatalogIngredientTerms(t *testing.T) {
+	c := CatalogIngredient{Name: "Bay Leaf", Plural: "bay leaves", Synonyms: []string{"laurel leaves", "bay leafs", " "}}
+	got := strings.Join(c.Terms(), "|")
+	if want := "bay leaf|bay leave|laurel leave"; got != want {
+		t.Errorf("Terms() = %s, want %s", got, want)
+	}
+}
+
+func TestCatalogIngredientValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry CatalogIngredient
+		field string
+	}{
+		{"valid", CatalogIngredient{Name: "green onion", Synonyms: []string{"scallion"}}, ""},
+		{"blank name", CatalogIngredient{Name: " - "}, "name"},
+		{"long category", CatalogIngredient{Name: "salt", Category: strings.Repeat("a", MaxCatalogCategoryLength+1)}, "category"},
+		{"blank synonym", CatalogIngredient{Name: "salt", Synonyms: []string{""}}, "synonyms"},
+		{"too many synonyms", CatalogIngredient{Name: "salt", Synonyms: make([]string, MaxCatalogSynonyms+1)}, "synonyms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.entry.Validate()
+			var fieldErr *FieldError
+			switch {
+			case tt.field == "" && err != nil:
+				t.Errorf("Validate() error = %v, want nil", err)
+			case tt.field != "" && (!errors.As(err, &fieldErr) || fieldErr.Field != tt.field):
+				t.Errorf("Validate() error = %v, want a %s field error", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestMatchCatalogTerm(t *testing.T) {
+	terms := map[string]bool{"chicken": true, "chicken stock": true, "stock": true, "green onion": true, "onion": true}
+	isTerm := func(term string) bool { return terms[term] }
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Low-sodium chicken stock", "chicken stock"},
+		{"chicken, stock", "chicken stock"},
+		{"Green Onions, sliced", "green onion"},
+		{"onion and chicken", "chicken"},
+		{"salt", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := MatchCatalogTerm(tt.name, isTerm)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("MatchCatalogTerm(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"strings"
+)
+
+// Allergens are the allergens catalog ingredients can be tagged with.
+var Allergens = []string{"gluten", "dairy", "eggs", "nuts", "peanuts", "soy", "fish", "shellfish", "sesame"}
+
+// Diets are the diets catalog ingredients can be tagged as fitting, from
+// the strictest: whatever fits a diet also fits every diet after it, so
+// tagging an ingredient vegan makes it vegetarian and pescatarian too.
+var Diets = []string{"vegan", "vegetarian", "pescatarian"}
+
+// Dietary is what a recipe's ingredients say about the diets it fits and
+// the allergens it contains, going by the catalog entries they are linked
+// to. It is maintained by the store.
+type Dietary struct {
+	// Diets lists the diets every ingredient fits. It is empty unless
+	// every ingredient is linked to the catalog.
+	Diets []string `json:"diets"`
+	// Allergens lists the allergens of the linked ingredients.
+	Allergens []string `json:"allergens"`
+	// Complete reports whether the recipe has ingredients and every one is
+	// linked to a catalog entry whose allergens have been reviewed, so that
+	// an allergen missing from Allergens is known to be absent.
+	Complete bool `json:"complete"`
+}
+
+// ComputeDietary works out a recipe's dietary labels from its ingredients,
+// looking their catalog entries up with entry. The storage package's SQL
+// follows the same rules.
+func ComputeDietary(ingredients []Ingredient, entry func(id string) (*CatalogIngredient, bool)) Dietary {
+	dietary := Dietary{Diets: []string{}, Allergens: []string{}, Complete: len(ingredients) > 0}
+	linked := dietary.Complete
+	allergens := make(map[string]bool)
+	diets := make(map[string]int)
+	for _, ing := range ingredients {
+		c, ok := entry(ing.CatalogID)
+		if !ok {
+			linked, dietary.Complete = false, false
+			continue
+		}
+		if !c.AllergensReviewed {
+			dietary.Complete = false
+		}
+		for _, allergen := range c.Allergens {
+			allergens[allergen] = true
+		}
+		for _, diet := range c.Diets {
+			diets[diet]++
+		}
+	}
+	for _, allergen := range Allergens {
+		if allergens[allergen] {
+			dietary.Allergens = append(dietary.Allergens, allergen)
+		}
+	}
+	if linked {
+		for _, diet := range Diets {
+			if diets[diet] == len(ingredients) {
+				dietary.Diets = append(dietary.Diets, diet)
+			}
+		}
+	}
+	return dietary
+}
+
+// Fits reports whether the labels satisfy a filter's dietary criteria. A
+// recipe is only free of an allergen when its labels are complete.
+func (d Dietary) Fits(filter RecipeFilter) bool {
+	for _, diet := range filter.Diets {
+		if !containsLabel(d.Diets, diet) {
+			return false
+		}
+	}
+	for _, diet := range filter.ExcludeDiets {
+		if containsLabel(d.Diets, diet) {
+			return false
+		}
+	}
+	for _, allergen := range filter.Allergens {
+		if !containsLabel(d.Allergens, allergen) {
+			return false
+		}
+	}
+	if len(filter.ExcludeAllergens) > 0 && !d.Complete {
+		return false
+	}
+	for _, allergen := range filter.ExcludeAllergens {
+		if containsLabel(d.Allergens, allergen) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeDietLabels lowercases and trims labels and returns those in
+// known, once each and in known's order. Unknown labels are dropped; use
+// ValidateDietLabels to reject them.
+func NormalizeDietLabels(labels, known []string) []string {
+	given := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		given[strings.ToLower(strings.TrimSpace(label))] = true
+	}
+	normalized := []string{}
+	for _, label := range known {
+		if given[label] {
+			normalized = append(normalized, label)
+		}
+	}
+	return normalized
+}
+
+// ExpandDiets normalizes the diets an ingredient fits and adds the diets
+// they imply.
+func ExpandDiets(diets []string) []string {
+	diets = NormalizeDietLabels(diets, Diets)
+	if len(diets) == 0 {
+		return diets
+	}
+	for i, diet := range Diets {
+		if diet == diets[0] {
+			return append([]string{}, Diets[i:]...)
+		}
+	}
+	return diets
+}
+
+// ValidateDietLabels returns a *FieldError for field when a label, ignoring
+// case and surrounding space, is not in known.
+func ValidateDietLabels(field string, labels, known []string) error {
+	for _, label := range labels {
+		if label = strings.ToLower(strings.TrimSpace(label)); label != "" && !containsLabel(known, label) {
+			return &FieldError{Field: field, Message: field + " must be any of " + strings.Join(known, ", ")}
+		}
+	}
+	return nil
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
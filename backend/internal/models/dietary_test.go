@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestComputeDietary(t *testing.T) {
+	catalog := map[string]*CatalogIngredient{
+		"flour":  {Allergens: []string{"gluten"}, Diets: []string{"vegan", "vegetarian", "pescatarian"}, AllergensReviewed: true},
+		"butter": {Allergens: []string{"dairy"}, Diets: []string{"vegetarian", "pescatarian"}, AllergensReviewed: true},
+		"salmon": {Allergens: []string{"fish"}, Diets: []string{"pescatarian"}, AllergensReviewed: true},
+		"water":  {Diets: []string{"vegan", "vegetarian", "pescatarian"}},
+	}
+	entry := func(id string) (*CatalogIngredient, bool) {
+		c, ok := catalog[id]
+		return c, ok
+	}
+
+	tests := []struct {
+		name  string
+		links []string
+		want  Dietary
+	}{
+		{"no ingredients", nil, Dietary{Diets: []string{}, Allergens: []string{}}},
+		{"vegan", []string{"flour"}, Dietary{Diets: []string{"vegan", "vegetarian", "pescatarian"}, Allergens: []string{"gluten"}, Complete: true}},
+		{"strictest shared diet", []string{"butter", "flour"}, Dietary{Diets: []string{"vegetarian", "pescatarian"}, Allergens: []string{"gluten", "dairy"}, Complete: true}},
+		{"unlinked ingredient", []string{"flour", ""}, Dietary{Diets: []string{}, Allergens: []string{"gluten"}}},
+		{"unreviewed allergens", []string{"flour", "water"}, Dietary{Diets: []string{"vegan", "vegetarian", "pescatarian"}, Allergens: []string{"gluten"}}},
+		{"fish", []string{"salmon", "butter"}, Dietary{Diets: []string{"pescatarian"}, Allergens: []string{"dairy", "fish"}, Complete: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ingredients []Ingredient
+			for _, id := range tt.links {
+				ingredients = append(ingredients, Ingredient{Name: id, CatalogID: id})
+			}
+			if got := ComputeDietary(ingredients, entry); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ComputeDietary() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDietaryFits(t *testing.T) {
+	bread := Dietary{Diets: []string{"vegan", "vegetarian", "pescatarian"}, Allergens: []string{"gluten"}, Complete: true}
+	partial := Dietary{Diets: []string{}, Allergens: []string{"gluten"}}
+
+	tests := []struct {
+		name    string
+		dietary Dietary
+		filter  RecipeFilter
+		want    bool
+	}{
+		{"no criteria", partial, RecipeFilter{}, true},
+		{"diet", bread, RecipeFilter{Diets: []string{"vegetarian"}}, true},
+		{"missing diet", partial, RecipeFilter{Diets: []string{"vegan"}}, false},
+		{"excluded diet", bread, RecipeFilter{ExcludeDiets: []string{"vegan"}}, false},
+		{"allergen", partial, RecipeFilter{Allergens: []string{"gluten"}}, true},
+		{"free of allergen", bread, RecipeFilter{ExcludeAllergens: []string{"nuts"}}, true},
+		{"contains allergen", bread, RecipeFilter{ExcludeAllergens: []string{"nuts", "gluten"}}, false},
+		{"unknown ingredients", partial, RecipeFilter{ExcludeAllergens: []string{"nuts"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dietary.Fits(tt.filter); got != tt.want {
+			t.Errorf("%s: Fits() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDietLabels(t *testing.T) {
+	if got := NormalizeDietLabels([]string{" Dairy", "gluten", "celery", "dairy"}, Allergens); !reflect.DeepEqual(got, []string{"gluten", "dairy"}) {
+		t.Errorf("NormalizeDietLabels() = %v, want known labels in canonical order", got)
+	}
+	if got := ExpandDiets([]string{"pescatarian", "Vegetarian"}); !reflect.DeepEqual(got, []string{"vegetarian", "pescatarian"}) {
+		t.Errorf("ExpandDiets() = %v, want vegetarian and what it implies", got)
+	}
+
+	var fieldErr *FieldError
+	if err := ValidateDietLabels("diets", []string{"vegan", "paleo"}, Diets); !errors.As(err, &fieldErr) || fieldErr.Field != "diets" {
+		t.Errorf("ValidateDietLabels() error = %v, want a diets field error", err)
+	}
+	if err := ValidateDietLabels("diets", []string{" Vegan ", ""}, Diets); err != nil {
+		t.Errorf("ValidateDietLabels() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Meal slots, in the order a day's meals are listed.
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+// MealSlots lists every meal slot in order.
+var MealSlots = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// DateLayout is how meal plan dates are written.
+const DateLayout = "2006-01-02"
+
+// MaxMealPlanDays bounds the days a single meal plan read, copy or clear
+// can span.
+const MaxMealPlanDays = 366
+
+// MealPlanEntry is a recipe planned for one meal slot of a day. Servings
+// overrides the recipe's own servings when set.
+type MealPlanEntry struct {
+	ID       string `json:"id" db:"id"`
+	UserID   string `json:"user_id" db:"user_id"`
+	Date     string `json:"date" db:"plan_date"`
+	Slot     string `json:"slot" db:"slot"`
+	RecipeID string `json:"recipe_id" db:"recipe_id"`
+	Servings int    `json:"servings" db:"servings"`
+	// RecipeTitle is filled in on reads.
+	RecipeTitle string    `json:"recipe_title,omitempty"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate returns a *FieldError naming the first invalid field.
+func (e *MealPlanEntry) Validate() error {
+	if _, err := ParseDate(e.Date); err != nil {
+		return &FieldError{Field: "date", Message: err.Error()}
+	}
+	if MealSlotIndex(e.Slot) < 0 {
+		return &FieldError{Field: "slot", Message: "slot must be breakfast, lunch, dinner, or snack"}
+	}
+	if e.RecipeID == "" {
+		return &FieldError{Field: "recipe_id", Message: "recipe_id is required"}
+	}
+	if e.Servings < 0 || e.Servings > MaxScaledServings {
+		return &FieldError{Field: "servings", Message: fmt.Sprintf("servings must be between 0 and %d", MaxScaledServings)}
+	}
+	return nil
+}
+
+// MealSlotIndex returns the slot's place in MealSlots, or -1 for an unknown
+// slot.
+func MealSlotIndex(slot string) int {
+	for i, s := range MealSlots {
+		if s == slot {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseDate reads a date written as DateLayout.
+func ParseDate(s string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date must be written as YYYY-MM-DD")
+	}
+	return t, nil
+}
+
+// WeekStart returns the Monday of the week holding t.
+func WeekStart(t time.Time) time.Time {
+	weekday := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -weekday)
+}
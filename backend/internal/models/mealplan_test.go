@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMealPlanEntry_Validation(t *testing.T) {
+	valid := func() MealPlanEntry {
+		return MealPlanEntry{Date: "2024-03-06", Slot: MealDinner, RecipeID: "recipe", Servings: 4}
+	}
+
+	tests := []struct {
+		name      string
+		change    func(e *MealPlanEntry)
+		wantField string
+	}{
+		{"valid", func(e *MealPlanEntry) {}, ""},
+		{"no servings override", func(e *MealPlanEntry) { e.Servings = 0 }, ""},
+		{"missing date", func(e *MealPlanEntry) { e.Date = "" }, "date"},
+		{"impossible date", func(e *MealPlanEntry) { e.Date = "2024-02-30" }, "date"},
+		{"unknown slot", func(e *MealPlanEntry) { e.Slot = "brunch" }, "slot"},
+		{"missing recipe", func(e *MealPlanEntry) { e.RecipeID = "" }, "recipe_id"},
+		{"negative servings", func(e *MealPlanEntry) { e.Servings = -1 }, "servings"},
+		{"too many servings", func(e *MealPlanEntry) { e.Servings = MaxScaledServings + 1 }, "servings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := valid()
+			tt.change(&entry)
+			err := entry.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+				t.Errorf("Validate() error = %v, want a FieldError for %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 7; day++ {
+		date := monday.AddDate(0, 0, day)
+		if got := WeekStart(date); !got.Equal(monday) {
+			t.Errorf("WeekStart(%s) = %s, want %s", date.Format(DateLayout), got.Format(DateLayout), monday.Format(DateLayout))
+		}
+	}
+	if got := WeekStart(monday.AddDate(0, 0, 7)); !got.Equal(monday.AddDate(0, 0, 7)) {
+		t.Errorf("WeekStart(next Monday) = %s", got.Format(DateLayout))
+	}
+}
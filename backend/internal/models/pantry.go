@@ -0,0 +1,98 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PantryItem is an ingredient a user has on hand. Pantries are keyed by
+// normalized ingredient name, so "Eggs" and "egg" are the same item.
+type PantryItem struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MaxPantryItemLength bounds a pantry item's normalized name.
+const MaxPantryItemLength = 255
+
+// PantryMatch is how much of a recipe a user's pantry covers.
+type PantryMatch struct {
+	Covered int `json:"covered"`
+	Total   int `json:"total"`
+	// Missing names the ingredients not on hand, in recipe order.
+	Missing []string `json:"missing"`
+}
+
+// NormalizeIngredientName lowercases a name, reduces punctuation to single
+// spaces and puts each word in the singular. It is the key pantry items
+// are stored under; the ingredient_name_phrases SQL function mirrors it.
+func NormalizeIngredientName(name string) string {
+	return strings.Join(ingredientWords(name), " ")
+}
+
+// IngredientPhrases returns every run of consecutive words in an
+// ingredient's normalized name. A pantry item covers an ingredient when
+// its name is one of them, so "olive oil" covers "extra virgin olive oil"
+// but "salt" does not cover "salted butter".
+func IngredientPhrases(name string) []string {
+	words := ingredientWords(name)
+	var phrases []string
+	for i := range words {
+		for j := i + 1; j <= len(words); j++ {
+			phrases = append(phrases, strings.Join(words[i:j], " "))
+		}
+	}
+	return phrases
+}
+
+func ingredientWords(name string) []string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = singular(word)
+	}
+	return words
+}
+
+// singular guesses the singular of an English word, which only has to be
+// consistent for the plurals ingredients are written with.
+func singular(word string) string {
+	n := utf8.RuneCountInString(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case n > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// MatchPantry reports which of a recipe's ingredients are covered by the
+// normalized names in pantry.
+func MatchPantry(ingredients []Ingredient, pantry map[string]bool) PantryMatch {
+	match := PantryMatch{Total: len(ingredients), Missing: []string{}}
+	for _, ing := range ingredients {
+		if pantryCovers(pantry, ing.Name) {
+			match.Covered++
+		} else {
+			match.Missing = append(match.Missing, ing.Name)
+		}
+	}
+	return match
+}
+
+func pantryCovers(pantry map[string]bool, name string) bool {
+	for _, phrase := range IngredientPhrases(name) {
+		if pantry[phrase] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeIngredientName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Eggs", "egg"},
+		{"Cherry  Tomatoes", "cherry tomato"},
+		{"berries", "berry"},
+		{"peaches", "peach"},
+		{"extra-virgin olive oil", "extra virgin olive oil"},
+		{"grass", "grass"},
+		{"Jalapeños", "jalapeño"},
+		{" ! ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeIngredientName(tt.name); got != tt.want {
+			t.Errorf("NormalizeIngredientName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIngredientPhrases(t *testing.T) {
+	got := strings.Join(IngredientPhrases("Olive Oils, extra"), "|")
+	want := "olive|olive oil|olive oil extra|oil|oil extra|extra"
+	if got != want {
+		t.Errorf("IngredientPhrases() = %s, want %s", got, want)
+	}
+}
+
+func TestMatchPantry(t *testing.T) {
+	pantry := map[string]bool{"egg": true, "olive oil": true, "salt": true}
+	ingredients := []Ingredient{
+		{Name: "Eggs"},
+		{Name: "extra virgin olive oil"},
+		{Name: "salted butter"},
+		{Name: "oil"},
+	}
+
+	match := MatchPantry(ingredients, pantry)
+	if match.Covered != 2 || match.Total != 4 || strings.Join(match.Missing, ", ") != "salted butter, oil" {
+		t.Errorf("MatchPantry() = %+v", match)
+	}
+}
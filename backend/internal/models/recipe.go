@@ -2,7 +2,13 @@ package models
 
 import (
 	"fmt"
+	"html/template"
+	"path"
+	"strings"
 	"time"
+
+	"recipe-app/internal/quantity"
+	"recipe-app/internal/units"
 )
 
 type Recipe struct {
@@ -19,8 +25,21 @@ type Recipe struct {
 	Instructions []Instruction `json:"instructions"`
 	Tags         []string      `json:"tags"`
 	ImageURL     string        `json:"image_url" db:"image_url"`
-	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at" db:"updated_at"`
+	AuthorID     string        `json:"author_id,omitempty" db:"author_id"`
+	// RatingAverage and RatingCount summarize the recipe's ratings. They
+	// are maintained by the store and ignored on write.
+	RatingAverage float64 `json:"rating_average" db:"rating_average"`
+	RatingCount   int     `json:"rating_count" db:"rating_count"`
+	// Nutrition is per serving and maintained by the store.
+	Nutrition *NutritionInfo `json:"nutrition,omitempty"`
+	// Dietary is computed from the ingredients' catalog links and
+	// maintained by the store.
+	Dietary Dietary `json:"dietary"`
+	// ScaledFrom is the stored number of servings when the ingredients
+	// have been scaled to Servings for display. It is never stored.
+	ScaledFrom int       `json:"scaled_from,omitempty"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type Ingredient struct {
@@ -31,6 +50,12 @@ type Ingredient struct {
 	Unit     string `json:"unit" db:"unit"`
 	Notes    string `json:"notes" db:"notes"`
 	Position int    `json:"position" db:"position"`
+	// CatalogID links the ingredient to its canonical catalog entry, and
+	// CatalogMatch says whether the matcher or an admin made the link. The
+	// store maintains them; they are ignored on write.
+	CatalogID    string `json:"catalog_id,omitempty" db:"catalog_id"`
+	CatalogName  string `json:"catalog_name,omitempty" db:"-"`
+	CatalogMatch string `json:"catalog_match,omitempty" db:"catalog_match"`
 }
 
 type Instruction struct {
@@ -39,25 +64,102 @@ type Instruction struct {
 	Text        string `json:"text" db:"text"`
 	Position    int    `json:"position" db:"position"`
 	Duration    int    `json:"duration" db:"duration"`       // optional time in minutes
-	Temperature int    `json:"temperature" db:"temperature"` // optional temperature in F/C
+	Temperature int    `json:"temperature" db:"temperature"` // optional
+	// TemperatureUnit is units.Celsius or units.Fahrenheit, and required
+	// with a temperature.
+	TemperatureUnit string `json:"temperature_unit,omitempty" db:"temperature_unit"`
+	// Images are photos of the step, uploaded like recipe images.
+	Images []string `json:"images,omitempty" db:"image_urls"`
 }
 
+// MaxInstructionImages bounds the photos attached to one step.
+const MaxInstructionImages = 10
+
 type RecipeFilter struct {
-	Category    string   `json:"category"`
-	Cuisine     string   `json:"cuisine"`
-	Difficulty  string   `json:"difficulty"`
-	Tags        []string `json:"tags"`
-	MaxPrepTime int      `json:"max_prep_time"`
-	MaxCookTime int      `json:"max_cook_time"`
-	MinServings int      `json:"min_servings"`
-	MaxServings int      `json:"max_servings"`
+	Category   string   `json:"category"`
+	Cuisine    string   `json:"cuisine"`
+	Difficulty string   `json:"difficulty"`
+	Tags       []string `json:"tags"`
+	// TagMatch is TagMatchAll (the default) to require every tag or
+	// TagMatchAny to require at least one.
+	TagMatch    string `json:"tag_match"`
+	MaxPrepTime int    `json:"max_prep_time"`
+	MaxCookTime int    `json:"max_cook_time"`
+	MinServings int    `json:"min_servings"`
+	MaxServings int    `json:"max_servings"`
+	// AuthorID limits results to one user's recipes.
+	AuthorID string `json:"author_id"`
+	// PantryUserID limits results to recipes using at least one ingredient
+	// in that user's pantry, and MaxMissing further to those lacking at
+	// most that many ingredients.
+	PantryUserID string `json:"pantry_user_id"`
+	MaxMissing   *int   `json:"max_missing"`
+	// Diets and Allergens require recipes to fit every diet and contain
+	// every allergen listed, and ExcludeDiets and ExcludeAllergens to fit
+	// none and be known to contain none. Labels are from Diets and
+	// Allergens.
+	Diets            []string `json:"diets"`
+	ExcludeDiets     []string `json:"exclude_diets"`
+	Allergens        []string `json:"allergens"`
+	ExcludeAllergens []string `json:"exclude_allergens"`
+}
+
+const (
+	TagMatchAll = "all"
+	TagMatchAny = "any"
+)
+
+// FieldError reports an invalid value for a single named field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
 }
 
 type SearchResult struct {
 	Recipes []Recipe `json:"recipes"`
 	Total   int      `json:"total"`
-	Page    int      `json:"page"`
-	PerPage int      `json:"per_page"`
+	// Page is 0 for pages read with a cursor.
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	// NextCursor and PrevCursor are opaque positions of the adjacent pages,
+	// empty when there is none.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Highlights is keyed by recipe ID and only set for text searches.
+	Highlights map[string]SearchHighlight `json:"highlights,omitempty"`
+	// Pantry is keyed by recipe ID and only set for pantry searches.
+	Pantry map[string]PantryMatch `json:"pantry,omitempty"`
+	// Facets is only set when requested.
+	Facets *SearchFacets `json:"facets,omitempty"`
+}
+
+// FacetCount is the number of matching recipes for one facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchFacets counts matching recipes per filter value. Each facet is
+// counted under every active filter except its own, so that it lists the
+// alternatives to the current choice. CookTime buckets count recipes that
+// cook in at most Value minutes.
+type SearchFacets struct {
+	Category   []FacetCount `json:"category"`
+	Cuisine    []FacetCount `json:"cuisine"`
+	Difficulty []FacetCount `json:"difficulty"`
+	Tags       []FacetCount `json:"tags"`
+	CookTime   []FacetCount `json:"cook_time"`
+}
+
+// SearchHighlight holds escaped HTML for a search hit with the matched
+// words wrapped in <mark>, safe to render as is.
+type SearchHighlight struct {
+	Title   template.HTML `json:"title"`
+	Snippet template.HTML `json:"snippet"`
 }
 
 func (r *Recipe) Validate() error {
@@ -79,24 +181,52 @@ func (r *Recipe) Validate() error {
 	return nil
 }
 
+// CanBeModifiedBy reports whether the given user may update or delete the
+// recipe. Admins may change any recipe; recipes without an author can only
+// be changed by admins.
+func (r *Recipe) CanBeModifiedBy(userID string, isAdmin bool) bool {
+	if isAdmin {
+		return true
+	}
+	return r.AuthorID != "" && r.AuthorID == userID
+}
+
+// Validate returns a *FieldError naming the first invalid field.
 func (rf *RecipeFilter) Validate() error {
 	if rf.Difficulty != "" && rf.Difficulty != "easy" && rf.Difficulty != "medium" && rf.Difficulty != "hard" {
-		return fmt.Errorf("difficulty must be easy, medium, or hard")
+		return &FieldError{Field: "difficulty", Message: "difficulty must be easy, medium, or hard"}
+	}
+	if rf.TagMatch != "" && rf.TagMatch != TagMatchAll && rf.TagMatch != TagMatchAny {
+		return &FieldError{Field: "tag_match", Message: "tag match must be all or any"}
 	}
 	if rf.MaxPrepTime < 0 {
-		return fmt.Errorf("max prep time cannot be negative")
+		return &FieldError{Field: "max_prep_time", Message: "max prep time cannot be negative"}
 	}
 	if rf.MaxCookTime < 0 {
-		return fmt.Errorf("max cook time cannot be negative")
+		return &FieldError{Field: "max_cook_time", Message: "max cook time cannot be negative"}
 	}
 	if rf.MinServings < 0 {
-		return fmt.Errorf("min servings cannot be negative")
+		return &FieldError{Field: "min_servings", Message: "min servings cannot be negative"}
 	}
 	if rf.MaxServings < 0 {
-		return fmt.Errorf("max servings cannot be negative")
+		return &FieldError{Field: "max_servings", Message: "max servings cannot be negative"}
 	}
 	if rf.MinServings > 0 && rf.MaxServings > 0 && rf.MinServings > rf.MaxServings {
-		return fmt.Errorf("min servings cannot be greater than max servings")
+		return &FieldError{Field: "min_servings", Message: "min servings cannot be greater than max servings"}
+	}
+	if rf.MaxMissing != nil && *rf.MaxMissing < 0 {
+		return &FieldError{Field: "max_missing", Message: "max missing cannot be negative"}
+	}
+	for _, labels := range []struct {
+		field         string
+		values, known []string
+	}{
+		{"diet", rf.Diets, Diets}, {"exclude_diet", rf.ExcludeDiets, Diets},
+		{"allergen", rf.Allergens, Allergens}, {"exclude_allergen", rf.ExcludeAllergens, Allergens},
+	} {
+		if err := ValidateDietLabels(labels.field, labels.values, labels.known); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -124,6 +254,20 @@ func (i *Instruction) Validate() error {
 	if i.Temperature < 0 {
 		return fmt.Errorf("instruction temperature cannot be negative")
 	}
+	if i.Temperature > 0 && !units.ValidTemperatureScale(i.TemperatureUnit) {
+		return fmt.Errorf("instruction temperature unit must be C or F")
+	}
+	if i.Temperature == 0 && i.TemperatureUnit != "" {
+		return fmt.Errorf("instruction temperature unit requires a temperature")
+	}
+	if len(i.Images) > MaxInstructionImages {
+		return fmt.Errorf("instruction cannot have more than %d images", MaxInstructionImages)
+	}
+	for _, image := range i.Images {
+		if strings.TrimSpace(image) == "" {
+			return fmt.Errorf("instruction image URL cannot be empty")
+		}
+	}
 	return nil
 }
 
@@ -133,3 +277,114 @@ func (sr *SearchResult) TotalPages() int {
 	}
 	return (sr.Total + sr.PerPage - 1) / sr.PerPage
 }
+
+// MediaURLPrefix is the path uploaded images are served under.
+const MediaURLPrefix = "/media/"
+
+// ImageVariantURL returns the URL of a resized variant of an image, such
+// as "card" or "detail". Uploaded images keep their variants next to the
+// original; any other image URL is returned as it is.
+func ImageVariantURL(imageURL, variant string) string {
+	dir, file := path.Split(imageURL)
+	if !strings.HasPrefix(dir, MediaURLPrefix) || !strings.HasPrefix(file, "original.") {
+		return imageURL
+	}
+	return dir + variant + ".jpg"
+}
+
+// ImageVariantURL returns the URL of a resized variant of the recipe's
+// image.
+func (r *Recipe) ImageVariantURL(variant string) string {
+	return ImageVariantURL(r.ImageURL, variant)
+}
+
+// ImageVariantURLs returns the URLs of a resized variant of each of the
+// step's images.
+func (i *Instruction) ImageVariantURLs(variant string) []string {
+	urls := make([]string, len(i.Images))
+	for n, image := range i.Images {
+		urls[n] = ImageVariantURL(image, variant)
+	}
+	return urls
+}
+
+// ImageURLs returns the URLs of the recipe's image and of every step's
+// images.
+func (r *Recipe) ImageURLs() []string {
+	var urls []string
+	if r.ImageURL != "" {
+		urls = append(urls, r.ImageURL)
+	}
+	for _, inst := range r.Instructions {
+		urls = append(urls, inst.Images...)
+	}
+	return urls
+}
+
+// MaxScaledServings bounds the servings a recipe can be scaled to.
+const MaxScaledServings = 100
+
+// ScaleTo returns a copy of the recipe with its ingredient amounts scaled
+// from its servings to the given servings and rounded to kitchen-friendly
+// fractions. Recipes without servings count as one serving, and amounts
+// that are not numbers, such as "to taste", are kept as written.
+func (r *Recipe) ScaleTo(servings int) *Recipe {
+	base := r.Servings
+	if base < 1 {
+		base = 1
+	}
+	scaled := *r
+	scaled.Servings = servings
+	scaled.ScaledFrom = base
+	scaled.Ingredients = make([]Ingredient, len(r.Ingredients))
+	for i, ing := range r.Ingredients {
+		if q, err := quantity.Parse(ing.Amount); err == nil {
+			q = q.Scale(float64(servings) / float64(base))
+			ing.Amount = q.String()
+			ing.Unit = units.Canonical(ing.Unit, q.Max)
+		}
+		scaled.Ingredients[i] = ing
+	}
+	return &scaled
+}
+
+// NormalizeUnits rewrites known ingredient units in their canonical
+// spelling, such as "tbsp" for "Tablespoons", in the plural where the
+// amount calls for one.
+func (r *Recipe) NormalizeUnits() {
+	for i := range r.Ingredients {
+		ing := &r.Ingredients[i]
+		amount := 1.0
+		if q, err := quantity.Parse(ing.Amount); err == nil {
+			amount = q.Max
+		}
+		ing.Unit = units.Canonical(ing.Unit, amount)
+	}
+}
+
+// ConvertUnits returns a copy of the recipe with its measured ingredients
+// and temperatures stated in system, units.Metric or units.Imperial.
+// density returns an ingredient's grams per millilitre, or 0 when it is
+// not known, so that ingredients can be converted between volume and
+// weight. Ingredients without a known unit or a numeric amount are kept as
+// written.
+func (r *Recipe) ConvertUnits(system string, density func(Ingredient) float64) *Recipe {
+	converted := *r
+	converted.Ingredients = make([]Ingredient, len(r.Ingredients))
+	for i, ing := range r.Ingredients {
+		if amount, unit, ok := units.ConvertAmount(ing.Amount, ing.Unit, system, density(ing)); ok {
+			ing.Amount, ing.Unit = amount, unit
+		}
+		converted.Ingredients[i] = ing
+	}
+	converted.Instructions = make([]Instruction, len(r.Instructions))
+	scale := units.TemperatureScale(system)
+	for i, inst := range r.Instructions {
+		if inst.Temperature > 0 && units.ValidTemperatureScale(inst.TemperatureUnit) {
+			inst.Temperature = units.ConvertTemperature(inst.Temperature, inst.TemperatureUnit, scale)
+			inst.TemperatureUnit = scale
+		}
+		converted.Instructions[i] = inst
+	}
+	return &converted
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -83,6 +84,31 @@ func TestRecipe_Validation(t *testing.T) {
 	}
 }
 
+func TestRecipe_CanBeModifiedBy(t *testing.T) {
+	tests := []struct {
+		name     string
+		authorID string
+		userID   string
+		isAdmin  bool
+		expected bool
+	}{
+		{"Author", "user-1", "user-1", false, true},
+		{"Other user", "user-1", "user-2", false, false},
+		{"Admin", "user-1", "user-2", true, true},
+		{"No author", "", "", false, false},
+		{"No author as admin", "", "user-2", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe := Recipe{Title: "Soup", AuthorID: tt.authorID}
+			if got := recipe.CanBeModifiedBy(tt.userID, tt.isAdmin); got != tt.expected {
+				t.Errorf("CanBeModifiedBy(%q, %v) = %v, want %v", tt.userID, tt.isAdmin, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestRecipeFilter_Validation(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -200,15 +226,40 @@ func TestInstruction_Validation(t *testing.T) {
 		{
 			name: "Valid instruction",
 			instruction: Instruction{
-				ID:          "1",
-				RecipeID:    "1",
-				Text:        "Mix ingredients",
-				Position:    1,
-				Duration:    5,
-				Temperature: 350,
+				ID:              "1",
+				RecipeID:        "1",
+				Text:            "Mix ingredients",
+				Position:        1,
+				Duration:        5,
+				Temperature:     350,
+				TemperatureUnit: "F",
 			},
 			expectError: false,
 		},
+		{
+			name: "With images",
+			instruction: Instruction{
+				Text:   "Fold the dough",
+				Images: []string{"/media/recipes/1/a/original.jpg", "/media/recipes/1/b/original.jpg"},
+			},
+			expectError: false,
+		},
+		{
+			name: "Empty image URL",
+			instruction: Instruction{
+				Text:   "Fold the dough",
+				Images: []string{" "},
+			},
+			expectError: true,
+		},
+		{
+			name: "Too many images",
+			instruction: Instruction{
+				Text:   "Fold the dough",
+				Images: make([]string, MaxInstructionImages+1),
+			},
+			expectError: true,
+		},
 		{
 			name: "Empty text",
 			instruction: Instruction{
@@ -251,6 +302,31 @@ func TestInstruction_Validation(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Temperature without unit",
+			instruction: Instruction{
+				Text:        "Bake",
+				Temperature: 180,
+			},
+			expectError: true,
+		},
+		{
+			name: "Unknown temperature unit",
+			instruction: Instruction{
+				Text:            "Bake",
+				Temperature:     180,
+				TemperatureUnit: "K",
+			},
+			expectError: true,
+		},
+		{
+			name: "Unit without temperature",
+			instruction: Instruction{
+				Text:            "Rest",
+				TemperatureUnit: "C",
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -310,3 +386,134 @@ func TestSearchResult_Pagination(t *testing.T) {
 		})
 	}
 }
+
+func TestRecipe_ScaleTo(t *testing.T) {
+	recipe := Recipe{
+		Servings: 2,
+		Ingredients: []Ingredient{
+			{Name: "flour", Amount: "1 1/2", Unit: "cups"},
+			{Name: "eggs", Amount: "2-3"},
+			{Name: "salt", Amount: "to taste"},
+		},
+	}
+
+	scaled := recipe.ScaleTo(3)
+	if scaled.Servings != 3 || scaled.ScaledFrom != 2 {
+		t.Errorf("Expected 3 servings scaled from 2, got %d from %d", scaled.Servings, scaled.ScaledFrom)
+	}
+	for i, want := range []string{"2 1/4", "3-4 1/2", "to taste"} {
+		if got := scaled.Ingredients[i].Amount; got != want {
+			t.Errorf("Expected %s amount %q, got %q", scaled.Ingredients[i].Name, want, got)
+		}
+	}
+	if recipe.Ingredients[0].Amount != "1 1/2" || recipe.Servings != 2 {
+		t.Errorf("Expected the original recipe to be unchanged, got %+v", recipe)
+	}
+
+	recipe.Servings = 0
+	if got := recipe.ScaleTo(2).Ingredients[0].Amount; got != "3" {
+		t.Errorf("Expected a recipe without servings to scale from one serving, got %q", got)
+	}
+}
+
+func TestRecipe_NormalizeUnits(t *testing.T) {
+	recipe := Recipe{Ingredients: []Ingredient{
+		{Name: "oil", Amount: "2", Unit: "Tablespoons"},
+		{Name: "salt", Amount: "1", Unit: "t"},
+		{Name: "flour", Amount: "1 1/2", Unit: "Cup"},
+		{Name: "milk", Amount: "1", Unit: "cups"},
+		{Name: "garlic", Amount: "2", Unit: " cloves "},
+	}}
+	recipe.NormalizeUnits()
+	for i, want := range []string{"tbsp", "tsp", "cups", "cup", "cloves"} {
+		if got := recipe.Ingredients[i].Unit; got != want {
+			t.Errorf("Expected %s unit %q, got %q", recipe.Ingredients[i].Name, want, got)
+		}
+	}
+}
+
+func TestRecipe_ConvertUnits(t *testing.T) {
+	recipe := Recipe{
+		Ingredients: []Ingredient{
+			{Name: "beef", Amount: "1-3", Unit: "lb"},
+			{Name: "milk", Amount: "2", Unit: "cups"},
+			{Name: "oil", Amount: "2", Unit: "tbsp"},
+			{Name: "eggs", Amount: "3"},
+			{Name: "salt", Amount: "to taste", Unit: "g"},
+			{Name: "flour", Amount: "2", Unit: "cups"},
+		},
+		Instructions: []Instruction{
+			{Text: "Bake", Temperature: 350, TemperatureUnit: "F"},
+			{Text: "Rest"},
+		},
+	}
+	density := func(ing Ingredient) float64 {
+		if ing.Name == "flour" {
+			return 0.53
+		}
+		return 0
+	}
+
+	metric := recipe.ConvertUnits("metric", density)
+	for i, want := range []string{"0.45-1.4 kg", "475 ml", "2 tbsp", "3 ", "to taste g", "250 g"} {
+		if got := metric.Ingredients[i].Amount + " " + metric.Ingredients[i].Unit; got != want {
+			t.Errorf("Expected metric %s %q, got %q", metric.Ingredients[i].Name, want, got)
+		}
+	}
+	if got := metric.Instructions[0]; got.Temperature != 175 || got.TemperatureUnit != "C" {
+		t.Errorf("Expected 175°C, got %d°%s", got.Temperature, got.TemperatureUnit)
+	}
+	if got := metric.Instructions[1]; got.Temperature != 0 || got.TemperatureUnit != "" {
+		t.Errorf("Expected no temperature, got %d°%s", got.Temperature, got.TemperatureUnit)
+	}
+	if recipe.Ingredients[0].Unit != "lb" || recipe.Instructions[0].Temperature != 350 {
+		t.Errorf("Expected the original recipe to be unchanged, got %+v", recipe)
+	}
+
+	imperial := metric.ConvertUnits("imperial", density)
+	if got := imperial.Ingredients[1].Amount + " " + imperial.Ingredients[1].Unit; got != "2 cups" {
+		t.Errorf("Expected imperial milk 2 cups, got %q", got)
+	}
+	if got := imperial.Ingredients[5].Amount + " " + imperial.Ingredients[5].Unit; got != "2 cups" {
+		t.Errorf("Expected imperial flour 2 cups, got %q", got)
+	}
+	if got := imperial.Instructions[0]; got.Temperature != 345 || got.TemperatureUnit != "F" {
+		t.Errorf("Expected 345°F, got %d°%s", got.Temperature, got.TemperatureUnit)
+	}
+}
+
+func TestRecipe_ImageVariantURL(t *testing.T) {
+	tests := []struct {
+		imageURL string
+		want     string
+	}{
+		{"/media/recipes/r1/i1/original.png", "/media/recipes/r1/i1/card.jpg"},
+		{"/media/recipes/r1/i1/original.jpg", "/media/recipes/r1/i1/card.jpg"},
+		{"https://example.com/original.jpg", "https://example.com/original.jpg"},
+		{"/static/pasta.jpg", "/static/pasta.jpg"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		recipe := Recipe{ImageURL: tt.imageURL}
+		if got := recipe.ImageVariantURL("card"); got != tt.want {
+			t.Errorf("ImageVariantURL(%q) = %q, want %q", tt.imageURL, got, tt.want)
+		}
+	}
+}
+
+func TestRecipe_ImageURLs(t *testing.T) {
+	recipe := Recipe{
+		ImageURL: "/media/a.jpg",
+		Instructions: []Instruction{
+			{Images: []string{"/media/b.jpg", "/media/c.jpg"}},
+			{},
+			{Images: []string{"/media/d.jpg"}},
+		},
+	}
+	if got := strings.Join(recipe.ImageURLs(), ","); got != "/media/a.jpg,/media/b.jpg,/media/c.jpg,/media/d.jpg" {
+		t.Errorf("ImageURLs() = %s", got)
+	}
+	if got := (&Recipe{}).ImageURLs(); len(got) != 0 {
+		t.Errorf("ImageURLs() of a recipe without images = %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ShoppingList is a user's saved list of ingredients to buy, grouped by
+// aisle.
+type ShoppingList struct {
+	ID        string             `json:"id" db:"id"`
+	UserID    string             `json:"user_id" db:"user_id"`
+	Name      string             `json:"name" db:"name"`
+	Items     []ShoppingListItem `json:"items"`
+	CreatedAt time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// ShoppingListItem is one ingredient to buy, with the amounts every recipe
+// calls for added together.
+type ShoppingListItem struct {
+	ID       string `json:"id" db:"id"`
+	ListID   string `json:"list_id" db:"list_id"`
+	Name     string `json:"name" db:"name"`
+	Amount   string `json:"amount" db:"amount"`
+	Unit     string `json:"unit" db:"unit"`
+	Aisle    string `json:"aisle" db:"aisle"`
+	Checked  bool   `json:"checked" db:"checked"`
+	Position int    `json:"position" db:"position"`
+}
+
+// Validate returns a *FieldError naming the first invalid field.
+func (l *ShoppingList) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return &FieldError{Field: "name", Message: "shopping list name is required"}
+	}
+	if len(l.Name) > 255 {
+		return &FieldError{Field: "name", Message: "shopping list name must be at most 255 characters"}
+	}
+	return nil
+}
+
+// Line writes the item as it is read out, such as "1 1/2 cups flour".
+func (i *ShoppingListItem) Line() string {
+	return strings.Join(strings.Fields(i.Amount+" "+i.Unit+" "+i.Name), " ")
+}
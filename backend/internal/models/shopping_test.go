@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+func TestShoppingListItem_Line(t *testing.T) {
+	tests := []struct {
+		item ShoppingListItem
+		want string
+	}{
+		{ShoppingListItem{Name: "flour", Amount: "1 1/2", Unit: "cups"}, "1 1/2 cups flour"},
+		{ShoppingListItem{Name: "onions", Amount: "2"}, "2 onions"},
+		{ShoppingListItem{Name: "salt", Amount: "to taste"}, "to taste salt"},
+		{ShoppingListItem{Name: " kitchen  roll "}, "kitchen roll"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.item.Line(); got != tt.want {
+			t.Errorf("Line(%+v) = %q, want %q", tt.item, got, tt.want)
+		}
+	}
+}
@@ -1,21 +1,54 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
 type User struct {
-	ID        string    `json:"id" db:"id"`
-	Email     string    `json:"email" db:"email"`
-	Username  string    `json:"username" db:"username"`
-	FirstName string    `json:"first_name" db:"first_name"`
-	LastName  string    `json:"last_name" db:"last_name"`
-	Password  string    `json:"-" db:"password_hash"`
-	AvatarURL string    `json:"avatar_url" db:"avatar_url"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        string `json:"id" db:"id"`
+	Email     string `json:"email" db:"email"`
+	Username  string `json:"username" db:"username"`
+	FirstName string `json:"first_name" db:"first_name"`
+	LastName  string `json:"last_name" db:"last_name"`
+	Password  string `json:"-" db:"password_hash"`
+	AvatarURL string `json:"avatar_url" db:"avatar_url"`
+	IsAdmin   bool   `json:"is_admin" db:"is_admin"`
+	// PreferredUnits is units.Metric, units.Imperial or empty to show
+	// recipes as written.
+	PreferredUnits string `json:"preferred_units" db:"preferred_units"`
+	// Diets and AvoidAllergens are applied to the user's recipe searches
+	// that set no dietary criteria of their own.
+	Diets          []string  `json:"diets" db:"diets"`
+	AvoidAllergens []string  `json:"avoid_allergens" db:"avoid_allergens"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DisplayName returns the user's full name, falling back to the username.
+func (u *User) DisplayName() string {
+	if name := strings.TrimSpace(u.FirstName + " " + u.LastName); name != "" {
+		return name
+	}
+	return u.Username
 }
 
+// RefreshToken is the stored form of an opaque refresh token. Only the
+// SHA-256 hash of the token is persisted.
+type RefreshToken struct {
+	ID        string     `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	FamilyID  string     `json:"family_id" db:"family_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RecipeCollection is a user's named list of recipes. RecipeIDs is in the
+// collection's order.
 type RecipeCollection struct {
 	ID          string    `json:"id" db:"id"`
 	UserID      string    `json:"user_id" db:"user_id"`
@@ -27,24 +60,130 @@ type RecipeCollection struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+func (c *RecipeCollection) Validate() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return &FieldError{Field: "name", Message: "collection name is required"}
+	}
+	if len(c.Name) > 255 {
+		return &FieldError{Field: "name", Message: "collection name must be at most 255 characters"}
+	}
+	return nil
+}
+
+// CanBeViewedBy reports whether the given user may see the collection.
+// Private collections are only visible to their owner.
+func (c *RecipeCollection) CanBeViewedBy(userID string) bool {
+	return c.IsPublic || (userID != "" && c.UserID == userID)
+}
+
+// Contains reports whether the recipe is in the collection.
+func (c *RecipeCollection) Contains(recipeID string) bool {
+	for _, id := range c.RecipeIDs {
+		if id == recipeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Rating is one user's score for a recipe, optionally with a written
+// review. Each user rates a recipe at most once.
 type Rating struct {
 	ID       string `json:"id" db:"id"`
 	RecipeID string `json:"recipe_id" db:"recipe_id"`
 	UserID   string `json:"user_id" db:"user_id"`
-	Score    int    `json:"score" db:"score"` // 1-5
-	Comment  string `json:"comment" db:"comment"`
+	// Username is filled in when listing reviews.
+	Username  string    `json:"username,omitempty" db:"username"`
+	Score     int       `json:"score" db:"score"` // 1-5
+	Comment   string    `json:"comment" db:"comment"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+const (
+	MinRatingScore = 1
+	MaxRatingScore = 5
+
+	MaxReviewLength = 5000
+)
+
+// Validate reports the first invalid field of a rating.
+func (r *Rating) Validate() error {
+	if r.Score < MinRatingScore || r.Score > MaxRatingScore {
+		return &FieldError{Field: "score", Message: fmt.Sprintf("score must be between %d and %d", MinRatingScore, MaxRatingScore)}
+	}
+	if len(r.Comment) > MaxReviewLength {
+		return &FieldError{Field: "comment", Message: fmt.Sprintf("comment must be at most %d characters", MaxReviewLength)}
+	}
+	return nil
+}
+
+// RatingList is a page of a recipe's ratings, most recently updated first.
+type RatingList struct {
+	Ratings []Rating `json:"ratings"`
+	Total   int      `json:"total"`
+	Page    int      `json:"page"`
+	PerPage int      `json:"per_page"`
+}
+
+// NutritionInfo holds a recipe's nutrition facts per serving. They are
+// either entered by the recipe's author or computed from its ingredients.
 type NutritionInfo struct {
-	ID           string  `json:"id" db:"id"`
-	RecipeID     string  `json:"recipe_id" db:"recipe_id"`
-	Calories     float64 `json:"calories" db:"calories"`
-	Protein      float64 `json:"protein" db:"protein"`
-	Carbs        float64 `json:"carbs" db:"carbs"`
-	Fat          float64 `json:"fat" db:"fat"`
-	Fiber        float64 `json:"fiber" db:"fiber"`
-	Sugar        float64 `json:"sugar" db:"sugar"`
-	Sodium       float64 `json:"sodium" db:"sodium"`
-	ServingSize  string  `json:"serving_size" db:"serving_size"`
-}
\ No newline at end of file
+	ID          string  `json:"id" db:"id"`
+	RecipeID    string  `json:"recipe_id" db:"recipe_id"`
+	Calories    float64 `json:"calories" db:"calories"` // kcal
+	Protein     float64 `json:"protein" db:"protein"`   // g
+	Carbs       float64 `json:"carbs" db:"carbs"`       // g
+	Fat         float64 `json:"fat" db:"fat"`           // g
+	Fiber       float64 `json:"fiber" db:"fiber"`       // g
+	Sugar       float64 `json:"sugar" db:"sugar"`       // g
+	Sodium      float64 `json:"sodium" db:"sodium"`     // mg
+	ServingSize string  `json:"serving_size" db:"serving_size"`
+	// Source is NutritionSourceManual or NutritionSourceComputed.
+	Source string `json:"source" db:"source"`
+	// Unmatched lists the ingredients a computed estimate had to leave out.
+	Unmatched []string  `json:"unmatched_ingredients,omitempty" db:"unmatched"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+const (
+	NutritionSourceManual   = "manual"
+	NutritionSourceComputed = "computed"
+)
+
+// MaxNutrientValue is the largest value the nutrition_info columns hold.
+const MaxNutrientValue = 999999.99
+
+// Validate reports the first invalid field of author-entered nutrition
+// facts.
+func (n *NutritionInfo) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{"calories", n.Calories}, {"protein", n.Protein}, {"carbs", n.Carbs}, {"fat", n.Fat},
+		{"fiber", n.Fiber}, {"sugar", n.Sugar}, {"sodium", n.Sodium},
+	} {
+		if field.value < 0 || field.value > MaxNutrientValue {
+			return &FieldError{Field: field.name, Message: fmt.Sprintf("%s must be between 0 and %.2f", field.name, MaxNutrientValue)}
+		}
+	}
+	if len(n.ServingSize) > 100 {
+		return &FieldError{Field: "serving_size", Message: "serving size must be at most 100 characters"}
+	}
+	return nil
+}
+
+// Scaled returns the facts multiplied by factor, such as the number of
+// servings to get whole-recipe totals.
+func (n *NutritionInfo) Scaled(factor float64) NutritionInfo {
+	c := *n
+	c.Calories *= factor
+	c.Protein *= factor
+	c.Carbs *= factor
+	c.Fat *= factor
+	c.Fiber *= factor
+	c.Sugar *= factor
+	c.Sodium *= factor
+	return c
+}
@@ -0,0 +1,208 @@
+// Package nutrition estimates recipe nutrition from ingredient lists using
+// a bundled table of common foods.
+package nutrition
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/quantity"
+	"recipe-app/internal/units"
+)
+
+// Facts are nutrient amounts: energy in kcal, sodium in mg and everything
+// else in grams.
+type Facts struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Fiber    float64 `json:"fiber"`
+	Sugar    float64 `json:"sugar"`
+	Sodium   float64 `json:"sodium"`
+}
+
+func (f Facts) add(g Facts) Facts {
+	return Facts{
+		Calories: f.Calories + g.Calories,
+		Protein:  f.Protein + g.Protein,
+		Carbs:    f.Carbs + g.Carbs,
+		Fat:      f.Fat + g.Fat,
+		Fiber:    f.Fiber + g.Fiber,
+		Sugar:    f.Sugar + g.Sugar,
+		Sodium:   f.Sodium + g.Sodium,
+	}
+}
+
+// Scale returns the facts multiplied by factor.
+func (f Facts) Scale(factor float64) Facts {
+	return Facts{
+		Calories: f.Calories * factor,
+		Protein:  f.Protein * factor,
+		Carbs:    f.Carbs * factor,
+		Fat:      f.Fat * factor,
+		Fiber:    f.Fiber * factor,
+		Sugar:    f.Sugar * factor,
+		Sodium:   f.Sodium * factor,
+	}
+}
+
+// Food is one entry of the bundled dataset.
+type Food struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Per100g Facts    `json:"per_100g"`
+	// GramsPerML converts volumes to weight; 0 means volumes are unknown.
+	GramsPerML float64 `json:"grams_per_ml"`
+	// PieceGrams is the weight of one item given without a unit.
+	PieceGrams float64 `json:"piece_grams"`
+	// Pieces weighs named portions and sizes, such as a clove or a large
+	// one.
+	Pieces map[string]float64 `json:"pieces"`
+}
+
+//go:embed foods.json
+var foodsJSON []byte
+
+// foods indexes the dataset by normalized name and alias.
+var foods = func() map[string]*Food {
+	var list []Food
+	if err := json.Unmarshal(foodsJSON, &list); err != nil {
+		panic("nutrition: invalid foods.json: " + err.Error())
+	}
+	index := make(map[string]*Food)
+	for i := range list {
+		food := &list[i]
+		for _, name := range append([]string{food.Name}, food.Aliases...) {
+			index[normalizeName(name)] = food
+		}
+	}
+	return index
+}()
+
+// Lookup finds the dataset entry for an ingredient name. Names match
+// case-insensitively, in singular or plural, and by their longest known
+// phrase, so "lean ground beef" finds ground beef.
+func Lookup(name string) (*Food, bool) {
+	words := strings.Fields(normalizeName(name))
+	for size := len(words); size > 0; size-- {
+		for start := 0; start+size <= len(words); start++ {
+			phrase := words[start : start+size]
+			for _, candidate := range singulars(strings.Join(phrase, " ")) {
+				if food, ok := foods[candidate]; ok {
+					return food, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// Result is a recipe's estimated nutrition.
+type Result struct {
+	Total Facts
+	// Unmatched names the ingredients that were left out because they are
+	// not in the dataset or their amount or unit could not be converted.
+	Unmatched []string
+}
+
+// PerServing divides the totals between servings, treating anything below
+// one serving as the whole recipe.
+func (r Result) PerServing(servings int) Facts {
+	if servings < 1 {
+		servings = 1
+	}
+	return r.Total.Scale(1 / float64(servings))
+}
+
+// Compute estimates the total nutrition of a list of ingredients.
+func Compute(ingredients []models.Ingredient) Result {
+	result := Result{Unmatched: []string{}}
+	for _, ing := range ingredients {
+		facts, ok := ingredientFacts(ing)
+		if !ok {
+			result.Unmatched = append(result.Unmatched, ing.Name)
+			continue
+		}
+		result.Total = result.Total.add(facts)
+	}
+	return result
+}
+
+// lookupIngredient finds the dataset entry for an ingredient by its name,
+// falling back to the name of the catalog entry it is linked to.
+func lookupIngredient(ing models.Ingredient) (*Food, bool) {
+	food, ok := Lookup(ing.Name)
+	if !ok && ing.CatalogName != "" {
+		food, ok = Lookup(ing.CatalogName)
+	}
+	return food, ok
+}
+
+// GramsPerML returns an ingredient's density, or 0 when it is not known.
+func GramsPerML(ing models.Ingredient) float64 {
+	if food, ok := lookupIngredient(ing); ok {
+		return food.GramsPerML
+	}
+	return 0
+}
+
+// ingredientFacts estimates the nutrition of one ingredient.
+func ingredientFacts(ing models.Ingredient) (Facts, bool) {
+	food, ok := lookupIngredient(ing)
+	if !ok {
+		return Facts{}, false
+	}
+	amount, err := quantity.Parse(ing.Amount)
+	if err != nil || amount.Value() == 0 {
+		return Facts{}, false
+	}
+	grams, ok := food.grams(amount.Value(), ing.Unit)
+	if !ok {
+		return Facts{}, false
+	}
+	return food.Per100g.Scale(grams / 100), true
+}
+
+// grams converts an amount of the food in unit to its weight.
+func (f *Food) grams(amount float64, unit string) (float64, bool) {
+	if u, ok := units.Lookup(unit); ok {
+		return units.Grams(amount, u, f.GramsPerML)
+	}
+	unit = normalizeName(unit)
+	if unit == "" || unit == "piece" || unit == "pieces" || unit == "whole" {
+		return amount * f.PieceGrams, f.PieceGrams > 0
+	}
+	for _, u := range singulars(unit) {
+		if g, ok := f.Pieces[u]; ok {
+			return amount * g, true
+		}
+	}
+	return 0, false
+}
+
+// normalizeName lowercases a name and reduces punctuation to single spaces.
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// singulars returns a phrase followed by the singular forms its last word
+// may have.
+func singulars(phrase string) []string {
+	out := []string{phrase}
+	if strings.HasSuffix(phrase, "ies") {
+		out = append(out, strings.TrimSuffix(phrase, "ies")+"y")
+	}
+	if strings.HasSuffix(phrase, "es") {
+		out = append(out, strings.TrimSuffix(phrase, "es"))
+	}
+	if strings.HasSuffix(phrase, "s") {
+		out = append(out, strings.TrimSuffix(phrase, "s"))
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package nutrition
+
+import (
+	"math"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Flour", "all-purpose flour"},
+		{"eggs", "egg"},
+		{"Tomatoes", "tomato"},
+		{"taco shells", "taco shell"},
+		{"lean ground beef", "ground beef"},
+		{"coconut milk", "coconut milk"},
+		{"extra-virgin olive oil", "olive oil"},
+		{"cherries", ""},
+		{"saffron", ""},
+	}
+	for _, tt := range tests {
+		food, ok := Lookup(tt.name)
+		got := ""
+		if ok {
+			got = food.Name
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	result := Compute([]models.Ingredient{
+		{Name: "sugar", Amount: "100", Unit: "g"},
+		{Name: "butter", Amount: "2", Unit: "tbsp"},
+		{Name: "eggs", Amount: "2", Unit: "large"},
+		{Name: "garlic", Amount: "3", Unit: "cloves"},
+		{Name: "saffron", Amount: "1", Unit: "pinch"},
+		{Name: "salt", Amount: "to taste"},
+		{Name: "flour", Amount: "1", Unit: "handful"},
+	})
+
+	// 100 g sugar, 28.4 g butter, 100 g egg and 9 g garlic.
+	wantCalories := 387 + 717*2*14.7868*0.96/100 + 143 + 149*0.09
+	if math.Abs(result.Total.Calories-wantCalories) > 0.01 {
+		t.Errorf("Total.Calories = %v, want %v", result.Total.Calories, wantCalories)
+	}
+	if math.Abs(result.Total.Sugar-(100+0.1*0.284+0.4+0.09)) > 0.01 {
+		t.Errorf("Total.Sugar = %v", result.Total.Sugar)
+	}
+	if got := result.Unmatched; len(got) != 3 || got[0] != "saffron" || got[1] != "salt" || got[2] != "flour" {
+		t.Errorf("Unmatched = %v, want saffron, salt and flour", got)
+	}
+
+	perServing := result.PerServing(4)
+	if math.Abs(perServing.Calories*4-result.Total.Calories) > 1e-9 {
+		t.Errorf("PerServing(4).Calories = %v, want a quarter of %v", perServing.Calories, result.Total.Calories)
+	}
+	if result.PerServing(0) != result.Total {
+		t.Errorf("PerServing(0) = %+v, want the whole recipe", result.PerServing(0))
+	}
+}
+
+func TestCompute_CatalogName(t *testing.T) {
+	result := Compute([]models.Ingredient{
+		{Name: "jaggery", Amount: "100", Unit: "g", CatalogName: "sugar"},
+		{Name: "dragon fruit", Amount: "1", CatalogName: "pitaya"},
+	})
+	if math.Abs(result.Total.Calories-387) > 0.01 || len(result.Unmatched) != 1 || result.Unmatched[0] != "dragon fruit" {
+		t.Errorf("Compute() = %+v, want the catalog name looked up", result)
+	}
+}
+
+func TestDatasetCoversDemoUnits(t *testing.T) {
+	for _, ing := range []models.Ingredient{
+		{Name: "onion", Amount: "1", Unit: "large"},
+		{Name: "romaine lettuce", Amount: "1", Unit: "head"},
+		{Name: "croutons", Amount: "1", Unit: "cup"},
+		{Name: "olives", Amount: "1/2", Unit: "cup"},
+		{Name: "cucumber", Amount: "1"},
+	} {
+		if _, ok := ingredientFacts(ing); !ok {
+			t.Errorf("ingredientFacts(%+v) found no match", ing)
+		}
+	}
+}
+
+// TestComputeAmountForms checks that every amount form is read, taking the
+// middle of a range.
+func TestComputeAmountForms(t *testing.T) {
+	want := Compute([]models.Ingredient{{Name: "sugar", Amount: "150", Unit: "g"}}).Total
+	for _, amount := range []string{"1½", "1 1/2", "1-2", "1,5"} {
+		got := Compute([]models.Ingredient{{Name: "sugar", Amount: amount, Unit: "g"}})
+		if math.Abs(got.Total.Calories*100-want.Calories) > 1e-9 {
+			t.Errorf("Compute(%q g).Calories = %v, want %v", amount, got.Total.Calories, want.Calories/100)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package quantity parses, scales and formats the free-form ingredient
+// amounts recipes are written with.
+package quantity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for amounts that are not a number or range.
+var ErrInvalid = errors.New("invalid quantity")
+
+// Quantity is an amount or, when Max is above Min, a range of amounts.
+type Quantity struct {
+	Min float64
+	Max float64
+}
+
+// IsRange reports whether the quantity is a range such as "2-3".
+func (q Quantity) IsRange() bool {
+	return q.Max != q.Min
+}
+
+// Value returns the amount, or the middle of a range.
+func (q Quantity) Value() float64 {
+	return (q.Min + q.Max) / 2
+}
+
+// Scale returns the quantity multiplied by factor.
+func (q Quantity) Scale(factor float64) Quantity {
+	return Quantity{Min: q.Min * factor, Max: q.Max * factor}
+}
+
+// String formats the quantity with Format, joining ranges with a hyphen.
+func (q Quantity) String() string {
+	min, max := Format(q.Min), Format(q.Max)
+	if min == max {
+		return min
+	}
+	return min + "-" + max
+}
+
+// unicodeFractions are the vulgar fraction characters Parse accepts.
+var unicodeFractions = map[rune]string{
+	'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5", '⅙': "1/6", '⅚': "5/6",
+	'⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// Parse reads whole numbers, decimals (with a point or a comma), fractions,
+// mixed numbers such as "1 1/2" or "1½", and ranges of those such as "2-3"
+// or "1 to 2".
+func Parse(s string) (Quantity, error) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicodeFractions[r] != "":
+			b.WriteString(" " + unicodeFractions[r])
+		case r == '⁄':
+			b.WriteRune('/')
+		case r == '–' || r == '—':
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	normalized := strings.ReplaceAll(b.String(), " to ", "-")
+
+	low, high, isRange := strings.Cut(normalized, "-")
+	min, err := parseNumber(low)
+	if err != nil {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	if !isRange {
+		return Quantity{Min: min, Max: min}, nil
+	}
+	max, err := parseNumber(high)
+	if err != nil || max < min {
+		return Quantity{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	return Quantity{Min: min, Max: max}, nil
+}
+
+// parseNumber reads a single non-negative amount, adding up the parts of a
+// mixed number.
+func parseNumber(s string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, ErrInvalid
+	}
+	total := 0.0
+	for i, field := range fields {
+		num, den, isFraction := strings.Cut(field, "/")
+		if i == 1 && !isFraction {
+			return 0, ErrInvalid
+		}
+		n, err := parseDecimal(num)
+		if err != nil {
+			return 0, err
+		}
+		if isFraction {
+			d, err := parseDecimal(den)
+			if err != nil || d == 0 {
+				return 0, ErrInvalid
+			}
+			n /= d
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func parseDecimal(s string) (float64, error) {
+	if s == "" || strings.ContainsAny(s, "+-eE") {
+		return 0, ErrInvalid
+	}
+	n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+		return 0, ErrInvalid
+	}
+	return n, nil
+}
+
+// kitchenFractions are the fractions Format rounds small amounts to.
+var kitchenFractions = []struct {
+	value float64
+	text  string
+}{
+	{0, ""}, {1.0 / 8, "1/8"}, {1.0 / 4, "1/4"}, {1.0 / 3, "1/3"}, {3.0 / 8, "3/8"},
+	{1.0 / 2, "1/2"}, {5.0 / 8, "5/8"}, {2.0 / 3, "2/3"}, {3.0 / 4, "3/4"}, {7.0 / 8, "7/8"}, {1, ""},
+}
+
+// Format writes an amount the way a cook would measure it: below 10 as a
+// whole number and the nearest of eighths, quarters, thirds and halves,
+// then to the nearest whole number, and from 100 to the nearest 5. Amounts
+// too small for an eighth keep two significant digits.
+func Format(v float64) string {
+	switch {
+	case v >= 100:
+		return strconv.FormatFloat(math.Round(v/5)*5, 'f', -1, 64)
+	case v >= 10:
+		return strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+	case v > 0 && v < 1.0/16:
+		return strconv.FormatFloat(v, 'g', 2, 64)
+	}
+
+	whole := math.Floor(v)
+	frac := v - whole
+	best := kitchenFractions[0]
+	for _, f := range kitchenFractions[1:] {
+		if math.Abs(frac-f.value) <= math.Abs(frac-best.value) {
+			best = f
+		}
+	}
+	if best.value == 1 {
+		whole++
+	}
+	switch {
+	case best.text == "":
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	case whole == 0:
+		return best.text
+	default:
+		return strconv.FormatFloat(whole, 'f', -1, 64) + " " + best.text
+	}
+}
+
+// FormatDecimal writes an amount for metric measures: to the nearest 5 from
+// 100, whole numbers from 10, one decimal from 1 and two significant digits
+// below that.
+func FormatDecimal(v float64) string {
+	switch {
+	case v >= 100:
+		return strconv.FormatFloat(math.Round(v/5)*5, 'f', -1, 64)
+	case v >= 10:
+		return strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+	case v >= 1:
+		return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(v, 'g', 2, 64)
+	}
+}
@@ -0,0 +1,118 @@
+package quantity
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		min, max float64
+	}{
+		{"2", 2, 2},
+		{" 1.5 ", 1.5, 1.5},
+		{"0,5", 0.5, 0.5},
+		{"3/4", 0.75, 0.75},
+		{"1 1/2", 1.5, 1.5},
+		{"½", 0.5, 0.5},
+		{"1½", 1.5, 1.5},
+		{"2 ¾", 2.75, 2.75},
+		{"1⁄3", 1.0 / 3, 1.0 / 3},
+		{"2-3", 2, 3},
+		{"1 - 1 1/2", 1, 1.5},
+		{"½–1", 0.5, 1},
+		{"2 to 4", 2, 4},
+		{"0", 0, 0},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.amount)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.amount, err)
+			continue
+		}
+		if math.Abs(got.Min-tt.min) > 1e-9 || math.Abs(got.Max-tt.max) > 1e-9 {
+			t.Errorf("Parse(%q) = %+v, want %v-%v", tt.amount, got, tt.min, tt.max)
+		}
+		if got.IsRange() != (tt.min != tt.max) {
+			t.Errorf("Parse(%q).IsRange() = %v", tt.amount, got.IsRange())
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, amount := range []string{"", "a pinch", "to taste", "1/0", "-2", "3-2", "1e3", "1 2", "2-", "1 1/2 3"} {
+		if got, err := Parse(amount); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) = %+v, %v, want ErrInvalid", amount, got, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{0, "0"},
+		{2, "2"},
+		{0.5, "1/2"},
+		{0.3, "1/3"},
+		{0.7, "2/3"},
+		{1.1, "1 1/8"},
+		{2.26, "2 1/4"},
+		{2.95, "3"},
+		{0.08, "1/8"},
+		{0.02, "0.02"},
+		{12.4, "12"},
+		{666.67, "665"},
+		{375, "375"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.value); got != tt.want {
+			t.Errorf("Format(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestQuantity_ScaleString(t *testing.T) {
+	tests := []struct {
+		amount string
+		factor float64
+		want   string
+	}{
+		{"1 1/2", 2, "3"},
+		{"3/4", 1.0 / 3, "1/4"},
+		{"400", 1.5, "600"},
+		{"2-3", 0.5, "1-1 1/2"},
+		{"1-1 1/8", 0.5, "1/2-5/8"},
+		{"1-1 1/32", 1, "1"},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.amount)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.amount, err)
+		}
+		if got := q.Scale(tt.factor).String(); got != tt.want {
+			t.Errorf("Parse(%q).Scale(%v) = %q, want %q", tt.amount, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  string
+	}{
+		{473.18, "475"},
+		{28.35, "28"},
+		{4.93, "4.9"},
+		{1.04, "1"},
+		{0.454, "0.45"},
+	}
+	for _, tt := range tests {
+		if got := FormatDecimal(tt.value); got != tt.want {
+			t.Errorf("FormatDecimal(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
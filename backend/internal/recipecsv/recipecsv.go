@@ -0,0 +1,345 @@
+// Package recipecsv reads and writes recipes as CSV, one recipe per row,
+// for moving recipe books in and out of spreadsheets.
+//
+// The header row names the columns, in any order; only title is required:
+//
+//	title, description, category, cuisine, difficulty, prep_time,
+//	cook_time, servings, tags, ingredients, instructions, image_url
+//
+// Times are whole minutes. Tags are separated by commas. Ingredients and
+// instructions hold one item per line of the cell. An ingredient line is
+// either "amount | unit | name | notes" or free text such as
+// "2 cups flour, sifted". An instruction line is its text, optionally
+// followed by "| duration | temperature", as in "Bake | 25 | 180C".
+package recipecsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"recipe-app/internal/importer"
+	"recipe-app/internal/models"
+)
+
+// Columns is the layout written by Writer.
+var Columns = []string{
+	"title", "description", "category", "cuisine", "difficulty", "prep_time",
+	"cook_time", "servings", "tags", "ingredients", "instructions", "image_url",
+}
+
+// MaxRows bounds the recipes read from one file.
+const MaxRows = 1000
+
+// ErrTooManyRows is returned by Read for files over MaxRows recipes.
+var ErrTooManyRows = fmt.Errorf("a CSV file can hold at most %d recipes", MaxRows)
+
+// Row is one recipe read from a file. Errors lists every problem found in
+// the row; Recipe is only safe to save when there are none.
+type Row struct {
+	Line   int
+	Recipe *models.Recipe
+	Errors []string
+}
+
+// Read parses and validates every recipe in a CSV file. It fails only for
+// files it cannot read as a whole: a bad header, broken quoting or too
+// many rows. Problems with single rows are reported in their Errors.
+func Read(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 0
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			rows = append(rows, Row{Line: parseErr.StartLine, Errors: []string{
+				fmt.Sprintf("expected %d columns, found %d", len(header), len(record)),
+			}})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+
+		fields := make(map[string]string, len(columns))
+		for i, name := range columns {
+			fields[name] = strings.TrimSpace(record[i])
+		}
+		recipe, problems := parseRecipe(fields)
+		rows = append(rows, Row{Line: line, Recipe: recipe, Errors: problems})
+	}
+	return rows, nil
+}
+
+// parseHeader returns the column names of a header row, accepting any case
+// and spaces for underscores.
+func parseHeader(header []string) ([]string, error) {
+	known := make(map[string]bool, len(Columns))
+	for _, name := range Columns {
+		known[name] = true
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets often save UTF-8 with a byte order mark.
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+		switch {
+		case !known[name]:
+			return nil, fmt.Errorf("unknown column %q", header[i])
+		case seen[name]:
+			return nil, fmt.Errorf("column %q appears more than once", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	if !seen["title"] {
+		return nil, errors.New("the title column is required")
+	}
+	return columns, nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRecipe builds a recipe from a row's fields and validates it.
+func parseRecipe(fields map[string]string) (*models.Recipe, []string) {
+	var problems []string
+	number := func(column string) int {
+		if fields[column] == "" {
+			return 0
+		}
+		n, err := strconv.Atoi(fields[column])
+		if err != nil {
+			problems = append(problems, column+" must be a whole number")
+		}
+		return n
+	}
+
+	recipe := &models.Recipe{
+		Title:       fields["title"],
+		Description: fields["description"],
+		Category:    fields["category"],
+		Cuisine:     fields["cuisine"],
+		Difficulty:  strings.ToLower(fields["difficulty"]),
+		PrepTime:    number("prep_time"),
+		CookTime:    number("cook_time"),
+		Servings:    number("servings"),
+		ImageURL:    fields["image_url"],
+	}
+	for _, tag := range strings.Split(fields["tags"], ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			recipe.Tags = append(recipe.Tags, tag)
+		}
+	}
+
+	for i, line := range lines(fields["ingredients"]) {
+		ing, err := parseIngredient(line)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("ingredient %d: %v", i+1, err))
+			continue
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ing)
+	}
+	for i, line := range lines(fields["instructions"]) {
+		inst, err := parseInstruction(line)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("instruction %d: %v", i+1, err))
+			continue
+		}
+		recipe.Instructions = append(recipe.Instructions, inst)
+	}
+
+	recipe.NormalizeUnits()
+	if err := recipe.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for i := range recipe.Ingredients {
+		if err := recipe.Ingredients[i].Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("ingredient %d: %v", i+1, err))
+		}
+	}
+	for i := range recipe.Instructions {
+		if err := recipe.Instructions[i].Validate(); err != nil {
+			problems = append(problems, fmt.Sprintf("instruction %d: %v", i+1, err))
+		}
+	}
+	return recipe, problems
+}
+
+// lines splits a multi-valued cell into its non-blank lines.
+func lines(cell string) []string {
+	var out []string
+	for _, line := range strings.Split(cell, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func parseIngredient(line string) (models.Ingredient, error) {
+	if !strings.Contains(line, "|") {
+		ing, _ := importer.ParseIngredient(line)
+		return ing, nil
+	}
+	parts := strings.SplitN(line, "|", 4)
+	if len(parts) < 3 {
+		return models.Ingredient{}, errors.New("write amount | unit | name | notes")
+	}
+	ing := models.Ingredient{
+		Amount: strings.TrimSpace(parts[0]),
+		Unit:   strings.TrimSpace(parts[1]),
+		Name:   strings.TrimSpace(parts[2]),
+	}
+	if len(parts) == 4 {
+		ing.Notes = strings.TrimSpace(parts[3])
+	}
+	return ing, nil
+}
+
+// temperature matches "180C", "350 F" or "200°C".
+var temperature = regexp.MustCompile(`^(\d+)\s*°?\s*([CcFf])$`)
+
+// parseInstruction reads a step's text and, when the line has at least two
+// "|" separators, the duration and temperature after the last two.
+func parseInstruction(line string) (models.Instruction, error) {
+	parts := strings.Split(line, "|")
+	if len(parts) < 3 {
+		return models.Instruction{Text: line}, nil
+	}
+	n := len(parts)
+	inst := models.Instruction{Text: strings.TrimSpace(strings.Join(parts[:n-2], "|"))}
+	if duration := strings.TrimSpace(parts[n-2]); duration != "" {
+		minutes, err := strconv.Atoi(duration)
+		if err != nil {
+			return models.Instruction{}, errors.New("duration must be a whole number of minutes")
+		}
+		inst.Duration = minutes
+	}
+	if temp := strings.TrimSpace(parts[n-1]); temp != "" {
+		m := temperature.FindStringSubmatch(temp)
+		if m == nil {
+			return models.Instruction{}, errors.New("temperature must be written like 180C or 350F")
+		}
+		inst.Temperature, _ = strconv.Atoi(m[1])
+		inst.TemperatureUnit = strings.ToUpper(m[2])
+	}
+	return inst, nil
+}
+
+// Writer writes recipes in the layout of Columns, starting with a header
+// row, so that its output can be read back with Read.
+type Writer struct {
+	csv         *csv.Writer
+	wroteHeader bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{csv: csv.NewWriter(w)}
+}
+
+// WriteHeader writes the header row, unless it has been written already.
+// Write calls it before the first recipe.
+func (w *Writer) WriteHeader() error {
+	if w.wroteHeader {
+		return nil
+	}
+	w.wroteHeader = true
+	return w.csv.Write(Columns)
+}
+
+func (w *Writer) Write(recipe *models.Recipe) error {
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+
+	ingredients := make([]string, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		fields := []string{oneLine(ing.Amount), oneLine(ing.Unit), oneLine(ing.Name)}
+		if ing.Notes != "" {
+			fields = append(fields, oneLine(ing.Notes))
+		}
+		ingredients[i] = strings.Join(fields, " | ")
+	}
+
+	instructions := make([]string, len(recipe.Instructions))
+	for i, inst := range recipe.Instructions {
+		text := oneLine(inst.Text)
+		if inst.Duration == 0 && inst.Temperature == 0 && !strings.Contains(text, "|") {
+			instructions[i] = text
+			continue
+		}
+		duration, temp := "", ""
+		if inst.Duration > 0 {
+			duration = strconv.Itoa(inst.Duration)
+		}
+		if inst.Temperature > 0 {
+			temp = strconv.Itoa(inst.Temperature) + inst.TemperatureUnit
+		}
+		instructions[i] = text + " | " + duration + " | " + temp
+	}
+
+	return w.csv.Write([]string{
+		recipe.Title,
+		recipe.Description,
+		recipe.Category,
+		recipe.Cuisine,
+		recipe.Difficulty,
+		strconv.Itoa(recipe.PrepTime),
+		strconv.Itoa(recipe.CookTime),
+		strconv.Itoa(recipe.Servings),
+		strings.Join(recipe.Tags, ", "),
+		strings.Join(ingredients, "\n"),
+		strings.Join(instructions, "\n"),
+		recipe.ImageURL,
+	})
+}
+
+// Flush writes any buffered rows and reports the first write error.
+func (w *Writer) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// oneLine keeps a value on one line of its cell.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
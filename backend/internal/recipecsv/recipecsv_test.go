@@ -0,0 +1,188 @@
+package recipecsv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+const validCSV = "\ufeffTitle,Prep Time,cook_time,servings,difficulty,tags,ingredients,instructions\n" +
+	`Pancakes,10,15,4,Easy,"Breakfast, quick","1 1/2 | Cups | flour | sifted
+2 eggs
+Salt to taste","Whisk everything together.
+Fry in a hot pan | 5 |
+Keep warm | | 90C"` + "\n" +
+	",,,,,,,\n" +
+	"Toast,,,,,,,Toast the bread.\n"
+
+func TestRead(t *testing.T) {
+	rows, err := Read(strings.NewReader(validCSV))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Read() returned %d rows, want 2", len(rows))
+	}
+	if rows[0].Line != 2 || rows[1].Line != 8 {
+		t.Errorf("Read() lines = %d, %d, want 2, 8", rows[0].Line, rows[1].Line)
+	}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			t.Errorf("line %d errors = %v", row.Line, row.Errors)
+		}
+	}
+
+	recipe := rows[0].Recipe
+	if recipe.Title != "Pancakes" || recipe.PrepTime != 10 || recipe.CookTime != 15 || recipe.Servings != 4 || recipe.Difficulty != "easy" {
+		t.Errorf("Read() recipe = %+v", recipe)
+	}
+	if got := strings.Join(recipe.Tags, ","); got != "breakfast,quick" {
+		t.Errorf("Read() tags = %s, want breakfast,quick", got)
+	}
+
+	wantIngredients := []models.Ingredient{
+		{Amount: "1 1/2", Unit: "cups", Name: "flour", Notes: "sifted"},
+		{Amount: "2", Name: "eggs"},
+		{Name: "Salt to taste"},
+	}
+	if !reflect.DeepEqual(recipe.Ingredients, wantIngredients) {
+		t.Errorf("Read() ingredients = %+v, want %+v", recipe.Ingredients, wantIngredients)
+	}
+
+	wantInstructions := []models.Instruction{
+		{Text: "Whisk everything together."},
+		{Text: "Fry in a hot pan", Duration: 5},
+		{Text: "Keep warm", Temperature: 90, TemperatureUnit: "C"},
+	}
+	if !reflect.DeepEqual(recipe.Instructions, wantInstructions) {
+		t.Errorf("Read() instructions = %+v, want %+v", recipe.Instructions, wantInstructions)
+	}
+}
+
+func TestRead_RowErrors(t *testing.T) {
+	input := "title,prep_time,difficulty,ingredients,instructions\n" +
+		",abc,extreme,flour | cups,\"Bake | soon | 180C\nRest | 5 | warm\"\n" +
+		"Only,two\n" +
+		"Fine,5,,,Mix\n"
+
+	rows, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Read() returned %d rows, want 3", len(rows))
+	}
+
+	want := []string{
+		"prep_time must be a whole number",
+		"ingredient 1: write amount | unit | name | notes",
+		"instruction 1: duration must be a whole number of minutes",
+		"instruction 2: temperature must be written like 180C or 350F",
+		"title is required",
+	}
+	if !reflect.DeepEqual(rows[0].Errors, want) {
+		t.Errorf("line 2 errors = %q, want %q", rows[0].Errors, want)
+	}
+	if rows[1].Line != 4 || len(rows[1].Errors) != 1 || rows[1].Recipe != nil {
+		t.Errorf("short row = %+v, want one error on line 4", rows[1])
+	}
+	if len(rows[2].Errors) != 0 {
+		t.Errorf("line 5 errors = %v, want none", rows[2].Errors)
+	}
+}
+
+func TestRead_FileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Empty", "", "empty"},
+		{"Unknown column", "title,rating\n", `unknown column "rating"`},
+		{"Duplicate column", "title,Title\n", "more than once"},
+		{"Missing title", "description\n", "title column is required"},
+		{"Broken quoting", "title\n\"Soup\n", "reading CSV"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Read() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Too many rows", func(t *testing.T) {
+		var b strings.Builder
+		b.WriteString("title\n")
+		for i := 0; i <= MaxRows; i++ {
+			fmt.Fprintf(&b, "Recipe %d\n", i)
+		}
+		if _, err := Read(strings.NewReader(b.String())); !errors.Is(err, ErrTooManyRows) {
+			t.Errorf("Read() error = %v, want ErrTooManyRows", err)
+		}
+	})
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	recipes := []models.Recipe{
+		{
+			Title:       "Bread",
+			Description: "A \"simple\" loaf,\nwith a crust.",
+			Category:    "baking",
+			Cuisine:     "french",
+			Difficulty:  "medium",
+			PrepTime:    20,
+			CookTime:    35,
+			Servings:    8,
+			Tags:        []string{"bread", "vegan"},
+			ImageURL:    "/media/recipes/1/2/original.jpg",
+			Ingredients: []models.Ingredient{
+				{Amount: "500", Unit: "g", Name: "flour"},
+				{Name: "water", Notes: "lukewarm, about\n300 ml"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Knead | then rest", Duration: 60},
+				{Text: "Bake", Duration: 35, Temperature: 220, TemperatureUnit: "C"},
+				{Text: "Cool"},
+			},
+		},
+		{Title: "Water"},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for i := range recipes {
+		if err := w.Write(&recipes[i]); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	rows, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(rows) != len(recipes) {
+		t.Fatalf("Read() returned %d rows, want %d", len(rows), len(recipes))
+	}
+
+	// Values are kept to one line within multi-valued cells.
+	recipes[0].Ingredients[1].Notes = "lukewarm, about 300 ml"
+	for i, row := range rows {
+		if len(row.Errors) > 0 {
+			t.Errorf("row %d errors = %v", i, row.Errors)
+		}
+		if !reflect.DeepEqual(*row.Recipe, recipes[i]) {
+			t.Errorf("row %d = %+v, want %+v", i, *row.Recipe, recipes[i])
+		}
+	}
+}
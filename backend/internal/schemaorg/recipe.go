@@ -0,0 +1,211 @@
+// Package schemaorg maps recipes onto schema.org Recipe documents in
+// JSON-LD, the form search engines read and package importer reads back.
+package schemaorg
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"recipe-app/internal/models"
+)
+
+// ContentType is the media type of JSON-LD documents.
+const ContentType = "application/ld+json"
+
+// Recipe is a schema.org Recipe. Fields follow the schema.org names; only
+// those the recipe has are set.
+type Recipe struct {
+	Context            string           `json:"@context"`
+	Type               string           `json:"@type"`
+	ID                 string           `json:"@id,omitempty"`
+	URL                string           `json:"url,omitempty"`
+	Name               string           `json:"name"`
+	Description        string           `json:"description,omitempty"`
+	Image              []string         `json:"image,omitempty"`
+	RecipeCategory     string           `json:"recipeCategory,omitempty"`
+	RecipeCuisine      string           `json:"recipeCuisine,omitempty"`
+	Keywords           string           `json:"keywords,omitempty"`
+	RecipeYield        string           `json:"recipeYield,omitempty"`
+	PrepTime           string           `json:"prepTime,omitempty"`
+	CookTime           string           `json:"cookTime,omitempty"`
+	TotalTime          string           `json:"totalTime,omitempty"`
+	RecipeIngredient   []string         `json:"recipeIngredient"`
+	RecipeInstructions []HowToStep      `json:"recipeInstructions"`
+	Nutrition          *Nutrition       `json:"nutrition,omitempty"`
+	AggregateRating    *AggregateRating `json:"aggregateRating,omitempty"`
+	DatePublished      string           `json:"datePublished,omitempty"`
+	DateModified       string           `json:"dateModified,omitempty"`
+}
+
+// HowToStep is one instruction. TimeRequired carries its duration.
+type HowToStep struct {
+	Type         string   `json:"@type"`
+	Position     int      `json:"position"`
+	Text         string   `json:"text"`
+	TimeRequired string   `json:"timeRequired,omitempty"`
+	Image        []string `json:"image,omitempty"`
+}
+
+// Nutrition is a schema.org NutritionInformation, per serving.
+type Nutrition struct {
+	Type                string `json:"@type"`
+	ServingSize         string `json:"servingSize,omitempty"`
+	Calories            string `json:"calories"`
+	ProteinContent      string `json:"proteinContent"`
+	CarbohydrateContent string `json:"carbohydrateContent"`
+	FatContent          string `json:"fatContent"`
+	FiberContent        string `json:"fiberContent"`
+	SugarContent        string `json:"sugarContent"`
+	SodiumContent       string `json:"sodiumContent"`
+}
+
+// AggregateRating summarizes a recipe's ratings on a scale of 1 to 5.
+type AggregateRating struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	RatingCount int     `json:"ratingCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
+
+// FromRecipe maps a recipe onto a schema.org Recipe. baseURL, such as
+// "https://example.com", makes the recipe's page and image links absolute.
+//
+// Ingredients are written as lines such as "1 1/2 cups flour, sifted",
+// which the importer splits back into their parts. schema.org has no
+// terms for difficulty or step temperatures; temperatures are only read
+// back where the step's text states them.
+func FromRecipe(recipe *models.Recipe, baseURL string) *Recipe {
+	doc := &Recipe{
+		Context:            "https://schema.org",
+		Type:               "Recipe",
+		Name:               recipe.Title,
+		Description:        recipe.Description,
+		RecipeCategory:     recipe.Category,
+		RecipeCuisine:      recipe.Cuisine,
+		Keywords:           strings.Join(recipe.Tags, ", "),
+		PrepTime:           Duration(recipe.PrepTime),
+		CookTime:           Duration(recipe.CookTime),
+		TotalTime:          Duration(recipe.PrepTime + recipe.CookTime),
+		RecipeIngredient:   make([]string, len(recipe.Ingredients)),
+		RecipeInstructions: make([]HowToStep, len(recipe.Instructions)),
+	}
+	if recipe.ID != "" {
+		doc.URL = absolute(baseURL, "/recipes/"+recipe.ID)
+		doc.ID = doc.URL + "#recipe"
+	}
+	if recipe.Servings > 0 {
+		doc.RecipeYield = strconv.Itoa(recipe.Servings)
+	}
+	if recipe.ImageURL != "" {
+		// The original comes first, as the importer takes the first image.
+		for _, variant := range []string{"", "detail", "card"} {
+			image := recipe.ImageURL
+			if variant != "" {
+				image = recipe.ImageVariantURL(variant)
+			}
+			if image = absolute(baseURL, image); !slices.Contains(doc.Image, image) {
+				doc.Image = append(doc.Image, image)
+			}
+		}
+	}
+
+	for i, ing := range recipe.Ingredients {
+		doc.RecipeIngredient[i] = ingredientLine(ing)
+	}
+	for i, inst := range recipe.Instructions {
+		step := HowToStep{
+			Type:         "HowToStep",
+			Position:     i + 1,
+			Text:         inst.Text,
+			TimeRequired: Duration(inst.Duration),
+		}
+		for _, image := range inst.Images {
+			step.Image = append(step.Image, absolute(baseURL, image))
+		}
+		doc.RecipeInstructions[i] = step
+	}
+
+	if n := recipe.Nutrition; n != nil {
+		doc.Nutrition = &Nutrition{
+			Type:                "NutritionInformation",
+			ServingSize:         n.ServingSize,
+			Calories:            amount(n.Calories, "calories"),
+			ProteinContent:      amount(n.Protein, "g"),
+			CarbohydrateContent: amount(n.Carbs, "g"),
+			FatContent:          amount(n.Fat, "g"),
+			FiberContent:        amount(n.Fiber, "g"),
+			SugarContent:        amount(n.Sugar, "g"),
+			SodiumContent:       amount(n.Sodium, "mg"),
+		}
+	}
+	if recipe.RatingCount > 0 {
+		doc.AggregateRating = &AggregateRating{
+			Type:        "AggregateRating",
+			RatingValue: recipe.RatingAverage,
+			RatingCount: recipe.RatingCount,
+			BestRating:  5,
+			WorstRating: 1,
+		}
+	}
+	if !recipe.CreatedAt.IsZero() {
+		doc.DatePublished = recipe.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	if !recipe.UpdatedAt.IsZero() {
+		doc.DateModified = recipe.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return doc
+}
+
+// Duration formats minutes as an ISO 8601 duration such as "PT1H30M", or
+// returns "" for none.
+func Duration(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+	s := "PT"
+	if h := minutes / 60; h > 0 {
+		s += strconv.Itoa(h) + "H"
+	}
+	if m := minutes % 60; m > 0 {
+		s += strconv.Itoa(m) + "M"
+	}
+	return s
+}
+
+// ingredientLine writes an ingredient as a recipe would list it.
+func ingredientLine(ing models.Ingredient) string {
+	var parts []string
+	for _, part := range []string{ing.Amount, ing.Unit, ing.Name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	line := strings.Join(parts, " ")
+	if ing.Notes != "" {
+		line += ", " + ing.Notes
+	}
+	return line
+}
+
+// absolute resolves a link against baseURL, leaving it as it is when
+// either does not parse.
+func absolute(baseURL, link string) string {
+	base, err := url.Parse(baseURL)
+	if err != nil || baseURL == "" {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func amount(value float64, unit string) string {
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(value, 'f', -1, 64), unit)
+}
@@ -0,0 +1,152 @@
+package schemaorg
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"recipe-app/internal/importer"
+	"recipe-app/internal/models"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    string
+	}{
+		{0, ""},
+		{-5, ""},
+		{45, "PT45M"},
+		{60, "PT1H"},
+		{90, "PT1H30M"},
+		{1500, "PT25H"},
+	}
+
+	for _, tt := range tests {
+		if got := Duration(tt.minutes); got != tt.want {
+			t.Errorf("Duration(%d) = %q, want %q", tt.minutes, got, tt.want)
+		}
+		if tt.minutes > 0 {
+			if back, ok := importer.ParseDuration(Duration(tt.minutes)); !ok || back != tt.minutes {
+				t.Errorf("ParseDuration(Duration(%d)) = %d, %v", tt.minutes, back, ok)
+			}
+		}
+	}
+}
+
+func bread() *models.Recipe {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	return &models.Recipe{
+		ID:          "b7f5c9e2-1d2a-4c3b-9a8e-2f1d0c9b8a7e",
+		Title:       "Country Bread",
+		Description: "A crusty loaf.",
+		PrepTime:    30,
+		CookTime:    45,
+		Servings:    8,
+		Difficulty:  "medium",
+		Category:    "baking",
+		Cuisine:     "french",
+		Tags:        []string{"bread", "vegan"},
+		ImageURL:    "/media/recipes/b7f5/9d1e/original.jpg",
+		Ingredients: []models.Ingredient{
+			{Amount: "500", Unit: "g", Name: "bread flour"},
+			{Amount: "1 1/2", Unit: "tsp", Name: "salt", Notes: "fine"},
+			{Amount: "350", Unit: "ml", Name: "water", Notes: "lukewarm"},
+			{Name: "olive oil"},
+		},
+		Instructions: []models.Instruction{
+			{Text: "Mix and knead the dough.", Duration: 10},
+			{Text: "Let it rise.", Duration: 120, Images: []string{"/media/recipes/b7f5/4e2a/original.jpg"}},
+			{Text: "Bake at 230 °C until golden.", Duration: 45, Temperature: 230, TemperatureUnit: "C"},
+		},
+		RatingAverage: 4.5,
+		RatingCount:   12,
+		Nutrition:     &models.NutritionInfo{Calories: 220, Protein: 7.5, Carbs: 45, Fat: 1.2, Sodium: 450},
+		CreatedAt:     created,
+		UpdatedAt:     created.Add(time.Hour),
+	}
+}
+
+func TestFromRecipe(t *testing.T) {
+	doc := FromRecipe(bread(), "https://recipes.example.com")
+
+	if doc.Context != "https://schema.org" || doc.Type != "Recipe" || doc.Name != "Country Bread" {
+		t.Errorf("FromRecipe() = %+v", doc)
+	}
+	if doc.URL != "https://recipes.example.com/recipes/b7f5c9e2-1d2a-4c3b-9a8e-2f1d0c9b8a7e" {
+		t.Errorf("url = %q", doc.URL)
+	}
+	wantImages := []string{
+		"https://recipes.example.com/media/recipes/b7f5/9d1e/original.jpg",
+		"https://recipes.example.com/media/recipes/b7f5/9d1e/detail.jpg",
+		"https://recipes.example.com/media/recipes/b7f5/9d1e/card.jpg",
+	}
+	if !reflect.DeepEqual(doc.Image, wantImages) {
+		t.Errorf("image = %v, want %v", doc.Image, wantImages)
+	}
+	if doc.PrepTime != "PT30M" || doc.CookTime != "PT45M" || doc.TotalTime != "PT1H15M" || doc.RecipeYield != "8" {
+		t.Errorf("times and yield = %q, %q, %q, %q", doc.PrepTime, doc.CookTime, doc.TotalTime, doc.RecipeYield)
+	}
+	wantIngredients := []string{"500 g bread flour", "1 1/2 tsp salt, fine", "350 ml water, lukewarm", "olive oil"}
+	if !reflect.DeepEqual(doc.RecipeIngredient, wantIngredients) {
+		t.Errorf("recipeIngredient = %q, want %q", doc.RecipeIngredient, wantIngredients)
+	}
+	if step := doc.RecipeInstructions[1]; step.Position != 2 || step.TimeRequired != "PT2H" || len(step.Image) != 1 {
+		t.Errorf("second step = %+v", step)
+	}
+	if doc.Nutrition == nil || doc.Nutrition.Calories != "220 calories" || doc.Nutrition.SodiumContent != "450 mg" {
+		t.Errorf("nutrition = %+v", doc.Nutrition)
+	}
+	if doc.AggregateRating == nil || doc.AggregateRating.RatingValue != 4.5 || doc.AggregateRating.RatingCount != 12 {
+		t.Errorf("aggregateRating = %+v", doc.AggregateRating)
+	}
+	if doc.DatePublished != "2024-03-01T09:30:00Z" || doc.DateModified != "2024-03-01T10:30:00Z" {
+		t.Errorf("dates = %q, %q", doc.DatePublished, doc.DateModified)
+	}
+
+	empty := FromRecipe(&models.Recipe{Title: "Water"}, "")
+	data, err := json.Marshal(empty)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"@context":"https://schema.org","@type":"Recipe","name":"Water","recipeIngredient":[],"recipeInstructions":[]}`
+	if string(data) != want {
+		t.Errorf("Marshal(FromRecipe(empty)) = %s, want %s", data, want)
+	}
+}
+
+// TestFromRecipe_RoundTrip reads the document back with the importer,
+// which must recover every field schema.org can carry.
+func TestFromRecipe_RoundTrip(t *testing.T) {
+	const base = "https://recipes.example.com"
+	recipe := bread()
+
+	data, err := json.Marshal(FromRecipe(recipe, base))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	page := `<html><head><script type="application/ld+json">` + string(data) + `</script></head></html>`
+	got, err := importer.Parse([]byte(page), base+"/recipes/"+recipe.ID)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := &models.Recipe{
+		Title:        recipe.Title,
+		Description:  recipe.Description,
+		PrepTime:     recipe.PrepTime,
+		CookTime:     recipe.CookTime,
+		Servings:     recipe.Servings,
+		Category:     recipe.Category,
+		Cuisine:      recipe.Cuisine,
+		Tags:         recipe.Tags,
+		ImageURL:     base + recipe.ImageURL,
+		Ingredients:  recipe.Ingredients,
+		Instructions: recipe.Instructions,
+	}
+	want.Instructions[1].Images = []string{base + want.Instructions[1].Images[0]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(FromRecipe()) =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,319 @@
+// Package shopping builds shopping lists from recipes, adding up the
+// ingredients they share, and writes them out as plain text or Markdown.
+package shopping
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/quantity"
+	"recipe-app/internal/units"
+)
+
+// Aisles group a shopping list's items, listed in the order a shop is
+// walked.
+const (
+	Produce = "produce"
+	Meat    = "meat & seafood"
+	Dairy   = "dairy & eggs"
+	Bakery  = "bakery"
+	Pantry  = "pantry"
+	Spices  = "spices & seasonings"
+	Frozen  = "frozen"
+	Other   = "other"
+)
+
+// Aisles lists every aisle in order.
+var Aisles = []string{Produce, Meat, Dairy, Bakery, Pantry, Spices, Frozen, Other}
+
+//go:embed aisles.json
+var aislesJSON []byte
+
+// aisles indexes the bundled aisle table by normalized ingredient name.
+var aisles = func() map[string]string {
+	var list []struct {
+		Aisle string   `json:"aisle"`
+		Names []string `json:"names"`
+	}
+	if err := json.Unmarshal(aislesJSON, &list); err != nil {
+		panic("shopping: invalid aisles.json: " + err.Error())
+	}
+	index := make(map[string]string)
+	for _, group := range list {
+		if aisleIndex(group.Aisle) < 0 {
+			panic("shopping: unknown aisle in aisles.json: " + group.Aisle)
+		}
+		for _, name := range group.Names {
+			index[models.NormalizeIngredientName(name)] = group.Aisle
+		}
+	}
+	return index
+}()
+
+// Aisle returns the aisle an ingredient is found in, or Other. Names match
+// case-insensitively, in singular or plural, and by their longest known
+// phrase, so "cherry tomatoes" is produce. Of equally long phrases the last
+// wins, as in "chicken stock".
+func Aisle(name string) string {
+	words := strings.Fields(models.NormalizeIngredientName(name))
+	for size := len(words); size > 0; size-- {
+		for start := len(words) - size; start >= 0; start-- {
+			if aisle, ok := aisles[strings.Join(words[start:start+size], " ")]; ok {
+				return aisle
+			}
+		}
+	}
+	return Other
+}
+
+// AisleLabel returns an aisle's heading, such as "Meat & Seafood".
+func AisleLabel(aisle string) string {
+	words := strings.Fields(aisle)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func aisleIndex(aisle string) int {
+	for i, a := range Aisles {
+		if a == aisle {
+			return i
+		}
+	}
+	return -1
+}
+
+// Source is a recipe to shop for. Servings overrides the recipe's own
+// servings when above 0.
+type Source struct {
+	Recipe   *models.Recipe
+	Servings int
+}
+
+// item adds up the ingredients that share a name and a compatible unit.
+type item struct {
+	name  string
+	aisle string
+	// best is the largest amount name was written with, which is the most
+	// likely to be in the plural the total calls for.
+	best float64
+
+	// Known measuring units are added up in grams or millilitres and
+	// written in the largest unit any recipe used.
+	unit    *units.Unit
+	measure string
+	// singular and plural are the spellings of any other unit.
+	singular, plural string
+
+	// counted items have a numeric amount; others keep text as written.
+	counted  bool
+	min, max float64
+	text     string
+}
+
+// Build returns the ingredients of every source, scaled to its servings,
+// as shopping list items. Ingredients with the same name, compared in
+// the singular and ignoring case, or linked to the same catalog entry,
+// are added together when their units are compatible: any two masses,
+// any two volumes, or the same other unit.
+// Amounts that are not numbers, such as "to taste", are listed once per
+// wording. Items are ordered by aisle and then name, numbered from 1.
+func Build(sources []Source) []models.ShoppingListItem {
+	items := make(map[string]*item)
+	var order []string
+	for _, src := range sources {
+		factor := 1.0
+		if src.Servings > 0 && src.Recipe.Servings > 0 {
+			factor = float64(src.Servings) / float64(src.Recipe.Servings)
+		}
+		for _, ing := range src.Recipe.Ingredients {
+			name := strings.TrimSpace(ing.Name)
+			if name == "" {
+				continue
+			}
+			next := newItem(name, ing.Amount, ing.Unit, factor)
+			key := models.NormalizeIngredientName(name) + "|" + next.measure
+			if ing.CatalogName != "" {
+				key = models.NormalizeIngredientName(ing.CatalogName) + "|" + next.measure
+			}
+			if existing, ok := items[key]; ok {
+				existing.add(next)
+				continue
+			}
+			items[key] = next
+			order = append(order, key)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := items[order[i]], items[order[j]]
+		if ai, bi := aisleIndex(a.aisle), aisleIndex(b.aisle); ai != bi {
+			return ai < bi
+		}
+		return order[i] < order[j]
+	})
+
+	list := make([]models.ShoppingListItem, len(order))
+	for i, key := range order {
+		list[i] = items[key].listItem()
+		list[i].Position = i + 1
+	}
+	return list
+}
+
+func newItem(name, amount, unit string, factor float64) *item {
+	it := &item{name: name, aisle: Aisle(name), text: strings.TrimSpace(amount)}
+	q, err := quantity.Parse(amount)
+	it.counted = err == nil
+	if it.counted {
+		q = q.Scale(factor)
+		it.min, it.max, it.best = q.Min, q.Max, q.Max
+		it.text = ""
+	}
+
+	if u, ok := units.Lookup(unit); ok && it.counted {
+		it.unit = &u
+		it.min *= u.Factor
+		it.max *= u.Factor
+		it.measure = "volume"
+		if u.Kind == units.Mass {
+			it.measure = "mass"
+		}
+		return it
+	}
+
+	unit = strings.TrimSpace(unit)
+	if it.best > 1 {
+		it.plural = unit
+	} else {
+		it.singular = unit
+	}
+	it.measure = "unit:" + models.NormalizeIngredientName(unit)
+	if !it.counted {
+		it.measure = "text:" + strings.ToLower(it.text) + ":" + models.NormalizeIngredientName(unit)
+	}
+	return it
+}
+
+func (it *item) add(other *item) {
+	it.min += other.min
+	it.max += other.max
+	if other.best > it.best {
+		it.best = other.best
+		it.name = other.name
+	}
+	if it.unit != nil && other.unit.Factor > it.unit.Factor {
+		it.unit = other.unit
+	}
+	if it.singular == "" {
+		it.singular = other.singular
+	}
+	if it.plural == "" {
+		it.plural = other.plural
+	}
+}
+
+func (it *item) listItem() models.ShoppingListItem {
+	out := models.ShoppingListItem{Name: it.name, Aisle: it.aisle, Amount: it.text}
+	if !it.counted {
+		out.Unit = it.singular
+		return out
+	}
+
+	min, max := it.min, it.max
+	format := quantity.Format
+	if it.unit != nil {
+		min /= it.unit.Factor
+		max /= it.unit.Factor
+		if it.unit.System == units.Metric {
+			format = quantity.FormatDecimal
+		}
+		out.Unit = it.unit.Label(max)
+	} else {
+		out.Unit = it.singular
+		if out.Unit == "" || (max > 1 && it.plural != "") {
+			out.Unit = it.plural
+		}
+	}
+
+	out.Amount = format(min)
+	if text := format(max); text != out.Amount {
+		out.Amount += "-" + text
+	}
+	return out
+}
+
+// WriteText writes the list as plain text, one "[ ]" or "[x]" line per
+// item under each aisle's heading.
+func WriteText(w io.Writer, list *models.ShoppingList) error {
+	var b strings.Builder
+	b.WriteString(list.Name + "\n")
+	for _, group := range Group(list.Items) {
+		fmt.Fprintf(&b, "\n%s\n", group.Label)
+		for _, it := range group.Items {
+			fmt.Fprintf(&b, "[%s] %s\n", checkMark(it.Checked), it.Line())
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the list as a Markdown task list with a section per
+// aisle.
+func WriteMarkdown(w io.Writer, list *models.ShoppingList) error {
+	var b strings.Builder
+	b.WriteString("# " + escapeMarkdown(list.Name) + "\n")
+	for _, group := range Group(list.Items) {
+		fmt.Fprintf(&b, "\n## %s\n\n", group.Label)
+		for _, it := range group.Items {
+			fmt.Fprintf(&b, "- [%s] %s\n", checkMark(it.Checked), escapeMarkdown(it.Line()))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// AisleGroup is the items of one aisle.
+type AisleGroup struct {
+	Aisle string
+	Label string
+	Items []models.ShoppingListItem
+}
+
+// Group splits items, ordered by aisle, into one group per aisle.
+func Group(items []models.ShoppingListItem) []AisleGroup {
+	groups := []AisleGroup{}
+	for _, it := range items {
+		aisle := it.Aisle
+		if aisle == "" {
+			aisle = Other
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Aisle != aisle {
+			groups = append(groups, AisleGroup{Aisle: aisle, Label: AisleLabel(aisle)})
+		}
+		last := &groups[len(groups)-1]
+		last.Items = append(last.Items, it)
+	}
+	return groups
+}
+
+func checkMark(checked bool) string {
+	if checked {
+		return "x"
+	}
+	return " "
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
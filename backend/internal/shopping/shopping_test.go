@@ -0,0 +1,145 @@
+package shopping
+
+import (
+	"strings"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+func TestAisle(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Onions", Produce},
+		{"cherry tomatoes", Produce},
+		{"red bell pepper", Produce},
+		{"garlic cloves", Produce},
+		{"chicken stock", Pantry},
+		{"boneless chicken thighs", Meat},
+		{"Eggs", Dairy},
+		{"freshly ground black pepper", Spices},
+		{"bay leaves", Spices},
+		{"extra virgin olive oil", Pantry},
+		{"dragon fruit", Other},
+		{"", Other},
+	}
+
+	for _, tt := range tests {
+		if got := Aisle(tt.name); got != tt.want {
+			t.Errorf("Aisle(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	pasta := &models.Recipe{
+		Servings: 2,
+		Ingredients: []models.Ingredient{
+			{Name: "spaghetti", Amount: "200", Unit: "g"},
+			{Name: "Tomatoes", Amount: "3"},
+			{Name: "olive oil", Amount: "2", Unit: "tbsp"},
+			{Name: "garlic", Amount: "2", Unit: "cloves"},
+			{Name: "salt", Amount: "to taste"},
+		},
+	}
+	salad := &models.Recipe{
+		Servings: 4,
+		Ingredients: []models.Ingredient{
+			{Name: "tomato", Amount: "1"},
+			{Name: "Olive Oil", Amount: "1/4", Unit: "cup"},
+			{Name: "garlic", Amount: "1", Unit: "clove"},
+			{Name: "spaghetti", Amount: "1", Unit: "kg"},
+			{Name: "salt", Amount: "to taste"},
+			{Name: "salt", Amount: "1", Unit: "pinch"},
+			{Name: "feta", Amount: "1-2", Unit: "cups"},
+			{Name: "  "},
+		},
+	}
+
+	got := Build([]Source{
+		{Recipe: pasta, Servings: 4}, // doubled
+		{Recipe: salad},
+	})
+
+	want := []string{
+		"produce: 5 cloves garlic",
+		"produce: 7 Tomatoes",
+		"dairy & eggs: 1-2 cups feta",
+		"pantry: 1/2 cup olive oil",
+		"pantry: 1.4 kg spaghetti",
+		"spices & seasonings: to taste salt",
+		"spices & seasonings: 1 pinch salt",
+	}
+	var lines []string
+	for i, it := range got {
+		if it.Position != i+1 {
+			t.Errorf("item %d has position %d", i, it.Position)
+		}
+		lines = append(lines, it.Aisle+": "+it.Line())
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Build() =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestBuild_IncompatibleUnits(t *testing.T) {
+	recipe := &models.Recipe{
+		Ingredients: []models.Ingredient{
+			{Name: "flour", Amount: "2", Unit: "cups"},
+			{Name: "flour", Amount: "100", Unit: "g"},
+			{Name: "butter", Amount: "2", Unit: "tbsp"},
+			{Name: "butter", Amount: "1", Unit: "stick"},
+		},
+	}
+
+	got := Build([]Source{{Recipe: recipe}})
+	if len(got) != 4 {
+		t.Fatalf("Build() = %+v, want the units kept apart", got)
+	}
+}
+
+func TestBuild_CatalogLinks(t *testing.T) {
+	recipe := &models.Recipe{
+		Ingredients: []models.Ingredient{
+			{Name: "scallions", Amount: "2", CatalogName: "green onion"},
+			{Name: "green onions", Amount: "4", CatalogName: "green onion"},
+			{Name: "spring onion", Amount: "1"},
+		},
+	}
+
+	got := Build([]Source{{Recipe: recipe}})
+	if len(got) != 2 || got[0].Name != "green onions" || got[0].Amount != "6" || got[1].Name != "spring onion" {
+		t.Errorf("Build() = %+v, want the linked ingredients added together", got)
+	}
+}
+
+func TestWriteTextAndMarkdown(t *testing.T) {
+	list := &models.ShoppingList{
+		Name: "Week *10*",
+		Items: []models.ShoppingListItem{
+			{Name: "onions", Amount: "2", Aisle: Produce, Checked: true},
+			{Name: "chicken_thighs", Amount: "500", Unit: "g", Aisle: Meat},
+			{Name: "kitchen roll", Aisle: ""},
+		},
+	}
+
+	var text strings.Builder
+	if err := WriteText(&text, list); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	wantText := "Week *10*\n\nProduce\n[x] 2 onions\n\nMeat & Seafood\n[ ] 500 g chicken_thighs\n\nOther\n[ ] kitchen roll\n"
+	if text.String() != wantText {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", text.String(), wantText)
+	}
+
+	var md strings.Builder
+	if err := WriteMarkdown(&md, list); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	wantMarkdown := "# Week \\*10\\*\n\n## Produce\n\n- [x] 2 onions\n\n## Meat & Seafood\n\n- [ ] 500 g chicken\\_thighs\n\n## Other\n\n- [ ] kitchen roll\n"
+	if md.String() != wantMarkdown {
+		t.Errorf("WriteMarkdown() =\n%s\nwant\n%s", md.String(), wantMarkdown)
+	}
+}
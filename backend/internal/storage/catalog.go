@@ -0,0 +1,534 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+// ingredientColumns selects an ingredient i with the name of its catalog
+// entry c, in the order scanIngredient reads them.
+const ingredientColumns = `i.id, i.recipe_id, i.name, COALESCE(i.amount, ''), COALESCE(i.unit, ''), COALESCE(i.notes, ''),
+	i.position, COALESCE(i.catalog_id::text, ''), COALESCE(c.name, ''), COALESCE(i.catalog_match, '')`
+
+const catalogColumns = "id, name, plural, category, synonyms, allergens, diets, allergens_reviewed, created_at, updated_at"
+
+func (db *DB) ListCatalog(ctx context.Context, search string) ([]models.CatalogIngredient, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+catalogColumns+` FROM ingredient_catalog c
+		WHERE $1 = '' OR EXISTS (
+			SELECT 1 FROM ingredient_catalog_terms t WHERE t.catalog_id = c.id AND strpos(t.term, $1) > 0)
+		ORDER BY name COLLATE "C", id`,
+		models.NormalizeIngredientName(search))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query catalog: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.CatalogIngredient{}
+	for rows.Next() {
+		entry, err := scanCatalogIngredient(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	return entries, nil
+}
+
+func (db *DB) GetCatalogIngredient(ctx context.Context, id string) (*models.CatalogIngredient, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	entry, err := scanCatalogIngredient(db.QueryRowContext(ctx,
+		"SELECT "+catalogColumns+" FROM ingredient_catalog WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (db *DB) CreateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error {
+	prepareCatalogIngredient(ingredient)
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO ingredient_catalog (name, plural, category, synonyms, allergens, diets, allergens_reviewed)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at`,
+			ingredient.Name, ingredient.Plural, ingredient.Category, pq.Array(ingredient.Synonyms),
+			pq.Array(ingredient.Allergens), pq.Array(ingredient.Diets), ingredient.AllergensReviewed,
+		).Scan(&ingredient.ID, &ingredient.CreatedAt, &ingredient.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert catalog ingredient: %w", err)
+		}
+		if err := insertCatalogTerms(ctx, tx, ingredient); err != nil {
+			return err
+		}
+		_, err = matchIngredients(ctx, tx, "i.name_phrases && $1::text[]", pq.Array(ingredient.Terms()))
+		return err
+	})
+}
+
+func (db *DB) UpdateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error {
+	if uuid.Validate(ingredient.ID) != nil {
+		return ErrNotFound
+	}
+
+	prepareCatalogIngredient(ingredient)
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE ingredient_catalog
+			SET name = $2, plural = $3, category = $4, synonyms = $5, allergens = $6, diets = $7,
+				allergens_reviewed = $8, updated_at = NOW()
+			WHERE id = $1
+			RETURNING created_at, updated_at`,
+			ingredient.ID, ingredient.Name, ingredient.Plural, ingredient.Category, pq.Array(ingredient.Synonyms),
+			pq.Array(ingredient.Allergens), pq.Array(ingredient.Diets), ingredient.AllergensReviewed,
+		).Scan(&ingredient.CreatedAt, &ingredient.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update catalog ingredient: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM ingredient_catalog_terms WHERE catalog_id = $1", ingredient.ID); err != nil {
+			return fmt.Errorf("failed to clear catalog terms: %w", err)
+		}
+		if err := insertCatalogTerms(ctx, tx, ingredient); err != nil {
+			return err
+		}
+		// Ingredients linked by a dropped term rematch along with those a
+		// new term may now match.
+		if _, err := matchIngredients(ctx, tx, "i.catalog_id = $1 OR i.name_phrases && $2::text[]",
+			ingredient.ID, pq.Array(ingredient.Terms())); err != nil {
+			return err
+		}
+		// The entry's allergens, diets and review may have changed too.
+		return refreshRecipeDietary(ctx, tx,
+			"r.id IN (SELECT recipe_id FROM ingredients WHERE catalog_id = $1)", ingredient.ID)
+	})
+}
+
+func (db *DB) DeleteCatalogIngredient(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		var unlinked []string
+		err := tx.QueryRowContext(ctx, `
+			WITH unlinked AS (
+				UPDATE ingredients SET catalog_id = NULL, catalog_match = NULL
+				WHERE catalog_id = $1
+				RETURNING id
+			)
+			SELECT COALESCE(array_agg(id::text), '{}') FROM unlinked`, id,
+		).Scan(pq.Array(&unlinked))
+		if err != nil {
+			return fmt.Errorf("failed to unlink ingredients: %w", err)
+		}
+
+		res, err := tx.ExecContext(ctx, "DELETE FROM ingredient_catalog WHERE id = $1", id)
+		if err != nil {
+			return fmt.Errorf("failed to delete catalog ingredient: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+
+		if _, err := matchIngredients(ctx, tx, "i.id = ANY($1::uuid[])", pq.Array(unlinked)); err != nil {
+			return err
+		}
+		return refreshRecipeDietary(ctx, tx,
+			"r.id IN (SELECT recipe_id FROM ingredients WHERE id = ANY($1::uuid[]))", pq.Array(unlinked))
+	})
+}
+
+func (db *DB) LinkIngredient(ctx context.Context, ingredientID, catalogID string) (*models.Ingredient, error) {
+	if uuid.Validate(ingredientID) != nil || (catalogID != "" && uuid.Validate(catalogID) != nil) {
+		return nil, ErrNotFound
+	}
+
+	var ing *models.Ingredient
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE ingredients SET catalog_id = NULLIF($2, '')::uuid, catalog_match = 'manual'
+			WHERE id = $1`,
+			ingredientID, catalogID)
+		if err != nil {
+			return referenceError(err, "failed to link ingredient")
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+		if err := refreshIngredientRecipeDietary(ctx, tx, ingredientID); err != nil {
+			return err
+		}
+		ing, err = getIngredient(ctx, tx, ingredientID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ing, nil
+}
+
+func (db *DB) ResetIngredientLink(ctx context.Context, ingredientID string) (*models.Ingredient, error) {
+	if uuid.Validate(ingredientID) != nil {
+		return nil, ErrNotFound
+	}
+
+	var ing *models.Ingredient
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE ingredients SET catalog_id = NULL, catalog_match = NULL WHERE id = $1", ingredientID)
+		if err != nil {
+			return fmt.Errorf("failed to reset ingredient link: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+		if _, err := matchIngredients(ctx, tx, "i.id = $1", ingredientID); err != nil {
+			return err
+		}
+		if err := refreshIngredientRecipeDietary(ctx, tx, ingredientID); err != nil {
+			return err
+		}
+		ing, err = getIngredient(ctx, tx, ingredientID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ing, nil
+}
+
+func (db *DB) RelinkIngredients(ctx context.Context) (int, error) {
+	var n int
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if n, err = matchIngredients(ctx, tx, "TRUE"); err != nil {
+			return err
+		}
+		return refreshRecipeDietary(ctx, tx, "TRUE")
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (db *DB) UnmatchedIngredients(ctx context.Context, limit int) ([]models.IngredientCount, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name, count FROM (
+			SELECT lower(name) AS name, COUNT(*) AS count FROM ingredients
+			WHERE catalog_id IS NULL AND catalog_match IS NULL
+			GROUP BY 1
+		) unmatched
+		ORDER BY count DESC, name COLLATE "C"
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unmatched ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.IngredientCount{}
+	for rows.Next() {
+		var count models.IngredientCount
+		if err := rows.Scan(&count.Name, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan unmatched ingredient: %w", err)
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read unmatched ingredients: %w", err)
+	}
+	return counts, nil
+}
+
+// insertCatalogTerms stores an entry's terms, returning
+// ErrCatalogTermTaken when another entry already has one of them.
+func insertCatalogTerms(ctx context.Context, tx *sql.Tx, ingredient *models.CatalogIngredient) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ingredient_catalog_terms (term, catalog_id)
+		SELECT term, $2 FROM unnest($1::text[]) AS term`,
+		pq.Array(ingredient.Terms()), ingredient.ID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrCatalogTermTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert catalog terms: %w", err)
+	}
+	return nil
+}
+
+// matchIngredients links the ingredients i matching scope that were not
+// linked by hand, picking the term models.MatchCatalogTerm would: of the
+// phrases in the name that are terms, the one with the most words, and of
+// those the last. It refreshes the dietary labels of the recipes whose
+// links changed and returns how many links changed.
+func matchIngredients(ctx context.Context, q queryer, scope string, args ...interface{}) (int, error) {
+	rows, err := q.QueryContext(ctx, `
+		UPDATE ingredients SET
+			catalog_id = m.catalog_id,
+			catalog_match = CASE WHEN m.catalog_id IS NULL THEN NULL ELSE 'auto' END
+		FROM (
+			SELECT i.id, (
+				SELECT t.catalog_id
+				FROM unnest(i.name_phrases) WITH ORDINALITY AS p(phrase, n)
+				JOIN ingredient_catalog_terms t ON t.term = p.phrase
+				ORDER BY cardinality(string_to_array(p.phrase, ' ')) DESC, p.n DESC
+				LIMIT 1
+			) AS catalog_id
+			FROM ingredients i
+			WHERE (`+scope+`) AND i.catalog_match IS DISTINCT FROM 'manual'
+		) m
+		WHERE ingredients.id = m.id AND ingredients.catalog_id IS DISTINCT FROM m.catalog_id
+		RETURNING ingredients.recipe_id`,
+		args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to match ingredients: %w", err)
+	}
+	defer rows.Close()
+
+	n := 0
+	recipeIDs := []string{}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var recipeID string
+		if err := rows.Scan(&recipeID); err != nil {
+			return 0, fmt.Errorf("failed to scan matched ingredient: %w", err)
+		}
+		n++
+		if !seen[recipeID] {
+			seen[recipeID] = true
+			recipeIDs = append(recipeIDs, recipeID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to match ingredients: %w", err)
+	}
+	rows.Close()
+
+	if len(recipeIDs) > 0 {
+		if err := refreshRecipeDietary(ctx, q, "r.id = ANY($1::uuid[])", pq.Array(recipeIDs)); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// refreshRecipeDietary recomputes the dietary labels of the recipes r
+// matching scope from their ingredients' catalog entries, following the
+// rules of models.ComputeDietary.
+func refreshRecipeDietary(ctx context.Context, q queryer, scope string, args ...interface{}) error {
+	allergens, diets := len(args)+1, len(args)+2
+	_, err := q.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE recipes SET diets = d.diets, allergens = d.allergens, dietary_complete = d.complete
+		FROM (
+			SELECT r.id, v.complete,
+				ARRAY(
+					SELECT k.label FROM unnest($%[1]d::text[]) WITH ORDINALITY AS k(label, n)
+					WHERE EXISTS (
+						SELECT 1 FROM ingredients i JOIN ingredient_catalog c ON c.id = i.catalog_id
+						WHERE i.recipe_id = r.id AND k.label = ANY(c.allergens))
+					ORDER BY k.n
+				) AS allergens,
+				ARRAY(
+					SELECT k.label FROM unnest($%[2]d::text[]) WITH ORDINALITY AS k(label, n)
+					WHERE l.linked AND NOT EXISTS (
+						SELECT 1 FROM ingredients i LEFT JOIN ingredient_catalog c ON c.id = i.catalog_id
+						WHERE i.recipe_id = r.id AND (c.id IS NULL OR NOT k.label = ANY(c.diets)))
+					ORDER BY k.n
+				) AS diets
+			FROM recipes r
+			CROSS JOIN LATERAL (
+				SELECT EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id)
+					AND NOT EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id AND i.catalog_id IS NULL)
+					AS linked
+			) l
+			CROSS JOIN LATERAL (
+				SELECT l.linked AND NOT EXISTS (
+					SELECT 1 FROM ingredients i JOIN ingredient_catalog c ON c.id = i.catalog_id
+					WHERE i.recipe_id = r.id AND NOT c.allergens_reviewed)
+					AS complete
+			) v
+			WHERE `+scope+`
+		) d
+		WHERE recipes.id = d.id`, allergens, diets),
+		append(args, pq.Array(models.Allergens), pq.Array(models.Diets))...)
+	if err != nil {
+		return fmt.Errorf("failed to refresh recipe dietary labels: %w", err)
+	}
+	return nil
+}
+
+// refreshIngredientRecipeDietary refreshes the dietary labels of the recipe
+// an ingredient belongs to.
+func refreshIngredientRecipeDietary(ctx context.Context, q queryer, ingredientID string) error {
+	return refreshRecipeDietary(ctx, q, "r.id = (SELECT recipe_id FROM ingredients WHERE id = $1)", ingredientID)
+}
+
+// manualIngredientLinks returns the manual links of a recipe's ingredients
+// keyed by normalized name, which UpdateRecipe carries over to the new
+// ingredients.
+func manualIngredientLinks(ctx context.Context, tx *sql.Tx, recipeID string) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT name, COALESCE(catalog_id::text, '') FROM ingredients
+		WHERE recipe_id = $1 AND catalog_match = 'manual'`, recipeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ingredient links: %w", err)
+	}
+	defer rows.Close()
+
+	links := make(map[string]string)
+	for rows.Next() {
+		var name, catalogID string
+		if err := rows.Scan(&name, &catalogID); err != nil {
+			return nil, fmt.Errorf("failed to scan ingredient link: %w", err)
+		}
+		links[models.NormalizeIngredientName(name)] = catalogID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ingredient links: %w", err)
+	}
+	return links, nil
+}
+
+// linkRecipeIngredients restores the manual links in manual to a recipe's
+// newly inserted ingredients, matches the rest, refreshes the recipe's
+// dietary labels and reads the links and labels back into recipe.
+func linkRecipeIngredients(ctx context.Context, tx *sql.Tx, recipe *models.Recipe, manual map[string]string) error {
+	for _, ing := range recipe.Ingredients {
+		catalogID, ok := manual[models.NormalizeIngredientName(ing.Name)]
+		if !ok {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE ingredients SET catalog_id = NULLIF($2, '')::uuid, catalog_match = 'manual'
+			WHERE id = $1`,
+			ing.ID, catalogID)
+		if err != nil {
+			return fmt.Errorf("failed to link ingredient: %w", err)
+		}
+	}
+	if _, err := matchIngredients(ctx, tx, "i.recipe_id = $1", recipe.ID); err != nil {
+		return err
+	}
+	if err := refreshRecipeDietary(ctx, tx, "r.id = $1", recipe.ID); err != nil {
+		return err
+	}
+	err := tx.QueryRowContext(ctx, "SELECT diets, allergens, dietary_complete FROM recipes WHERE id = $1", recipe.ID).Scan(
+		pq.Array(&recipe.Dietary.Diets), pq.Array(&recipe.Dietary.Allergens), &recipe.Dietary.Complete)
+	if err != nil {
+		return fmt.Errorf("failed to query recipe dietary labels: %w", err)
+	}
+	normalizeDietary(&recipe.Dietary)
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+ingredientColumns+`
+		FROM ingredients i LEFT JOIN ingredient_catalog c ON c.id = i.catalog_id
+		WHERE i.recipe_id = $1`, recipe.ID)
+	if err != nil {
+		return fmt.Errorf("failed to query ingredient links: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]models.Ingredient, len(recipe.Ingredients))
+	for rows.Next() {
+		ing, err := scanIngredient(rows)
+		if err != nil {
+			return err
+		}
+		byID[ing.ID] = ing
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read ingredient links: %w", err)
+	}
+	for i := range recipe.Ingredients {
+		linked := byID[recipe.Ingredients[i].ID]
+		recipe.Ingredients[i].CatalogID = linked.CatalogID
+		recipe.Ingredients[i].CatalogName = linked.CatalogName
+		recipe.Ingredients[i].CatalogMatch = linked.CatalogMatch
+	}
+	return nil
+}
+
+func getIngredient(ctx context.Context, q queryer, id string) (*models.Ingredient, error) {
+	ing, err := scanIngredient(q.QueryRowContext(ctx, `
+		SELECT `+ingredientColumns+`
+		FROM ingredients i LEFT JOIN ingredient_catalog c ON c.id = i.catalog_id
+		WHERE i.id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ing, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanIngredient(row rowScanner) (models.Ingredient, error) {
+	var ing models.Ingredient
+	err := row.Scan(&ing.ID, &ing.RecipeID, &ing.Name, &ing.Amount, &ing.Unit, &ing.Notes,
+		&ing.Position, &ing.CatalogID, &ing.CatalogName, &ing.CatalogMatch)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ing, err
+	}
+	if err != nil {
+		return ing, fmt.Errorf("failed to scan ingredient: %w", err)
+	}
+	return ing, nil
+}
+
+func scanCatalogIngredient(row rowScanner) (models.CatalogIngredient, error) {
+	var entry models.CatalogIngredient
+	err := row.Scan(&entry.ID, &entry.Name, &entry.Plural, &entry.Category, pq.Array(&entry.Synonyms),
+		pq.Array(&entry.Allergens), pq.Array(&entry.Diets), &entry.AllergensReviewed, &entry.CreatedAt, &entry.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entry, err
+	}
+	if err != nil {
+		return entry, fmt.Errorf("failed to scan catalog ingredient: %w", err)
+	}
+	if entry.Synonyms == nil {
+		entry.Synonyms = []string{}
+	}
+	if entry.Allergens == nil {
+		entry.Allergens = []string{}
+	}
+	if entry.Diets == nil {
+		entry.Diets = []string{}
+	}
+	return entry, nil
+}
+
+// normalizeDietary replaces the nil slices an empty array scans into.
+func normalizeDietary(dietary *models.Dietary) {
+	if dietary.Diets == nil {
+		dietary.Diets = []string{}
+	}
+	if dietary.Allergens == nil {
+		dietary.Allergens = []string{}
+	}
+}
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// catalogStore is the subset of Store the catalog suite needs to create
+// the recipes it links.
+type catalogStore interface {
+	RecipeRepository
+	CatalogRepository
+}
+
+// testCatalogRepository is the conformance suite for CatalogRepository
+// implementations and catalog matches in ListRecipes. newRepo must return
+// an empty repository.
+func testCatalogRepository(t *testing.T, newRepo func(t *testing.T) catalogStore) {
+	names := func(entries []models.CatalogIngredient) string {
+		out := make([]string, len(entries))
+		for i, entry := range entries {
+			out[i] = entry.Name
+		}
+		return strings.Join(out, ", ")
+	}
+	create := func(t *testing.T, repo catalogStore, entry models.CatalogIngredient) models.CatalogIngredient {
+		t.Helper()
+		if err := repo.CreateCatalogIngredient(context.Background(), &entry); err != nil {
+			t.Fatalf("CreateCatalogIngredient(%s) error = %v", entry.Name, err)
+		}
+		return entry
+	}
+	newRecipe := func(t *testing.T, repo catalogStore, title string, names ...string) models.Recipe {
+		t.Helper()
+		recipe := models.Recipe{Title: title, Category: "dinner"}
+		for _, name := range names {
+			recipe.Ingredients = append(recipe.Ingredients, models.Ingredient{Name: name, Amount: "1"})
+		}
+		if err := repo.CreateRecipe(context.Background(), &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		return recipe
+	}
+	links := func(t *testing.T, repo catalogStore, id string) string {
+		t.Helper()
+		recipe, err := repo.GetRecipe(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		out := make([]string, len(recipe.Ingredients))
+		for i, ing := range recipe.Ingredients {
+			out[i] = ing.Name + "=" + ing.CatalogName + "/" + ing.CatalogMatch
+		}
+		return strings.Join(out, ", ")
+	}
+
+	t.Run("CRUD", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		onion := create(t, repo, models.CatalogIngredient{
+			Name: " green onion ", Category: " Produce", Synonyms: []string{"scallion", "Green Onions", " "},
+		})
+		if onion.ID == "" || onion.CreatedAt.IsZero() || onion.Name != "green onion" || onion.Category != "produce" {
+			t.Fatalf("CreateCatalogIngredient() = %+v, want trimmed fields", onion)
+		}
+		if strings.Join(onion.Synonyms, ", ") != "scallion" {
+			t.Errorf("synonyms = %q, want blanks and the name's plural dropped", onion.Synonyms)
+		}
+		create(t, repo, models.CatalogIngredient{Name: "bay leaf", Plural: "bay leaves"})
+
+		dup := models.CatalogIngredient{Name: "spring onion", Synonyms: []string{"Scallions"}}
+		if err := repo.CreateCatalogIngredient(ctx, &dup); !errors.Is(err, ErrCatalogTermTaken) {
+			t.Errorf("CreateCatalogIngredient() with a taken synonym error = %v, want ErrCatalogTermTaken", err)
+		}
+
+		all, err := repo.ListCatalog(ctx, "")
+		if err != nil {
+			t.Fatalf("ListCatalog() error = %v", err)
+		}
+		if names(all) != "bay leaf, green onion" {
+			t.Errorf("ListCatalog() = %s, want name order", names(all))
+		}
+		if found, _ := repo.ListCatalog(ctx, "Scall"); names(found) != "green onion" {
+			t.Errorf("ListCatalog(Scall) = %s, want a synonym match", names(found))
+		}
+		if found, _ := repo.ListCatalog(ctx, "leaves"); names(found) != "bay leaf" {
+			t.Errorf("ListCatalog(leaves) = %s, want a plural match", names(found))
+		}
+
+		onion.Synonyms = []string{"spring onion"}
+		onion.Category = "vegetables"
+		if err := repo.UpdateCatalogIngredient(ctx, &onion); err != nil {
+			t.Fatalf("UpdateCatalogIngredient() error = %v", err)
+		}
+		got, err := repo.GetCatalogIngredient(ctx, onion.ID)
+		if err != nil {
+			t.Fatalf("GetCatalogIngredient() error = %v", err)
+		}
+		if got.Category != "vegetables" || strings.Join(got.Synonyms, ", ") != "spring onion" || !got.UpdatedAt.After(got.CreatedAt) {
+			t.Errorf("GetCatalogIngredient() = %+v, want the update", got)
+		}
+		if err := repo.CreateCatalogIngredient(ctx, &models.CatalogIngredient{Name: "scallion"}); err != nil {
+			t.Errorf("CreateCatalogIngredient() with a dropped synonym error = %v", err)
+		}
+
+		missing := models.CatalogIngredient{ID: uuid.NewString(), Name: "leek"}
+		if err := repo.UpdateCatalogIngredient(ctx, &missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateCatalogIngredient() for a missing entry error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteCatalogIngredient(ctx, onion.ID); err != nil {
+			t.Fatalf("DeleteCatalogIngredient() error = %v", err)
+		}
+		if _, err := repo.GetCatalogIngredient(ctx, onion.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetCatalogIngredient() after delete error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteCatalogIngredient(ctx, "not-a-uuid"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteCatalogIngredient() for a bad id error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Linking", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		soup := newRecipe(t, repo, "Soup", "Scallions, sliced", "chicken stock", "salt")
+		if got := links(t, repo, soup.ID); got != "Scallions, sliced=/, chicken stock=/, salt=/" {
+			t.Fatalf("links before the catalog = %s", got)
+		}
+
+		onion := create(t, repo, models.CatalogIngredient{Name: "green onion", Synonyms: []string{"scallion"}})
+		create(t, repo, models.CatalogIngredient{Name: "chicken"})
+		stock := create(t, repo, models.CatalogIngredient{Name: "chicken stock", Synonyms: []string{"chicken broth"}})
+		if got := links(t, repo, soup.ID); got != "Scallions, sliced=green onion/auto, chicken stock=chicken stock/auto, salt=/" {
+			t.Errorf("links after adding to the catalog = %s, want the longest term to win", got)
+		}
+
+		newRecipe(t, repo, "Fries", "potatoes", "Salt")
+		unmatched, err := repo.UnmatchedIngredients(ctx, 10)
+		if err != nil {
+			t.Fatalf("UnmatchedIngredients() error = %v", err)
+		}
+		if len(unmatched) != 2 || unmatched[0] != (models.IngredientCount{Name: "salt", Count: 2}) || unmatched[1].Name != "potatoes" {
+			t.Errorf("UnmatchedIngredients() = %+v, want the most common first", unmatched)
+		}
+
+		recipe, err := repo.GetRecipe(ctx, soup.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		stockID := recipe.Ingredients[1].ID
+		ing, err := repo.LinkIngredient(ctx, stockID, "")
+		if err != nil {
+			t.Fatalf("LinkIngredient() error = %v", err)
+		}
+		if ing.CatalogID != "" || ing.CatalogMatch != models.CatalogMatchManual {
+			t.Errorf("LinkIngredient() = %+v, want it unlinked by hand", ing)
+		}
+		if n, err := repo.RelinkIngredients(ctx); err != nil || n != 0 {
+			t.Errorf("RelinkIngredients() = %d, %v, want nothing to change", n, err)
+		}
+		if _, err := repo.LinkIngredient(ctx, stockID, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("LinkIngredient() to a missing entry error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.LinkIngredient(ctx, uuid.NewString(), onion.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("LinkIngredient() for a missing ingredient error = %v, want ErrNotFound", err)
+		}
+		saltID := recipe.Ingredients[2].ID
+		if ing, err = repo.LinkIngredient(ctx, saltID, onion.ID); err != nil || ing.CatalogName != "green onion" {
+			t.Fatalf("LinkIngredient() = %+v, %v", ing, err)
+		}
+
+		recipe.Ingredients = append(recipe.Ingredients, models.Ingredient{Name: "chicken broth", Amount: "1"})
+		if err := repo.UpdateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		want := "Scallions, sliced=green onion/auto, chicken stock=/manual, salt=green onion/manual, chicken broth=chicken stock/auto"
+		if got := links(t, repo, soup.ID); got != want {
+			t.Errorf("links after UpdateRecipe() = %s, want %s", got, want)
+		}
+		if recipe.Ingredients[3].CatalogName != "chicken stock" {
+			t.Errorf("UpdateRecipe() ingredient = %+v, want its link filled in", recipe.Ingredients[3])
+		}
+
+		ing, err = repo.ResetIngredientLink(ctx, recipe.Ingredients[1].ID)
+		if err != nil {
+			t.Fatalf("ResetIngredientLink() error = %v", err)
+		}
+		if ing.CatalogID != stock.ID || ing.CatalogMatch != models.CatalogMatchAuto {
+			t.Errorf("ResetIngredientLink() = %+v, want it matched again", ing)
+		}
+
+		if err := repo.DeleteCatalogIngredient(ctx, onion.ID); err != nil {
+			t.Fatalf("DeleteCatalogIngredient() error = %v", err)
+		}
+		want = "Scallions, sliced=/, chicken stock=chicken stock/auto, salt=/, chicken broth=chicken stock/auto"
+		if got := links(t, repo, soup.ID); got != want {
+			t.Errorf("links after DeleteCatalogIngredient() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("Dietary", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		labels := func(t *testing.T, id string) string {
+			t.Helper()
+			recipe, err := repo.GetRecipe(ctx, id)
+			if err != nil {
+				t.Fatalf("GetRecipe() error = %v", err)
+			}
+			d := recipe.Dietary
+			return strings.Join(d.Diets, " ") + "/" + strings.Join(d.Allergens, " ") + "/" + map[bool]string{true: "complete", false: "partial"}[d.Complete]
+		}
+		titles := func(t *testing.T, filter models.RecipeFilter) string {
+			t.Helper()
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Filter: filter, Sort: SortTitle})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			out := make([]string, len(result.Recipes))
+			for i, recipe := range result.Recipes {
+				out[i] = recipe.Title
+			}
+			return strings.Join(out, ", ")
+		}
+
+		create(t, repo, models.CatalogIngredient{Name: "flour", Allergens: []string{"gluten"}, Diets: []string{"vegan"}, AllergensReviewed: true})
+		butter := create(t, repo, models.CatalogIngredient{Name: "butter", Allergens: []string{"Dairy"}, Diets: []string{"vegetarian"}, AllergensReviewed: true})
+		if want := []string{"vegetarian", "pescatarian"}; strings.Join(butter.Diets, " ") != strings.Join(want, " ") {
+			t.Errorf("CreateCatalogIngredient() diets = %v, want %v", butter.Diets, want)
+		}
+		bread := newRecipe(t, repo, "Bread", "flour", "water")
+		shortbread := newRecipe(t, repo, "Shortbread", "flour", "butter")
+		if got := labels(t, bread.ID); got != "/gluten/partial" {
+			t.Errorf("Bread labels = %s, want only its linked allergens", got)
+		}
+		if got := labels(t, shortbread.ID); got != "vegetarian pescatarian/gluten dairy/complete" {
+			t.Errorf("Shortbread labels = %s", got)
+		}
+		if d := shortbread.Dietary; !d.Complete || len(d.Allergens) != 2 {
+			t.Errorf("CreateRecipe() dietary = %+v, want the labels filled in", d)
+		}
+
+		water := create(t, repo, models.CatalogIngredient{Name: "water", Diets: []string{"vegan"}})
+		if got := labels(t, bread.ID); got != "vegan vegetarian pescatarian/gluten/partial" {
+			t.Errorf("Bread labels after linking unreviewed water = %s", got)
+		}
+		water.AllergensReviewed = true
+		if err := repo.UpdateCatalogIngredient(ctx, &water); err != nil {
+			t.Fatalf("UpdateCatalogIngredient() error = %v", err)
+		}
+		if got := labels(t, bread.ID); got != "vegan vegetarian pescatarian/gluten/complete" {
+			t.Errorf("Bread labels after reviewing water = %s", got)
+		}
+
+		filters := []struct {
+			filter models.RecipeFilter
+			want   string
+		}{
+			{models.RecipeFilter{Diets: []string{"vegan"}}, "Bread"},
+			{models.RecipeFilter{Diets: []string{"vegetarian"}}, "Bread, Shortbread"},
+			{models.RecipeFilter{ExcludeDiets: []string{"vegan"}}, "Shortbread"},
+			{models.RecipeFilter{Allergens: []string{"dairy"}}, "Shortbread"},
+			{models.RecipeFilter{ExcludeAllergens: []string{"dairy"}}, "Bread"},
+			{models.RecipeFilter{ExcludeAllergens: []string{"gluten"}}, ""},
+		}
+		for _, tt := range filters {
+			if got := titles(t, tt.filter); got != tt.want {
+				t.Errorf("ListRecipes(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		}
+
+		butter.Allergens = nil
+		if err := repo.UpdateCatalogIngredient(ctx, &butter); err != nil {
+			t.Fatalf("UpdateCatalogIngredient() error = %v", err)
+		}
+		if got := labels(t, shortbread.ID); got != "vegetarian pescatarian/gluten/complete" {
+			t.Errorf("Shortbread labels after updating butter = %s", got)
+		}
+
+		recipe, err := repo.GetRecipe(ctx, bread.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if _, err := repo.LinkIngredient(ctx, recipe.Ingredients[1].ID, ""); err != nil {
+			t.Fatalf("LinkIngredient() error = %v", err)
+		}
+		if got := titles(t, models.RecipeFilter{ExcludeAllergens: []string{"dairy"}}); got != "Shortbread" {
+			t.Errorf("ListRecipes(exclude dairy) = %q, want recipes with unlinked ingredients left out", got)
+		}
+
+		if err := repo.DeleteCatalogIngredient(ctx, water.ID); err != nil {
+			t.Fatalf("DeleteCatalogIngredient() error = %v", err)
+		}
+		if err := repo.DeleteCatalogIngredient(ctx, butter.ID); err != nil {
+			t.Fatalf("DeleteCatalogIngredient() error = %v", err)
+		}
+		if got := labels(t, shortbread.ID); got != "/gluten/partial" {
+			t.Errorf("Shortbread labels after deleting butter = %s", got)
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		create(t, repo, models.CatalogIngredient{Name: "green onion", Synonyms: []string{"scallion"}})
+		newRecipe(t, repo, "Fried Rice", "rice", "green onions")
+		newRecipe(t, repo, "Noodles", "noodles", "scallions")
+
+		result, err := repo.ListRecipes(ctx, RecipeQuery{Search: "scallion", Sort: SortTitle})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if len(result.Recipes) != 2 || result.Recipes[0].Title != "Fried Rice" {
+			t.Errorf("ListRecipes(scallion) = %+v, want both recipes", result.Recipes)
+		}
+		facets, err := repo.RecipeFacets(ctx, RecipeQuery{Search: "green onion"})
+		if err != nil {
+			t.Fatalf("RecipeFacets() error = %v", err)
+		}
+		if len(facets.Category) != 1 || facets.Category[0] != (models.FacetCount{Value: "dinner", Count: 2}) {
+			t.Errorf("RecipeFacets(green onion) categories = %+v, want both recipes", facets.Category)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const collectionColumns = `id, user_id, name, COALESCE(description, ''), COALESCE(is_public, FALSE), created_at, updated_at`
+
+func (db *DB) ListCollections(ctx context.Context, userID string, includePrivate bool) ([]models.RecipeCollection, error) {
+	if uuid.Validate(userID) != nil {
+		return []models.RecipeCollection{}, nil
+	}
+
+	stmt := "SELECT " + collectionColumns + " FROM recipe_collections WHERE user_id = $1"
+	if !includePrivate {
+		stmt += " AND is_public"
+	}
+	collections, err := scanCollections(ctx, db, stmt+" ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadCollectionRecipeIDs(ctx, db, collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (db *DB) GetCollection(ctx context.Context, id string) (*models.RecipeCollection, error) {
+	return getCollection(ctx, db, id, "")
+}
+
+func (db *DB) CreateCollection(ctx context.Context, collection *models.RecipeCollection) error {
+	if uuid.Validate(collection.UserID) != nil {
+		return ErrNotFound
+	}
+
+	recipeIDs := []string{}
+	for _, id := range collection.RecipeIDs {
+		if uuid.Validate(id) != nil {
+			return ErrNotFound
+		}
+		if !containsString(recipeIDs, id) {
+			recipeIDs = append(recipeIDs, id)
+		}
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO recipe_collections (user_id, name, description, is_public)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at, updated_at`,
+			collection.UserID, collection.Name, collection.Description, collection.IsPublic,
+		).Scan(&collection.ID, &collection.CreatedAt, &collection.UpdatedAt)
+		if err != nil {
+			return referenceError(err, "failed to insert collection")
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO collection_recipes (collection_id, recipe_id, position)
+			SELECT $1, o.recipe_id, o.position FROM unnest($2::uuid[]) WITH ORDINALITY AS o(recipe_id, position)`,
+			collection.ID, pq.Array(recipeIDs)); err != nil {
+			return referenceError(err, "failed to add recipes to collection")
+		}
+		collection.RecipeIDs = recipeIDs
+		return nil
+	})
+}
+
+func (db *DB) UpdateCollection(ctx context.Context, collection *models.RecipeCollection) error {
+	if uuid.Validate(collection.ID) != nil {
+		return ErrNotFound
+	}
+
+	err := db.QueryRowContext(ctx, `
+		UPDATE recipe_collections
+		SET name = $2, description = $3, is_public = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING user_id, created_at, updated_at`,
+		collection.ID, collection.Name, collection.Description, collection.IsPublic,
+	).Scan(&collection.UserID, &collection.CreatedAt, &collection.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	collections := []models.RecipeCollection{*collection}
+	if err := loadCollectionRecipeIDs(ctx, db, collections); err != nil {
+		return err
+	}
+	collection.RecipeIDs = collections[0].RecipeIDs
+	return nil
+}
+
+func (db *DB) DeleteCollection(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM recipe_collections WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) AddCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error {
+	if uuid.Validate(recipeID) != nil {
+		return ErrNotFound
+	}
+	return db.reorderCollection(ctx, collectionID, func(tx *sql.Tx, ids []string) ([]string, error) {
+		if containsString(ids, recipeID) {
+			return nil, ErrAlreadyInCollection
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO collection_recipes (collection_id, recipe_id, position) VALUES ($1, $2, 0)", collectionID, recipeID)
+		if err != nil {
+			return nil, referenceError(err, "failed to add recipe to collection")
+		}
+		return insertAt(ids, recipeID, position), nil
+	})
+}
+
+func (db *DB) MoveCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error {
+	return db.reorderCollection(ctx, collectionID, func(tx *sql.Tx, ids []string) ([]string, error) {
+		if !containsString(ids, recipeID) {
+			return nil, ErrNotFound
+		}
+		return insertAt(removeString(ids, recipeID), recipeID, position), nil
+	})
+}
+
+func (db *DB) RemoveCollectionRecipe(ctx context.Context, collectionID, recipeID string) error {
+	return db.reorderCollection(ctx, collectionID, func(tx *sql.Tx, ids []string) ([]string, error) {
+		if !containsString(ids, recipeID) {
+			return nil, ErrNotFound
+		}
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM collection_recipes WHERE collection_id = $1 AND recipe_id = $2", collectionID, recipeID); err != nil {
+			return nil, fmt.Errorf("failed to remove recipe from collection: %w", err)
+		}
+		return removeString(ids, recipeID), nil
+	})
+}
+
+// reorderCollection locks a collection, lets change rewrite its ordered
+// recipe IDs and renumbers the stored positions to match.
+func (db *DB) reorderCollection(ctx context.Context, id string, change func(tx *sql.Tx, ids []string) ([]string, error)) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		collection, err := getCollection(ctx, tx, id, " FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		ids, err := change(tx, collection.RecipeIDs)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE collection_recipes c SET position = o.position
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS o(recipe_id, position)
+			WHERE c.collection_id = $1 AND c.recipe_id = o.recipe_id`, id, pq.Array(ids)); err != nil {
+			return fmt.Errorf("failed to renumber collection: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE recipe_collections SET updated_at = NOW() WHERE id = $1", id); err != nil {
+			return fmt.Errorf("failed to touch collection: %w", err)
+		}
+		return nil
+	})
+}
+
+func getCollection(ctx context.Context, q queryer, id string, lock string) (*models.RecipeCollection, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	collections, err := scanCollections(ctx, q, "SELECT "+collectionColumns+" FROM recipe_collections WHERE id = $1"+lock, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 {
+		return nil, ErrNotFound
+	}
+	if err := loadCollectionRecipeIDs(ctx, q, collections); err != nil {
+		return nil, err
+	}
+	return &collections[0], nil
+}
+
+func scanCollections(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.RecipeCollection, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collections: %w", err)
+	}
+	defer rows.Close()
+
+	collections := []models.RecipeCollection{}
+	for rows.Next() {
+		var c models.RecipeCollection
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.Description, &c.IsPublic, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collection: %w", err)
+		}
+		collections = append(collections, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read collections: %w", err)
+	}
+	return collections, nil
+}
+
+// loadCollectionRecipeIDs fills RecipeIDs for all collections in order.
+func loadCollectionRecipeIDs(ctx context.Context, q queryer, collections []models.RecipeCollection) error {
+	if len(collections) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(collections))
+	byID := make(map[string]*models.RecipeCollection, len(collections))
+	for i := range collections {
+		ids[i] = collections[i].ID
+		byID[collections[i].ID] = &collections[i]
+		collections[i].RecipeIDs = []string{}
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT collection_id, recipe_id FROM collection_recipes
+		WHERE collection_id = ANY($1::uuid[]) ORDER BY position, recipe_id`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query collection recipes: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID, recipeID string
+		if err := rows.Scan(&collectionID, &recipeID); err != nil {
+			return fmt.Errorf("failed to scan collection recipe: %w", err)
+		}
+		collection := byID[collectionID]
+		collection.RecipeIDs = append(collection.RecipeIDs, recipeID)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read collection recipes: %w", err)
+	}
+	return nil
+}
+
+// referenceError maps foreign key violations, from a missing owner or
+// recipe, to ErrNotFound.
+func referenceError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrNotFound
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// insertAt returns ids with id inserted at the 1-based position, appending
+// it when position is 0 or past the end.
+func insertAt(ids []string, id string, position int) []string {
+	i := position - 1
+	if position < 1 || i > len(ids) {
+		i = len(ids)
+	}
+	out := make([]string, 0, len(ids)+1)
+	out = append(out, ids[:i]...)
+	out = append(out, id)
+	return append(out, ids[i:]...)
+}
+
+func removeString(values []string, value string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
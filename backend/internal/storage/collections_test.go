@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// collectionStore is the subset of Store the collection suite needs to
+// create owners and recipes.
+type collectionStore interface {
+	RecipeRepository
+	UserRepository
+	CollectionRepository
+}
+
+// testCollectionRepository is the conformance suite for
+// CollectionRepository implementations. newRepo must return an empty
+// repository.
+func testCollectionRepository(t *testing.T, newRepo func(t *testing.T) collectionStore) {
+	setup := func(t *testing.T) (collectionStore, *models.RecipeCollection, []string) {
+		t.Helper()
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		collection := models.RecipeCollection{UserID: user.ID, Name: "Weeknight", Description: "Quick dinners"}
+		if err := repo.CreateCollection(ctx, &collection); err != nil {
+			t.Fatalf("CreateCollection() error = %v", err)
+		}
+
+		var ids []string
+		for _, title := range []string{"A", "B", "C"} {
+			recipe := models.Recipe{Title: title}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+			ids = append(ids, recipe.ID)
+		}
+		return repo, &collection, ids
+	}
+
+	// order returns the collection's recipes as the titles A, B and C.
+	order := func(t *testing.T, repo collectionStore, id string, recipeIDs []string) string {
+		t.Helper()
+		got, err := repo.GetCollection(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetCollection() error = %v", err)
+		}
+		titles := make([]string, len(got.RecipeIDs))
+		for i, recipeID := range got.RecipeIDs {
+			for j, known := range recipeIDs {
+				if recipeID == known {
+					titles[i] = string(rune('A' + j))
+				}
+			}
+		}
+		return strings.Join(titles, "")
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo, collection, _ := setup(t)
+
+		if collection.ID == "" || collection.CreatedAt.IsZero() {
+			t.Fatalf("CreateCollection() = %+v, want an ID and timestamps", collection)
+		}
+		got, err := repo.GetCollection(context.Background(), collection.ID)
+		if err != nil {
+			t.Fatalf("GetCollection() error = %v", err)
+		}
+		if got.Name != "Weeknight" || got.Description != "Quick dinners" || got.UserID != collection.UserID || got.IsPublic {
+			t.Errorf("GetCollection() = %+v", got)
+		}
+		if got.RecipeIDs == nil || len(got.RecipeIDs) != 0 {
+			t.Errorf("RecipeIDs = %v, want empty", got.RecipeIDs)
+		}
+
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			if _, err := repo.GetCollection(context.Background(), id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetCollection(%q) error = %v, want ErrNotFound", id, err)
+			}
+		}
+		missingOwner := models.RecipeCollection{UserID: uuid.NewString(), Name: "Orphan"}
+		if err := repo.CreateCollection(context.Background(), &missingOwner); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CreateCollection() for a missing user error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CreateWithRecipes", func(t *testing.T) {
+		repo, collection, ids := setup(t)
+		ctx := context.Background()
+
+		withRecipes := models.RecipeCollection{UserID: collection.UserID, Name: "Baking", RecipeIDs: []string{ids[2], ids[0], ids[2]}}
+		if err := repo.CreateCollection(ctx, &withRecipes); err != nil {
+			t.Fatalf("CreateCollection() error = %v", err)
+		}
+		if got := order(t, repo, withRecipes.ID, ids); got != "CA" {
+			t.Errorf("order = %s, want CA", got)
+		}
+
+		for _, missing := range []string{uuid.NewString(), "not-a-uuid"} {
+			orphan := models.RecipeCollection{UserID: collection.UserID, Name: "Orphan", RecipeIDs: []string{ids[0], missing}}
+			if err := repo.CreateCollection(ctx, &orphan); !errors.Is(err, ErrNotFound) {
+				t.Errorf("CreateCollection() with recipe %q error = %v, want ErrNotFound", missing, err)
+			}
+		}
+		list, err := repo.ListCollections(ctx, collection.UserID, true)
+		if err != nil {
+			t.Fatalf("ListCollections() error = %v", err)
+		}
+		if len(list) != 2 {
+			t.Errorf("ListCollections() = %d collections, want the failed creates left unsaved", len(list))
+		}
+	})
+
+	t.Run("UpdateAndList", func(t *testing.T) {
+		repo, collection, _ := setup(t)
+		ctx := context.Background()
+
+		public := models.RecipeCollection{UserID: collection.UserID, Name: "Party", IsPublic: true}
+		if err := repo.CreateCollection(ctx, &public); err != nil {
+			t.Fatalf("CreateCollection() error = %v", err)
+		}
+
+		update := models.RecipeCollection{ID: collection.ID, Name: "Weeknights", IsPublic: false}
+		if err := repo.UpdateCollection(ctx, &update); err != nil {
+			t.Fatalf("UpdateCollection() error = %v", err)
+		}
+		if update.UserID != collection.UserID || !update.CreatedAt.Equal(collection.CreatedAt) {
+			t.Errorf("UpdateCollection() = %+v, want owner and creation time kept", update)
+		}
+
+		all, err := repo.ListCollections(ctx, collection.UserID, true)
+		if err != nil {
+			t.Fatalf("ListCollections() error = %v", err)
+		}
+		if len(all) != 2 || all[0].Name != "Weeknights" || all[1].Name != "Party" {
+			t.Errorf("ListCollections() = %+v, want Weeknights then Party", all)
+		}
+		publicOnly, err := repo.ListCollections(ctx, collection.UserID, false)
+		if err != nil {
+			t.Fatalf("ListCollections() error = %v", err)
+		}
+		if len(publicOnly) != 1 || publicOnly[0].ID != public.ID {
+			t.Errorf("ListCollections() public = %+v, want only Party", publicOnly)
+		}
+
+		missing := models.RecipeCollection{ID: uuid.NewString(), Name: "Ghost"}
+		if err := repo.UpdateCollection(ctx, &missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateCollection() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("AddMoveRemove", func(t *testing.T) {
+		repo, collection, ids := setup(t)
+		ctx := context.Background()
+
+		steps := []struct {
+			name string
+			do   func() error
+			want string
+		}{
+			{"Append", func() error { return repo.AddCollectionRecipe(ctx, collection.ID, ids[0], 0) }, "A"},
+			{"Append past end", func() error { return repo.AddCollectionRecipe(ctx, collection.ID, ids[1], 9) }, "AB"},
+			{"Insert first", func() error { return repo.AddCollectionRecipe(ctx, collection.ID, ids[2], 1) }, "CAB"},
+			{"Move last", func() error { return repo.MoveCollectionRecipe(ctx, collection.ID, ids[2], 3) }, "ABC"},
+			{"Move middle", func() error { return repo.MoveCollectionRecipe(ctx, collection.ID, ids[0], 2) }, "BAC"},
+			{"Remove", func() error { return repo.RemoveCollectionRecipe(ctx, collection.ID, ids[0]) }, "BC"},
+		}
+		for _, step := range steps {
+			if err := step.do(); err != nil {
+				t.Fatalf("%s: error = %v", step.name, err)
+			}
+			if got := order(t, repo, collection.ID, ids); got != step.want {
+				t.Fatalf("%s: order = %s, want %s", step.name, got, step.want)
+			}
+		}
+
+		if err := repo.AddCollectionRecipe(ctx, collection.ID, ids[1], 0); !errors.Is(err, ErrAlreadyInCollection) {
+			t.Errorf("AddCollectionRecipe() duplicate error = %v, want ErrAlreadyInCollection", err)
+		}
+		if err := repo.AddCollectionRecipe(ctx, collection.ID, uuid.NewString(), 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddCollectionRecipe() missing recipe error = %v, want ErrNotFound", err)
+		}
+		if err := repo.AddCollectionRecipe(ctx, uuid.NewString(), ids[0], 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddCollectionRecipe() missing collection error = %v, want ErrNotFound", err)
+		}
+		if err := repo.MoveCollectionRecipe(ctx, collection.ID, ids[0], 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("MoveCollectionRecipe() absent recipe error = %v, want ErrNotFound", err)
+		}
+		if err := repo.RemoveCollectionRecipe(ctx, collection.ID, ids[0]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RemoveCollectionRecipe() absent recipe error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DeletingRecipeRemovesIt", func(t *testing.T) {
+		repo, collection, ids := setup(t)
+		ctx := context.Background()
+
+		for _, id := range ids {
+			if err := repo.AddCollectionRecipe(ctx, collection.ID, id, 0); err != nil {
+				t.Fatalf("AddCollectionRecipe() error = %v", err)
+			}
+		}
+		if err := repo.DeleteRecipe(ctx, ids[1]); err != nil {
+			t.Fatalf("DeleteRecipe() error = %v", err)
+		}
+		if got := order(t, repo, collection.ID, ids); got != "AC" {
+			t.Errorf("order = %s, want AC", got)
+		}
+		if err := repo.AddCollectionRecipe(ctx, collection.ID, ids[1], 0); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddCollectionRecipe() deleted recipe error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, collection, _ := setup(t)
+		ctx := context.Background()
+
+		if err := repo.DeleteCollection(ctx, collection.ID); err != nil {
+			t.Fatalf("DeleteCollection() error = %v", err)
+		}
+		if _, err := repo.GetCollection(ctx, collection.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetCollection() after delete error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteCollection(ctx, collection.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteCollection() again error = %v, want ErrNotFound", err)
+		}
+	})
+}
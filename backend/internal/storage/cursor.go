@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"recipe-app/internal/models"
+)
+
+// ErrInvalidCursor is returned for a cursor that cannot be decoded or was
+// issued for a different search, filter or sort.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// recipeCursor is the keyset position a listing cursor encodes: the sort
+// key of the first or last recipe on a page.
+type recipeCursor struct {
+	Sort RecipeSort `json:"s"`
+	// Query fingerprints the search and filter the cursor was issued for.
+	Query     string    `json:"q"`
+	Before    bool      `json:"b,omitempty"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+	Title     string    `json:"t,omitempty"`
+	Rank      float64   `json:"r,omitempty"`
+	Rating    float64   `json:"a,omitempty"`
+	Ratings   int       `json:"n,omitempty"`
+	Covered   int       `json:"v,omitempty"`
+	Missing   int       `json:"m,omitempty"`
+}
+
+// newRecipeCursor returns the encoded position of recipe in a normalized
+// query's order, given its search rank and pantry match. Before cursors
+// select the recipes ordered ahead of it.
+func newRecipeCursor(query RecipeQuery, recipe *models.Recipe, rank float64, match models.PantryMatch, before bool) string {
+	c := recipeCursor{
+		Sort:      query.Sort,
+		Query:     query.fingerprint(),
+		Before:    before,
+		CreatedAt: recipe.CreatedAt,
+		ID:        recipe.ID,
+	}
+	switch query.Sort {
+	case SortTitle:
+		c.Title = recipe.Title
+	case SortRelevance:
+		c.Rank = rank
+	case SortRating:
+		c.Rating, c.Ratings = recipe.RatingAverage, recipe.RatingCount
+	case SortPantry:
+		c.Covered, c.Missing = match.Covered, match.Total-match.Covered
+	}
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor returns the position of a normalized query's Cursor, or nil
+// when it has none.
+func (q RecipeQuery) decodeCursor() (*recipeCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c recipeCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != q.Sort || c.Query != q.fingerprint() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// fingerprint identifies the recipes a normalized query matches,
+// regardless of sort and paging. The filter is compared as JSON so that
+// its pointer fields compare by value.
+func (q RecipeQuery) fingerprint() string {
+	filter, _ := json.Marshal(q.Filter)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%q %s", q.Search, filter)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// pageCursors sets result's NextCursor and PrevCursor for a page of
+// recipes. hasMore reports whether a recipe follows the page in the
+// direction it was read, and ranks holds each recipe's search rank.
+func pageCursors(result *models.SearchResult, query RecipeQuery, cursor *recipeCursor, ranks []float64, hasMore bool) {
+	n := len(result.Recipes)
+	if n == 0 {
+		return
+	}
+
+	hasNext, hasPrev := hasMore, cursor != nil || query.offset() > 0
+	if cursor != nil && cursor.Before {
+		hasNext, hasPrev = true, hasMore
+	}
+	if hasNext {
+		last := &result.Recipes[n-1]
+		result.NextCursor = newRecipeCursor(query, last, ranks[n-1], result.Pantry[last.ID], false)
+	}
+	if hasPrev {
+		first := &result.Recipes[0]
+		result.PrevCursor = newRecipeCursor(query, first, ranks[0], result.Pantry[first.ID], true)
+	}
+}
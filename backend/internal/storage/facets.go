@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"recipe-app/internal/models"
+)
+
+// facetFilters returns a copy of filter per facet with that facet's own
+// constraint cleared.
+func facetFilters(filter models.RecipeFilter) (category, cuisine, difficulty, tags, cookTime models.RecipeFilter) {
+	category, cuisine, difficulty, tags, cookTime = filter, filter, filter, filter, filter
+	category.Category = ""
+	cuisine.Cuisine = ""
+	difficulty.Difficulty = ""
+	tags.Tags = nil
+	cookTime.MaxCookTime = 0
+	return
+}
+
+func (db *DB) RecipeFacets(ctx context.Context, query RecipeQuery) (*models.SearchFacets, error) {
+	query = query.normalize()
+	category, cuisine, difficulty, tags, cookTime := facetFilters(query.Filter)
+
+	facets := &models.SearchFacets{}
+	var err error
+	if facets.Category, err = db.columnFacet(ctx, query.terms(), category, "r.category"); err != nil {
+		return nil, err
+	}
+	if facets.Cuisine, err = db.columnFacet(ctx, query.terms(), cuisine, "r.cuisine"); err != nil {
+		return nil, err
+	}
+	if facets.Difficulty, err = db.columnFacet(ctx, query.terms(), difficulty, "r.difficulty"); err != nil {
+		return nil, err
+	}
+	if facets.Tags, err = db.tagFacet(ctx, query.terms(), tags); err != nil {
+		return nil, err
+	}
+	if facets.CookTime, err = db.cookTimeFacet(ctx, query.terms(), cookTime); err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+func (db *DB) columnFacet(ctx context.Context, terms []string, filter models.RecipeFilter, column string) ([]models.FacetCount, error) {
+	b := &sqlBuilder{}
+	applyRecipeSearch(b, terms)
+	applyRecipeFilter(b, filter)
+	b.where(fmt.Sprintf("COALESCE(%s, '') <> ''", column))
+
+	return queryFacet(ctx, db, fmt.Sprintf(
+		`SELECT %[1]s, COUNT(*) FROM recipes r%[2]s GROUP BY %[1]s ORDER BY COUNT(*) DESC, %[1]s COLLATE "C" ASC`,
+		column, b.whereClause()), b.args...)
+}
+
+func (db *DB) tagFacet(ctx context.Context, terms []string, filter models.RecipeFilter) ([]models.FacetCount, error) {
+	b := &sqlBuilder{}
+	applyRecipeSearch(b, terms)
+	applyRecipeFilter(b, filter)
+
+	return queryFacet(ctx, db, fmt.Sprintf(
+		`SELECT t.tag, COUNT(*) FROM recipe_tags t JOIN recipes r ON r.id = t.recipe_id%s GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag COLLATE "C" ASC LIMIT %s`,
+		b.whereClause(), b.arg(MaxTagFacets)), b.args...)
+}
+
+func (db *DB) cookTimeFacet(ctx context.Context, terms []string, filter models.RecipeFilter) ([]models.FacetCount, error) {
+	b := &sqlBuilder{}
+	applyRecipeSearch(b, terms)
+	applyRecipeFilter(b, filter)
+
+	counts := make([]string, len(CookTimeFacetBuckets))
+	for i, bucket := range CookTimeFacetBuckets {
+		counts[i] = fmt.Sprintf("COUNT(*) FILTER (WHERE COALESCE(r.cook_time, 0) <= %s)", b.arg(bucket))
+	}
+
+	facet := make([]models.FacetCount, len(CookTimeFacetBuckets))
+	dest := make([]interface{}, len(CookTimeFacetBuckets))
+	for i, bucket := range CookTimeFacetBuckets {
+		facet[i].Value = strconv.Itoa(bucket)
+		dest[i] = &facet[i].Count
+	}
+
+	stmt := fmt.Sprintf("SELECT %s FROM recipes r%s", strings.Join(counts, ", "), b.whereClause())
+	if err := db.QueryRowContext(ctx, stmt, b.args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to count cook time facet: %w", err)
+	}
+	return facet, nil
+}
+
+func queryFacet(ctx context.Context, q queryer, stmt string, args ...interface{}) ([]models.FacetCount, error) {
+	rows, err := q.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facet: %w", err)
+	}
+	defer rows.Close()
+
+	facet := []models.FacetCount{}
+	for rows.Next() {
+		var fc models.FacetCount
+		if err := rows.Scan(&fc.Value, &fc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+		facet = append(facet, fc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read facet: %w", err)
+	}
+	return facet, nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const mealPlanSelect = `
+	SELECT m.id, m.user_id, m.plan_date, m.slot, m.recipe_id, m.servings, r.title, m.created_at, m.updated_at
+	FROM meal_plans m JOIN recipes r ON r.id = m.recipe_id`
+
+// mealSlotOrder sorts slots as models.MealSlots lists them.
+const mealSlotOrder = `CASE m.slot WHEN 'breakfast' THEN 1 WHEN 'lunch' THEN 2 WHEN 'dinner' THEN 3 ELSE 4 END`
+
+func (db *DB) ListMealPlan(ctx context.Context, userID, start, end string) ([]models.MealPlanEntry, error) {
+	if uuid.Validate(userID) != nil {
+		return []models.MealPlanEntry{}, nil
+	}
+	return scanMealPlan(ctx, db, mealPlanSelect+`
+		WHERE m.user_id = $1 AND m.plan_date BETWEEN $2::date AND $3::date
+		ORDER BY m.plan_date, `+mealSlotOrder+`, m.created_at, m.id`, userID, start, end)
+}
+
+func (db *DB) GetMealPlanEntry(ctx context.Context, id string) (*models.MealPlanEntry, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+	entries, err := scanMealPlan(ctx, db, mealPlanSelect+" WHERE m.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrNotFound
+	}
+	return &entries[0], nil
+}
+
+func (db *DB) CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error {
+	if uuid.Validate(entry.UserID) != nil || uuid.Validate(entry.RecipeID) != nil {
+		return ErrNotFound
+	}
+
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO meal_plans (user_id, plan_date, slot, recipe_id, servings)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`,
+		entry.UserID, entry.Date, entry.Slot, entry.RecipeID, entry.Servings,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return mealPlanWriteError(err, "failed to insert meal plan entry")
+	}
+	return nil
+}
+
+func (db *DB) UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error {
+	if uuid.Validate(entry.ID) != nil {
+		return ErrNotFound
+	}
+
+	err := db.QueryRowContext(ctx, `
+		UPDATE meal_plans SET plan_date = $2, slot = $3, servings = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING user_id, recipe_id, created_at, updated_at`,
+		entry.ID, entry.Date, entry.Slot, entry.Servings,
+	).Scan(&entry.UserID, &entry.RecipeID, &entry.CreatedAt, &entry.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return mealPlanWriteError(err, "failed to update meal plan entry")
+	}
+	return nil
+}
+
+func (db *DB) DeleteMealPlanEntry(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM meal_plans WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete meal plan entry: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) CopyMealPlan(ctx context.Context, userID, start, end string, days int) (int, error) {
+	if uuid.Validate(userID) != nil {
+		return 0, nil
+	}
+
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO meal_plans (user_id, plan_date, slot, recipe_id, servings)
+		SELECT user_id, plan_date + $4::int, slot, recipe_id, servings
+		FROM meal_plans m
+		WHERE user_id = $1 AND plan_date BETWEEN $2::date AND $3::date
+		ORDER BY plan_date, `+mealSlotOrder+`, created_at, id
+		ON CONFLICT (user_id, plan_date, slot, recipe_id) DO NOTHING`,
+		userID, start, end, days)
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy meal plan: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy meal plan: %w", err)
+	}
+	return int(n), nil
+}
+
+func (db *DB) ClearMealPlan(ctx context.Context, userID, start, end string) (int, error) {
+	if uuid.Validate(userID) != nil {
+		return 0, nil
+	}
+
+	res, err := db.ExecContext(ctx,
+		"DELETE FROM meal_plans WHERE user_id = $1 AND plan_date BETWEEN $2::date AND $3::date", userID, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear meal plan: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear meal plan: %w", err)
+	}
+	return int(n), nil
+}
+
+func scanMealPlan(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.MealPlanEntry, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query meal plan: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.MealPlanEntry{}
+	for rows.Next() {
+		var e models.MealPlanEntry
+		var date time.Time
+		if err := rows.Scan(&e.ID, &e.UserID, &date, &e.Slot, &e.RecipeID, &e.Servings, &e.RecipeTitle, &e.CreatedAt, &e.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan meal plan entry: %w", err)
+		}
+		e.Date = date.Format(models.DateLayout)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read meal plan: %w", err)
+	}
+	return entries, nil
+}
+
+// mealPlanWriteError maps a meal already holding the recipe to
+// ErrAlreadyPlanned and a missing user or recipe to ErrNotFound.
+func mealPlanWriteError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrAlreadyPlanned
+	}
+	return referenceError(err, msg)
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// mealPlanStore is the subset of Store the meal plan suite needs to create
+// users and recipes.
+type mealPlanStore interface {
+	RecipeRepository
+	UserRepository
+	MealPlanRepository
+}
+
+// testMealPlanRepository is the conformance suite for MealPlanRepository
+// implementations. newRepo must return an empty repository.
+func testMealPlanRepository(t *testing.T, newRepo func(t *testing.T) mealPlanStore) {
+	setup := func(t *testing.T) (mealPlanStore, string, map[string]string) {
+		t.Helper()
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		recipes := make(map[string]string)
+		for _, title := range []string{"Oats", "Soup", "Stew"} {
+			recipe := models.Recipe{Title: title}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+			recipes[title] = recipe.ID
+		}
+		return repo, user.ID, recipes
+	}
+
+	plan := func(t *testing.T, repo mealPlanStore, entry models.MealPlanEntry) models.MealPlanEntry {
+		t.Helper()
+		if err := repo.CreateMealPlanEntry(context.Background(), &entry); err != nil {
+			t.Fatalf("CreateMealPlanEntry(%+v) error = %v", entry, err)
+		}
+		return entry
+	}
+
+	// listing describes a user's plan as "date slot title" entries.
+	listing := func(t *testing.T, repo mealPlanStore, userID, start, end string) string {
+		t.Helper()
+		entries, err := repo.ListMealPlan(context.Background(), userID, start, end)
+		if err != nil {
+			t.Fatalf("ListMealPlan() error = %v", err)
+		}
+		parts := make([]string, len(entries))
+		for i, e := range entries {
+			parts[i] = fmt.Sprintf("%s %s %s", e.Date, e.Slot, e.RecipeTitle)
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	t.Run("CreateAndList", func(t *testing.T) {
+		repo, userID, recipes := setup(t)
+		ctx := context.Background()
+
+		stew := plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealDinner, RecipeID: recipes["Stew"], Servings: 6})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealDinner, RecipeID: recipes["Soup"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealBreakfast, RecipeID: recipes["Oats"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-04", Slot: models.MealSnack, RecipeID: recipes["Oats"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-11", Slot: models.MealLunch, RecipeID: recipes["Soup"]})
+
+		if stew.ID == "" || stew.CreatedAt.IsZero() {
+			t.Errorf("CreateMealPlanEntry() = %+v, want ID and timestamps", stew)
+		}
+		want := "2024-03-04 snack Oats, 2024-03-05 breakfast Oats, 2024-03-05 dinner Stew, 2024-03-05 dinner Soup"
+		if got := listing(t, repo, userID, "2024-03-04", "2024-03-10"); got != want {
+			t.Errorf("ListMealPlan() = %s, want %s", got, want)
+		}
+
+		got, err := repo.GetMealPlanEntry(ctx, stew.ID)
+		if err != nil {
+			t.Fatalf("GetMealPlanEntry() error = %v", err)
+		}
+		if got.RecipeTitle != "Stew" || got.Servings != 6 || got.UserID != userID || got.Date != "2024-03-05" {
+			t.Errorf("GetMealPlanEntry() = %+v", got)
+		}
+
+		if got := listing(t, repo, uuid.NewString(), "2024-03-04", "2024-03-10"); got != "" {
+			t.Errorf("ListMealPlan() for another user = %s, want none", got)
+		}
+	})
+
+	t.Run("CreateErrors", func(t *testing.T) {
+		repo, userID, recipes := setup(t)
+		ctx := context.Background()
+
+		entry := models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealLunch, RecipeID: recipes["Soup"]}
+		plan(t, repo, entry)
+		if err := repo.CreateMealPlanEntry(ctx, &entry); !errors.Is(err, ErrAlreadyPlanned) {
+			t.Errorf("CreateMealPlanEntry() twice error = %v, want ErrAlreadyPlanned", err)
+		}
+
+		missing := []models.MealPlanEntry{
+			{UserID: userID, Date: "2024-03-05", Slot: models.MealLunch, RecipeID: uuid.NewString()},
+			{UserID: uuid.NewString(), Date: "2024-03-05", Slot: models.MealLunch, RecipeID: recipes["Soup"]},
+		}
+		for _, entry := range missing {
+			if err := repo.CreateMealPlanEntry(ctx, &entry); !errors.Is(err, ErrNotFound) {
+				t.Errorf("CreateMealPlanEntry(%+v) error = %v, want ErrNotFound", entry, err)
+			}
+		}
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		repo, userID, recipes := setup(t)
+		ctx := context.Background()
+
+		soup := plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealLunch, RecipeID: recipes["Soup"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-06", Slot: models.MealDinner, RecipeID: recipes["Soup"]})
+
+		soup.Date, soup.Slot, soup.Servings = "2024-03-06", models.MealLunch, 2
+		if err := repo.UpdateMealPlanEntry(ctx, &soup); err != nil {
+			t.Fatalf("UpdateMealPlanEntry() error = %v", err)
+		}
+		if soup.UserID != userID || soup.RecipeID != recipes["Soup"] {
+			t.Errorf("UpdateMealPlanEntry() = %+v, want owner and recipe kept", soup)
+		}
+		if got := listing(t, repo, userID, "2024-03-01", "2024-03-31"); got != "2024-03-06 lunch Soup, 2024-03-06 dinner Soup" {
+			t.Errorf("ListMealPlan() after move = %s", got)
+		}
+
+		soup.Slot = models.MealDinner
+		if err := repo.UpdateMealPlanEntry(ctx, &soup); !errors.Is(err, ErrAlreadyPlanned) {
+			t.Errorf("UpdateMealPlanEntry() onto a planned meal error = %v, want ErrAlreadyPlanned", err)
+		}
+
+		if err := repo.DeleteMealPlanEntry(ctx, soup.ID); err != nil {
+			t.Fatalf("DeleteMealPlanEntry() error = %v", err)
+		}
+		if _, err := repo.GetMealPlanEntry(ctx, soup.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetMealPlanEntry() after delete error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteMealPlanEntry(ctx, soup.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteMealPlanEntry() twice error = %v, want ErrNotFound", err)
+		}
+		missing := models.MealPlanEntry{ID: uuid.NewString(), Date: "2024-03-06", Slot: models.MealLunch}
+		if err := repo.UpdateMealPlanEntry(ctx, &missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateMealPlanEntry() on missing entry error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CopyAndClear", func(t *testing.T) {
+		repo, userID, recipes := setup(t)
+		ctx := context.Background()
+
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-04", Slot: models.MealBreakfast, RecipeID: recipes["Oats"], Servings: 1})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-10", Slot: models.MealDinner, RecipeID: recipes["Stew"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-17", Slot: models.MealDinner, RecipeID: recipes["Stew"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-03", Slot: models.MealLunch, RecipeID: recipes["Soup"]})
+
+		copied, err := repo.CopyMealPlan(ctx, userID, "2024-03-04", "2024-03-10", 7)
+		if err != nil {
+			t.Fatalf("CopyMealPlan() error = %v", err)
+		}
+		if copied != 1 {
+			t.Errorf("CopyMealPlan() = %d, want 1 as the stew is already planned", copied)
+		}
+		if got := listing(t, repo, userID, "2024-03-11", "2024-03-17"); got != "2024-03-11 breakfast Oats, 2024-03-17 dinner Stew" {
+			t.Errorf("ListMealPlan() after copy = %s", got)
+		}
+		entries, _ := repo.ListMealPlan(ctx, userID, "2024-03-11", "2024-03-11")
+		if len(entries) != 1 || entries[0].Servings != 1 {
+			t.Errorf("copied entries = %+v, want servings kept", entries)
+		}
+
+		deleted, err := repo.ClearMealPlan(ctx, userID, "2024-03-04", "2024-03-11")
+		if err != nil {
+			t.Fatalf("ClearMealPlan() error = %v", err)
+		}
+		if deleted != 3 {
+			t.Errorf("ClearMealPlan() = %d, want 3", deleted)
+		}
+		if got := listing(t, repo, userID, "2024-03-01", "2024-03-31"); got != "2024-03-03 lunch Soup, 2024-03-17 dinner Stew" {
+			t.Errorf("ListMealPlan() after clear = %s", got)
+		}
+	})
+
+	t.Run("DeletingRecipeRemovesEntries", func(t *testing.T) {
+		repo, userID, recipes := setup(t)
+		ctx := context.Background()
+
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealLunch, RecipeID: recipes["Soup"]})
+		plan(t, repo, models.MealPlanEntry{UserID: userID, Date: "2024-03-05", Slot: models.MealDinner, RecipeID: recipes["Stew"]})
+		if err := repo.DeleteRecipe(ctx, recipes["Soup"]); err != nil {
+			t.Fatalf("DeleteRecipe() error = %v", err)
+		}
+		if got := listing(t, repo, userID, "2024-03-05", "2024-03-05"); got != "2024-03-05 dinner Stew" {
+			t.Errorf("ListMealPlan() = %s, want only the stew", got)
+		}
+	})
+}
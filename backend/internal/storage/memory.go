@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"recipe-app/internal/models"
+)
+
+// Memory is a concurrency-safe, in-process implementation of the storage
+// repositories. It mirrors the Postgres semantics closely enough to back
+// handler tests and the server's local demo mode.
+type Memory struct {
+	mu       sync.RWMutex
+	lastTime time.Time
+
+	recipes map[string]*recipeRecord
+	users   map[string]*models.User
+
+	// refreshTokens is keyed by token hash.
+	refreshTokens map[string]*models.RefreshToken
+
+	collections map[string]*models.RecipeCollection
+
+	ratings map[ratingKey]*models.Rating
+
+	mealPlans map[string]*models.MealPlanEntry
+
+	shoppingLists map[string]*models.ShoppingList
+
+	pantry map[pantryKey]*models.PantryItem
+
+	catalog map[string]*models.CatalogIngredient
+	// catalogTerms maps each catalog term to the ID of its entry.
+	catalogTerms map[string]string
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		recipes: make(map[string]*recipeRecord),
+		users:   make(map[string]*models.User),
+
+		refreshTokens: make(map[string]*models.RefreshToken),
+
+		collections: make(map[string]*models.RecipeCollection),
+
+		ratings: make(map[ratingKey]*models.Rating),
+
+		mealPlans: make(map[string]*models.MealPlanEntry),
+
+		shoppingLists: make(map[string]*models.ShoppingList),
+
+		pantry: make(map[pantryKey]*models.PantryItem),
+
+		catalog:      make(map[string]*models.CatalogIngredient),
+		catalogTerms: make(map[string]string),
+	}
+}
+
+// now returns a strictly increasing timestamp with the same microsecond
+// precision Postgres stores. Callers must hold the write lock.
+func (m *Memory) now() time.Time {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	if !now.After(m.lastTime) {
+		now = m.lastTime.Add(time.Microsecond)
+	}
+	m.lastTime = now
+	return now
+}
@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) ListCatalog(ctx context.Context, search string) ([]models.CatalogIngredient, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	search = models.NormalizeIngredientName(search)
+	entries := []models.CatalogIngredient{}
+	for _, entry := range m.catalog {
+		if search == "" || containsSubstring(entry.Terms(), search) {
+			entries = append(entries, copyCatalogIngredient(entry))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+func (m *Memory) GetCatalogIngredient(ctx context.Context, id string) (*models.CatalogIngredient, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.catalog[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := copyCatalogIngredient(entry)
+	return &c, nil
+}
+
+func (m *Memory) CreateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prepareCatalogIngredient(ingredient)
+	if m.catalogTermTaken(ingredient.Terms(), "") {
+		return ErrCatalogTermTaken
+	}
+
+	ingredient.ID = uuid.NewString()
+	ingredient.CreatedAt = m.now()
+	ingredient.UpdatedAt = ingredient.CreatedAt
+	stored := copyCatalogIngredient(ingredient)
+	m.catalog[ingredient.ID] = &stored
+	m.setCatalogTerms(&stored)
+	m.relinkIngredients()
+	return nil
+}
+
+func (m *Memory) UpdateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.catalog[ingredient.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	prepareCatalogIngredient(ingredient)
+	if m.catalogTermTaken(ingredient.Terms(), ingredient.ID) {
+		return ErrCatalogTermTaken
+	}
+
+	ingredient.CreatedAt = entry.CreatedAt
+	ingredient.UpdatedAt = m.now()
+	stored := copyCatalogIngredient(ingredient)
+	m.catalog[ingredient.ID] = &stored
+	m.setCatalogTerms(&stored)
+	m.relinkIngredients()
+	return nil
+}
+
+func (m *Memory) DeleteCatalogIngredient(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.catalog[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.catalog, id)
+	for term, catalogID := range m.catalogTerms {
+		if catalogID == id {
+			delete(m.catalogTerms, term)
+		}
+	}
+	for _, rec := range m.recipes {
+		for i := range rec.recipe.Ingredients {
+			if ing := &rec.recipe.Ingredients[i]; ing.CatalogID == id {
+				ing.CatalogID, ing.CatalogMatch = "", ""
+			}
+		}
+	}
+	m.relinkIngredients()
+	return nil
+}
+
+func (m *Memory) LinkIngredient(ctx context.Context, ingredientID, catalogID string) (*models.Ingredient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.catalog[catalogID]; catalogID != "" && !ok {
+		return nil, ErrNotFound
+	}
+	rec, ing := m.findIngredient(ingredientID)
+	if ing == nil {
+		return nil, ErrNotFound
+	}
+	ing.CatalogID, ing.CatalogMatch = catalogID, models.CatalogMatchManual
+	m.linkIngredient(ing)
+	rec.recipe.Dietary = m.dietary(rec.recipe.Ingredients)
+	c := *ing
+	return &c, nil
+}
+
+func (m *Memory) ResetIngredientLink(ctx context.Context, ingredientID string) (*models.Ingredient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ing := m.findIngredient(ingredientID)
+	if ing == nil {
+		return nil, ErrNotFound
+	}
+	ing.CatalogMatch = ""
+	m.linkIngredient(ing)
+	rec.recipe.Dietary = m.dietary(rec.recipe.Ingredients)
+	c := *ing
+	return &c, nil
+}
+
+func (m *Memory) RelinkIngredients(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.relinkIngredients(), nil
+}
+
+func (m *Memory) UnmatchedIngredients(ctx context.Context, limit int) ([]models.IngredientCount, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byName := make(map[string]int)
+	for _, rec := range m.recipes {
+		for _, ing := range rec.recipe.Ingredients {
+			if ing.CatalogID == "" && ing.CatalogMatch != models.CatalogMatchManual {
+				byName[strings.ToLower(ing.Name)]++
+			}
+		}
+	}
+	counts := make([]models.IngredientCount, 0, len(byName))
+	for name, count := range byName {
+		counts = append(counts, models.IngredientCount{Name: name, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+	return counts, nil
+}
+
+// catalogTermTaken reports whether any of terms belongs to an entry other
+// than exceptID. Callers must hold the lock.
+func (m *Memory) catalogTermTaken(terms []string, exceptID string) bool {
+	for _, term := range terms {
+		if id, ok := m.catalogTerms[term]; ok && id != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// setCatalogTerms replaces an entry's terms. Callers must hold the write
+// lock.
+func (m *Memory) setCatalogTerms(entry *models.CatalogIngredient) {
+	for term, id := range m.catalogTerms {
+		if id == entry.ID {
+			delete(m.catalogTerms, term)
+		}
+	}
+	for _, term := range entry.Terms() {
+		m.catalogTerms[term] = entry.ID
+	}
+}
+
+// linkIngredients links a recipe's ingredients as it is written, keeping
+// the manual links in manual, keyed by normalized name. Callers must hold
+// the write lock.
+func (m *Memory) linkIngredients(ingredients []models.Ingredient, manual map[string]string) {
+	for i := range ingredients {
+		ing := &ingredients[i]
+		if catalogID, ok := manual[models.NormalizeIngredientName(ing.Name)]; ok {
+			ing.CatalogID, ing.CatalogMatch = catalogID, models.CatalogMatchManual
+		}
+		m.linkIngredient(ing)
+	}
+}
+
+// relinkIngredients refreshes every stored ingredient's link and recipe's
+// dietary labels and returns how many linked entries changed. Callers must
+// hold the write lock.
+func (m *Memory) relinkIngredients() int {
+	changed := 0
+	for _, rec := range m.recipes {
+		for i := range rec.recipe.Ingredients {
+			if m.linkIngredient(&rec.recipe.Ingredients[i]) {
+				changed++
+			}
+		}
+		rec.recipe.Dietary = m.dietary(rec.recipe.Ingredients)
+	}
+	return changed
+}
+
+// dietary computes the labels of a recipe's linked ingredients. Callers
+// must hold the lock.
+func (m *Memory) dietary(ingredients []models.Ingredient) models.Dietary {
+	return models.ComputeDietary(ingredients, func(id string) (*models.CatalogIngredient, bool) {
+		entry, ok := m.catalog[id]
+		return entry, ok
+	})
+}
+
+// linkIngredient rematches an ingredient unless it was linked by hand,
+// refreshes its catalog name and reports whether its entry changed.
+// Callers must hold the write lock.
+func (m *Memory) linkIngredient(ing *models.Ingredient) bool {
+	previous := ing.CatalogID
+	if ing.CatalogMatch != models.CatalogMatchManual {
+		ing.CatalogID, ing.CatalogMatch = "", ""
+		term, ok := models.MatchCatalogTerm(ing.Name, func(term string) bool {
+			_, ok := m.catalogTerms[term]
+			return ok
+		})
+		if ok {
+			ing.CatalogID, ing.CatalogMatch = m.catalogTerms[term], models.CatalogMatchAuto
+		}
+	}
+	ing.CatalogName = ""
+	if entry, ok := m.catalog[ing.CatalogID]; ok {
+		ing.CatalogName = entry.Name
+	}
+	return ing.CatalogID != previous
+}
+
+// findIngredient returns the stored ingredient with the given ID and its
+// recipe, or nils. Callers must hold the write lock to change them.
+func (m *Memory) findIngredient(id string) (*recipeRecord, *models.Ingredient) {
+	for _, rec := range m.recipes {
+		for i := range rec.recipe.Ingredients {
+			if rec.recipe.Ingredients[i].ID == id {
+				return rec, &rec.recipe.Ingredients[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// manualLinks returns the manual links of a recipe's ingredients keyed by
+// normalized name, which UpdateRecipe carries over to the new ingredients.
+func manualLinks(ingredients []models.Ingredient) map[string]string {
+	links := make(map[string]string)
+	for _, ing := range ingredients {
+		if ing.CatalogMatch == models.CatalogMatchManual {
+			links[models.NormalizeIngredientName(ing.Name)] = ing.CatalogID
+		}
+	}
+	return links
+}
+
+func containsSubstring(values []string, substr string) bool {
+	for _, v := range values {
+		if strings.Contains(v, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyCatalogIngredient(entry *models.CatalogIngredient) models.CatalogIngredient {
+	c := *entry
+	c.Synonyms = append([]string{}, entry.Synonyms...)
+	c.Allergens = append([]string{}, entry.Allergens...)
+	c.Diets = append([]string{}, entry.Diets...)
+	return c
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) ListCollections(ctx context.Context, userID string, includePrivate bool) ([]models.RecipeCollection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collections := []models.RecipeCollection{}
+	for _, collection := range m.collections {
+		if collection.UserID == userID && (includePrivate || collection.IsPublic) {
+			collections = append(collections, copyCollection(collection))
+		}
+	}
+	sort.Slice(collections, func(i, j int) bool {
+		a, b := collections[i], collections[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return collections, nil
+}
+
+func (m *Memory) GetCollection(ctx context.Context, id string) (*models.RecipeCollection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collection, ok := m.collections[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := copyCollection(collection)
+	return &c, nil
+}
+
+func (m *Memory) CreateCollection(ctx context.Context, collection *models.RecipeCollection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[collection.UserID]; !ok {
+		return ErrNotFound
+	}
+	recipeIDs := []string{}
+	for _, id := range collection.RecipeIDs {
+		if _, ok := m.recipes[id]; !ok {
+			return ErrNotFound
+		}
+		if !containsString(recipeIDs, id) {
+			recipeIDs = append(recipeIDs, id)
+		}
+	}
+	collection.ID = uuid.NewString()
+	collection.RecipeIDs = recipeIDs
+	collection.CreatedAt = m.now()
+	collection.UpdatedAt = collection.CreatedAt
+
+	stored := copyCollection(collection)
+	m.collections[collection.ID] = &stored
+	return nil
+}
+
+func (m *Memory) UpdateCollection(ctx context.Context, collection *models.RecipeCollection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.collections[collection.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Name = collection.Name
+	existing.Description = collection.Description
+	existing.IsPublic = collection.IsPublic
+	existing.UpdatedAt = m.now()
+	*collection = copyCollection(existing)
+	return nil
+}
+
+func (m *Memory) DeleteCollection(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.collections, id)
+	return nil
+}
+
+func (m *Memory) AddCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error {
+	return m.reorderCollection(collectionID, func(ids []string) ([]string, error) {
+		if _, ok := m.recipes[recipeID]; !ok {
+			return nil, ErrNotFound
+		}
+		if containsString(ids, recipeID) {
+			return nil, ErrAlreadyInCollection
+		}
+		return insertAt(ids, recipeID, position), nil
+	})
+}
+
+func (m *Memory) MoveCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error {
+	return m.reorderCollection(collectionID, func(ids []string) ([]string, error) {
+		if !containsString(ids, recipeID) {
+			return nil, ErrNotFound
+		}
+		return insertAt(removeString(ids, recipeID), recipeID, position), nil
+	})
+}
+
+func (m *Memory) RemoveCollectionRecipe(ctx context.Context, collectionID, recipeID string) error {
+	return m.reorderCollection(collectionID, func(ids []string) ([]string, error) {
+		if !containsString(ids, recipeID) {
+			return nil, ErrNotFound
+		}
+		return removeString(ids, recipeID), nil
+	})
+}
+
+// reorderCollection lets change rewrite a collection's ordered recipe IDs
+// under the write lock.
+func (m *Memory) reorderCollection(id string, change func(ids []string) ([]string, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collection, ok := m.collections[id]
+	if !ok {
+		return ErrNotFound
+	}
+	ids, err := change(collection.RecipeIDs)
+	if err != nil {
+		return err
+	}
+	collection.RecipeIDs = ids
+	collection.UpdatedAt = m.now()
+	return nil
+}
+
+func copyCollection(collection *models.RecipeCollection) models.RecipeCollection {
+	c := *collection
+	c.RecipeIDs = append([]string{}, collection.RecipeIDs...)
+	return c
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) RecipeFacets(ctx context.Context, query RecipeQuery) (*models.SearchFacets, error) {
+	query = query.normalize()
+	category, cuisine, difficulty, tags, cookTime := facetFilters(query.Filter)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := struct{ category, cuisine, difficulty, tags map[string]int }{
+		make(map[string]int), make(map[string]int), make(map[string]int), make(map[string]int),
+	}
+	cookTimes := make([]models.FacetCount, len(CookTimeFacetBuckets))
+	for i, bucket := range CookTimeFacetBuckets {
+		cookTimes[i].Value = strconv.Itoa(bucket)
+	}
+
+	pantry := m.pantryNames(query.Filter.PantryUserID)
+	for _, rec := range m.recipes {
+		recipe := &rec.recipe
+		if _, ok := searchRank(recipe, query.terms(), m.catalog); !ok {
+			continue
+		}
+		if _, ok := matchesPantry(recipe, query.Filter, pantry); !ok {
+			continue
+		}
+		if recipe.Category != "" && matchesRecipeFilter(recipe, category) {
+			counts.category[recipe.Category]++
+		}
+		if recipe.Cuisine != "" && matchesRecipeFilter(recipe, cuisine) {
+			counts.cuisine[recipe.Cuisine]++
+		}
+		if recipe.Difficulty != "" && matchesRecipeFilter(recipe, difficulty) {
+			counts.difficulty[recipe.Difficulty]++
+		}
+		if matchesRecipeFilter(recipe, tags) {
+			for _, tag := range recipe.Tags {
+				counts.tags[tag]++
+			}
+		}
+		if matchesRecipeFilter(recipe, cookTime) {
+			for i, bucket := range CookTimeFacetBuckets {
+				if recipe.CookTime <= bucket {
+					cookTimes[i].Count++
+				}
+			}
+		}
+	}
+
+	tagFacet := sortedFacet(counts.tags)
+	if len(tagFacet) > MaxTagFacets {
+		tagFacet = tagFacet[:MaxTagFacets]
+	}
+	return &models.SearchFacets{
+		Category:   sortedFacet(counts.category),
+		Cuisine:    sortedFacet(counts.cuisine),
+		Difficulty: sortedFacet(counts.difficulty),
+		Tags:       tagFacet,
+		CookTime:   cookTimes,
+	}, nil
+}
+
+// sortedFacet orders counts like the facet queries: most matches first,
+// then by value.
+func sortedFacet(counts map[string]int) []models.FacetCount {
+	facet := make([]models.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facet = append(facet, models.FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facet, func(i, j int) bool {
+		if facet[i].Count != facet[j].Count {
+			return facet[i].Count > facet[j].Count
+		}
+		return facet[i].Value < facet[j].Value
+	})
+	return facet
+}
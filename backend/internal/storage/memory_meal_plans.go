@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) ListMealPlan(ctx context.Context, userID, start, end string) ([]models.MealPlanEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []models.MealPlanEntry{}
+	for _, entry := range m.mealPlans {
+		if entry.UserID == userID && entry.Date >= start && entry.Date <= end {
+			entries = append(entries, m.mealPlanEntry(entry))
+		}
+	}
+	sortMealPlan(entries)
+	return entries, nil
+}
+
+func (m *Memory) GetMealPlanEntry(ctx context.Context, id string) (*models.MealPlanEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.mealPlans[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	e := m.mealPlanEntry(entry)
+	return &e, nil
+}
+
+func (m *Memory) CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[entry.UserID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.recipes[entry.RecipeID]; !ok {
+		return ErrNotFound
+	}
+	if m.isPlanned(entry.UserID, entry.Date, entry.Slot, entry.RecipeID, "") {
+		return ErrAlreadyPlanned
+	}
+
+	entry.ID = uuid.NewString()
+	entry.CreatedAt = m.now()
+	entry.UpdatedAt = entry.CreatedAt
+	stored := *entry
+	stored.RecipeTitle = ""
+	m.mealPlans[entry.ID] = &stored
+	return nil
+}
+
+func (m *Memory) UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.mealPlans[entry.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if m.isPlanned(existing.UserID, entry.Date, entry.Slot, existing.RecipeID, existing.ID) {
+		return ErrAlreadyPlanned
+	}
+	existing.Date = entry.Date
+	existing.Slot = entry.Slot
+	existing.Servings = entry.Servings
+	existing.UpdatedAt = m.now()
+
+	title := entry.RecipeTitle
+	*entry = *existing
+	entry.RecipeTitle = title
+	return nil
+}
+
+func (m *Memory) DeleteMealPlanEntry(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.mealPlans[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.mealPlans, id)
+	return nil
+}
+
+func (m *Memory) CopyMealPlan(ctx context.Context, userID, start, end string, days int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var source []models.MealPlanEntry
+	for _, entry := range m.mealPlans {
+		if entry.UserID == userID && entry.Date >= start && entry.Date <= end {
+			source = append(source, *entry)
+		}
+	}
+	sortMealPlan(source)
+
+	copied := 0
+	for _, entry := range source {
+		date, err := models.ParseDate(entry.Date)
+		if err != nil {
+			return copied, err
+		}
+		entry.Date = date.AddDate(0, 0, days).Format(models.DateLayout)
+		if m.isPlanned(userID, entry.Date, entry.Slot, entry.RecipeID, "") {
+			continue
+		}
+		entry.ID = uuid.NewString()
+		entry.CreatedAt = m.now()
+		entry.UpdatedAt = entry.CreatedAt
+		m.mealPlans[entry.ID] = &entry
+		copied++
+	}
+	return copied, nil
+}
+
+func (m *Memory) ClearMealPlan(ctx context.Context, userID, start, end string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for id, entry := range m.mealPlans {
+		if entry.UserID == userID && entry.Date >= start && entry.Date <= end {
+			delete(m.mealPlans, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// isPlanned reports whether the user has the recipe planned for a meal in
+// an entry other than except. Callers must hold the lock.
+func (m *Memory) isPlanned(userID, date, slot, recipeID, except string) bool {
+	for _, entry := range m.mealPlans {
+		if entry.ID != except && entry.UserID == userID && entry.Date == date && entry.Slot == slot && entry.RecipeID == recipeID {
+			return true
+		}
+	}
+	return false
+}
+
+// mealPlanEntry returns a copy of a stored entry with its recipe's title.
+// Callers must hold the lock.
+func (m *Memory) mealPlanEntry(entry *models.MealPlanEntry) models.MealPlanEntry {
+	e := *entry
+	if rec, ok := m.recipes[e.RecipeID]; ok {
+		e.RecipeTitle = rec.recipe.Title
+	}
+	return e
+}
+
+// sortMealPlan orders entries by date, slot and the order they were
+// planned in.
+func sortMealPlan(entries []models.MealPlanEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if sa, sb := models.MealSlotIndex(a.Slot), models.MealSlotIndex(b.Slot); sa != sb {
+			return sa < sb
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) GetNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rec, ok := m.recipes[recipeID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := copyNutrition(rec.recipe.Nutrition)
+	return &info, nil
+}
+
+func (m *Memory) SetNutrition(ctx context.Context, info *models.NutritionInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[info.RecipeID]
+	if !ok {
+		return ErrNotFound
+	}
+	info.ID = rec.recipe.Nutrition.ID
+	info.Source = models.NutritionSourceManual
+	info.Unmatched = []string{}
+	info.UpdatedAt = m.now()
+
+	stored := copyNutrition(info)
+	rec.recipe.Nutrition = &stored
+	return nil
+}
+
+func (m *Memory) ClearNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[recipeID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	id := rec.recipe.Nutrition.ID
+	m.refreshNutrition(&rec.recipe, nil)
+	rec.recipe.Nutrition.ID = id
+	info := copyNutrition(rec.recipe.Nutrition)
+	return &info, nil
+}
+
+// refreshNutrition sets recipe.Nutrition to freshly computed facts, or to
+// a copy of previous when the author entered those. Callers must hold the
+// write lock.
+func (m *Memory) refreshNutrition(recipe *models.Recipe, previous *models.NutritionInfo) {
+	if previous != nil && previous.Source == models.NutritionSourceManual {
+		info := copyNutrition(previous)
+		recipe.Nutrition = &info
+		return
+	}
+	info := computeNutrition(recipe)
+	info.ID = uuid.NewString()
+	if previous != nil {
+		info.ID = previous.ID
+	}
+	info.UpdatedAt = m.now()
+	recipe.Nutrition = info
+}
+
+func copyNutrition(info *models.NutritionInfo) models.NutritionInfo {
+	c := *info
+	c.Unmatched = append([]string{}, info.Unmatched...)
+	return c
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"recipe-app/internal/models"
+)
+
+// pantryKey identifies an ingredient in a user's pantry.
+type pantryKey struct {
+	userID, name string
+}
+
+func (m *Memory) ListPantry(ctx context.Context, userID string) ([]models.PantryItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := []models.PantryItem{}
+	for key, item := range m.pantry {
+		if key.userID == userID {
+			items = append(items, *item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
+func (m *Memory) AddPantryItems(ctx context.Context, userID string, names []string) ([]models.PantryItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, ErrNotFound
+	}
+
+	items := []models.PantryItem{}
+	for _, name := range normalizePantryNames(names) {
+		key := pantryKey{userID, name}
+		item, ok := m.pantry[key]
+		if !ok {
+			item = &models.PantryItem{UserID: userID, Name: name, CreatedAt: m.now()}
+			m.pantry[key] = item
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+func (m *Memory) RemovePantryItem(ctx context.Context, userID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := pantryKey{userID, models.NormalizeIngredientName(name)}
+	if _, ok := m.pantry[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.pantry, key)
+	return nil
+}
+
+// pantryNames returns the set of names in a user's pantry, empty for no
+// user. Callers must hold the lock.
+func (m *Memory) pantryNames(userID string) map[string]bool {
+	names := make(map[string]bool)
+	if userID == "" {
+		return names
+	}
+	for key := range m.pantry {
+		if key.userID == userID {
+			names[key.name] = true
+		}
+	}
+	return names
+}
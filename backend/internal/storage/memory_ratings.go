@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// ratingKey identifies a user's rating of a recipe.
+type ratingKey struct {
+	recipeID, userID string
+}
+
+func (m *Memory) UpsertRating(ctx context.Context, rating *models.Rating) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[rating.RecipeID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.users[rating.UserID]; !ok {
+		return ErrNotFound
+	}
+
+	key := ratingKey{rating.RecipeID, rating.UserID}
+	stored, ok := m.ratings[key]
+	if !ok {
+		stored = &models.Rating{ID: uuid.NewString(), RecipeID: rating.RecipeID, UserID: rating.UserID, CreatedAt: m.now()}
+		stored.UpdatedAt = stored.CreatedAt
+		m.ratings[key] = stored
+	} else {
+		stored.UpdatedAt = m.now()
+	}
+	stored.Score, stored.Comment = rating.Score, rating.Comment
+
+	rating.ID, rating.CreatedAt, rating.UpdatedAt = stored.ID, stored.CreatedAt, stored.UpdatedAt
+	m.summarizeRatings(rec)
+	return nil
+}
+
+func (m *Memory) GetRating(ctx context.Context, recipeID, userID string) (*models.Rating, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.ratings[ratingKey{recipeID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rating := m.copyRating(stored)
+	return &rating, nil
+}
+
+func (m *Memory) DeleteRating(ctx context.Context, recipeID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := ratingKey{recipeID, userID}
+	if _, ok := m.ratings[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.ratings, key)
+	m.summarizeRatings(m.recipes[recipeID])
+	return nil
+}
+
+func (m *Memory) ListRatings(ctx context.Context, query RatingQuery) (*models.RatingList, error) {
+	query = query.normalize()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ratings := []models.Rating{}
+	for key, stored := range m.ratings {
+		if key.recipeID == query.RecipeID {
+			ratings = append(ratings, m.copyRating(stored))
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		a, b := ratings[i], ratings[j]
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.After(b.UpdatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	start := clamp((query.Page-1)*query.PerPage, 0, len(ratings))
+	end := clamp(start+query.PerPage, 0, len(ratings))
+	return &models.RatingList{
+		Ratings: ratings[start:end],
+		Total:   len(ratings),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}, nil
+}
+
+// summarizeRatings recomputes a recipe's rating summary. Callers must hold
+// the write lock.
+func (m *Memory) summarizeRatings(rec *recipeRecord) {
+	sum, count := 0, 0
+	for key, rating := range m.ratings {
+		if key.recipeID == rec.recipe.ID {
+			sum += rating.Score
+			count++
+		}
+	}
+	rec.recipe.RatingCount, rec.recipe.RatingAverage = count, 0
+	if count > 0 {
+		rec.recipe.RatingAverage = float64(sum) / float64(count)
+	}
+}
+
+// copyRating returns a copy of a stored rating with its author's username.
+func (m *Memory) copyRating(stored *models.Rating) models.Rating {
+	rating := *stored
+	if user, ok := m.users[rating.UserID]; ok {
+		rating.Username = user.Username
+	}
+	return rating
+}
@@ -0,0 +1,459 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+type recipeRecord struct {
+	recipe models.Recipe
+}
+
+func (m *Memory) ListRecipes(ctx context.Context, query RecipeQuery) (*models.SearchResult, error) {
+	query = query.normalize()
+	cursor, err := query.decodeCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	terms := query.terms()
+	pantry := m.pantryNames(query.Filter.PantryUserID)
+	matches := make([]*recipeRecord, 0, len(m.recipes))
+	ranks := make(map[*recipeRecord]float64)
+	pantryMatches := make(map[*recipeRecord]models.PantryMatch)
+	for _, rec := range m.recipes {
+		rank, ok := searchRank(&rec.recipe, terms, m.catalog)
+		if !ok || !matchesRecipeFilter(&rec.recipe, query.Filter) {
+			continue
+		}
+		match, ok := matchesPantry(&rec.recipe, query.Filter, pantry)
+		if !ok {
+			continue
+		}
+		matches = append(matches, rec)
+		ranks[rec] = rank
+		pantryMatches[rec] = match
+	}
+	sortRecipeRecords(matches, query.Sort, ranks, pantryMatches)
+
+	result := &models.SearchResult{
+		Recipes: []models.Recipe{},
+		Total:   len(matches),
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+
+	start := query.offset()
+	if cursor != nil {
+		result.Page = 0
+		// start is the first record after the cursor's position.
+		pos := &recipeRecord{recipe: models.Recipe{
+			ID: cursor.ID, Title: cursor.Title, CreatedAt: cursor.CreatedAt,
+			RatingAverage: cursor.Rating, RatingCount: cursor.Ratings,
+		}}
+		ranks[pos] = cursor.Rank
+		pantryMatches[pos] = models.PantryMatch{Covered: cursor.Covered, Total: cursor.Covered + cursor.Missing}
+		start = sort.Search(len(matches), func(i int) bool {
+			return compareRecipeRecords(matches[i], pos, query.Sort, ranks, pantryMatches) > 0
+		})
+		if cursor.Before {
+			start = sort.Search(len(matches), func(i int) bool {
+				return compareRecipeRecords(matches[i], pos, query.Sort, ranks, pantryMatches) >= 0
+			}) - query.PerPage
+		}
+	}
+
+	end := start + query.PerPage
+	hasMore := end < len(matches)
+	if cursor != nil && cursor.Before {
+		hasMore = start > 0
+	}
+	start = clamp(start, 0, len(matches))
+	end = clamp(end, 0, len(matches))
+
+	pageRanks := []float64{}
+	if query.Filter.PantryUserID != "" {
+		result.Pantry = make(map[string]models.PantryMatch, end-start)
+	}
+	for _, rec := range matches[start:end] {
+		result.Recipes = append(result.Recipes, copyRecipe(&rec.recipe))
+		pageRanks = append(pageRanks, ranks[rec])
+		if result.Pantry != nil {
+			result.Pantry[rec.recipe.ID] = pantryMatches[rec]
+		}
+	}
+	pageCursors(result, query, cursor, pageRanks, hasMore)
+	highlightResults(result, terms)
+	return result, nil
+}
+
+func (m *Memory) GetRecipe(ctx context.Context, id string) (*models.Recipe, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rec, ok := m.recipes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	recipe := copyRecipe(&rec.recipe)
+	return &recipe, nil
+}
+
+func (m *Memory) GetRecipes(ctx context.Context, ids []string) ([]models.Recipe, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	recipes := make([]models.Recipe, 0, len(ids))
+	for _, id := range ids {
+		if rec, ok := m.recipes[id]; ok {
+			recipes = append(recipes, copyRecipe(&rec.recipe))
+		}
+	}
+	return recipes, nil
+}
+
+func (m *Memory) CreateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	recipe.ID = uuid.NewString()
+	recipe.CreatedAt = m.now()
+	recipe.UpdatedAt = recipe.CreatedAt
+	recipe.RatingAverage, recipe.RatingCount = 0, 0
+	prepareRecipe(recipe)
+	assignChildIDs(recipe)
+	m.linkIngredients(recipe.Ingredients, nil)
+	recipe.Dietary = m.dietary(recipe.Ingredients)
+	m.refreshNutrition(recipe, nil)
+
+	m.recipes[recipe.ID] = &recipeRecord{recipe: copyRecipe(recipe)}
+	return nil
+}
+
+func (m *Memory) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[recipe.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	recipe.AuthorID = rec.recipe.AuthorID
+	recipe.RatingAverage, recipe.RatingCount = rec.recipe.RatingAverage, rec.recipe.RatingCount
+	recipe.CreatedAt = rec.recipe.CreatedAt
+	recipe.UpdatedAt = m.now()
+	prepareRecipe(recipe)
+	assignChildIDs(recipe)
+	m.linkIngredients(recipe.Ingredients, manualLinks(rec.recipe.Ingredients))
+	recipe.Dietary = m.dietary(recipe.Ingredients)
+	m.refreshNutrition(recipe, rec.recipe.Nutrition)
+
+	rec.recipe = copyRecipe(recipe)
+	return nil
+}
+
+func (m *Memory) DeleteRecipe(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.recipes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.recipes, id)
+	for _, collection := range m.collections {
+		collection.RecipeIDs = removeString(collection.RecipeIDs, id)
+	}
+	for key := range m.ratings {
+		if key.recipeID == id {
+			delete(m.ratings, key)
+		}
+	}
+	for entryID, entry := range m.mealPlans {
+		if entry.RecipeID == id {
+			delete(m.mealPlans, entryID)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) SetRecipeImage(ctx context.Context, id, imageURL string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[id]
+	if !ok {
+		return "", ErrNotFound
+	}
+	previous := rec.recipe.ImageURL
+	rec.recipe.ImageURL = imageURL
+	rec.recipe.UpdatedAt = m.now()
+	return previous, nil
+}
+
+func (m *Memory) AddInstructionImage(ctx context.Context, recipeID string, position int, imageURL string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[recipeID]
+	if !ok {
+		return ErrNotFound
+	}
+	for i := range rec.recipe.Instructions {
+		if inst := &rec.recipe.Instructions[i]; inst.Position == position {
+			inst.Images = append(inst.Images, imageURL)
+			rec.recipe.UpdatedAt = m.now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *Memory) RemoveInstructionImage(ctx context.Context, recipeID string, position, n int) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.recipes[recipeID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	for i := range rec.recipe.Instructions {
+		if inst := &rec.recipe.Instructions[i]; inst.Position == position {
+			if n < 1 || n > len(inst.Images) {
+				return "", ErrNotFound
+			}
+			removed := inst.Images[n-1]
+			inst.Images = append(inst.Images[:n-1:n-1], inst.Images[n:]...)
+			rec.recipe.UpdatedAt = m.now()
+			return removed, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// matchesRecipeFilter applies every constraint of filter but its pantry
+// ones, which matchesPantry applies.
+func matchesRecipeFilter(recipe *models.Recipe, filter models.RecipeFilter) bool {
+	if filter.Category != "" && recipe.Category != filter.Category {
+		return false
+	}
+	if filter.Cuisine != "" && recipe.Cuisine != filter.Cuisine {
+		return false
+	}
+	if filter.Difficulty != "" && recipe.Difficulty != filter.Difficulty {
+		return false
+	}
+	if len(filter.Tags) > 0 && filter.TagMatch == models.TagMatchAny {
+		found := false
+		for _, tag := range filter.Tags {
+			found = found || containsString(recipe.Tags, tag)
+		}
+		if !found {
+			return false
+		}
+	} else {
+		for _, tag := range filter.Tags {
+			if !containsString(recipe.Tags, tag) {
+				return false
+			}
+		}
+	}
+	if filter.MaxPrepTime > 0 && recipe.PrepTime > filter.MaxPrepTime {
+		return false
+	}
+	if filter.MaxCookTime > 0 && recipe.CookTime > filter.MaxCookTime {
+		return false
+	}
+	if filter.MinServings > 0 && recipe.Servings < filter.MinServings {
+		return false
+	}
+	if filter.MaxServings > 0 && recipe.Servings > filter.MaxServings {
+		return false
+	}
+	if filter.AuthorID != "" && recipe.AuthorID != filter.AuthorID {
+		return false
+	}
+	return recipe.Dietary.Fits(filter)
+}
+
+// matchesPantry reports whether recipe meets filter's pantry constraints
+// given the names in the pantry, and how much of it the pantry covers.
+func matchesPantry(recipe *models.Recipe, filter models.RecipeFilter, pantry map[string]bool) (models.PantryMatch, bool) {
+	if filter.PantryUserID == "" {
+		return models.PantryMatch{}, true
+	}
+	match := models.MatchPantry(recipe.Ingredients, pantry)
+	if match.Covered == 0 {
+		return match, false
+	}
+	if filter.MaxMissing != nil && match.Total-match.Covered > *filter.MaxMissing {
+		return match, false
+	}
+	return match, true
+}
+
+// searchRank reports whether recipe matches every search term and scores
+// it with the field weights recipeRankDocument uses. It approximates
+// ts_rank without stemming: each term scores its best-weighted field.
+// Ingredients also match by the terms of their catalog entries.
+func searchRank(recipe *models.Recipe, terms []string, catalog map[string]*models.CatalogIngredient) (float64, bool) {
+	var rank float64
+	for _, term := range terms {
+		best := 0.0
+		if matchesTerm(recipe.Title, term) {
+			best = 1.0
+		} else if matchesTerm(recipe.Description, term) || matchesAnyTerm(recipe.Tags, term) {
+			best = 0.4
+		} else if matchesIngredient(recipe.Ingredients, term, catalog) {
+			best = 0.2
+		}
+		if best == 0 {
+			return 0, false
+		}
+		rank += best
+	}
+	return rank, true
+}
+
+func matchesAnyTerm(values []string, term string) bool {
+	for _, v := range values {
+		if matchesTerm(v, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesIngredient(ingredients []models.Ingredient, term string, catalog map[string]*models.CatalogIngredient) bool {
+	for _, ing := range ingredients {
+		if matchesTerm(ing.Name, term) {
+			return true
+		}
+		if entry, ok := catalog[ing.CatalogID]; ok && matchesAnyTerm(entry.Terms(), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortRecipeRecords orders records exactly like recipeSortKeys does in SQL.
+// ranks is only consulted for SortRelevance, and matches for SortPantry.
+func sortRecipeRecords(records []*recipeRecord, order RecipeSort, ranks map[*recipeRecord]float64, matches map[*recipeRecord]models.PantryMatch) {
+	sort.Slice(records, func(i, j int) bool {
+		return compareRecipeRecords(records[i], records[j], order, ranks, matches) < 0
+	})
+}
+
+// compareRecipeRecords returns -1, 0 or 1 as a is listed before, at the
+// same position as or after b.
+func compareRecipeRecords(a, b *recipeRecord, order RecipeSort, ranks map[*recipeRecord]float64, matches map[*recipeRecord]models.PantryMatch) int {
+	ra, rb := &a.recipe, &b.recipe
+	if order == SortRelevance && ranks[a] != ranks[b] {
+		if ranks[a] > ranks[b] {
+			return -1
+		}
+		return 1
+	}
+	if order == SortPantry {
+		ma, mb := matches[a], matches[b]
+		if ma.Covered != mb.Covered {
+			if ma.Covered > mb.Covered {
+				return -1
+			}
+			return 1
+		}
+		if missingA, missingB := ma.Total-ma.Covered, mb.Total-mb.Covered; missingA != missingB {
+			if missingA < missingB {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch order {
+	case SortOldest:
+		if c := ra.CreatedAt.Compare(rb.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(ra.ID, rb.ID)
+	case SortTitle:
+		if c := strings.Compare(strings.ToLower(ra.Title), strings.ToLower(rb.Title)); c != 0 {
+			return c
+		}
+		return strings.Compare(ra.ID, rb.ID)
+	case SortRating:
+		if ra.RatingAverage != rb.RatingAverage {
+			if ra.RatingAverage > rb.RatingAverage {
+				return -1
+			}
+			return 1
+		}
+		if ra.RatingCount != rb.RatingCount {
+			if ra.RatingCount > rb.RatingCount {
+				return -1
+			}
+			return 1
+		}
+		fallthrough
+	default:
+		if c := rb.CreatedAt.Compare(ra.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(rb.ID, ra.ID)
+	}
+}
+
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+func assignChildIDs(recipe *models.Recipe) {
+	for i := range recipe.Ingredients {
+		recipe.Ingredients[i].ID = uuid.NewString()
+	}
+	for i := range recipe.Instructions {
+		recipe.Instructions[i].ID = uuid.NewString()
+	}
+}
+
+// copyRecipe returns a deep copy so callers never share slices with the store.
+func copyRecipe(recipe *models.Recipe) models.Recipe {
+	c := *recipe
+	c.Ingredients = append([]models.Ingredient{}, recipe.Ingredients...)
+	c.Instructions = append([]models.Instruction{}, recipe.Instructions...)
+	for i := range c.Instructions {
+		if c.Instructions[i].Images != nil {
+			c.Instructions[i].Images = append([]string{}, c.Instructions[i].Images...)
+		}
+	}
+	c.Tags = append([]string{}, recipe.Tags...)
+	if recipe.Nutrition != nil {
+		nutrition := copyNutrition(recipe.Nutrition)
+		c.Nutrition = &nutrition
+	}
+	c.Dietary.Diets = append([]string{}, recipe.Dietary.Diets...)
+	c.Dietary.Allergens = append([]string{}, recipe.Dietary.Allergens...)
+	return c
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[token.UserID]; !ok {
+		return ErrNotFound
+	}
+	m.insertRefreshToken(token)
+	return nil
+}
+
+func (m *Memory) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	token, ok := m.refreshTokens[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyRefreshToken(token), nil
+}
+
+func (m *Memory) RotateRefreshToken(ctx context.Context, hash string, next *models.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.refreshTokens[hash]
+	if !ok {
+		return ErrNotFound
+	}
+	now := m.now()
+	if err := checkRefreshToken(current, now); err != nil {
+		if errors.Is(err, ErrTokenReused) {
+			m.revokeRefreshTokenFamily(current.FamilyID, now)
+		}
+		return err
+	}
+
+	current.UsedAt = &now
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	m.insertRefreshToken(next)
+	return nil
+}
+
+func (m *Memory) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revokeRefreshTokenFamily(familyID, m.now())
+	return nil
+}
+
+// insertRefreshToken stores a copy of token. Callers must hold the write lock.
+func (m *Memory) insertRefreshToken(token *models.RefreshToken) {
+	token.ID = uuid.NewString()
+	token.CreatedAt = m.now()
+	token.UsedAt = nil
+	token.RevokedAt = nil
+	m.refreshTokens[token.TokenHash] = copyRefreshToken(token)
+}
+
+// revokeRefreshTokenFamily marks every live token in the family revoked.
+// Callers must hold the write lock.
+func (m *Memory) revokeRefreshTokenFamily(familyID string, now time.Time) {
+	for _, token := range m.refreshTokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+		}
+	}
+}
+
+func copyRefreshToken(token *models.RefreshToken) *models.RefreshToken {
+	c := *token
+	if token.UsedAt != nil {
+		usedAt := *token.UsedAt
+		c.UsedAt = &usedAt
+	}
+	if token.RevokedAt != nil {
+		revokedAt := *token.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return &c
+}
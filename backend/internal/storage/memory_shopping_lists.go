@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) ListShoppingLists(ctx context.Context, userID string) ([]models.ShoppingList, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	lists := []models.ShoppingList{}
+	for _, list := range m.shoppingLists {
+		if list.UserID == userID {
+			lists = append(lists, copyShoppingList(list))
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if !lists[i].CreatedAt.Equal(lists[j].CreatedAt) {
+			return lists[i].CreatedAt.After(lists[j].CreatedAt)
+		}
+		return lists[i].ID < lists[j].ID
+	})
+	return lists, nil
+}
+
+func (m *Memory) GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list, ok := m.shoppingLists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	l := copyShoppingList(list)
+	return &l, nil
+}
+
+func (m *Memory) CreateShoppingList(ctx context.Context, list *models.ShoppingList) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[list.UserID]; !ok {
+		return ErrNotFound
+	}
+
+	list.ID = uuid.NewString()
+	list.CreatedAt = m.now()
+	list.UpdatedAt = list.CreatedAt
+	if list.Items == nil {
+		list.Items = []models.ShoppingListItem{}
+	}
+	for i := range list.Items {
+		list.Items[i].ID = uuid.NewString()
+		list.Items[i].ListID = list.ID
+		list.Items[i].Position = i + 1
+	}
+	stored := copyShoppingList(list)
+	m.shoppingLists[list.ID] = &stored
+	return nil
+}
+
+func (m *Memory) SetShoppingListItemChecked(ctx context.Context, listID, itemID string, checked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list, ok := m.shoppingLists[listID]
+	if !ok {
+		return ErrNotFound
+	}
+	for i := range list.Items {
+		if list.Items[i].ID == itemID {
+			list.Items[i].Checked = checked
+			list.UpdatedAt = m.now()
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *Memory) DeleteShoppingList(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.shoppingLists[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.shoppingLists, id)
+	return nil
+}
+
+func copyShoppingList(list *models.ShoppingList) models.ShoppingList {
+	l := *list
+	l.Items = append([]models.ShoppingListItem{}, list.Items...)
+	return l
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+func TestMemory_RecipeRepository(t *testing.T) {
+	testRecipeRepository(t, func(t *testing.T) RecipeRepository {
+		return NewMemory()
+	})
+}
+
+func TestMemory_UserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) UserRepository {
+		return NewMemory()
+	})
+}
+
+func TestMemory_RefreshTokenRepository(t *testing.T) {
+	testRefreshTokenRepository(t, func(t *testing.T) refreshTokenStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_CollectionRepository(t *testing.T) {
+	testCollectionRepository(t, func(t *testing.T) collectionStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_RatingRepository(t *testing.T) {
+	testRatingRepository(t, func(t *testing.T) ratingStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_MealPlanRepository(t *testing.T) {
+	testMealPlanRepository(t, func(t *testing.T) mealPlanStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_ShoppingListRepository(t *testing.T) {
+	testShoppingListRepository(t, func(t *testing.T) shoppingListStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_PantryRepository(t *testing.T) {
+	testPantryRepository(t, func(t *testing.T) pantryStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_CatalogRepository(t *testing.T) {
+	testCatalogRepository(t, func(t *testing.T) catalogStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_NutritionRepository(t *testing.T) {
+	testNutritionRepository(t, func(t *testing.T) nutritionStore {
+		return NewMemory()
+	})
+}
+
+func TestMemory_ReturnsCopies(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	recipe := models.Recipe{Title: "Salad", Ingredients: []models.Ingredient{{Name: "lettuce"}}, Tags: []string{"green"}}
+	if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+		t.Fatalf("CreateRecipe() error = %v", err)
+	}
+	recipe.Ingredients[0].Name = "mutated"
+
+	got, err := repo.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	got.Tags[0] = "mutated"
+
+	again, _ := repo.GetRecipe(ctx, recipe.ID)
+	if again.Ingredients[0].Name != "lettuce" || again.Tags[0] != "green" {
+		t.Errorf("stored recipe was mutated through a returned value: %+v", again)
+	}
+}
+
+func TestMemory_ConcurrentAccess(t *testing.T) {
+	repo := NewMemory()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recipe := models.Recipe{Title: fmt.Sprintf("Recipe %d", i)}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Errorf("CreateRecipe() error = %v", err)
+				return
+			}
+			if _, err := repo.ListRecipes(ctx, RecipeQuery{}); err != nil {
+				t.Errorf("ListRecipes() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	result, err := repo.ListRecipes(ctx, RecipeQuery{})
+	if err != nil {
+		t.Fatalf("ListRecipes() error = %v", err)
+	}
+	if result.Total != 20 {
+		t.Errorf("Total = %d, want 20", result.Total)
+	}
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+func (m *Memory) CreateUser(ctx context.Context, user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prepareUser(user)
+	if err := m.checkUserUnique(user); err != nil {
+		return err
+	}
+
+	user.ID = uuid.NewString()
+	user.CreatedAt = m.now()
+	user.UpdatedAt = user.CreatedAt
+
+	stored := copyUser(user)
+	m.users[user.ID] = &stored
+	return nil
+}
+
+func (m *Memory) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found := copyUser(user)
+	return &found, nil
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	email = normalizeEmail(email)
+	for _, user := range m.users {
+		if user.Email == email {
+			found := copyUser(user)
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) UpdateUser(ctx context.Context, user *models.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.users[user.ID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	prepareUser(user)
+	if err := m.checkUserUnique(user); err != nil {
+		return err
+	}
+
+	user.CreatedAt = existing.CreatedAt
+	user.UpdatedAt = m.now()
+	*existing = copyUser(user)
+	return nil
+}
+
+// checkUserUnique reports which unique column user would violate, checking
+// email first like the users table constraints. Callers must hold the lock.
+func (m *Memory) checkUserUnique(user *models.User) error {
+	for id, other := range m.users {
+		if id == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return ErrEmailTaken
+		}
+	}
+	for id, other := range m.users {
+		if id != user.ID && other.Username == user.Username {
+			return ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+func copyUser(user *models.User) models.User {
+	c := *user
+	c.Diets = append([]string{}, user.Diets...)
+	c.AvoidAllergens = append([]string{}, user.AvoidAllergens...)
+	return c
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"recipe-app/migrations"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_ratings.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"001_initial.up.sql":       {Data: []byte("CREATE TABLE a ();")},
+		"001_initial.down.sql":     {Data: []byte("DROP TABLE a;")},
+		"010_without_down.up.sql":  {Data: []byte("CREATE TABLE c ();")},
+		"README.md":                {Data: []byte("ignored")},
+		"002_add_ratings.down.sql": {Data: []byte("DROP TABLE b;")},
+	}
+
+	loaded, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+
+	want := []struct {
+		version int
+		name    string
+		hasDown bool
+	}{
+		{1, "initial", true},
+		{2, "add_ratings", true},
+		{10, "without_down", false},
+	}
+	if len(loaded) != len(want) {
+		t.Fatalf("LoadMigrations() returned %d migrations, want %d", len(loaded), len(want))
+	}
+	for i, w := range want {
+		if loaded[i].Version != w.version || loaded[i].Name != w.name || (loaded[i].Down != "") != w.hasDown {
+			t.Errorf("migration %d = %+v, want %+v", i, loaded[i], w)
+		}
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"Bad file name", fstest.MapFS{"initial.sql": {Data: []byte("SELECT 1;")}}},
+		{"Down without up", fstest.MapFS{"001_initial.down.sql": {Data: []byte("SELECT 1;")}}},
+		{"Conflicting names", fstest.MapFS{
+			"001_initial.up.sql": {Data: []byte("SELECT 1;")},
+			"001_other.down.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.fsys); err == nil {
+				t.Error("LoadMigrations() expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.Files)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	if len(loaded) == 0 || loaded[0].Version != 1 {
+		t.Fatalf("embedded migrations should start at version 1, got %+v", loaded)
+	}
+	for i, migration := range loaded {
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+		if i > 0 && migration.Version != loaded[i-1].Version+1 {
+			t.Errorf("migration versions skip from %d to %d", loaded[i-1].Version, migration.Version)
+		}
+	}
+}
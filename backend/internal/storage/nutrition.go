@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const nutritionColumns = `id, recipe_id, COALESCE(calories, 0), COALESCE(protein, 0), COALESCE(carbs, 0),
+	COALESCE(fat, 0), COALESCE(fiber, 0), COALESCE(sugar, 0), COALESCE(sodium, 0),
+	COALESCE(serving_size, ''), source, unmatched, updated_at`
+
+func (db *DB) GetNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error) {
+	recipe, err := db.GetRecipe(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if recipe.Nutrition == nil {
+		// Not backfilled yet; estimate without storing, as reads do not
+		// write.
+		return computeNutrition(recipe), nil
+	}
+	return recipe.Nutrition, nil
+}
+
+// nutritionBackfillBatch is how many recipes BackfillNutrition computes
+// facts for per transaction.
+const nutritionBackfillBatch = 100
+
+// BackfillNutrition computes and stores nutrition facts for every recipe
+// that has none, as recipes written before facts were computed do, and
+// returns how many it filled in. It is safe to run repeatedly.
+func (db *DB) BackfillNutrition(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n := 0
+		err := db.withTx(ctx, func(tx *sql.Tx) error {
+			recipes, err := scanRecipes(ctx, tx, `
+				SELECT `+recipeColumns+` FROM recipes r
+				WHERE NOT EXISTS (SELECT 1 FROM nutrition_info n WHERE n.recipe_id = r.id)
+				ORDER BY r.id LIMIT $1 FOR UPDATE OF r SKIP LOCKED`, nutritionBackfillBatch)
+			if err != nil {
+				return err
+			}
+			if err := loadRecipeChildren(ctx, tx, recipes); err != nil {
+				return err
+			}
+			for i := range recipes {
+				if _, err := saveNutrition(ctx, tx, computeNutrition(&recipes[i]), true); err != nil {
+					return err
+				}
+			}
+			n = len(recipes)
+			return nil
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to backfill nutrition: %w", err)
+		}
+		total += n
+		if n < nutritionBackfillBatch {
+			return total, nil
+		}
+	}
+}
+
+func (db *DB) SetNutrition(ctx context.Context, info *models.NutritionInfo) error {
+	if uuid.Validate(info.RecipeID) != nil {
+		return ErrNotFound
+	}
+
+	info.Source = models.NutritionSourceManual
+	info.Unmatched = []string{}
+	if _, err := saveNutrition(ctx, db, info, false); err != nil {
+		return referenceError(err, "failed to save nutrition")
+	}
+	return nil
+}
+
+func (db *DB) ClearNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error) {
+	if uuid.Validate(recipeID) != nil {
+		return nil, ErrNotFound
+	}
+
+	var info *models.NutritionInfo
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		recipes, err := scanRecipes(ctx, tx, "SELECT "+recipeColumns+" FROM recipes r WHERE r.id = $1 FOR UPDATE", recipeID)
+		if err != nil {
+			return err
+		}
+		if len(recipes) == 0 {
+			return ErrNotFound
+		}
+		if err := loadRecipeChildren(ctx, tx, recipes); err != nil {
+			return err
+		}
+
+		info = computeNutrition(&recipes[0])
+		_, err = saveNutrition(ctx, tx, info, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// refreshNutrition recomputes a recipe's facts, unless its author entered
+// them, and sets recipe.Nutrition to the stored facts.
+func refreshNutrition(ctx context.Context, q queryer, recipe *models.Recipe) error {
+	info := computeNutrition(recipe)
+	saved, err := saveNutrition(ctx, q, info, true)
+	if err != nil {
+		return err
+	}
+	if saved {
+		recipe.Nutrition = info
+		return nil
+	}
+	recipe.Nutrition, err = getNutrition(ctx, q, recipe.ID)
+	return err
+}
+
+// saveNutrition upserts a recipe's facts, filling in their ID and
+// timestamp. With keepManual it leaves author-entered facts in place and
+// reports false.
+func saveNutrition(ctx context.Context, q queryer, info *models.NutritionInfo, keepManual bool) (bool, error) {
+	stmt := `
+		INSERT INTO nutrition_info (recipe_id, calories, protein, carbs, fat, fiber, sugar, sodium, serving_size, source, unmatched)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (recipe_id) DO UPDATE SET
+			calories = EXCLUDED.calories, protein = EXCLUDED.protein, carbs = EXCLUDED.carbs, fat = EXCLUDED.fat,
+			fiber = EXCLUDED.fiber, sugar = EXCLUDED.sugar, sodium = EXCLUDED.sodium, serving_size = EXCLUDED.serving_size,
+			source = EXCLUDED.source, unmatched = EXCLUDED.unmatched, updated_at = NOW()`
+	if keepManual {
+		stmt += " WHERE nutrition_info.source = 'computed'"
+	}
+	err := q.QueryRowContext(ctx, stmt+" RETURNING id, updated_at",
+		info.RecipeID, info.Calories, info.Protein, info.Carbs, info.Fat, info.Fiber, info.Sugar, info.Sodium,
+		info.ServingSize, info.Source, pq.Array(info.Unmatched),
+	).Scan(&info.ID, &info.UpdatedAt)
+	if keepManual && errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save nutrition: %w", err)
+	}
+	return true, nil
+}
+
+func getNutrition(ctx context.Context, q queryer, recipeID string) (*models.NutritionInfo, error) {
+	infos, err := scanNutrition(ctx, q, "SELECT "+nutritionColumns+" FROM nutrition_info WHERE recipe_id = $1", recipeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, ErrNotFound
+	}
+	return &infos[0], nil
+}
+
+func scanNutrition(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.NutritionInfo, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nutrition: %w", err)
+	}
+	defer rows.Close()
+
+	infos := []models.NutritionInfo{}
+	for rows.Next() {
+		var n models.NutritionInfo
+		if err := rows.Scan(&n.ID, &n.RecipeID, &n.Calories, &n.Protein, &n.Carbs, &n.Fat, &n.Fiber, &n.Sugar,
+			&n.Sodium, &n.ServingSize, &n.Source, pq.Array(&n.Unmatched), &n.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan nutrition: %w", err)
+		}
+		infos = append(infos, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nutrition: %w", err)
+	}
+	return infos, nil
+}
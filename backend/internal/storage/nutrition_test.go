@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// nutritionStore is the subset of Store the nutrition suite needs to
+// create and edit recipes.
+type nutritionStore interface {
+	RecipeRepository
+	NutritionRepository
+}
+
+// testNutritionRepository is the conformance suite for NutritionRepository
+// implementations. newRepo must return an empty repository.
+func testNutritionRepository(t *testing.T, newRepo func(t *testing.T) nutritionStore) {
+	setup := func(t *testing.T) (nutritionStore, *models.Recipe) {
+		t.Helper()
+		repo := newRepo(t)
+		recipe := &models.Recipe{
+			Title:    "Sweet eggs",
+			Servings: 2,
+			Ingredients: []models.Ingredient{
+				{Name: "eggs", Amount: "4"},
+				{Name: "sugar", Amount: "50", Unit: "g"},
+				{Name: "saffron", Amount: "1", Unit: "pinch"},
+			},
+		}
+		if err := repo.CreateRecipe(context.Background(), recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		return repo, recipe
+	}
+
+	t.Run("ComputedOnCreate", func(t *testing.T) {
+		repo, recipe := setup(t)
+
+		// 200 g of egg and 50 g of sugar, halved.
+		want := models.NutritionInfo{Calories: 239.75, Protein: 12.6, Sugar: 25.4, Sodium: 142.25}
+		for _, got := range []*models.NutritionInfo{recipe.Nutrition, mustGetNutrition(t, repo, recipe.ID)} {
+			if got == nil || got.Source != models.NutritionSourceComputed || got.ServingSize != "1/2 of recipe" {
+				t.Fatalf("nutrition = %+v, want computed facts for 1/2 of the recipe", got)
+			}
+			if got.Calories != want.Calories || got.Protein != want.Protein || got.Sugar != want.Sugar || got.Sodium != want.Sodium {
+				t.Errorf("nutrition = %+v, want %+v", got, want)
+			}
+			if len(got.Unmatched) != 1 || got.Unmatched[0] != "saffron" {
+				t.Errorf("Unmatched = %v, want [saffron]", got.Unmatched)
+			}
+		}
+
+		listed, err := repo.ListRecipes(context.Background(), RecipeQuery{})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if n := listed.Recipes[0].Nutrition; n == nil || n.Calories != want.Calories {
+			t.Errorf("listed nutrition = %+v, want %v calories", n, want.Calories)
+		}
+	})
+
+	t.Run("RecomputedOnUpdate", func(t *testing.T) {
+		repo, recipe := setup(t)
+		ctx := context.Background()
+		before := recipe.Nutrition
+
+		recipe.Servings = 4
+		recipe.Ingredients = recipe.Ingredients[:2]
+		recipe.Nutrition = &models.NutritionInfo{Calories: 1}
+		if err := repo.UpdateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		got := mustGetNutrition(t, repo, recipe.ID)
+		if got.Calories != 119.88 || got.ServingSize != "1/4 of recipe" || len(got.Unmatched) != 0 {
+			t.Errorf("nutrition after update = %+v, want 119.88 calories for 1/4 of the recipe", got)
+		}
+		if got.ID != before.ID || !got.UpdatedAt.After(before.UpdatedAt) {
+			t.Errorf("nutrition after update = %+v, want %s updated", got, before.ID)
+		}
+		if recipe.Nutrition.Calories != got.Calories {
+			t.Errorf("UpdateRecipe() nutrition = %+v, want the stored facts", recipe.Nutrition)
+		}
+	})
+
+	t.Run("ManualSurvivesUpdates", func(t *testing.T) {
+		repo, recipe := setup(t)
+		ctx := context.Background()
+
+		manual := &models.NutritionInfo{RecipeID: recipe.ID, Calories: 300, Protein: 20, ServingSize: "1 bowl"}
+		if err := repo.SetNutrition(ctx, manual); err != nil {
+			t.Fatalf("SetNutrition() error = %v", err)
+		}
+		if manual.Source != models.NutritionSourceManual || manual.ID == "" {
+			t.Errorf("SetNutrition() = %+v, want manual facts with an ID", manual)
+		}
+
+		recipe.Servings = 1
+		if err := repo.UpdateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		got := mustGetNutrition(t, repo, recipe.ID)
+		if got.Source != models.NutritionSourceManual || got.Calories != 300 || got.ServingSize != "1 bowl" {
+			t.Errorf("nutrition after update = %+v, want the manual facts", got)
+		}
+
+		cleared, err := repo.ClearNutrition(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("ClearNutrition() error = %v", err)
+		}
+		if cleared.Source != models.NutritionSourceComputed || cleared.Calories != 479.5 || cleared.ServingSize != "whole recipe" {
+			t.Errorf("ClearNutrition() = %+v, want computed facts for the whole recipe", cleared)
+		}
+		if got := mustGetNutrition(t, repo, recipe.ID); got.Calories != cleared.Calories {
+			t.Errorf("nutrition after clear = %+v, want %+v", got, cleared)
+		}
+	})
+
+	t.Run("MissingRecipe", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		missing := uuid.NewString()
+
+		if _, err := repo.GetNutrition(ctx, missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetNutrition() error = %v, want ErrNotFound", err)
+		}
+		if err := repo.SetNutrition(ctx, &models.NutritionInfo{RecipeID: missing}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetNutrition() error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.ClearNutrition(ctx, "not-a-uuid"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ClearNutrition() error = %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func mustGetNutrition(t *testing.T, repo NutritionRepository, recipeID string) *models.NutritionInfo {
+	t.Helper()
+	info, err := repo.GetNutrition(context.Background(), recipeID)
+	if err != nil {
+		t.Fatalf("GetNutrition() error = %v", err)
+	}
+	return info
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+func (db *DB) ListPantry(ctx context.Context, userID string) ([]models.PantryItem, error) {
+	if uuid.Validate(userID) != nil {
+		return []models.PantryItem{}, nil
+	}
+	return scanPantryItems(ctx, db,
+		`SELECT user_id, name, created_at FROM pantry_items WHERE user_id = $1 ORDER BY name COLLATE "C"`, userID)
+}
+
+func (db *DB) AddPantryItems(ctx context.Context, userID string, names []string) ([]models.PantryItem, error) {
+	if uuid.Validate(userID) != nil {
+		return nil, ErrNotFound
+	}
+
+	names = normalizePantryNames(names)
+	var items []models.PantryItem
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO pantry_items (user_id, name)
+			SELECT $1, name FROM unnest($2::text[]) AS name
+			ON CONFLICT (user_id, name) DO NOTHING`,
+			userID, pq.Array(names))
+		if err != nil {
+			return referenceError(err, "failed to add pantry items")
+		}
+
+		items, err = scanPantryItems(ctx, tx, `
+			SELECT user_id, name, created_at FROM pantry_items
+			WHERE user_id = $1 AND name = ANY($2::text[])
+			ORDER BY array_position($2::text[], name::text)`,
+			userID, pq.Array(names))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (db *DB) RemovePantryItem(ctx context.Context, userID, name string) error {
+	if uuid.Validate(userID) != nil {
+		return ErrNotFound
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM pantry_items WHERE user_id = $1 AND name = $2",
+		userID, models.NormalizeIngredientName(name))
+	if err != nil {
+		return fmt.Errorf("failed to remove pantry item: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanPantryItems(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.PantryItem, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pantry: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.PantryItem{}
+	for rows.Next() {
+		var item models.PantryItem
+		if err := rows.Scan(&item.UserID, &item.Name, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pantry item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pantry: %w", err)
+	}
+	return items, nil
+}
+
+// pantryArray selects the names in a user's pantry as a text array, which
+// Postgres evaluates once per statement.
+func pantryArray(b *sqlBuilder, userID string) string {
+	return "ARRAY(SELECT name::text FROM pantry_items WHERE user_id = " + b.arg(userID) + "::uuid)"
+}
+
+// loadPantryMatches fills result.Pantry for every recipe on the page,
+// matching ingredients in SQL exactly as the listing ranked them.
+func loadPantryMatches(ctx context.Context, q queryer, result *models.SearchResult, userID string) error {
+	result.Pantry = make(map[string]models.PantryMatch, len(result.Recipes))
+	if len(result.Recipes) == 0 {
+		return nil
+	}
+	ids := make([]string, len(result.Recipes))
+	for i, recipe := range result.Recipes {
+		ids[i] = recipe.ID
+	}
+
+	b := &sqlBuilder{}
+	stmt := fmt.Sprintf("SELECT id FROM ingredients WHERE recipe_id = ANY(%s::uuid[]) AND name_phrases && %s",
+		b.arg(pq.Array(ids)), pantryArray(b, userID))
+	rows, err := q.QueryContext(ctx, stmt, b.args...)
+	if err != nil {
+		return fmt.Errorf("failed to match pantry: %w", err)
+	}
+	defer rows.Close()
+
+	covered := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan pantry match: %w", err)
+		}
+		covered[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read pantry matches: %w", err)
+	}
+
+	for _, recipe := range result.Recipes {
+		match := models.PantryMatch{Total: len(recipe.Ingredients), Missing: []string{}}
+		for _, ing := range recipe.Ingredients {
+			if covered[ing.ID] {
+				match.Covered++
+			} else {
+				match.Missing = append(match.Missing, ing.Name)
+			}
+		}
+		result.Pantry[recipe.ID] = match
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// pantryStore is the subset of Store the pantry suite needs to create
+// users and the recipes it searches.
+type pantryStore interface {
+	UserRepository
+	RecipeRepository
+	PantryRepository
+}
+
+// testPantryRepository is the conformance suite for PantryRepository
+// implementations and pantry searches through ListRecipes. newRepo must
+// return an empty repository.
+func testPantryRepository(t *testing.T, newRepo func(t *testing.T) pantryStore) {
+	setup := func(t *testing.T) (pantryStore, string) {
+		t.Helper()
+		repo := newRepo(t)
+		user := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(context.Background(), &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		return repo, user.ID
+	}
+	names := func(items []models.PantryItem) string {
+		out := make([]string, len(items))
+		for i, item := range items {
+			out[i] = item.Name
+		}
+		return strings.Join(out, ", ")
+	}
+
+	t.Run("AddListRemove", func(t *testing.T) {
+		repo, userID := setup(t)
+		ctx := context.Background()
+
+		added, err := repo.AddPantryItems(ctx, userID, []string{"Eggs", "egg", "  ", "Olive Oil!"})
+		if err != nil {
+			t.Fatalf("AddPantryItems() error = %v", err)
+		}
+		if names(added) != "egg, olive oil" || added[0].UserID != userID || added[0].CreatedAt.IsZero() {
+			t.Fatalf("AddPantryItems() = %+v, want normalized names once each", added)
+		}
+
+		again, err := repo.AddPantryItems(ctx, userID, []string{"flour", "EGGS"})
+		if err != nil {
+			t.Fatalf("AddPantryItems() error = %v", err)
+		}
+		if names(again) != "flour, egg" || !again[1].CreatedAt.Equal(added[0].CreatedAt) {
+			t.Errorf("AddPantryItems() = %+v, want existing items kept", again)
+		}
+
+		items, err := repo.ListPantry(ctx, userID)
+		if err != nil {
+			t.Fatalf("ListPantry() error = %v", err)
+		}
+		if names(items) != "egg, flour, olive oil" {
+			t.Errorf("ListPantry() = %s, want name order", names(items))
+		}
+		if items, _ := repo.ListPantry(ctx, uuid.NewString()); len(items) != 0 {
+			t.Errorf("ListPantry() for another user = %+v, want none", items)
+		}
+		if _, err := repo.AddPantryItems(ctx, uuid.NewString(), []string{"salt"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddPantryItems() for a missing user error = %v, want ErrNotFound", err)
+		}
+
+		if err := repo.RemovePantryItem(ctx, userID, "Egg"); err != nil {
+			t.Fatalf("RemovePantryItem() error = %v", err)
+		}
+		if err := repo.RemovePantryItem(ctx, userID, "eggs"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RemovePantryItem() twice error = %v, want ErrNotFound", err)
+		}
+		if items, _ := repo.ListPantry(ctx, userID); names(items) != "flour, olive oil" {
+			t.Errorf("ListPantry() after removing = %s", names(items))
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo, userID := setup(t)
+		ctx := context.Background()
+
+		ingredients := func(names ...string) []models.Ingredient {
+			out := make([]models.Ingredient, len(names))
+			for i, name := range names {
+				out[i] = models.Ingredient{Name: name, Amount: "1"}
+			}
+			return out
+		}
+		recipes := []models.Recipe{
+			{Title: "Omelette", Category: "breakfast", Ingredients: ingredients("Eggs", "butter", "salt")},
+			{Title: "Pancakes", Category: "breakfast", Ingredients: ingredients("flour", "eggs", "milk", "unsalted butter")},
+			{Title: "Salad", Category: "lunch", Ingredients: ingredients("extra virgin olive oil", "lettuce")},
+			{Title: "Peanut Steak", Category: "dinner", Ingredients: ingredients("beef", "salted peanuts")},
+			{Title: "Scrambled Eggs", Category: "breakfast", Ingredients: ingredients("egg", "butter", "chives")},
+		}
+		for i := range recipes {
+			if err := repo.CreateRecipe(ctx, &recipes[i]); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+		}
+		if _, err := repo.AddPantryItems(ctx, userID, []string{"eggs", "butter", "salt", "olive oil"}); err != nil {
+			t.Fatalf("AddPantryItems() error = %v", err)
+		}
+
+		titles := func(result *models.SearchResult) string {
+			out := make([]string, len(result.Recipes))
+			for i, r := range result.Recipes {
+				out[i] = r.Title
+			}
+			return strings.Join(out, ", ")
+		}
+		filter := models.RecipeFilter{PantryUserID: userID}
+
+		result, err := repo.ListRecipes(ctx, RecipeQuery{Filter: filter})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if got := titles(result); got != "Omelette, Scrambled Eggs, Pancakes, Salad" || result.Total != 4 {
+			t.Errorf("ListRecipes() = %s (%d), want the most covered first and the steak left out", got, result.Total)
+		}
+		pancakes := result.Pantry[recipes[1].ID]
+		if pancakes.Covered != 2 || pancakes.Total != 4 || strings.Join(pancakes.Missing, ", ") != "flour, milk" {
+			t.Errorf("pancakes match = %+v", pancakes)
+		}
+		if salad := result.Pantry[recipes[2].ID]; salad.Covered != 1 || len(salad.Missing) != 1 {
+			t.Errorf("salad match = %+v, want olive oil to cover extra virgin olive oil", salad)
+		}
+
+		one := 1
+		filter.MaxMissing = &one
+		result, err = repo.ListRecipes(ctx, RecipeQuery{Filter: filter, PerPage: 2})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if got := titles(result); got != "Omelette, Scrambled Eggs" || result.Total != 3 || result.NextCursor == "" {
+			t.Fatalf("ListRecipes() with max missing = %s (%d)", got, result.Total)
+		}
+		next, err := repo.ListRecipes(ctx, RecipeQuery{Filter: filter, PerPage: 2, Cursor: result.NextCursor})
+		if err != nil {
+			t.Fatalf("ListRecipes() next error = %v", err)
+		}
+		if got := titles(next); got != "Salad" || next.NextCursor != "" || next.PrevCursor == "" {
+			t.Fatalf("next page = %s", got)
+		}
+		prev, err := repo.ListRecipes(ctx, RecipeQuery{Filter: filter, PerPage: 2, Cursor: next.PrevCursor})
+		if err != nil {
+			t.Fatalf("ListRecipes() prev error = %v", err)
+		}
+		if got := titles(prev); got != "Omelette, Scrambled Eggs" {
+			t.Errorf("previous page = %s", got)
+		}
+
+		zero := 0
+		facets, err := repo.RecipeFacets(ctx, RecipeQuery{Filter: models.RecipeFilter{PantryUserID: userID, MaxMissing: &zero}})
+		if err != nil {
+			t.Fatalf("RecipeFacets() error = %v", err)
+		}
+		if len(facets.Category) != 1 || facets.Category[0] != (models.FacetCount{Value: "breakfast", Count: 1}) {
+			t.Errorf("category facet = %+v, want only the omelette", facets.Category)
+		}
+
+		stranger := models.User{Email: "stranger@example.com", Username: "stranger", Password: "hash"}
+		if err := repo.CreateUser(ctx, &stranger); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		for _, id := range []string{stranger.ID, "not-a-uuid"} {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Filter: models.RecipeFilter{PantryUserID: id}})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if len(result.Recipes) != 0 {
+				t.Errorf("ListRecipes() with an empty pantry = %s, want none", titles(result))
+			}
+		}
+	})
+}
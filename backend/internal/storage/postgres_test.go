@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+// newTestDB connects to TEST_DATABASE_URL, skipping the test when it is not
+// set. It migrates the schema to the latest version and truncates all data.
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	connString := os.Getenv("TEST_DATABASE_URL")
+	if connString == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := NewDB(connString)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// Every table is listed or references one that is: the catalog is the
+	// only data that belongs to neither recipes nor users.
+	if _, err := db.Exec("TRUNCATE recipes, users, refresh_tokens, ingredient_catalog, ingredient_catalog_terms CASCADE"); err != nil {
+		t.Fatalf("failed to reset database: %v", err)
+	}
+	return db
+}
+
+func TestDB_RecipeRepository(t *testing.T) {
+	testRecipeRepository(t, func(t *testing.T) RecipeRepository {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_UserRepository(t *testing.T) {
+	testUserRepository(t, func(t *testing.T) UserRepository {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_RefreshTokenRepository(t *testing.T) {
+	testRefreshTokenRepository(t, func(t *testing.T) refreshTokenStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_CollectionRepository(t *testing.T) {
+	testCollectionRepository(t, func(t *testing.T) collectionStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_RatingRepository(t *testing.T) {
+	testRatingRepository(t, func(t *testing.T) ratingStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_MealPlanRepository(t *testing.T) {
+	testMealPlanRepository(t, func(t *testing.T) mealPlanStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_ShoppingListRepository(t *testing.T) {
+	testShoppingListRepository(t, func(t *testing.T) shoppingListStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_PantryRepository(t *testing.T) {
+	testPantryRepository(t, func(t *testing.T) pantryStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_CatalogRepository(t *testing.T) {
+	testCatalogRepository(t, func(t *testing.T) catalogStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_NutritionRepository(t *testing.T) {
+	testNutritionRepository(t, func(t *testing.T) nutritionStore {
+		return newTestDB(t)
+	})
+}
+
+func TestDB_MigrateDownAndUp(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if len(status.Pending) != 0 || status.Current != status.Latest {
+		t.Fatalf("Status() after Up = %+v, want fully applied", status)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if len(reverted) != 1 || reverted[0].Version != status.Latest {
+		t.Errorf("Down() reverted %+v, want version %d", reverted, status.Latest)
+	}
+	if err := migrator.CheckCurrent(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("CheckCurrent() error = %v, want ErrSchemaOutdated", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("Up() applied %d migrations, want 1", len(applied))
+	}
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		t.Errorf("CheckCurrent() error = %v", err)
+	}
+}
+
+func TestDB_BackfillNutrition(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	recipe := models.Recipe{Title: "Toast", Servings: 1, Ingredients: []models.Ingredient{{Name: "bread", Amount: "2", Unit: "slices"}}}
+	if err := db.CreateRecipe(ctx, &recipe); err != nil {
+		t.Fatalf("CreateRecipe() error = %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM nutrition_info WHERE recipe_id = $1`, recipe.ID); err != nil {
+		t.Fatalf("failed to drop nutrition row: %v", err)
+	}
+
+	got, err := db.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if got.Nutrition != nil {
+		t.Errorf("GetRecipe() Nutrition = %+v, want nil before backfill", got.Nutrition)
+	}
+
+	n, err := db.BackfillNutrition(ctx)
+	if err != nil {
+		t.Fatalf("BackfillNutrition() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("BackfillNutrition() = %d, want 1", n)
+	}
+	got, err = db.GetRecipe(ctx, recipe.ID)
+	if err != nil {
+		t.Fatalf("GetRecipe() error = %v", err)
+	}
+	if got.Nutrition == nil || got.Nutrition.Source != models.NutritionSourceComputed {
+		t.Errorf("GetRecipe() Nutrition = %+v, want computed facts", got.Nutrition)
+	}
+	if n, err := db.BackfillNutrition(ctx); err != nil || n != 0 {
+		t.Errorf("BackfillNutrition() again = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestDB_MigrationStatusIsReadOnly(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	if _, err := db.ExecContext(ctx, "ALTER TABLE schema_migrations RENAME TO schema_migrations_saved"); err != nil {
+		t.Fatalf("failed to set schema_migrations aside: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := db.ExecContext(context.Background(), "ALTER TABLE schema_migrations_saved RENAME TO schema_migrations"); err != nil {
+			t.Errorf("failed to restore schema_migrations: %v", err)
+		}
+	})
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if status.Current != 0 || len(status.Pending) != status.Latest {
+		t.Errorf("Status() without schema_migrations = %+v, want every migration pending", status)
+	}
+	if err := migrator.CheckCurrent(ctx); !errors.Is(err, ErrSchemaOutdated) {
+		t.Errorf("CheckCurrent() error = %v, want ErrSchemaOutdated", err)
+	}
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		t.Fatalf("failed to look up schema_migrations: %v", err)
+	}
+	if exists {
+		t.Error("Status() created schema_migrations")
+	}
+}
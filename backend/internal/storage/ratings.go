@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+const ratingColumns = `r.id, r.recipe_id, r.user_id, u.username, r.score, COALESCE(r.comment, ''), r.created_at, r.updated_at`
+
+func (db *DB) UpsertRating(ctx context.Context, rating *models.Rating) error {
+	if uuid.Validate(rating.RecipeID) != nil || uuid.Validate(rating.UserID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withRecipeRatings(ctx, rating.RecipeID, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO ratings (recipe_id, user_id, score, comment)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (recipe_id, user_id)
+			DO UPDATE SET score = EXCLUDED.score, comment = EXCLUDED.comment, updated_at = NOW()
+			RETURNING id, created_at, updated_at`,
+			rating.RecipeID, rating.UserID, rating.Score, rating.Comment,
+		).Scan(&rating.ID, &rating.CreatedAt, &rating.UpdatedAt)
+		if err != nil {
+			return referenceError(err, "failed to upsert rating")
+		}
+		return nil
+	})
+}
+
+func (db *DB) GetRating(ctx context.Context, recipeID, userID string) (*models.Rating, error) {
+	if uuid.Validate(recipeID) != nil || uuid.Validate(userID) != nil {
+		return nil, ErrNotFound
+	}
+
+	ratings, err := scanRatings(ctx, db, `
+		SELECT `+ratingColumns+` FROM ratings r JOIN users u ON u.id = r.user_id
+		WHERE r.recipe_id = $1 AND r.user_id = $2`, recipeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ratings) == 0 {
+		return nil, ErrNotFound
+	}
+	return &ratings[0], nil
+}
+
+func (db *DB) DeleteRating(ctx context.Context, recipeID, userID string) error {
+	if uuid.Validate(recipeID) != nil || uuid.Validate(userID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withRecipeRatings(ctx, recipeID, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM ratings WHERE recipe_id = $1 AND user_id = $2", recipeID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to delete rating: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (db *DB) ListRatings(ctx context.Context, query RatingQuery) (*models.RatingList, error) {
+	query = query.normalize()
+	result := &models.RatingList{
+		Ratings: []models.Rating{},
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+	if uuid.Validate(query.RecipeID) != nil {
+		return result, nil
+	}
+
+	if err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM ratings WHERE recipe_id = $1", query.RecipeID).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("failed to count ratings: %w", err)
+	}
+
+	ratings, err := scanRatings(ctx, db, `
+		SELECT `+ratingColumns+` FROM ratings r JOIN users u ON u.id = r.user_id
+		WHERE r.recipe_id = $1
+		ORDER BY r.updated_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3`,
+		query.RecipeID, query.PerPage, (query.Page-1)*query.PerPage)
+	if err != nil {
+		return nil, err
+	}
+	result.Ratings = ratings
+	return result, nil
+}
+
+// withRecipeRatings runs change in a transaction that holds the recipe's
+// row lock, then rewrites the recipe's rating summary. The lock serializes
+// concurrent changes so every summary is computed from committed ratings.
+func (db *DB) withRecipeRatings(ctx context.Context, recipeID string, change func(tx *sql.Tx) error) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		var id string
+		err := tx.QueryRowContext(ctx, "SELECT id FROM recipes WHERE id = $1 FOR UPDATE", recipeID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock recipe: %w", err)
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		// The average is a float8 division, like the in-memory store's.
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE recipes SET (rating_count, rating_average) = (
+				SELECT COUNT(*), COALESCE(SUM(score)::float8 / NULLIF(COUNT(*), 0), 0)
+				FROM ratings WHERE recipe_id = $1)
+			WHERE id = $1`, recipeID); err != nil {
+			return fmt.Errorf("failed to update rating summary: %w", err)
+		}
+		return nil
+	})
+}
+
+func scanRatings(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.Rating, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ratings: %w", err)
+	}
+	defer rows.Close()
+
+	ratings := []models.Rating{}
+	for rows.Next() {
+		var r models.Rating
+		if err := rows.Scan(&r.ID, &r.RecipeID, &r.UserID, &r.Username, &r.Score, &r.Comment, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan rating: %w", err)
+		}
+		ratings = append(ratings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ratings: %w", err)
+	}
+	return ratings, nil
+}
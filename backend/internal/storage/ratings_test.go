@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// ratingStore is the subset of Store the rating suite needs to create
+// raters and recipes.
+type ratingStore interface {
+	RecipeRepository
+	UserRepository
+	RatingRepository
+}
+
+// testRatingRepository is the conformance suite for RatingRepository
+// implementations. newRepo must return an empty repository.
+func testRatingRepository(t *testing.T, newRepo func(t *testing.T) ratingStore) {
+	// setup creates the recipes with the given titles and three users.
+	setup := func(t *testing.T, titles ...string) (ratingStore, []string, []string) {
+		t.Helper()
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		var recipeIDs, userIDs []string
+		for _, title := range titles {
+			recipe := models.Recipe{Title: title}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+			recipeIDs = append(recipeIDs, recipe.ID)
+		}
+		for i := 1; i <= 3; i++ {
+			user := models.User{Email: fmt.Sprintf("cook%d@example.com", i), Username: fmt.Sprintf("cook%d", i), Password: "hash"}
+			if err := repo.CreateUser(ctx, &user); err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+			userIDs = append(userIDs, user.ID)
+		}
+		return repo, recipeIDs, userIDs
+	}
+
+	rate := func(t *testing.T, repo ratingStore, recipeID, userID string, score int) *models.Rating {
+		t.Helper()
+		rating := &models.Rating{RecipeID: recipeID, UserID: userID, Score: score, Comment: fmt.Sprintf("%d stars", score)}
+		if err := repo.UpsertRating(context.Background(), rating); err != nil {
+			t.Fatalf("UpsertRating() error = %v", err)
+		}
+		return rating
+	}
+
+	summary := func(t *testing.T, repo ratingStore, recipeID string) (float64, int) {
+		t.Helper()
+		recipe, err := repo.GetRecipe(context.Background(), recipeID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		return recipe.RatingAverage, recipe.RatingCount
+	}
+
+	t.Run("UpsertMaintainsSummary", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup")
+		ctx := context.Background()
+
+		first := rate(t, repo, recipes[0], users[0], 4)
+		if first.ID == "" || first.CreatedAt.IsZero() || !first.UpdatedAt.Equal(first.CreatedAt) {
+			t.Fatalf("UpsertRating() = %+v, want an ID and equal timestamps", first)
+		}
+		rate(t, repo, recipes[0], users[1], 5)
+		if avg, count := summary(t, repo, recipes[0]); avg != 4.5 || count != 2 {
+			t.Errorf("summary = %v, %d, want 4.5, 2", avg, count)
+		}
+
+		again := rate(t, repo, recipes[0], users[0], 2)
+		if again.ID != first.ID || !again.CreatedAt.Equal(first.CreatedAt) || !again.UpdatedAt.After(first.UpdatedAt) {
+			t.Errorf("re-rating = %+v, want the same rating updated from %+v", again, first)
+		}
+		if avg, count := summary(t, repo, recipes[0]); avg != 3.5 || count != 2 {
+			t.Errorf("summary after re-rating = %v, %d, want 3.5, 2", avg, count)
+		}
+
+		got, err := repo.GetRating(ctx, recipes[0], users[0])
+		if err != nil {
+			t.Fatalf("GetRating() error = %v", err)
+		}
+		if got.Score != 2 || got.Comment != "2 stars" || got.Username != "cook1" {
+			t.Errorf("GetRating() = %+v", got)
+		}
+
+		result, err := repo.ListRecipes(ctx, RecipeQuery{})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if r := result.Recipes[0]; r.RatingAverage != 3.5 || r.RatingCount != 2 {
+			t.Errorf("listed summary = %v, %d, want 3.5, 2", r.RatingAverage, r.RatingCount)
+		}
+	})
+
+	t.Run("SummaryIgnoresRecipeWrites", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup")
+		ctx := context.Background()
+		rate(t, repo, recipes[0], users[0], 5)
+
+		recipe, err := repo.GetRecipe(ctx, recipes[0])
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		recipe.RatingAverage, recipe.RatingCount = 1, 99
+		if err := repo.UpdateRecipe(ctx, recipe); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		if recipe.RatingAverage != 5 || recipe.RatingCount != 1 {
+			t.Errorf("UpdateRecipe() summary = %v, %d, want 5, 1", recipe.RatingAverage, recipe.RatingCount)
+		}
+		if avg, count := summary(t, repo, recipes[0]); avg != 5 || count != 1 {
+			t.Errorf("summary = %v, %d, want 5, 1", avg, count)
+		}
+
+		created := models.Recipe{Title: "Stew", RatingAverage: 5, RatingCount: 10}
+		if err := repo.CreateRecipe(ctx, &created); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		if avg, count := summary(t, repo, created.ID); avg != 0 || count != 0 {
+			t.Errorf("new recipe summary = %v, %d, want 0, 0", avg, count)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup")
+		ctx := context.Background()
+		rate(t, repo, recipes[0], users[0], 4)
+		rate(t, repo, recipes[0], users[1], 1)
+
+		if err := repo.DeleteRating(ctx, recipes[0], users[1]); err != nil {
+			t.Fatalf("DeleteRating() error = %v", err)
+		}
+		if avg, count := summary(t, repo, recipes[0]); avg != 4 || count != 1 {
+			t.Errorf("summary = %v, %d, want 4, 1", avg, count)
+		}
+		if _, err := repo.GetRating(ctx, recipes[0], users[1]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetRating() after delete error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteRating(ctx, recipes[0], users[1]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteRating() twice error = %v, want ErrNotFound", err)
+		}
+
+		if err := repo.DeleteRating(ctx, recipes[0], users[0]); err != nil {
+			t.Fatalf("DeleteRating() error = %v", err)
+		}
+		if avg, count := summary(t, repo, recipes[0]); avg != 0 || count != 0 {
+			t.Errorf("summary without ratings = %v, %d, want 0, 0", avg, count)
+		}
+	})
+
+	t.Run("MissingRecipeOrUser", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup")
+		ctx := context.Background()
+
+		for _, rating := range []models.Rating{
+			{RecipeID: uuid.NewString(), UserID: users[0], Score: 3},
+			{RecipeID: recipes[0], UserID: uuid.NewString(), Score: 3},
+			{RecipeID: "not-a-uuid", UserID: users[0], Score: 3},
+		} {
+			if err := repo.UpsertRating(ctx, &rating); !errors.Is(err, ErrNotFound) {
+				t.Errorf("UpsertRating(%+v) error = %v, want ErrNotFound", rating, err)
+			}
+		}
+		if err := repo.DeleteRating(ctx, uuid.NewString(), users[0]); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteRating() for a missing recipe error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ListPaged", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup", "Stew")
+		ctx := context.Background()
+		rate(t, repo, recipes[0], users[0], 3)
+		rate(t, repo, recipes[0], users[1], 4)
+		rate(t, repo, recipes[0], users[2], 5)
+		rate(t, repo, recipes[1], users[0], 1)
+		// Re-rating moves cook1's review to the front.
+		rate(t, repo, recipes[0], users[0], 2)
+
+		var pages []string
+		for page := 1; page <= 2; page++ {
+			list, err := repo.ListRatings(ctx, RatingQuery{RecipeID: recipes[0], Page: page, PerPage: 2})
+			if err != nil {
+				t.Fatalf("ListRatings() error = %v", err)
+			}
+			if list.Total != 3 || list.Page != page || list.PerPage != 2 {
+				t.Errorf("ListRatings(page %d) = total %d, page %d, per page %d", page, list.Total, list.Page, list.PerPage)
+			}
+			var names []string
+			for _, rating := range list.Ratings {
+				names = append(names, fmt.Sprintf("%s:%d", rating.Username, rating.Score))
+			}
+			pages = append(pages, strings.Join(names, ","))
+		}
+		if got, want := strings.Join(pages, "|"), "cook1:2,cook3:5|cook2:4"; got != want {
+			t.Errorf("ListRatings() pages = %s, want %s", got, want)
+		}
+
+		empty, err := repo.ListRatings(ctx, RatingQuery{RecipeID: uuid.NewString()})
+		if err != nil {
+			t.Fatalf("ListRatings() error = %v", err)
+		}
+		if empty.Total != 0 || empty.Ratings == nil || empty.PerPage != DefaultPerPage {
+			t.Errorf("ListRatings() for an unrated recipe = %+v", empty)
+		}
+	})
+
+	t.Run("SortByRating", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Unrated", "Single five", "Double five", "Three")
+		ctx := context.Background()
+		rate(t, repo, recipes[1], users[0], 5)
+		rate(t, repo, recipes[2], users[0], 5)
+		rate(t, repo, recipes[2], users[1], 5)
+		rate(t, repo, recipes[3], users[0], 3)
+
+		want := "Double five,Single five,Three,Unrated"
+		result, err := repo.ListRecipes(ctx, RecipeQuery{Sort: SortRating})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if got := recipeTitles(result.Recipes); got != want {
+			t.Errorf("ListRecipes(rating) = %s, want %s", got, want)
+		}
+
+		var titles []string
+		query := RecipeQuery{Sort: SortRating, PerPage: 1}
+		for i := 0; i < len(recipes); i++ {
+			page, err := repo.ListRecipes(ctx, query)
+			if err != nil {
+				t.Fatalf("ListRecipes(cursor) error = %v", err)
+			}
+			titles = append(titles, recipeTitles(page.Recipes))
+			query.Cursor = page.NextCursor
+		}
+		if got := strings.Join(titles, ","); got != want || query.Cursor != "" {
+			t.Errorf("cursor pages = %s (next %q), want %s", got, query.Cursor, want)
+		}
+	})
+
+	t.Run("DeletingRecipeDeletesRatings", func(t *testing.T) {
+		repo, recipes, users := setup(t, "Soup")
+		ctx := context.Background()
+		rate(t, repo, recipes[0], users[0], 4)
+
+		if err := repo.DeleteRecipe(ctx, recipes[0]); err != nil {
+			t.Fatalf("DeleteRecipe() error = %v", err)
+		}
+		list, err := repo.ListRatings(ctx, RatingQuery{RecipeID: recipes[0]})
+		if err != nil {
+			t.Fatalf("ListRatings() error = %v", err)
+		}
+		if list.Total != 0 {
+			t.Errorf("ListRatings() total = %d after deleting the recipe, want 0", list.Total)
+		}
+	})
+}
@@ -0,0 +1,718 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const recipeColumns = `r.id, r.title, COALESCE(r.description, ''), COALESCE(r.prep_time, 0),
+	COALESCE(r.cook_time, 0), COALESCE(r.servings, 0), COALESCE(r.difficulty, ''),
+	COALESCE(r.category, ''), COALESCE(r.cuisine, ''), COALESCE(r.image_url, ''),
+	COALESCE(r.author_id::text, ''), r.rating_average, r.rating_count, r.diets, r.allergens, r.dietary_complete,
+	r.created_at, r.updated_at`
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (db *DB) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// sqlBuilder collects WHERE conditions and their positional arguments.
+type sqlBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+func (b *sqlBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *sqlBuilder) where(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *sqlBuilder) whereClause() string {
+	if len(b.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conds, " AND ")
+}
+
+func applyRecipeFilter(b *sqlBuilder, filter models.RecipeFilter) {
+	if filter.Category != "" {
+		b.where("r.category = " + b.arg(filter.Category))
+	}
+	if filter.Cuisine != "" {
+		b.where("r.cuisine = " + b.arg(filter.Cuisine))
+	}
+	if filter.Difficulty != "" {
+		b.where("r.difficulty = " + b.arg(filter.Difficulty))
+	}
+	if len(filter.Tags) > 0 && filter.TagMatch == models.TagMatchAny {
+		b.where(fmt.Sprintf("r.id IN (SELECT recipe_id FROM recipe_tags WHERE tag = ANY(%s))", b.arg(pq.Array(filter.Tags))))
+	} else if len(filter.Tags) > 0 {
+		b.where(fmt.Sprintf(
+			"r.id IN (SELECT recipe_id FROM recipe_tags WHERE tag = ANY(%s) GROUP BY recipe_id HAVING COUNT(*) = %s)",
+			b.arg(pq.Array(filter.Tags)), b.arg(len(filter.Tags)),
+		))
+	}
+	if filter.MaxPrepTime > 0 {
+		b.where("COALESCE(r.prep_time, 0) <= " + b.arg(filter.MaxPrepTime))
+	}
+	if filter.MaxCookTime > 0 {
+		b.where("COALESCE(r.cook_time, 0) <= " + b.arg(filter.MaxCookTime))
+	}
+	if filter.MinServings > 0 {
+		b.where("COALESCE(r.servings, 0) >= " + b.arg(filter.MinServings))
+	}
+	if filter.MaxServings > 0 {
+		b.where("COALESCE(r.servings, 0) <= " + b.arg(filter.MaxServings))
+	}
+	if filter.AuthorID != "" && uuid.Validate(filter.AuthorID) != nil {
+		// No recipe can have an author that is not a valid ID.
+		b.where("FALSE")
+	} else if filter.AuthorID != "" {
+		b.where("r.author_id = " + b.arg(filter.AuthorID))
+	}
+	if len(filter.Diets) > 0 {
+		b.where("r.diets @> " + b.arg(pq.Array(filter.Diets)) + "::text[]")
+	}
+	if len(filter.ExcludeDiets) > 0 {
+		b.where("NOT r.diets && " + b.arg(pq.Array(filter.ExcludeDiets)) + "::text[]")
+	}
+	if len(filter.Allergens) > 0 {
+		b.where("r.allergens @> " + b.arg(pq.Array(filter.Allergens)) + "::text[]")
+	}
+	if len(filter.ExcludeAllergens) > 0 {
+		// Only recipes whose ingredients are all linked are known to be free
+		// of an allergen.
+		b.where("r.dietary_complete AND NOT r.allergens && " + b.arg(pq.Array(filter.ExcludeAllergens)) + "::text[]")
+	}
+	if filter.PantryUserID != "" && uuid.Validate(filter.PantryUserID) != nil {
+		b.where("FALSE")
+	} else if filter.PantryUserID != "" {
+		b.where("r.id IN (SELECT recipe_id FROM ingredients WHERE name_phrases && " + pantryArray(b, filter.PantryUserID) + ")")
+		if filter.MaxMissing != nil {
+			b.where(pantryCount(b, filter.PantryUserID, false) + " <= " + b.arg(*filter.MaxMissing))
+		}
+	}
+}
+
+// pantryCount counts a recipe's ingredients that a user's pantry covers,
+// or those it does not.
+func pantryCount(b *sqlBuilder, userID string, covered bool) string {
+	match := "i.name_phrases && " + pantryArray(b, userID)
+	if !covered {
+		match = "NOT (" + match + ")"
+	}
+	return "(SELECT COUNT(*) FROM ingredients i WHERE i.recipe_id = r.id AND " + match + ")"
+}
+
+// recipeSearchDocument is the expression idx_recipes_search is built on; it
+// must stay identical for the index to be used.
+const recipeSearchDocument = `to_tsvector('english', r.title || ' ' || COALESCE(r.description, ''))`
+
+// recipeRankDocument weights every searchable field for ranking: titles
+// highest, then descriptions and tags, then ingredient names and the
+// catalog terms of the entries they are linked to.
+const recipeRankDocument = `setweight(to_tsvector('english', r.title), 'A') ||
+	setweight(to_tsvector('english', COALESCE(r.description, '')), 'B') ||
+	setweight(to_tsvector('english', COALESCE((SELECT string_agg(tag, ' ') FROM recipe_tags WHERE recipe_id = r.id), '')), 'B') ||
+	setweight(to_tsvector('english', COALESCE((SELECT string_agg(i.name || ' ' || COALESCE(
+		(SELECT string_agg(t.term, ' ') FROM ingredient_catalog_terms t WHERE t.catalog_id = i.catalog_id), ''), ' ')
+		FROM ingredients i WHERE i.recipe_id = r.id), '')), 'C')`
+
+// applyRecipeSearch requires every term to prefix-match a word in the
+// title or description, an ingredient name or a catalog term it is linked
+// by, or a tag.
+func applyRecipeSearch(b *sqlBuilder, terms []string) {
+	for _, term := range terms {
+		q := "to_tsquery('english', " + b.arg(prefixQuery(term)) + ")"
+		b.where(fmt.Sprintf(`(%s @@ %s
+			OR r.id IN (SELECT recipe_id FROM ingredients WHERE to_tsvector('english', name) @@ %s)
+			OR r.id IN (SELECT i.recipe_id FROM ingredients i JOIN ingredient_catalog_terms t ON t.catalog_id = i.catalog_id
+				WHERE to_tsvector('english', t.term) @@ %s)
+			OR r.id IN (SELECT recipe_id FROM recipe_tags WHERE to_tsvector('english', tag) @@ %s))`,
+			recipeSearchDocument, q, q, q, q))
+	}
+}
+
+// recipeSortKey is one column of a listing's order. bind adds the value a
+// cursor holds for it as an argument.
+type recipeSortKey struct {
+	expr string
+	desc bool
+	bind func(b *sqlBuilder, c *recipeCursor) string
+}
+
+// recipeSortKeys returns the order of a normalized query and the rank
+// expression it sorts by, which is 0 unless sorting by relevance.
+func recipeSortKeys(b *sqlBuilder, query RecipeQuery) ([]recipeSortKey, string) {
+	createdAt := func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.CreatedAt) + "::timestamptz" }
+	id := func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.ID) + "::uuid" }
+
+	switch query.Sort {
+	case SortRelevance:
+		prefixes := make([]string, 0, len(query.terms()))
+		for _, term := range query.terms() {
+			prefixes = append(prefixes, prefixQuery(term))
+		}
+		rank := fmt.Sprintf("ts_rank(%s, to_tsquery('english', %s))",
+			recipeRankDocument, b.arg(strings.Join(prefixes, " | ")))
+		return []recipeSortKey{
+			{rank, true, func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.Rank) }},
+			{"r.created_at", true, createdAt},
+			{"r.id", true, id},
+		}, rank
+	case SortPantry:
+		if uuid.Validate(query.Filter.PantryUserID) != nil {
+			break
+		}
+		return []recipeSortKey{
+			{pantryCount(b, query.Filter.PantryUserID, true), true, func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.Covered) + "::bigint" }},
+			{pantryCount(b, query.Filter.PantryUserID, false), false, func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.Missing) + "::bigint" }},
+			{"r.created_at", true, createdAt},
+			{"r.id", true, id},
+		}, "0"
+	case SortOldest:
+		return []recipeSortKey{{"r.created_at", false, createdAt}, {"r.id", false, id}}, "0"
+	case SortTitle:
+		title := func(b *sqlBuilder, c *recipeCursor) string { return "LOWER(" + b.arg(c.Title) + `) COLLATE "C"` }
+		return []recipeSortKey{{`LOWER(r.title) COLLATE "C"`, false, title}, {"r.id", false, id}}, "0"
+	case SortRating:
+		// These keys match idx_recipes_rating.
+		return []recipeSortKey{
+			{"r.rating_average", true, func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.Rating) + "::float8" }},
+			{"r.rating_count", true, func(b *sqlBuilder, c *recipeCursor) string { return b.arg(c.Ratings) + "::integer" }},
+			{"r.created_at", true, createdAt},
+			{"r.id", true, id},
+		}, "0"
+	}
+	return []recipeSortKey{{"r.created_at", true, createdAt}, {"r.id", true, id}}, "0"
+}
+
+// recipeOrderBy formats keys as an ORDER BY list, reversed when reading
+// backwards from a before cursor.
+func recipeOrderBy(keys []recipeSortKey, reverse bool) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "ASC"
+		if key.desc != reverse {
+			dir = "DESC"
+		}
+		parts[i] = key.expr + " " + dir
+	}
+	return strings.Join(parts, ", ")
+}
+
+// applyRecipeCursor restricts a listing to the recipes ordered after the
+// cursor's position, or before it for before cursors.
+func applyRecipeCursor(b *sqlBuilder, keys []recipeSortKey, cursor *recipeCursor) {
+	alternatives := make([]string, len(keys))
+	for i, key := range keys {
+		var conds []string
+		for _, prev := range keys[:i] {
+			conds = append(conds, prev.expr+" = "+prev.bind(b, cursor))
+		}
+		op := ">"
+		if key.desc != cursor.Before {
+			op = "<"
+		}
+		conds = append(conds, key.expr+" "+op+" "+key.bind(b, cursor))
+		alternatives[i] = "(" + strings.Join(conds, " AND ") + ")"
+	}
+	b.where("(" + strings.Join(alternatives, " OR ") + ")")
+}
+
+func (db *DB) ListRecipes(ctx context.Context, query RecipeQuery) (*models.SearchResult, error) {
+	query = query.normalize()
+	cursor, err := query.decodeCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &sqlBuilder{}
+	applyRecipeSearch(b, query.terms())
+	applyRecipeFilter(b, query.Filter)
+
+	result := &models.SearchResult{
+		Recipes: []models.Recipe{},
+		Page:    query.Page,
+		PerPage: query.PerPage,
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM recipes r"+b.whereClause(), b.args...).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("failed to count recipes: %w", err)
+	}
+
+	keys, rank := recipeSortKeys(b, query)
+	offset := query.offset()
+	if cursor != nil {
+		applyRecipeCursor(b, keys, cursor)
+		result.Page, offset = 0, 0
+	}
+
+	// One extra row tells whether another page follows.
+	stmt := fmt.Sprintf("SELECT %s, %s FROM recipes r%s ORDER BY %s LIMIT %s OFFSET %s",
+		recipeColumns, rank, b.whereClause(), recipeOrderBy(keys, cursor != nil && cursor.Before),
+		b.arg(query.PerPage+1), b.arg(offset))
+
+	recipes, ranks, err := scanRankedRecipes(ctx, db, stmt, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(recipes) > query.PerPage
+	if hasMore {
+		recipes, ranks = recipes[:query.PerPage], ranks[:query.PerPage]
+	}
+	if cursor != nil && cursor.Before {
+		reverseRecipes(recipes, ranks)
+	}
+	if err := loadRecipeChildren(ctx, db, recipes); err != nil {
+		return nil, err
+	}
+
+	result.Recipes = recipes
+	if query.Filter.PantryUserID != "" {
+		if err := loadPantryMatches(ctx, db, result, query.Filter.PantryUserID); err != nil {
+			return nil, err
+		}
+	}
+	pageCursors(result, query, cursor, ranks, hasMore)
+	highlightResults(result, query.terms())
+	return result, nil
+}
+
+func (db *DB) GetRecipe(ctx context.Context, id string) (*models.Recipe, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	recipes, err := scanRecipes(ctx, db, "SELECT "+recipeColumns+" FROM recipes r WHERE r.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipes) == 0 {
+		return nil, ErrNotFound
+	}
+	if err := loadRecipeChildren(ctx, db, recipes); err != nil {
+		return nil, err
+	}
+	return &recipes[0], nil
+}
+
+func (db *DB) GetRecipes(ctx context.Context, ids []string) ([]models.Recipe, error) {
+	valid := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if uuid.Validate(id) == nil {
+			valid = append(valid, id)
+		}
+	}
+	if len(valid) == 0 {
+		return []models.Recipe{}, nil
+	}
+
+	recipes, err := scanRecipes(ctx, db, `
+		SELECT `+recipeColumns+` FROM recipes r
+		JOIN unnest($1::uuid[]) WITH ORDINALITY AS o(id, n) ON o.id = r.id
+		ORDER BY o.n`, pq.Array(valid))
+	if err != nil {
+		return nil, err
+	}
+	if err := loadRecipeChildren(ctx, db, recipes); err != nil {
+		return nil, err
+	}
+	return recipes, nil
+}
+
+func (db *DB) CreateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO recipes (title, description, prep_time, cook_time, servings, difficulty, category, cuisine, image_url, author_id)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, NULLIF($10, '')::uuid)
+			RETURNING id, created_at, updated_at`,
+			recipe.Title, recipe.Description, recipe.PrepTime, recipe.CookTime, recipe.Servings,
+			recipe.Difficulty, recipe.Category, recipe.Cuisine, recipe.ImageURL, recipe.AuthorID,
+		).Scan(&recipe.ID, &recipe.CreatedAt, &recipe.UpdatedAt)
+		recipe.RatingAverage, recipe.RatingCount = 0, 0
+		if err != nil {
+			return fmt.Errorf("failed to insert recipe: %w", err)
+		}
+
+		prepareRecipe(recipe)
+		if err := insertRecipeChildren(ctx, tx, recipe); err != nil {
+			return err
+		}
+		if err := linkRecipeIngredients(ctx, tx, recipe, nil); err != nil {
+			return err
+		}
+		return refreshNutrition(ctx, tx, recipe)
+	})
+}
+
+func (db *DB) UpdateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if uuid.Validate(recipe.ID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			UPDATE recipes
+			SET title = $2, description = $3, prep_time = $4, cook_time = $5, servings = $6,
+				difficulty = NULLIF($7, ''), category = $8, cuisine = $9, image_url = $10, updated_at = NOW()
+			WHERE id = $1
+			RETURNING COALESCE(author_id::text, ''), rating_average, rating_count, created_at, updated_at`,
+			recipe.ID, recipe.Title, recipe.Description, recipe.PrepTime, recipe.CookTime, recipe.Servings,
+			recipe.Difficulty, recipe.Category, recipe.Cuisine, recipe.ImageURL,
+		).Scan(&recipe.AuthorID, &recipe.RatingAverage, &recipe.RatingCount, &recipe.CreatedAt, &recipe.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update recipe: %w", err)
+		}
+
+		manual, err := manualIngredientLinks(ctx, tx, recipe.ID)
+		if err != nil {
+			return err
+		}
+		for _, table := range []string{"ingredients", "instructions", "recipe_tags"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE recipe_id = $1", recipe.ID); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+
+		prepareRecipe(recipe)
+		if err := insertRecipeChildren(ctx, tx, recipe); err != nil {
+			return err
+		}
+		if err := linkRecipeIngredients(ctx, tx, recipe, manual); err != nil {
+			return err
+		}
+		return refreshNutrition(ctx, tx, recipe)
+	})
+}
+
+func (db *DB) DeleteRecipe(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM recipes WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recipe: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (db *DB) SetRecipeImage(ctx context.Context, id, imageURL string) (string, error) {
+	if uuid.Validate(id) != nil {
+		return "", ErrNotFound
+	}
+
+	// The subquery reads the row before the update, so RETURNING yields the
+	// previous URL.
+	var previous string
+	err := db.QueryRowContext(ctx, `
+		UPDATE recipes r
+		SET image_url = $2, updated_at = NOW()
+		FROM (SELECT id, COALESCE(image_url, '') AS image_url FROM recipes WHERE id = $1 FOR UPDATE) old
+		WHERE r.id = old.id
+		RETURNING old.image_url`,
+		id, imageURL,
+	).Scan(&previous)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to set recipe image: %w", err)
+	}
+	return previous, nil
+}
+
+func (db *DB) AddInstructionImage(ctx context.Context, recipeID string, position int, imageURL string) error {
+	if uuid.Validate(recipeID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE instructions SET image_urls = array_append(image_urls, $3)
+			WHERE recipe_id = $1 AND position = $2`,
+			recipeID, position, imageURL)
+		if err != nil {
+			return fmt.Errorf("failed to add instruction image: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE recipes SET updated_at = NOW() WHERE id = $1", recipeID); err != nil {
+			return fmt.Errorf("failed to touch recipe: %w", err)
+		}
+		return nil
+	})
+}
+
+func (db *DB) RemoveInstructionImage(ctx context.Context, recipeID string, position, n int) (string, error) {
+	if uuid.Validate(recipeID) != nil || n < 1 {
+		return "", ErrNotFound
+	}
+
+	var removed string
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		// As in SetRecipeImage, the subquery reads the step before the
+		// update, so RETURNING yields the removed URL.
+		err := tx.QueryRowContext(ctx, `
+			UPDATE instructions i
+			SET image_urls = old.image_urls[:$3 - 1] || old.image_urls[$3 + 1:]
+			FROM (SELECT id, image_urls FROM instructions WHERE recipe_id = $1 AND position = $2 FOR UPDATE) old
+			WHERE i.id = old.id AND cardinality(old.image_urls) >= $3
+			RETURNING old.image_urls[$3]`,
+			recipeID, position, n,
+		).Scan(&removed)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove instruction image: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE recipes SET updated_at = NOW() WHERE id = $1", recipeID); err != nil {
+			return fmt.Errorf("failed to touch recipe: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return removed, nil
+}
+
+func insertRecipeChildren(ctx context.Context, tx *sql.Tx, recipe *models.Recipe) error {
+	for i := range recipe.Ingredients {
+		ing := &recipe.Ingredients[i]
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO ingredients (recipe_id, name, amount, unit, notes, position)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id`,
+			recipe.ID, ing.Name, ing.Amount, ing.Unit, ing.Notes, ing.Position,
+		).Scan(&ing.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert ingredient: %w", err)
+		}
+	}
+
+	for i := range recipe.Instructions {
+		inst := &recipe.Instructions[i]
+		images := inst.Images
+		if images == nil {
+			images = []string{}
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO instructions (recipe_id, text, position, duration, temperature, temperature_unit, image_urls)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+			RETURNING id`,
+			recipe.ID, inst.Text, inst.Position, inst.Duration, inst.Temperature, inst.TemperatureUnit, pq.Array(images),
+		).Scan(&inst.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert instruction: %w", err)
+		}
+	}
+
+	for _, tag := range recipe.Tags {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO recipe_tags (recipe_id, tag) VALUES ($1, $2)", recipe.ID, tag); err != nil {
+			return fmt.Errorf("failed to insert tag: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func scanRecipes(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.Recipe, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipes: %w", err)
+	}
+	defer rows.Close()
+
+	recipes := []models.Recipe{}
+	for rows.Next() {
+		r, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recipes: %w", err)
+	}
+	return recipes, nil
+}
+
+// scanRankedRecipes reads rows of recipeColumns followed by a rank.
+func scanRankedRecipes(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.Recipe, []float64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query recipes: %w", err)
+	}
+	defer rows.Close()
+
+	recipes := []models.Recipe{}
+	ranks := []float64{}
+	for rows.Next() {
+		var rank float64
+		r, err := scanRecipe(rows, &rank)
+		if err != nil {
+			return nil, nil, err
+		}
+		recipes = append(recipes, r)
+		ranks = append(ranks, rank)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read recipes: %w", err)
+	}
+	return recipes, ranks, nil
+}
+
+// scanRecipe scans recipeColumns and then any extra columns from the
+// current row.
+func scanRecipe(rows *sql.Rows, extra ...interface{}) (models.Recipe, error) {
+	var r models.Recipe
+	dest := append([]interface{}{&r.ID, &r.Title, &r.Description, &r.PrepTime, &r.CookTime, &r.Servings,
+		&r.Difficulty, &r.Category, &r.Cuisine, &r.ImageURL, &r.AuthorID, &r.RatingAverage, &r.RatingCount,
+		pq.Array(&r.Dietary.Diets), pq.Array(&r.Dietary.Allergens), &r.Dietary.Complete, &r.CreatedAt, &r.UpdatedAt}, extra...)
+	if err := rows.Scan(dest...); err != nil {
+		return r, fmt.Errorf("failed to scan recipe: %w", err)
+	}
+	normalizeDietary(&r.Dietary)
+	return r, nil
+}
+
+// reverseRecipes reverses a page read backwards, with its ranks, into
+// listing order.
+func reverseRecipes(recipes []models.Recipe, ranks []float64) {
+	for i, j := 0, len(recipes)-1; i < j; i, j = i+1, j-1 {
+		recipes[i], recipes[j] = recipes[j], recipes[i]
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+}
+
+// loadRecipeChildren fills ingredients, instructions, tags and nutrition
+// for all recipes with one query per child table.
+func loadRecipeChildren(ctx context.Context, q queryer, recipes []models.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(recipes))
+	byID := make(map[string]*models.Recipe, len(recipes))
+	for i := range recipes {
+		ids[i] = recipes[i].ID
+		byID[recipes[i].ID] = &recipes[i]
+		recipes[i].Ingredients = []models.Ingredient{}
+		recipes[i].Instructions = []models.Instruction{}
+		recipes[i].Tags = []string{}
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+ingredientColumns+`
+		FROM ingredients i LEFT JOIN ingredient_catalog c ON c.id = i.catalog_id
+		WHERE i.recipe_id = ANY($1::uuid[]) ORDER BY i.position`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query ingredients: %w", err)
+	}
+	for rows.Next() {
+		ing, err := scanIngredient(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		recipe := byID[ing.RecipeID]
+		recipe.Ingredients = append(recipe.Ingredients, ing)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read ingredients: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT id, recipe_id, text, position, COALESCE(duration, 0), COALESCE(temperature, 0),
+			COALESCE(temperature_unit, ''), image_urls
+		FROM instructions WHERE recipe_id = ANY($1::uuid[]) ORDER BY position`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query instructions: %w", err)
+	}
+	for rows.Next() {
+		var inst models.Instruction
+		if err := rows.Scan(&inst.ID, &inst.RecipeID, &inst.Text, &inst.Position, &inst.Duration, &inst.Temperature,
+			&inst.TemperatureUnit, pq.Array(&inst.Images)); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan instruction: %w", err)
+		}
+		if len(inst.Images) == 0 {
+			inst.Images = nil
+		}
+		recipe := byID[inst.RecipeID]
+		recipe.Instructions = append(recipe.Instructions, inst)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read instructions: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT recipe_id, tag FROM recipe_tags WHERE recipe_id = ANY($1::uuid[]) ORDER BY tag COLLATE "C"`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var recipeID, tag string
+		if err := rows.Scan(&recipeID, &tag); err != nil {
+			return fmt.Errorf("failed to scan tag: %w", err)
+		}
+		recipe := byID[recipeID]
+		recipe.Tags = append(recipe.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	infos, err := scanNutrition(ctx, q, "SELECT "+nutritionColumns+" FROM nutrition_info WHERE recipe_id = ANY($1::uuid[])", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	for i := range infos {
+		byID[infos[i].RecipeID].Nutrition = &infos[i]
+	}
+
+	return nil
+}
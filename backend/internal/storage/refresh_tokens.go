@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at`
+
+func (db *DB) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	if uuid.Validate(token.UserID) != nil || uuid.Validate(token.FamilyID) != nil {
+		return ErrNotFound
+	}
+	return insertRefreshToken(ctx, db, token)
+}
+
+func (db *DB) GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	return getRefreshToken(ctx, db, hash, "")
+}
+
+func (db *DB) RotateRefreshToken(ctx context.Context, hash string, next *models.RefreshToken) error {
+	var reused bool
+	err := db.withTx(ctx, func(tx *sql.Tx) error {
+		current, err := getRefreshToken(ctx, tx, hash, " FOR UPDATE")
+		if err != nil {
+			return err
+		}
+		if err := checkRefreshToken(current, time.Now()); err != nil {
+			if !errors.Is(err, ErrTokenReused) {
+				return err
+			}
+			// Commit the revocation rather than rolling it back with the error.
+			reused = true
+			return revokeRefreshTokenFamily(ctx, tx, current.FamilyID)
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1", current.ID); err != nil {
+			return fmt.Errorf("failed to mark refresh token used: %w", err)
+		}
+
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		return insertRefreshToken(ctx, tx, next)
+	})
+	if err != nil {
+		return err
+	}
+	if reused {
+		return ErrTokenReused
+	}
+	return nil
+}
+
+func (db *DB) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	if uuid.Validate(familyID) != nil {
+		return nil
+	}
+	return revokeRefreshTokenFamily(ctx, db, familyID)
+}
+
+func insertRefreshToken(ctx context.Context, q queryer, token *models.RefreshToken) error {
+	err := q.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`,
+		token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+	return nil
+}
+
+func getRefreshToken(ctx context.Context, q queryer, hash, suffix string) (*models.RefreshToken, error) {
+	var t models.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := q.QueryRowContext(ctx,
+		"SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = $1"+suffix, hash,
+	).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.TokenHash, &t.ExpiresAt, &usedAt, &revokedAt, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refresh token: %w", err)
+	}
+	if usedAt.Valid {
+		t.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+	return &t, nil
+}
+
+func revokeRefreshTokenFamily(ctx context.Context, q queryer, familyID string) error {
+	_, err := q.ExecContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL", familyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// checkRefreshToken reports whether token may be exchanged at now. Revocation
+// takes precedence over reuse so that replaying a token from a family that was
+// already revoked (for example by logout) does not count as theft.
+func checkRefreshToken(token *models.RefreshToken, now time.Time) error {
+	switch {
+	case token.RevokedAt != nil:
+		return ErrTokenRevoked
+	case token.UsedAt != nil:
+		return ErrTokenReused
+	case !now.Before(token.ExpiresAt):
+		return ErrTokenExpired
+	}
+	return nil
+}
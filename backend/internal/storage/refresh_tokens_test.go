@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// refreshTokenStore is the subset of Store the refresh token suite needs to
+// create the owning user.
+type refreshTokenStore interface {
+	UserRepository
+	RefreshTokenRepository
+}
+
+// testRefreshTokenRepository is the conformance suite for
+// RefreshTokenRepository implementations. newRepo must return an empty
+// repository.
+func testRefreshTokenRepository(t *testing.T, newRepo func(t *testing.T) refreshTokenStore) {
+	setup := func(t *testing.T) (refreshTokenStore, *models.RefreshToken) {
+		t.Helper()
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		token := models.RefreshToken{
+			UserID:    user.ID,
+			FamilyID:  uuid.NewString(),
+			TokenHash: "hash-1",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		if err := repo.CreateRefreshToken(ctx, &token); err != nil {
+			t.Fatalf("CreateRefreshToken() error = %v", err)
+		}
+		return repo, &token
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo, token := setup(t)
+
+		got, err := repo.GetRefreshToken(context.Background(), "hash-1")
+		if err != nil {
+			t.Fatalf("GetRefreshToken() error = %v", err)
+		}
+		if got.ID != token.ID || got.UserID != token.UserID || got.FamilyID != token.FamilyID {
+			t.Errorf("GetRefreshToken() = %+v, want %+v", got, token)
+		}
+		if got.UsedAt != nil || got.RevokedAt != nil {
+			t.Errorf("new token should be neither used nor revoked: %+v", got)
+		}
+		if _, err := repo.GetRefreshToken(context.Background(), "unknown"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetRefreshToken() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CreateForMissingUser", func(t *testing.T) {
+		repo := newRepo(t)
+		token := models.RefreshToken{UserID: uuid.NewString(), FamilyID: uuid.NewString(), TokenHash: "orphan", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.CreateRefreshToken(context.Background(), &token); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CreateRefreshToken() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		repo, token := setup(t)
+		ctx := context.Background()
+
+		next := models.RefreshToken{TokenHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.RotateRefreshToken(ctx, "hash-1", &next); err != nil {
+			t.Fatalf("RotateRefreshToken() error = %v", err)
+		}
+		if next.ID == "" || next.UserID != token.UserID || next.FamilyID != token.FamilyID {
+			t.Errorf("rotated token = %+v, want same user and family as %+v", next, token)
+		}
+
+		old, _ := repo.GetRefreshToken(ctx, "hash-1")
+		if old.UsedAt == nil {
+			t.Error("rotated token should be marked used")
+		}
+		if err := repo.RotateRefreshToken(ctx, "missing", &models.RefreshToken{TokenHash: "hash-x", ExpiresAt: time.Now().Add(time.Hour)}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RotateRefreshToken() unknown error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ReuseRevokesFamily", func(t *testing.T) {
+		repo, _ := setup(t)
+		ctx := context.Background()
+
+		next := models.RefreshToken{TokenHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.RotateRefreshToken(ctx, "hash-1", &next); err != nil {
+			t.Fatalf("RotateRefreshToken() error = %v", err)
+		}
+
+		replay := models.RefreshToken{TokenHash: "hash-3", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.RotateRefreshToken(ctx, "hash-1", &replay); !errors.Is(err, ErrTokenReused) {
+			t.Fatalf("RotateRefreshToken() replay error = %v, want ErrTokenReused", err)
+		}
+		if _, err := repo.GetRefreshToken(ctx, "hash-3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("replayed rotation must not store a new token, got error %v", err)
+		}
+
+		latest, _ := repo.GetRefreshToken(ctx, "hash-2")
+		if latest.RevokedAt == nil {
+			t.Error("reuse should revoke the latest token in the family")
+		}
+		if err := repo.RotateRefreshToken(ctx, "hash-2", &replay); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("RotateRefreshToken() after reuse error = %v, want ErrTokenRevoked", err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		token := models.RefreshToken{UserID: user.ID, FamilyID: uuid.NewString(), TokenHash: "stale", ExpiresAt: time.Now().Add(-time.Minute)}
+		if err := repo.CreateRefreshToken(ctx, &token); err != nil {
+			t.Fatalf("CreateRefreshToken() error = %v", err)
+		}
+
+		next := models.RefreshToken{TokenHash: "fresh", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.RotateRefreshToken(ctx, "stale", &next); !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("RotateRefreshToken() error = %v, want ErrTokenExpired", err)
+		}
+	})
+
+	t.Run("RevokeFamily", func(t *testing.T) {
+		repo, token := setup(t)
+		ctx := context.Background()
+
+		if err := repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			t.Fatalf("RevokeRefreshTokenFamily() error = %v", err)
+		}
+		next := models.RefreshToken{TokenHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.RotateRefreshToken(ctx, "hash-1", &next); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("RotateRefreshToken() error = %v, want ErrTokenRevoked", err)
+		}
+		if err := repo.RevokeRefreshTokenFamily(ctx, "not-a-uuid"); err != nil {
+			t.Errorf("RevokeRefreshTokenFamily() unknown family error = %v", err)
+		}
+	})
+}
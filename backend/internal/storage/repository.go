@@ -0,0 +1,471 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"recipe-app/internal/models"
+	"recipe-app/internal/nutrition"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrEmailTaken    = errors.New("email already registered")
+	ErrUsernameTaken = errors.New("username already taken")
+
+	ErrTokenExpired = errors.New("refresh token expired")
+	ErrTokenRevoked = errors.New("refresh token revoked")
+	ErrTokenReused  = errors.New("refresh token reused")
+
+	ErrAlreadyInCollection = errors.New("recipe already in collection")
+	ErrAlreadyPlanned      = errors.New("recipe already planned for that meal")
+
+	ErrCatalogTermTaken = errors.New("ingredient name already in the catalog")
+)
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+
+	// MaxTagFacets limits the tag facet to the most used tags.
+	MaxTagFacets = 20
+)
+
+// CookTimeFacetBuckets are the max_cook_time thresholds, in minutes, that
+// the cook time facet counts.
+var CookTimeFacetBuckets = []int{30, 60, 120}
+
+type RecipeSort string
+
+const (
+	SortNewest RecipeSort = "newest"
+	SortOldest RecipeSort = "oldest"
+	SortTitle  RecipeSort = "title"
+	// SortRating orders by average rating, then by number of ratings, with
+	// unrated recipes last.
+	SortRating RecipeSort = "rating"
+	// SortRelevance orders text search hits by rank, newest first among
+	// equals. It is the default when Search is set.
+	SortRelevance RecipeSort = "relevance"
+	// SortPantry orders pantry searches by how many ingredients the pantry
+	// covers, then by how few are missing, newest first among equals. It is
+	// the default when Filter.PantryUserID is set.
+	SortPantry RecipeSort = "pantry"
+)
+
+// RecipeQuery selects a page of recipes. Zero values mean "no constraint",
+// page 1, DefaultPerPage results and newest-first ordering.
+type RecipeQuery struct {
+	// Search is free text matched against titles, descriptions, ingredient
+	// names and tags.
+	Search  string
+	Filter  models.RecipeFilter
+	Sort    RecipeSort
+	Page    int
+	PerPage int
+	// Cursor is a NextCursor or PrevCursor from an earlier result for the
+	// same search, filter and sort. It replaces Page with keyset paging,
+	// which stays stable while recipes are added.
+	Cursor string
+}
+
+// RecipeRepository reads and writes recipes together with their
+// ingredients, instructions and tags. Ingredient and instruction positions
+// are assigned from slice order on write. A recipe's author is set on create
+// and kept by UpdateRecipe.
+type RecipeRepository interface {
+	// ListRecipes returns ErrInvalidCursor when query.Cursor does not belong
+	// to the query.
+	ListRecipes(ctx context.Context, query RecipeQuery) (*models.SearchResult, error)
+	// RecipeFacets counts the recipes matching query per filter value,
+	// ignoring its sort and paging.
+	RecipeFacets(ctx context.Context, query RecipeQuery) (*models.SearchFacets, error)
+	GetRecipe(ctx context.Context, id string) (*models.Recipe, error)
+	// GetRecipes returns the recipes with the given IDs in the order of
+	// ids, leaving out those that do not exist.
+	GetRecipes(ctx context.Context, ids []string) ([]models.Recipe, error)
+	CreateRecipe(ctx context.Context, recipe *models.Recipe) error
+	UpdateRecipe(ctx context.Context, recipe *models.Recipe) error
+	DeleteRecipe(ctx context.Context, id string) error
+	// SetRecipeImage replaces the recipe's image URL, leaving the rest of
+	// the recipe untouched, and returns the URL it replaced.
+	SetRecipeImage(ctx context.Context, id, imageURL string) (string, error)
+	// AddInstructionImage appends an image to the recipe's step at the
+	// given 1-based position.
+	AddInstructionImage(ctx context.Context, recipeID string, position int, imageURL string) error
+	// RemoveInstructionImage removes the nth image, counting from 1, of the
+	// recipe's step at the given 1-based position and returns its URL.
+	RemoveInstructionImage(ctx context.Context, recipeID string, position, n int) (string, error)
+}
+
+// UserRepository persists user accounts. Emails are stored lowercased and
+// both email and username must be unique.
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+}
+
+// RefreshTokenRepository stores hashed refresh tokens grouped into
+// families, one family per login.
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RotateRefreshToken atomically marks the token with the given hash as
+	// used and stores next in the same family for the same user. Presenting
+	// an already-used token revokes the whole family and returns
+	// ErrTokenReused.
+	RotateRefreshToken(ctx context.Context, hash string, next *models.RefreshToken) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+}
+
+// CollectionRepository persists users' recipe collections. Recipes keep
+// an explicit order; positions are 1-based, and a position of 0 or past the
+// end means the end of the collection. Deleting a recipe removes it from
+// every collection.
+type CollectionRepository interface {
+	// ListCollections returns a user's collections, oldest first, leaving
+	// out private ones unless includePrivate is set.
+	ListCollections(ctx context.Context, userID string, includePrivate bool) ([]models.RecipeCollection, error)
+	GetCollection(ctx context.Context, id string) (*models.RecipeCollection, error)
+	// CreateCollection also adds the recipes in collection.RecipeIDs, in
+	// order, returning ErrNotFound and saving nothing when the owner or one
+	// of the recipes does not exist.
+	CreateCollection(ctx context.Context, collection *models.RecipeCollection) error
+	// UpdateCollection changes the name, description and visibility.
+	UpdateCollection(ctx context.Context, collection *models.RecipeCollection) error
+	DeleteCollection(ctx context.Context, id string) error
+	// AddCollectionRecipe returns ErrNotFound when the collection or recipe
+	// does not exist and ErrAlreadyInCollection when it is already added.
+	AddCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error
+	MoveCollectionRecipe(ctx context.Context, collectionID, recipeID string, position int) error
+	RemoveCollectionRecipe(ctx context.Context, collectionID, recipeID string) error
+}
+
+// RatingQuery selects a page of a recipe's ratings. Zero values mean page
+// 1 and DefaultPerPage results.
+type RatingQuery struct {
+	RecipeID string
+	Page     int
+	PerPage  int
+}
+
+// RatingRepository persists ratings and keeps each recipe's RatingAverage
+// and RatingCount in step with them, so listings read the summary instead of
+// aggregating. Deleting a recipe deletes its ratings.
+type RatingRepository interface {
+	// UpsertRating creates or replaces the rating's user's rating of its
+	// recipe, returning ErrNotFound when the recipe or user does not exist.
+	UpsertRating(ctx context.Context, rating *models.Rating) error
+	GetRating(ctx context.Context, recipeID, userID string) (*models.Rating, error)
+	DeleteRating(ctx context.Context, recipeID, userID string) error
+	// ListRatings returns ratings most recently updated first, with their
+	// authors' usernames.
+	ListRatings(ctx context.Context, query RatingQuery) (*models.RatingList, error)
+}
+
+// NutritionRepository stores recipes' per-serving nutrition facts. Unless
+// the author has entered facts, CreateRecipe and UpdateRecipe recompute them
+// from the recipe's ingredients and servings, and recipes are always read
+// with their facts.
+type NutritionRepository interface {
+	GetNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error)
+	// SetNutrition stores author-entered facts, which are kept through
+	// recipe updates until cleared. It returns ErrNotFound for a missing
+	// recipe.
+	SetNutrition(ctx context.Context, info *models.NutritionInfo) error
+	// ClearNutrition drops author-entered facts and returns the facts
+	// computed in their place.
+	ClearNutrition(ctx context.Context, recipeID string) (*models.NutritionInfo, error)
+}
+
+// MealPlanRepository stores users' meal plans: recipes planned for a meal
+// slot of a day. Dates are written as models.DateLayout, and ranges include
+// both their start and end. A recipe is planned at most once per slot, and
+// deleting a recipe removes it from every plan.
+type MealPlanRepository interface {
+	// ListMealPlan returns a user's entries from start to end, ordered by
+	// date, slot and the order they were planned in, with recipe titles.
+	ListMealPlan(ctx context.Context, userID, start, end string) ([]models.MealPlanEntry, error)
+	GetMealPlanEntry(ctx context.Context, id string) (*models.MealPlanEntry, error)
+	// CreateMealPlanEntry returns ErrNotFound when the user or recipe does
+	// not exist and ErrAlreadyPlanned when the recipe is already planned
+	// for that meal.
+	CreateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error
+	// UpdateMealPlanEntry moves an entry to its date and slot and changes
+	// its servings.
+	UpdateMealPlanEntry(ctx context.Context, entry *models.MealPlanEntry) error
+	DeleteMealPlanEntry(ctx context.Context, id string) error
+	// CopyMealPlan plans a user's entries from start to end again, days
+	// later, skipping recipes already planned for a meal, and returns how
+	// many entries it added.
+	CopyMealPlan(ctx context.Context, userID, start, end string, days int) (int, error)
+	// ClearMealPlan deletes a user's entries from start to end and returns
+	// how many it deleted.
+	ClearMealPlan(ctx context.Context, userID, start, end string) (int, error)
+}
+
+// ShoppingListRepository stores users' shopping lists. Item positions are
+// assigned from slice order on create, and lists are read with their items
+// in order.
+type ShoppingListRepository interface {
+	// ListShoppingLists returns a user's lists, newest first.
+	ListShoppingLists(ctx context.Context, userID string) ([]models.ShoppingList, error)
+	GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error)
+	// CreateShoppingList returns ErrNotFound when the user does not exist.
+	CreateShoppingList(ctx context.Context, list *models.ShoppingList) error
+	// SetShoppingListItemChecked checks an item off the list, or unchecks
+	// it. It returns ErrNotFound when the item is not on the list.
+	SetShoppingListItemChecked(ctx context.Context, listID, itemID string, checked bool) error
+	DeleteShoppingList(ctx context.Context, id string) error
+}
+
+// PantryRepository stores the ingredients each user has on hand. Names are
+// normalized with models.NormalizeIngredientName on write and lookup.
+type PantryRepository interface {
+	// ListPantry returns a user's pantry in name order.
+	ListPantry(ctx context.Context, userID string) ([]models.PantryItem, error)
+	// AddPantryItems adds names to a user's pantry, keeping any already in
+	// it, and returns the items for every name given. It returns
+	// ErrNotFound when the user does not exist.
+	AddPantryItems(ctx context.Context, userID string, names []string) ([]models.PantryItem, error)
+	// RemovePantryItem returns ErrNotFound when the name is not in the
+	// pantry.
+	RemovePantryItem(ctx context.Context, userID, name string) error
+}
+
+// CatalogRepository stores the canonical ingredient catalog and links
+// recipes' ingredients to it. Every ingredient not linked by hand is
+// linked automatically, on recipe write and whenever the catalog changes,
+// by models.MatchCatalogTerm over the catalog's terms. Manual links are
+// kept through recipe updates for ingredients whose normalized name is
+// unchanged. Recipes' Dietary labels follow their links and the linked
+// entries' allergens and diets.
+type CatalogRepository interface {
+	// ListCatalog returns catalog entries in name order, only those with a
+	// term containing search's normalized form when it is set.
+	ListCatalog(ctx context.Context, search string) ([]models.CatalogIngredient, error)
+	GetCatalogIngredient(ctx context.Context, id string) (*models.CatalogIngredient, error)
+	// CreateCatalogIngredient and UpdateCatalogIngredient return
+	// ErrCatalogTermTaken when one of the entry's terms belongs to another.
+	CreateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error
+	UpdateCatalogIngredient(ctx context.Context, ingredient *models.CatalogIngredient) error
+	// DeleteCatalogIngredient returns the ingredients linked to the entry,
+	// by hand or not, to automatic matching.
+	DeleteCatalogIngredient(ctx context.Context, id string) error
+	// LinkIngredient links a recipe ingredient to a catalog entry by hand,
+	// or leaves it unlinked by hand when catalogID is empty. It returns
+	// ErrNotFound when either does not exist.
+	LinkIngredient(ctx context.Context, ingredientID, catalogID string) (*models.Ingredient, error)
+	// ResetIngredientLink returns a recipe ingredient to automatic matching.
+	ResetIngredientLink(ctx context.Context, ingredientID string) (*models.Ingredient, error)
+	// RelinkIngredients rematches every ingredient not linked by hand and
+	// returns how many links changed.
+	RelinkIngredients(ctx context.Context) (int, error)
+	// UnmatchedIngredients counts the lowercased names of ingredients no
+	// entry matches, most common first, for curating the catalog.
+	UnmatchedIngredients(ctx context.Context, limit int) ([]models.IngredientCount, error)
+}
+
+// Store groups every repository the server depends on.
+type Store interface {
+	RecipeRepository
+	UserRepository
+	RefreshTokenRepository
+	CollectionRepository
+	RatingRepository
+	NutritionRepository
+	MealPlanRepository
+	ShoppingListRepository
+	PantryRepository
+	CatalogRepository
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*Memory)(nil)
+)
+
+func (q RecipeQuery) normalize() RecipeQuery {
+	if q.Page < 1 || q.Cursor != "" {
+		q.Page = 1
+	}
+	if q.PerPage < 1 {
+		q.PerPage = DefaultPerPage
+	}
+	if q.PerPage > MaxPerPage {
+		q.PerPage = MaxPerPage
+	}
+	terms := searchTerms(q.Search)
+	q.Search = strings.Join(terms, " ")
+	switch q.Sort {
+	case SortNewest, SortOldest, SortTitle, SortRating:
+	case SortRelevance:
+		if len(terms) == 0 {
+			q.Sort = SortNewest
+		}
+	case SortPantry:
+		if q.Filter.PantryUserID == "" {
+			q.Sort = SortNewest
+		}
+	default:
+		q.Sort = SortNewest
+		if q.Filter.PantryUserID != "" {
+			q.Sort = SortPantry
+		} else if len(terms) > 0 {
+			q.Sort = SortRelevance
+		}
+	}
+	q.Filter.Tags = normalizeTags(q.Filter.Tags)
+	if q.Filter.TagMatch != models.TagMatchAny {
+		q.Filter.TagMatch = models.TagMatchAll
+	}
+	q.Filter.Diets = models.NormalizeDietLabels(q.Filter.Diets, models.Diets)
+	q.Filter.ExcludeDiets = models.NormalizeDietLabels(q.Filter.ExcludeDiets, models.Diets)
+	q.Filter.Allergens = models.NormalizeDietLabels(q.Filter.Allergens, models.Allergens)
+	q.Filter.ExcludeAllergens = models.NormalizeDietLabels(q.Filter.ExcludeAllergens, models.Allergens)
+	return q
+}
+
+func (q RatingQuery) normalize() RatingQuery {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PerPage < 1 {
+		q.PerPage = DefaultPerPage
+	}
+	if q.PerPage > MaxPerPage {
+		q.PerPage = MaxPerPage
+	}
+	return q
+}
+
+// terms returns the search terms of a normalized query.
+func (q RecipeQuery) terms() []string {
+	return strings.Fields(q.Search)
+}
+
+func (q RecipeQuery) offset() int {
+	return (q.Page - 1) * q.PerPage
+}
+
+// normalizePantryNames normalizes ingredient names for a pantry, dropping
+// empty and duplicate names and preserving order.
+func normalizePantryNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		name = models.NormalizeIngredientName(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+	return normalized
+}
+
+// prepareCatalogIngredient trims a catalog entry's fields, lowercases its
+// category and drops blank synonyms and those normalizing to another term.
+func prepareCatalogIngredient(ingredient *models.CatalogIngredient) {
+	ingredient.Name = strings.TrimSpace(ingredient.Name)
+	ingredient.Plural = strings.TrimSpace(ingredient.Plural)
+	ingredient.Category = strings.ToLower(strings.TrimSpace(ingredient.Category))
+	seen := map[string]bool{
+		models.NormalizeIngredientName(ingredient.Name):   true,
+		models.NormalizeIngredientName(ingredient.Plural): true,
+	}
+	synonyms := make([]string, 0, len(ingredient.Synonyms))
+	for _, synonym := range ingredient.Synonyms {
+		synonym = strings.TrimSpace(synonym)
+		term := models.NormalizeIngredientName(synonym)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		synonyms = append(synonyms, synonym)
+	}
+	ingredient.Synonyms = synonyms
+	ingredient.Allergens = models.NormalizeDietLabels(ingredient.Allergens, models.Allergens)
+	ingredient.Diets = models.ExpandDiets(ingredient.Diets)
+}
+
+// normalizeTags lowercases, trims and de-duplicates tags, preserving order.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// prepareUser applies the write-time normalization shared by every
+// UserRepository implementation.
+func prepareUser(user *models.User) {
+	user.Email = normalizeEmail(user.Email)
+	user.Diets = models.NormalizeDietLabels(user.Diets, models.Diets)
+	user.AvoidAllergens = models.NormalizeDietLabels(user.AvoidAllergens, models.Allergens)
+}
+
+// prepareRecipe applies the write-time normalization shared by every
+// RecipeRepository implementation.
+func prepareRecipe(recipe *models.Recipe) {
+	recipe.Tags = normalizeTags(recipe.Tags)
+	sort.Strings(recipe.Tags)
+	for i := range recipe.Ingredients {
+		recipe.Ingredients[i].RecipeID = recipe.ID
+		recipe.Ingredients[i].Position = i + 1
+		recipe.Ingredients[i].CatalogID = ""
+		recipe.Ingredients[i].CatalogName = ""
+		recipe.Ingredients[i].CatalogMatch = ""
+	}
+	recipe.Dietary = models.Dietary{}
+	for i := range recipe.Instructions {
+		recipe.Instructions[i].RecipeID = recipe.ID
+		recipe.Instructions[i].Position = i + 1
+	}
+}
+
+// computeNutrition estimates a recipe's per-serving nutrition from its
+// ingredients, shared by every NutritionRepository implementation.
+func computeNutrition(recipe *models.Recipe) *models.NutritionInfo {
+	result := nutrition.Compute(recipe.Ingredients)
+	facts := result.PerServing(recipe.Servings)
+	info := &models.NutritionInfo{
+		RecipeID:    recipe.ID,
+		Calories:    roundNutrient(facts.Calories),
+		Protein:     roundNutrient(facts.Protein),
+		Carbs:       roundNutrient(facts.Carbs),
+		Fat:         roundNutrient(facts.Fat),
+		Fiber:       roundNutrient(facts.Fiber),
+		Sugar:       roundNutrient(facts.Sugar),
+		Sodium:      roundNutrient(facts.Sodium),
+		ServingSize: "whole recipe",
+		Source:      models.NutritionSourceComputed,
+		Unmatched:   result.Unmatched,
+	}
+	if recipe.Servings > 1 {
+		info.ServingSize = fmt.Sprintf("1/%d of recipe", recipe.Servings)
+	}
+	return info
+}
+
+// roundNutrient rounds to the two decimals nutrition_info stores.
+func roundNutrient(v float64) float64 {
+	return math.Min(math.Round(v*100)/100, models.MaxNutrientValue)
+}
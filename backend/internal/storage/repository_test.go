@@ -0,0 +1,729 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// testRecipeRepository is the conformance suite every RecipeRepository
+// implementation must pass. newRepo must return an empty repository.
+func testRecipeRepository(t *testing.T, newRepo func(t *testing.T) RecipeRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		recipe := models.Recipe{
+			Title:       "Pancakes",
+			Description: "Fluffy breakfast pancakes",
+			PrepTime:    10,
+			CookTime:    15,
+			Servings:    4,
+			Difficulty:  "easy",
+			Category:    "breakfast",
+			Cuisine:     "american",
+			Ingredients: []models.Ingredient{
+				{Name: "flour", Amount: "2", Unit: "cups", Position: 7},
+				{Name: "milk", Amount: "1 1/2", Unit: "cups", Notes: "whole"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Mix the batter"},
+				{Text: "Fry in a hot pan", Duration: 3, Temperature: 180, TemperatureUnit: "C"},
+			},
+			Tags: []string{" Sweet", "breakfast", "sweet"},
+		}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		if recipe.ID == "" {
+			t.Fatal("CreateRecipe() did not assign an ID")
+		}
+		if recipe.CreatedAt.IsZero() {
+			t.Error("CreateRecipe() did not set CreatedAt")
+		}
+
+		got, err := repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if got.Title != recipe.Title || got.Description != recipe.Description || got.Servings != 4 ||
+			got.Difficulty != "easy" || got.Category != "breakfast" || got.Cuisine != "american" {
+			t.Errorf("GetRecipe() = %+v, want fields of %+v", got, recipe)
+		}
+		if !got.CreatedAt.Equal(recipe.CreatedAt) {
+			t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, recipe.CreatedAt)
+		}
+		if len(got.Ingredients) != 2 || got.Ingredients[0].Name != "flour" || got.Ingredients[1].Notes != "whole" {
+			t.Fatalf("Ingredients = %+v", got.Ingredients)
+		}
+		for i, ing := range got.Ingredients {
+			if ing.Position != i+1 || ing.RecipeID != recipe.ID || ing.ID == "" {
+				t.Errorf("Ingredient %d = %+v, want position %d for recipe %s", i, ing, i+1, recipe.ID)
+			}
+		}
+		if len(got.Instructions) != 2 || got.Instructions[1].Duration != 3 || got.Instructions[1].Temperature != 180 ||
+			got.Instructions[1].TemperatureUnit != "C" {
+			t.Errorf("Instructions = %+v", got.Instructions)
+		}
+		if strings.Join(got.Tags, ",") != "breakfast,sweet" {
+			t.Errorf("Tags = %v, want [breakfast sweet]", got.Tags)
+		}
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		repo := newRepo(t)
+		for _, id := range []string{uuid.NewString(), "not-a-uuid"} {
+			if _, err := repo.GetRecipe(context.Background(), id); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetRecipe(%q) error = %v, want ErrNotFound", id, err)
+			}
+		}
+	})
+
+	t.Run("GetMany", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		var ids []string
+		for _, title := range []string{"A", "B", "C"} {
+			recipe := models.Recipe{Title: title, Ingredients: []models.Ingredient{{Name: "salt"}}}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+			ids = append(ids, recipe.ID)
+		}
+
+		got, err := repo.GetRecipes(ctx, []string{ids[2], uuid.NewString(), ids[0], "not-a-uuid"})
+		if err != nil {
+			t.Fatalf("GetRecipes() error = %v", err)
+		}
+		if len(got) != 2 || got[0].Title != "C" || got[1].Title != "A" || len(got[0].Ingredients) != 1 {
+			t.Errorf("GetRecipes() = %+v, want C and A with their ingredients", got)
+		}
+		if got, err := repo.GetRecipes(ctx, nil); err != nil || got == nil || len(got) != 0 {
+			t.Errorf("GetRecipes(nil) = %v, %v, want an empty list", got, err)
+		}
+	})
+
+	t.Run("AuthorIsKeptOnUpdate", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		users, ok := repo.(UserRepository)
+		if !ok {
+			t.Skip("repository does not store users")
+		}
+		author := models.User{Email: "author@example.com", Username: "author", Password: "hash"}
+		if err := users.CreateUser(ctx, &author); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+
+		recipe := models.Recipe{Title: "Stew", AuthorID: author.ID}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+
+		update := models.Recipe{ID: recipe.ID, Title: "Beef Stew"}
+		if err := repo.UpdateRecipe(ctx, &update); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		if update.AuthorID != author.ID {
+			t.Errorf("UpdateRecipe() AuthorID = %q, want %q", update.AuthorID, author.ID)
+		}
+
+		got, err := repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if got.AuthorID != author.ID {
+			t.Errorf("GetRecipe() AuthorID = %q, want %q", got.AuthorID, author.ID)
+		}
+	})
+
+	t.Run("UpdateReplacesChildren", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		recipe := models.Recipe{
+			Title:        "Soup",
+			Ingredients:  []models.Ingredient{{Name: "water"}, {Name: "salt"}},
+			Instructions: []models.Instruction{{Text: "Boil"}},
+			Tags:         []string{"winter"},
+		}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+
+		update := models.Recipe{
+			ID:           recipe.ID,
+			Title:        "Tomato Soup",
+			Difficulty:   "medium",
+			Ingredients:  []models.Ingredient{{Name: "tomatoes", Amount: "6"}},
+			Instructions: []models.Instruction{{Text: "Roast"}, {Text: "Blend"}},
+		}
+		if err := repo.UpdateRecipe(ctx, &update); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+
+		got, err := repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if got.Title != "Tomato Soup" || got.Difficulty != "medium" {
+			t.Errorf("GetRecipe() = %+v", got)
+		}
+		if len(got.Ingredients) != 1 || got.Ingredients[0].Name != "tomatoes" {
+			t.Errorf("Ingredients = %+v", got.Ingredients)
+		}
+		if len(got.Instructions) != 2 || got.Instructions[1].Text != "Blend" || got.Instructions[1].Position != 2 {
+			t.Errorf("Instructions = %+v", got.Instructions)
+		}
+		if len(got.Tags) != 0 {
+			t.Errorf("Tags = %v, want none", got.Tags)
+		}
+		if !got.CreatedAt.Equal(recipe.CreatedAt) {
+			t.Errorf("CreatedAt changed from %v to %v", recipe.CreatedAt, got.CreatedAt)
+		}
+		if got.UpdatedAt.Before(recipe.UpdatedAt) {
+			t.Errorf("UpdatedAt = %v, want at or after %v", got.UpdatedAt, recipe.UpdatedAt)
+		}
+	})
+
+	t.Run("UpdateAndDeleteMissing", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		missing := models.Recipe{ID: uuid.NewString(), Title: "Ghost"}
+		if err := repo.UpdateRecipe(ctx, &missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateRecipe() error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteRecipe(ctx, missing.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteRecipe() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("SetImage", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		recipe := models.Recipe{Title: "Toast", Ingredients: []models.Ingredient{{Name: "bread"}}}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		previous, err := repo.SetRecipeImage(ctx, recipe.ID, "/media/a.jpg")
+		if err != nil || previous != "" {
+			t.Fatalf("SetRecipeImage() = %q, %v, want \"\", nil", previous, err)
+		}
+		previous, err = repo.SetRecipeImage(ctx, recipe.ID, "/media/b.jpg")
+		if err != nil || previous != "/media/a.jpg" {
+			t.Fatalf("SetRecipeImage() = %q, %v, want /media/a.jpg, nil", previous, err)
+		}
+
+		got, err := repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if got.ImageURL != "/media/b.jpg" || len(got.Ingredients) != 1 {
+			t.Errorf("GetRecipe() = image %q with %d ingredients, want /media/b.jpg with 1", got.ImageURL, len(got.Ingredients))
+		}
+
+		if _, err := repo.SetRecipeImage(ctx, uuid.NewString(), "/media/c.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetRecipeImage() on missing recipe error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("InstructionImages", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		recipe := models.Recipe{Title: "Toast", Instructions: []models.Instruction{{Text: "Slice"}, {Text: "Toast"}}}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		for _, url := range []string{"/media/a.jpg", "/media/b.jpg"} {
+			if err := repo.AddInstructionImage(ctx, recipe.ID, 2, url); err != nil {
+				t.Fatalf("AddInstructionImage() error = %v", err)
+			}
+		}
+
+		got, err := repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if len(got.Instructions[0].Images) != 0 || strings.Join(got.Instructions[1].Images, ",") != "/media/a.jpg,/media/b.jpg" {
+			t.Errorf("GetRecipe() step images = %v, %v", got.Instructions[0].Images, got.Instructions[1].Images)
+		}
+
+		// Updates keep the images they are sent with.
+		got.Instructions = got.Instructions[1:]
+		if err := repo.UpdateRecipe(ctx, got); err != nil {
+			t.Fatalf("UpdateRecipe() error = %v", err)
+		}
+		got, err = repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if len(got.Instructions) != 1 || len(got.Instructions[0].Images) != 2 {
+			t.Errorf("GetRecipe() after update = %+v", got.Instructions)
+		}
+
+		if err := repo.AddInstructionImage(ctx, recipe.ID, 2, "/media/c.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddInstructionImage() on missing step error = %v, want ErrNotFound", err)
+		}
+		if err := repo.AddInstructionImage(ctx, uuid.NewString(), 1, "/media/c.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("AddInstructionImage() on missing recipe error = %v, want ErrNotFound", err)
+		}
+
+		removed, err := repo.RemoveInstructionImage(ctx, recipe.ID, 1, 1)
+		if err != nil || removed != "/media/a.jpg" {
+			t.Fatalf("RemoveInstructionImage() = %q, %v, want /media/a.jpg", removed, err)
+		}
+		got, err = repo.GetRecipe(ctx, recipe.ID)
+		if err != nil {
+			t.Fatalf("GetRecipe() error = %v", err)
+		}
+		if strings.Join(got.Instructions[0].Images, ",") != "/media/b.jpg" {
+			t.Errorf("GetRecipe() step images after remove = %v, want [/media/b.jpg]", got.Instructions[0].Images)
+		}
+		for _, n := range []int{0, 2} {
+			if _, err := repo.RemoveInstructionImage(ctx, recipe.ID, 1, n); !errors.Is(err, ErrNotFound) {
+				t.Errorf("RemoveInstructionImage(%d) error = %v, want ErrNotFound", n, err)
+			}
+		}
+		if _, err := repo.RemoveInstructionImage(ctx, recipe.ID, 2, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RemoveInstructionImage() on missing step error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.RemoveInstructionImage(ctx, uuid.NewString(), 1, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("RemoveInstructionImage() on missing recipe error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		recipe := models.Recipe{Title: "Toast", Ingredients: []models.Ingredient{{Name: "bread"}}}
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			t.Fatalf("CreateRecipe() error = %v", err)
+		}
+		if err := repo.DeleteRecipe(ctx, recipe.ID); err != nil {
+			t.Fatalf("DeleteRecipe() error = %v", err)
+		}
+		if _, err := repo.GetRecipe(ctx, recipe.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetRecipe() after delete error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ListFilters", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		if err := SeedDemoRecipes(ctx, repo); err != nil {
+			t.Fatalf("SeedDemoRecipes() error = %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter models.RecipeFilter
+			want   []string
+		}{
+			{"No filter", models.RecipeFilter{}, []string{"Beef Tacos", "Caesar Salad", "Chicken Curry", "Chocolate Cake", "Greek Salad", "Spaghetti Bolognese"}},
+			{"Category", models.RecipeFilter{Category: "lunch"}, []string{"Caesar Salad", "Greek Salad"}},
+			{"Cuisine", models.RecipeFilter{Cuisine: "american"}, []string{"Caesar Salad", "Chocolate Cake"}},
+			{"Difficulty", models.RecipeFilter{Difficulty: "hard"}, []string{"Chicken Curry", "Chocolate Cake"}},
+			{"Single tag", models.RecipeFilter{Tags: []string{"quick"}}, []string{"Beef Tacos", "Caesar Salad", "Greek Salad"}},
+			{"All tags", models.RecipeFilter{Tags: []string{"Quick", "vegetarian"}}, []string{"Caesar Salad", "Greek Salad"}},
+			{"Any tag", models.RecipeFilter{Tags: []string{"spicy", "baking"}, TagMatch: models.TagMatchAny}, []string{"Chicken Curry", "Chocolate Cake"}},
+			{"Any tag combined", models.RecipeFilter{Tags: []string{"quick", "pasta"}, TagMatch: models.TagMatchAny, MaxPrepTime: 10}, []string{"Beef Tacos", "Greek Salad"}},
+			{"Max prep time", models.RecipeFilter{MaxPrepTime: 10}, []string{"Beef Tacos", "Greek Salad"}},
+			{"Max cook time", models.RecipeFilter{MaxCookTime: 15}, []string{"Caesar Salad", "Greek Salad"}},
+			{"Servings range", models.RecipeFilter{MinServings: 3, MaxServings: 4}, []string{"Beef Tacos", "Chicken Curry", "Spaghetti Bolognese"}},
+			{"Combined", models.RecipeFilter{Category: "dinner", Difficulty: "medium", Tags: []string{"quick"}}, []string{"Beef Tacos"}},
+			{"No match", models.RecipeFilter{Cuisine: "french"}, []string{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := repo.ListRecipes(ctx, RecipeQuery{Filter: tt.filter, Sort: SortTitle})
+				if err != nil {
+					t.Fatalf("ListRecipes() error = %v", err)
+				}
+				if got := recipeTitles(result.Recipes); got != strings.Join(tt.want, ",") {
+					t.Errorf("ListRecipes() = %s, want %s", got, strings.Join(tt.want, ","))
+				}
+				if result.Total != len(tt.want) {
+					t.Errorf("Total = %d, want %d", result.Total, len(tt.want))
+				}
+			})
+		}
+	})
+
+	t.Run("ListByAuthor", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		users, ok := repo.(UserRepository)
+		if !ok {
+			t.Skip("repository does not store users")
+		}
+		author := models.User{Email: "author@example.com", Username: "author", Password: "hash"}
+		if err := users.CreateUser(ctx, &author); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		for _, recipe := range []models.Recipe{{Title: "Stew", AuthorID: author.ID}, {Title: "Soup"}} {
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+		}
+
+		for _, tt := range []struct{ authorID, want string }{
+			{author.ID, "Stew"},
+			{uuid.NewString(), ""},
+			{"not-an-id", ""},
+		} {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Filter: models.RecipeFilter{AuthorID: tt.authorID}})
+			if err != nil {
+				t.Fatalf("ListRecipes(%q) error = %v", tt.authorID, err)
+			}
+			if got := recipeTitles(result.Recipes); got != tt.want {
+				t.Errorf("ListRecipes(%q) = %s, want %s", tt.authorID, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		if err := SeedDemoRecipes(ctx, repo); err != nil {
+			t.Fatalf("SeedDemoRecipes() error = %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			search string
+			filter models.RecipeFilter
+			want   []string
+		}{
+			{"Title", "chicken", models.RecipeFilter{}, []string{"Chicken Curry"}},
+			{"Ingredient", "cocoa", models.RecipeFilter{}, []string{"Chocolate Cake"}},
+			{"Prefix", "choc", models.RecipeFilter{}, []string{"Chocolate Cake"}},
+			{"Every term must match", "salad veg", models.RecipeFilter{}, []string{"Caesar Salad", "Greek Salad"}},
+			{"Stop words ignored", "the pasta", models.RecipeFilter{}, []string{"Spaghetti Bolognese"}},
+			{"With filter", "beef", models.RecipeFilter{Tags: []string{"quick"}}, []string{"Beef Tacos"}},
+			{"No match", "sushi", models.RecipeFilter{}, []string{}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result, err := repo.ListRecipes(ctx, RecipeQuery{Search: tt.search, Filter: tt.filter, Sort: SortTitle})
+				if err != nil {
+					t.Fatalf("ListRecipes() error = %v", err)
+				}
+				if got := recipeTitles(result.Recipes); got != strings.Join(tt.want, ",") {
+					t.Errorf("ListRecipes() = %s, want %s", got, strings.Join(tt.want, ","))
+				}
+				if result.Total != len(tt.want) {
+					t.Errorf("Total = %d, want %d", result.Total, len(tt.want))
+				}
+			})
+		}
+
+		t.Run("RankedByField", func(t *testing.T) {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Search: "beef"})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if got := recipeTitles(result.Recipes); got != "Beef Tacos,Spaghetti Bolognese" {
+				t.Errorf("relevance order = %s, want the title match first", got)
+			}
+		})
+
+		t.Run("Highlights", func(t *testing.T) {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Search: "cocoa cake"})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if len(result.Recipes) != 1 {
+				t.Fatalf("ListRecipes() = %s, want one recipe", recipeTitles(result.Recipes))
+			}
+			hl := result.Highlights[result.Recipes[0].ID]
+			if hl.Title != "Chocolate <mark>Cake</mark>" {
+				t.Errorf("title highlight = %q", hl.Title)
+			}
+			if !strings.Contains(string(hl.Snippet), "<mark>cake</mark>") {
+				t.Errorf("snippet = %q, want the description match marked", hl.Snippet)
+			}
+
+			plain, err := repo.ListRecipes(ctx, RecipeQuery{})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if plain.Highlights != nil {
+				t.Errorf("Highlights = %v, want none without a search", plain.Highlights)
+			}
+		})
+	})
+
+	t.Run("Facets", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		if err := SeedDemoRecipes(ctx, repo); err != nil {
+			t.Fatalf("SeedDemoRecipes() error = %v", err)
+		}
+
+		tests := []struct {
+			name  string
+			query RecipeQuery
+			want  map[string]string
+		}{
+			{
+				name:  "Each facet ignores its own filter",
+				query: RecipeQuery{Filter: models.RecipeFilter{Category: "dinner", Tags: []string{"quick"}}},
+				want: map[string]string{
+					"category":   "lunch:2,dinner:1",
+					"cuisine":    "mexican:1",
+					"difficulty": "medium:1",
+					"tags":       "comfort food:1,pasta:1,quick:1,spicy:1",
+					"cook_time":  "30:1,60:1,120:1",
+				},
+			},
+			{
+				name:  "Search",
+				query: RecipeQuery{Search: "salad", Filter: models.RecipeFilter{MaxCookTime: 10}},
+				want: map[string]string{
+					"category":   "lunch:1",
+					"cuisine":    "greek:1",
+					"difficulty": "easy:1",
+					"tags":       "quick:1,salad:1,vegetarian:1",
+					"cook_time":  "30:2,60:2,120:2",
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				facets, err := repo.RecipeFacets(ctx, tt.query)
+				if err != nil {
+					t.Fatalf("RecipeFacets() error = %v", err)
+				}
+				got := map[string]string{
+					"category":   facetString(facets.Category),
+					"cuisine":    facetString(facets.Cuisine),
+					"difficulty": facetString(facets.Difficulty),
+					"tags":       facetString(facets.Tags),
+					"cook_time":  facetString(facets.CookTime),
+				}
+				for name, want := range tt.want {
+					if got[name] != want {
+						t.Errorf("%s facet = %s, want %s", name, got[name], want)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("ListPagination", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		for i := 0; i < 7; i++ {
+			recipe := models.Recipe{Title: fmt.Sprintf("Recipe %d", i)}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+		}
+
+		seen := make(map[string]bool)
+		for page, wantLen := range []int{3, 3, 1, 0} {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Page: page + 1, PerPage: 3, Sort: SortTitle})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if len(result.Recipes) != wantLen {
+				t.Errorf("page %d has %d recipes, want %d", page+1, len(result.Recipes), wantLen)
+			}
+			if result.Total != 7 || result.Page != page+1 || result.PerPage != 3 {
+				t.Errorf("page %d metadata = total %d page %d per_page %d", page+1, result.Total, result.Page, result.PerPage)
+			}
+			for _, recipe := range result.Recipes {
+				if seen[recipe.ID] {
+					t.Errorf("recipe %s returned on more than one page", recipe.ID)
+				}
+				seen[recipe.ID] = true
+			}
+		}
+
+		result, err := repo.ListRecipes(ctx, RecipeQuery{PerPage: MaxPerPage + 50})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if result.PerPage != MaxPerPage || result.Page != 1 {
+			t.Errorf("normalized query = page %d per_page %d, want page 1 per_page %d", result.Page, result.PerPage, MaxPerPage)
+		}
+	})
+
+	t.Run("ListCursor", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		for i := 0; i < 7; i++ {
+			recipe := models.Recipe{Title: fmt.Sprintf("Soup %d", i%4), Description: strings.Repeat("soup ", i%3)}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+		}
+
+		for _, query := range []RecipeQuery{{}, {Sort: SortOldest}, {Sort: SortTitle}, {Search: "soup"}} {
+			name := string(query.Sort)
+			if query.Search != "" {
+				name = "relevance"
+			}
+			t.Run(name, func(t *testing.T) {
+				all := query
+				all.PerPage = MaxPerPage
+				want, err := repo.ListRecipes(ctx, all)
+				if err != nil {
+					t.Fatalf("ListRecipes() error = %v", err)
+				}
+
+				var forward []models.Recipe
+				page := query
+				page.PerPage = 3
+				for i := 0; ; i++ {
+					result, err := repo.ListRecipes(ctx, page)
+					if err != nil {
+						t.Fatalf("ListRecipes() error = %v", err)
+					}
+					if (i == 0) != (result.PrevCursor == "") {
+						t.Errorf("page %d PrevCursor = %q", i+1, result.PrevCursor)
+					}
+					forward = append(forward, result.Recipes...)
+					if result.NextCursor == "" {
+						break
+					}
+					page.Cursor = result.NextCursor
+				}
+				if got := recipeIDs(forward); got != recipeIDs(want.Recipes) {
+					t.Errorf("forward pages = %s, want %s", recipeTitles(forward), recipeTitles(want.Recipes))
+				}
+
+				var backward []models.Recipe
+				last, err := repo.ListRecipes(ctx, page)
+				if err != nil {
+					t.Fatalf("ListRecipes() error = %v", err)
+				}
+				backward = last.Recipes
+				for last.PrevCursor != "" {
+					page.Cursor = last.PrevCursor
+					if last, err = repo.ListRecipes(ctx, page); err != nil {
+						t.Fatalf("ListRecipes() error = %v", err)
+					}
+					if last.NextCursor == "" {
+						t.Error("page read backwards has no NextCursor")
+					}
+					backward = append(last.Recipes, backward...)
+				}
+				if got := recipeIDs(backward); got != recipeIDs(want.Recipes) {
+					t.Errorf("backward pages = %s, want %s", recipeTitles(backward), recipeTitles(want.Recipes))
+				}
+			})
+		}
+
+		t.Run("StableWhileInserting", func(t *testing.T) {
+			first, err := repo.ListRecipes(ctx, RecipeQuery{PerPage: 3})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			recipe := models.Recipe{Title: "Fresh Soup"}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+			second, err := repo.ListRecipes(ctx, RecipeQuery{PerPage: 3, Cursor: first.NextCursor})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			if len(second.Recipes) != 3 || second.Page != 0 {
+				t.Fatalf("second page = %s (page %d)", recipeTitles(second.Recipes), second.Page)
+			}
+			if second.Recipes[0].ID == first.Recipes[2].ID || second.Recipes[0].ID == recipe.ID {
+				t.Errorf("second page repeats a recipe: %s", recipeTitles(second.Recipes))
+			}
+		})
+
+		t.Run("Invalid", func(t *testing.T) {
+			first, err := repo.ListRecipes(ctx, RecipeQuery{PerPage: 3})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			for _, query := range []RecipeQuery{
+				{Cursor: "not a cursor"},
+				{Cursor: first.NextCursor, Sort: SortTitle},
+				{Cursor: first.NextCursor, Search: "soup"},
+				{Cursor: first.NextCursor, Filter: models.RecipeFilter{Difficulty: "easy"}},
+			} {
+				if _, err := repo.ListRecipes(ctx, query); !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("ListRecipes(%+v) error = %v, want ErrInvalidCursor", query, err)
+				}
+			}
+		})
+	})
+
+	t.Run("ListOrdering", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+		for _, title := range []string{"banana bread", "Apple pie", "cherry tart"} {
+			recipe := models.Recipe{Title: title}
+			if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+				t.Fatalf("CreateRecipe() error = %v", err)
+			}
+		}
+
+		result, err := repo.ListRecipes(ctx, RecipeQuery{Sort: SortTitle})
+		if err != nil {
+			t.Fatalf("ListRecipes() error = %v", err)
+		}
+		if got := recipeTitles(result.Recipes); got != "Apple pie,banana bread,cherry tart" {
+			t.Errorf("title order = %s", got)
+		}
+
+		for _, sort := range []RecipeSort{SortNewest, SortOldest, ""} {
+			result, err := repo.ListRecipes(ctx, RecipeQuery{Sort: sort})
+			if err != nil {
+				t.Fatalf("ListRecipes() error = %v", err)
+			}
+			for i := 1; i < len(result.Recipes); i++ {
+				prev, cur := result.Recipes[i-1], result.Recipes[i]
+				less := prev.CreatedAt.Before(cur.CreatedAt) || (prev.CreatedAt.Equal(cur.CreatedAt) && prev.ID < cur.ID)
+				if (sort == SortOldest) != less {
+					t.Errorf("sort %q: %s (%v) ordered before %s (%v)", sort, prev.Title, prev.CreatedAt, cur.Title, cur.CreatedAt)
+				}
+			}
+		}
+	})
+}
+
+func facetString(facet []models.FacetCount) string {
+	parts := make([]string, len(facet))
+	for i, fc := range facet {
+		parts[i] = fmt.Sprintf("%s:%d", fc.Value, fc.Count)
+	}
+	return strings.Join(parts, ",")
+}
+
+func recipeIDs(recipes []models.Recipe) string {
+	ids := make([]string, len(recipes))
+	for i, recipe := range recipes {
+		ids[i] = recipe.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func recipeTitles(recipes []models.Recipe) string {
+	titles := make([]string, len(recipes))
+	for i, recipe := range recipes {
+		titles[i] = recipe.Title
+	}
+	return strings.Join(titles, ",")
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+
+	"recipe-app/internal/models"
+)
+
+// maxSearchTerms caps how many words of a search are used.
+const maxSearchTerms = 8
+
+// snippetWords is the length of a highlighted snippet, in words.
+const snippetWords = 24
+
+// searchStopWords are dropped from searches because Postgres' english
+// configuration ignores them; keeping them would make every query that
+// contains one match nothing there.
+var searchStopWords = func() map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be because
+		been before being below between both but by can did do does doing don down during each few for from
+		further had has have having he her here hers herself him himself his how i if in into is it its itself
+		just me more most my myself no nor not now of off on once only or other our ours ourselves out over own
+		s same she should so some such t than that the their theirs them themselves then there these they this
+		those through to too under until up very was we were what when where which while who whom why will
+		with you your yours yourself yourselves`) {
+		words[w] = true
+	}
+	return words
+}()
+
+// searchTerms splits a free-text search into lowercase words, dropping stop
+// words and duplicates. Every term must match for a recipe to be found, and
+// each term matches words it is a prefix of, so searches work while typing.
+func searchTerms(search string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range splitWords(search) {
+		word = strings.ToLower(word)
+		if searchStopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// splitWords returns the runs of letters and digits in s.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixQuery formats a term as a to_tsquery prefix match. Terms only ever
+// contain letters and digits, so no tsquery syntax can leak in.
+func prefixQuery(term string) string {
+	return term + ":*"
+}
+
+// matchesTerm reports whether any word in text starts with term.
+func matchesTerm(text, term string) bool {
+	for _, word := range splitWords(text) {
+		if strings.HasPrefix(strings.ToLower(word), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightResults fills result.Highlights for every recipe on the page.
+func highlightResults(result *models.SearchResult, terms []string) {
+	if len(terms) == 0 {
+		return
+	}
+	result.Highlights = make(map[string]models.SearchHighlight, len(result.Recipes))
+	for i := range result.Recipes {
+		recipe := &result.Recipes[i]
+		result.Highlights[recipe.ID] = highlightRecipe(recipe, terms)
+	}
+}
+
+// highlightRecipe marks the search terms in a recipe's title and picks a
+// snippet from the first of description, ingredients and tags that
+// contains a match.
+func highlightRecipe(recipe *models.Recipe, terms []string) models.SearchHighlight {
+	title, _ := highlightWords(strings.Fields(recipe.Title), terms, 0, -1)
+
+	names := make([]string, len(recipe.Ingredients))
+	for i, ing := range recipe.Ingredients {
+		names[i] = ing.Name
+	}
+	sources := []string{recipe.Description, strings.Join(names, ", "), strings.Join(recipe.Tags, ", ")}
+
+	for _, source := range sources {
+		if snippet, ok := highlightSnippet(source, terms); ok {
+			return models.SearchHighlight{Title: title, Snippet: snippet}
+		}
+	}
+	snippet, _ := highlightWords(strings.Fields(recipe.Description), terms, 0, snippetWords)
+	return models.SearchHighlight{Title: title, Snippet: snippet}
+}
+
+// highlightSnippet returns a window of text around its first match.
+func highlightSnippet(text string, terms []string) (template.HTML, bool) {
+	fields := strings.Fields(text)
+	for i, field := range fields {
+		if _, ok := markField(field, terms); ok {
+			start := i - snippetWords/4
+			if start < 0 {
+				start = 0
+			}
+			return highlightWords(fields, terms, start, snippetWords)
+		}
+	}
+	return "", false
+}
+
+// highlightWords escapes up to n fields starting at start (all of them when
+// n is negative), marking matched words and adding ellipses where the text
+// was cut.
+func highlightWords(fields []string, terms []string, start, n int) (template.HTML, bool) {
+	end := len(fields)
+	if n >= 0 && start+n < end {
+		end = start + n
+	}
+
+	var b strings.Builder
+	matched := false
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		marked, ok := markField(fields[i], terms)
+		matched = matched || ok
+		b.WriteString(marked)
+	}
+	if end < len(fields) {
+		b.WriteString(" …")
+	}
+	return template.HTML(b.String()), matched
+}
+
+// markField HTML-escapes a whitespace-delimited field and wraps each word
+// in it that starts with a search term in <mark>.
+func markField(field string, terms []string) (string, bool) {
+	var b strings.Builder
+	matched := false
+	runes := []rune(field)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		if j == i {
+			b.WriteString(html.EscapeString(string(runes[i])))
+			i++
+			continue
+		}
+
+		word := string(runes[i:j])
+		if wordMatches(word, terms) {
+			matched = true
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+	return b.String(), matched
+}
+
+func wordMatches(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
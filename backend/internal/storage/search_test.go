@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"recipe-app/internal/models"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"", nil},
+		{"  Chicken   CURRY ", []string{"chicken", "curry"}},
+		{"the best of pasta", []string{"best", "pasta"}},
+		{"crème brûlée", []string{"crème", "brûlée"}},
+		{"beef & beef's", []string{"beef"}},
+		{"a:* | !b", []string{"b"}},
+		{"one two three four five six seven eight nine", []string{"one", "two", "three", "four", "five", "six", "seven", "eight"}},
+	}
+
+	for _, tt := range tests {
+		if got := searchTerms(tt.search); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %q, want %q", tt.search, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightRecipe(t *testing.T) {
+	recipe := models.Recipe{
+		Title:       "Tomato <Soup>",
+		Description: "A warm & simple soup.",
+		Ingredients: []models.Ingredient{{Name: "tomatoes"}, {Name: "basil"}},
+		Tags:        []string{"vegetarian"},
+	}
+
+	tests := []struct {
+		name    string
+		terms   []string
+		title   string
+		snippet string
+	}{
+		{"Escapes and marks", []string{"soup"}, "Tomato &lt;<mark>Soup</mark>&gt;", "A warm &amp; simple <mark>soup</mark>."},
+		{"Falls back to ingredients", []string{"basil"}, "Tomato &lt;Soup&gt;", "tomatoes, <mark>basil</mark>"},
+		{"Falls back to tags", []string{"veg"}, "Tomato &lt;Soup&gt;", "<mark>vegetarian</mark>"},
+		{"Prefix", []string{"tom"}, "<mark>Tomato</mark> &lt;Soup&gt;", "<mark>tomatoes</mark>, basil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := highlightRecipe(&recipe, tt.terms)
+			if string(got.Title) != tt.title {
+				t.Errorf("Title = %q, want %q", got.Title, tt.title)
+			}
+			if string(got.Snippet) != tt.snippet {
+				t.Errorf("Snippet = %q, want %q", got.Snippet, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestHighlightSnippetWindow(t *testing.T) {
+	text := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen " +
+		"seventeen eighteen nineteen twenty twentyone twentytwo twentythree twentyfour twentyfive twentysix target"
+
+	got, ok := highlightSnippet(text, []string{"target"})
+	if !ok {
+		t.Fatal("highlightSnippet() found no match")
+	}
+	want := "… twentyone twentytwo twentythree twentyfour twentyfive twentysix <mark>target</mark>"
+	if string(got) != want {
+		t.Errorf("highlightSnippet() = %q, want %q", got, want)
+	}
+}
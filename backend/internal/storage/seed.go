@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"recipe-app/internal/models"
+)
+
+// DemoRecipes returns the sample recipes used to populate demo mode.
+func DemoRecipes() []models.Recipe {
+	return []models.Recipe{
+		{
+			Title: "Spaghetti Bolognese", Description: "Classic Italian pasta dish with rich meat sauce",
+			PrepTime: 15, CookTime: 30, Servings: 4, Difficulty: "medium", Category: "dinner", Cuisine: "italian",
+			Ingredients: []models.Ingredient{
+				{Name: "spaghetti", Amount: "400", Unit: "g"},
+				{Name: "ground beef", Amount: "500", Unit: "g"},
+				{Name: "tomato sauce", Amount: "800", Unit: "ml"},
+				{Name: "onion", Amount: "1", Unit: "large"},
+				{Name: "garlic", Amount: "3", Unit: "cloves"},
+				{Name: "olive oil", Amount: "2", Unit: "tbsp"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Bring a large pot of salted water to boil and cook spaghetti according to package directions."},
+				{Text: "Heat olive oil in a large pan over medium heat. Add chopped onion and cook until translucent."},
+				{Text: "Add minced garlic and cook for another minute until fragrant."},
+				{Text: "Add ground beef and cook until browned, breaking it up with a wooden spoon."},
+				{Text: "Pour in tomato sauce and simmer for 15-20 minutes, stirring occasionally.", Duration: 20},
+				{Text: "Drain pasta and toss with the bolognese sauce. Serve hot with grated Parmesan cheese."},
+			},
+			Tags: []string{"pasta", "comfort food"},
+		},
+		{
+			Title: "Chicken Curry", Description: "Spicy and aromatic Indian curry with tender chicken",
+			PrepTime: 20, CookTime: 45, Servings: 4, Difficulty: "hard", Category: "dinner", Cuisine: "indian",
+			Ingredients: []models.Ingredient{
+				{Name: "chicken thighs", Amount: "600", Unit: "g"},
+				{Name: "onion", Amount: "2", Unit: "medium"},
+				{Name: "curry paste", Amount: "3", Unit: "tbsp"},
+				{Name: "coconut milk", Amount: "400", Unit: "ml"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Brown the chicken in batches and set aside."},
+				{Text: "Soften the onion, stir in the curry paste and cook until fragrant."},
+				{Text: "Return the chicken, add coconut milk and simmer until tender.", Duration: 30},
+			},
+			Tags: []string{"spicy"},
+		},
+		{
+			Title: "Caesar Salad", Description: "Fresh romaine lettuce with creamy Caesar dressing",
+			PrepTime: 15, CookTime: 15, Servings: 2, Difficulty: "easy", Category: "lunch", Cuisine: "american",
+			Ingredients: []models.Ingredient{
+				{Name: "romaine lettuce", Amount: "1", Unit: "head"},
+				{Name: "parmesan", Amount: "50", Unit: "g"},
+				{Name: "croutons", Amount: "1", Unit: "cup"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Tear the lettuce and toss with dressing."},
+				{Text: "Top with parmesan and croutons."},
+			},
+			Tags: []string{"salad", "vegetarian", "quick"},
+		},
+		{
+			Title: "Beef Tacos", Description: "Mexican-style tacos with seasoned ground beef",
+			PrepTime: 10, CookTime: 25, Servings: 4, Difficulty: "medium", Category: "dinner", Cuisine: "mexican",
+			Ingredients: []models.Ingredient{
+				{Name: "ground beef", Amount: "500", Unit: "g"},
+				{Name: "taco shells", Amount: "8"},
+				{Name: "cheddar", Amount: "100", Unit: "g"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Brown the beef with taco seasoning."},
+				{Text: "Fill the shells and top with cheese."},
+			},
+			Tags: []string{"quick"},
+		},
+		{
+			Title: "Chocolate Cake", Description: "Rich and moist chocolate cake with fudge frosting",
+			PrepTime: 25, CookTime: 60, Servings: 8, Difficulty: "hard", Category: "dessert", Cuisine: "american",
+			Ingredients: []models.Ingredient{
+				{Name: "flour", Amount: "2", Unit: "cups"},
+				{Name: "cocoa powder", Amount: "3/4", Unit: "cup"},
+				{Name: "sugar", Amount: "1 1/2", Unit: "cups"},
+				{Name: "eggs", Amount: "3"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Whisk the dry ingredients together."},
+				{Text: "Beat in the eggs and pour into a lined tin."},
+				{Text: "Bake until a skewer comes out clean.", Duration: 45, Temperature: 350, TemperatureUnit: "F"},
+			},
+			Tags: []string{"baking", "vegetarian"},
+		},
+		{
+			Title: "Greek Salad", Description: "Mediterranean salad with feta cheese and olives",
+			PrepTime: 10, CookTime: 10, Servings: 2, Difficulty: "easy", Category: "lunch", Cuisine: "greek",
+			Ingredients: []models.Ingredient{
+				{Name: "tomatoes", Amount: "3"},
+				{Name: "cucumber", Amount: "1"},
+				{Name: "feta", Amount: "200", Unit: "g"},
+				{Name: "olives", Amount: "1/2", Unit: "cup"},
+			},
+			Instructions: []models.Instruction{
+				{Text: "Chop the vegetables and combine with olives."},
+				{Text: "Crumble feta over the top and dress with olive oil."},
+			},
+			Tags: []string{"salad", "vegetarian", "quick"},
+		},
+	}
+}
+
+// SeedDemoRecipes inserts DemoRecipes into repo.
+func SeedDemoRecipes(ctx context.Context, repo RecipeRepository) error {
+	for _, recipe := range DemoRecipes() {
+		if err := repo.CreateRecipe(ctx, &recipe); err != nil {
+			return fmt.Errorf("failed to seed recipe %q: %w", recipe.Title, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const shoppingListColumns = `id, user_id, name, created_at, updated_at`
+
+func (db *DB) ListShoppingLists(ctx context.Context, userID string) ([]models.ShoppingList, error) {
+	if uuid.Validate(userID) != nil {
+		return []models.ShoppingList{}, nil
+	}
+
+	lists, err := scanShoppingLists(ctx, db,
+		"SELECT "+shoppingListColumns+" FROM shopping_lists WHERE user_id = $1 ORDER BY created_at DESC, id", userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadShoppingListItems(ctx, db, lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+func (db *DB) GetShoppingList(ctx context.Context, id string) (*models.ShoppingList, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+
+	lists, err := scanShoppingLists(ctx, db, "SELECT "+shoppingListColumns+" FROM shopping_lists WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, ErrNotFound
+	}
+	if err := loadShoppingListItems(ctx, db, lists); err != nil {
+		return nil, err
+	}
+	return &lists[0], nil
+}
+
+func (db *DB) CreateShoppingList(ctx context.Context, list *models.ShoppingList) error {
+	if uuid.Validate(list.UserID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO shopping_lists (user_id, name)
+			VALUES ($1, $2)
+			RETURNING id, created_at, updated_at`,
+			list.UserID, list.Name,
+		).Scan(&list.ID, &list.CreatedAt, &list.UpdatedAt)
+		if err != nil {
+			return referenceError(err, "failed to insert shopping list")
+		}
+
+		if list.Items == nil {
+			list.Items = []models.ShoppingListItem{}
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			item.ListID = list.ID
+			item.Position = i + 1
+			err := tx.QueryRowContext(ctx, `
+				INSERT INTO shopping_list_items (list_id, name, amount, unit, aisle, checked, position)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				RETURNING id`,
+				list.ID, item.Name, item.Amount, item.Unit, item.Aisle, item.Checked, item.Position,
+			).Scan(&item.ID)
+			if err != nil {
+				return fmt.Errorf("failed to insert shopping list item: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (db *DB) SetShoppingListItemChecked(ctx context.Context, listID, itemID string, checked bool) error {
+	if uuid.Validate(listID) != nil || uuid.Validate(itemID) != nil {
+		return ErrNotFound
+	}
+
+	return db.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"UPDATE shopping_list_items SET checked = $3 WHERE id = $2 AND list_id = $1", listID, itemID, checked)
+		if err != nil {
+			return fmt.Errorf("failed to check shopping list item: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return ErrNotFound
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE shopping_lists SET updated_at = NOW() WHERE id = $1", listID); err != nil {
+			return fmt.Errorf("failed to update shopping list: %w", err)
+		}
+		return nil
+	})
+}
+
+func (db *DB) DeleteShoppingList(ctx context.Context, id string) error {
+	if uuid.Validate(id) != nil {
+		return ErrNotFound
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM shopping_lists WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete shopping list: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanShoppingLists(ctx context.Context, q queryer, query string, args ...interface{}) ([]models.ShoppingList, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shopping lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []models.ShoppingList{}
+	for rows.Next() {
+		var l models.ShoppingList
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Name, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shopping list: %w", err)
+		}
+		lists = append(lists, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read shopping lists: %w", err)
+	}
+	return lists, nil
+}
+
+// loadShoppingListItems fills in the items of every list in one query.
+func loadShoppingListItems(ctx context.Context, q queryer, lists []models.ShoppingList) error {
+	if len(lists) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(lists))
+	byID := make(map[string]*models.ShoppingList, len(lists))
+	for i := range lists {
+		ids[i] = lists[i].ID
+		byID[lists[i].ID] = &lists[i]
+		lists[i].Items = []models.ShoppingListItem{}
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, list_id, name, amount, unit, aisle, checked, position FROM shopping_list_items
+		WHERE list_id = ANY($1::uuid[]) ORDER BY position, id`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to query shopping list items: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.ShoppingListItem
+		if err := rows.Scan(&item.ID, &item.ListID, &item.Name, &item.Amount, &item.Unit, &item.Aisle, &item.Checked, &item.Position); err != nil {
+			return fmt.Errorf("failed to scan shopping list item: %w", err)
+		}
+		list := byID[item.ListID]
+		list.Items = append(list.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read shopping list items: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// shoppingListStore is the subset of Store the shopping list suite needs to
+// create users.
+type shoppingListStore interface {
+	UserRepository
+	ShoppingListRepository
+}
+
+// testShoppingListRepository is the conformance suite for
+// ShoppingListRepository implementations. newRepo must return an empty
+// repository.
+func testShoppingListRepository(t *testing.T, newRepo func(t *testing.T) shoppingListStore) {
+	setup := func(t *testing.T) (shoppingListStore, string) {
+		t.Helper()
+		repo := newRepo(t)
+		user := models.User{Email: "shopper@example.com", Username: "shopper", Password: "hash"}
+		if err := repo.CreateUser(context.Background(), &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		return repo, user.ID
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo, userID := setup(t)
+		ctx := context.Background()
+
+		list := models.ShoppingList{
+			UserID: userID,
+			Name:   "Week 10",
+			Items: []models.ShoppingListItem{
+				{Name: "onions", Amount: "2", Aisle: "produce"},
+				{Name: "flour", Amount: "1 1/2", Unit: "cups", Aisle: "pantry", Checked: true},
+			},
+		}
+		if err := repo.CreateShoppingList(ctx, &list); err != nil {
+			t.Fatalf("CreateShoppingList() error = %v", err)
+		}
+		if list.ID == "" || list.CreatedAt.IsZero() || list.Items[1].ID == "" || list.Items[1].Position != 2 || list.Items[1].ListID != list.ID {
+			t.Errorf("CreateShoppingList() = %+v, want IDs, positions and timestamps", list)
+		}
+
+		got, err := repo.GetShoppingList(ctx, list.ID)
+		if err != nil {
+			t.Fatalf("GetShoppingList() error = %v", err)
+		}
+		if got.Name != "Week 10" || got.UserID != userID || len(got.Items) != 2 {
+			t.Fatalf("GetShoppingList() = %+v", got)
+		}
+		if flour := got.Items[1]; flour.Name != "flour" || flour.Amount != "1 1/2" || flour.Unit != "cups" || flour.Aisle != "pantry" || !flour.Checked {
+			t.Errorf("second item = %+v", flour)
+		}
+
+		empty := models.ShoppingList{UserID: userID, Name: "Empty"}
+		if err := repo.CreateShoppingList(ctx, &empty); err != nil {
+			t.Fatalf("CreateShoppingList() error = %v", err)
+		}
+		lists, err := repo.ListShoppingLists(ctx, userID)
+		if err != nil {
+			t.Fatalf("ListShoppingLists() error = %v", err)
+		}
+		if len(lists) != 2 || lists[0].ID != empty.ID || lists[0].Items == nil || len(lists[1].Items) != 2 {
+			t.Errorf("ListShoppingLists() = %+v, want the newest first with items", lists)
+		}
+		if lists, _ := repo.ListShoppingLists(ctx, uuid.NewString()); len(lists) != 0 {
+			t.Errorf("ListShoppingLists() for another user = %+v, want none", lists)
+		}
+
+		orphan := models.ShoppingList{UserID: uuid.NewString(), Name: "Nobody's"}
+		if err := repo.CreateShoppingList(ctx, &orphan); !errors.Is(err, ErrNotFound) {
+			t.Errorf("CreateShoppingList() for a missing user error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("CheckAndDelete", func(t *testing.T) {
+		repo, userID := setup(t)
+		ctx := context.Background()
+
+		list := models.ShoppingList{UserID: userID, Name: "Party", Items: []models.ShoppingListItem{{Name: "limes"}, {Name: "mint"}}}
+		other := models.ShoppingList{UserID: userID, Name: "Other", Items: []models.ShoppingListItem{{Name: "rum"}}}
+		for _, l := range []*models.ShoppingList{&list, &other} {
+			if err := repo.CreateShoppingList(ctx, l); err != nil {
+				t.Fatalf("CreateShoppingList() error = %v", err)
+			}
+		}
+
+		if err := repo.SetShoppingListItemChecked(ctx, list.ID, list.Items[1].ID, true); err != nil {
+			t.Fatalf("SetShoppingListItemChecked() error = %v", err)
+		}
+		got, _ := repo.GetShoppingList(ctx, list.ID)
+		if got.Items[0].Checked || !got.Items[1].Checked || !got.UpdatedAt.After(list.UpdatedAt) {
+			t.Errorf("after checking = %+v", got)
+		}
+		if err := repo.SetShoppingListItemChecked(ctx, list.ID, list.Items[1].ID, false); err != nil {
+			t.Fatalf("SetShoppingListItemChecked() error = %v", err)
+		}
+		if got, _ := repo.GetShoppingList(ctx, list.ID); got.Items[1].Checked {
+			t.Errorf("after unchecking = %+v", got.Items[1])
+		}
+
+		if err := repo.SetShoppingListItemChecked(ctx, list.ID, other.Items[0].ID, true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetShoppingListItemChecked() with another list's item error = %v, want ErrNotFound", err)
+		}
+
+		if err := repo.DeleteShoppingList(ctx, list.ID); err != nil {
+			t.Fatalf("DeleteShoppingList() error = %v", err)
+		}
+		if _, err := repo.GetShoppingList(ctx, list.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetShoppingList() after delete error = %v, want ErrNotFound", err)
+		}
+		if err := repo.DeleteShoppingList(ctx, list.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("DeleteShoppingList() twice error = %v, want ErrNotFound", err)
+		}
+		if err := repo.SetShoppingListItemChecked(ctx, list.ID, list.Items[0].ID, true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetShoppingListItemChecked() on a deleted list error = %v, want ErrNotFound", err)
+		}
+	})
+}
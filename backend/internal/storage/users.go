@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"recipe-app/internal/models"
+)
+
+const userColumns = `id, email, username, COALESCE(first_name, ''), COALESCE(last_name, ''),
+	password_hash, COALESCE(avatar_url, ''), is_admin, preferred_units, diets, avoid_allergens, created_at, updated_at`
+
+func (db *DB) CreateUser(ctx context.Context, user *models.User) error {
+	prepareUser(user)
+
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO users (email, username, first_name, last_name, password_hash, avatar_url, is_admin, preferred_units,
+			diets, avoid_allergens)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at`,
+		user.Email, user.Username, user.FirstName, user.LastName, user.Password, user.AvatarURL, user.IsAdmin, user.PreferredUnits,
+		pq.Array(user.Diets), pq.Array(user.AvoidAllergens),
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return userWriteError(err, "failed to insert user")
+	}
+	return nil
+}
+
+func (db *DB) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	if uuid.Validate(id) != nil {
+		return nil, ErrNotFound
+	}
+	return db.getUser(ctx, "id = $1", id)
+}
+
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return db.getUser(ctx, "email = $1", normalizeEmail(email))
+}
+
+func (db *DB) UpdateUser(ctx context.Context, user *models.User) error {
+	if uuid.Validate(user.ID) != nil {
+		return ErrNotFound
+	}
+	prepareUser(user)
+
+	err := db.QueryRowContext(ctx, `
+		UPDATE users
+		SET email = $2, username = $3, first_name = $4, last_name = $5, password_hash = $6,
+			avatar_url = $7, is_admin = $8, preferred_units = $9, diets = $10, avoid_allergens = $11, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at`,
+		user.ID, user.Email, user.Username, user.FirstName, user.LastName, user.Password, user.AvatarURL, user.IsAdmin,
+		user.PreferredUnits, pq.Array(user.Diets), pq.Array(user.AvoidAllergens),
+	).Scan(&user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return userWriteError(err, "failed to update user")
+	}
+	return nil
+}
+
+func (db *DB) getUser(ctx context.Context, cond string, arg interface{}) (*models.User, error) {
+	var u models.User
+	err := db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+cond, arg).Scan(
+		&u.ID, &u.Email, &u.Username, &u.FirstName, &u.LastName, &u.Password, &u.AvatarURL, &u.IsAdmin, &u.PreferredUnits,
+		pq.Array(&u.Diets), pq.Array(&u.AvoidAllergens), &u.CreatedAt, &u.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user: %w", err)
+	}
+	if u.Diets == nil {
+		u.Diets = []string{}
+	}
+	if u.AvoidAllergens == nil {
+		u.AvoidAllergens = []string{}
+	}
+	return &u, nil
+}
+
+// userWriteError maps unique constraint violations on users to
+// ErrEmailTaken and ErrUsernameTaken.
+func userWriteError(err error, msg string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		switch pqErr.Constraint {
+		case "users_email_key":
+			return ErrEmailTaken
+		case "users_username_key":
+			return ErrUsernameTaken
+		}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
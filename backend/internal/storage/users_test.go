@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"recipe-app/internal/models"
+)
+
+// testUserRepository is the conformance suite for UserRepository
+// implementations. newRepo must return an empty repository.
+func testUserRepository(t *testing.T, newRepo func(t *testing.T) UserRepository) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: " Ada@Example.com", Username: "ada", FirstName: "Ada", LastName: "Lovelace", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		if user.ID == "" || user.CreatedAt.IsZero() {
+			t.Fatalf("CreateUser() did not assign ID and timestamps: %+v", user)
+		}
+		if user.Email != "ada@example.com" {
+			t.Errorf("Email = %q, want it normalized", user.Email)
+		}
+
+		byID, err := repo.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if byID.Username != "ada" || byID.Password != "hash" || byID.FirstName != "Ada" {
+			t.Errorf("GetUserByID() = %+v", byID)
+		}
+
+		byEmail, err := repo.GetUserByEmail(ctx, "ADA@example.com ")
+		if err != nil {
+			t.Fatalf("GetUserByEmail() error = %v", err)
+		}
+		if byEmail.ID != user.ID {
+			t.Errorf("GetUserByEmail() ID = %s, want %s", byEmail.ID, user.ID)
+		}
+	})
+
+	t.Run("AdminFlag", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "chef@example.com", Username: "chef", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		user.IsAdmin = true
+		if err := repo.UpdateUser(ctx, &user); err != nil {
+			t.Fatalf("UpdateUser() error = %v", err)
+		}
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetUserByID() error = %v", err)
+		}
+		if !got.IsAdmin {
+			t.Error("IsAdmin was not persisted")
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		if _, err := repo.GetUserByID(ctx, uuid.NewString()); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUserByID() error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetUserByID(ctx, "not-a-uuid"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUserByID() error = %v, want ErrNotFound", err)
+		}
+		if _, err := repo.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetUserByEmail() error = %v, want ErrNotFound", err)
+		}
+		missing := models.User{ID: uuid.NewString(), Email: "x@example.com", Username: "x", Password: "hash"}
+		if err := repo.UpdateUser(ctx, &missing); !errors.Is(err, ErrNotFound) {
+			t.Errorf("UpdateUser() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		first := models.User{Email: "cook@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &first); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+
+		sameEmail := models.User{Email: "COOK@example.com", Username: "other", Password: "hash"}
+		if err := repo.CreateUser(ctx, &sameEmail); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("CreateUser() duplicate email error = %v, want ErrEmailTaken", err)
+		}
+
+		sameUsername := models.User{Email: "other@example.com", Username: "cook", Password: "hash"}
+		if err := repo.CreateUser(ctx, &sameUsername); !errors.Is(err, ErrUsernameTaken) {
+			t.Errorf("CreateUser() duplicate username error = %v, want ErrUsernameTaken", err)
+		}
+
+		second := models.User{Email: "second@example.com", Username: "second", Password: "hash"}
+		if err := repo.CreateUser(ctx, &second); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+		second.Email = first.Email
+		if err := repo.UpdateUser(ctx, &second); !errors.Is(err, ErrEmailTaken) {
+			t.Errorf("UpdateUser() duplicate email error = %v, want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := context.Background()
+
+		user := models.User{Email: "old@example.com", Username: "old", Password: "hash"}
+		if err := repo.CreateUser(ctx, &user); err != nil {
+			t.Fatalf("CreateUser() error = %v", err)
+		}
+
+		user.Email = "New@example.com"
+		user.FirstName = "Grace"
+		user.PreferredUnits = "metric"
+		if err := repo.UpdateUser(ctx, &user); err != nil {
+			t.Fatalf("UpdateUser() error = %v", err)
+		}
+
+		got, err := repo.GetUserByEmail(ctx, "new@example.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail() error = %v", err)
+		}
+		if got.FirstName != "Grace" || got.PreferredUnits != "metric" || got.ID != user.ID {
+			t.Errorf("GetUserByEmail() = %+v", got)
+		}
+	})
+}
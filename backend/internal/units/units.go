@@ -0,0 +1,269 @@
+// Package units canonicalizes the measuring units ingredients are written
+// with and converts amounts and temperatures between the metric and US
+// customary (imperial) systems.
+package units
+
+import (
+	"math"
+	"strings"
+
+	"recipe-app/internal/quantity"
+)
+
+// Measuring systems a recipe can be displayed in.
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+// ValidSystem reports whether system is Metric or Imperial.
+func ValidSystem(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+// Kind is what a unit measures.
+type Kind int
+
+const (
+	Mass Kind = iota
+	Volume
+)
+
+// Unit is a known measuring unit.
+type Unit struct {
+	// Symbol is the canonical spelling.
+	Symbol string
+	// Plural is used for amounts above one; empty for abbreviations.
+	Plural string
+	Kind   Kind
+	// System is Metric, Imperial or empty for spoons, which both systems
+	// measure with and which are never converted.
+	System string
+	// Factor is grams per unit for masses and millilitres for volumes.
+	Factor float64
+}
+
+// Label returns the unit's spelling for amount.
+func (u Unit) Label(amount float64) string {
+	if amount > 1 && u.Plural != "" {
+		return u.Plural
+	}
+	return u.Symbol
+}
+
+var (
+	gram       = Unit{Symbol: "g", Kind: Mass, System: Metric, Factor: 1}
+	kilogram   = Unit{Symbol: "kg", Kind: Mass, System: Metric, Factor: 1000}
+	milligram  = Unit{Symbol: "mg", Kind: Mass, System: Metric, Factor: 0.001}
+	ounce      = Unit{Symbol: "oz", Kind: Mass, System: Imperial, Factor: 28.3495}
+	pound      = Unit{Symbol: "lb", Kind: Mass, System: Imperial, Factor: 453.592}
+	millilitre = Unit{Symbol: "ml", Kind: Volume, System: Metric, Factor: 1}
+	centilitre = Unit{Symbol: "cl", Kind: Volume, System: Metric, Factor: 10}
+	decilitre  = Unit{Symbol: "dl", Kind: Volume, System: Metric, Factor: 100}
+	litre      = Unit{Symbol: "l", Kind: Volume, System: Metric, Factor: 1000}
+	teaspoon   = Unit{Symbol: "tsp", Kind: Volume, Factor: 4.92892}
+	tablespoon = Unit{Symbol: "tbsp", Kind: Volume, Factor: 14.7868}
+	fluidOunce = Unit{Symbol: "fl oz", Kind: Volume, System: Imperial, Factor: 29.5735}
+	cup        = Unit{Symbol: "cup", Plural: "cups", Kind: Volume, System: Imperial, Factor: 236.588}
+	pint       = Unit{Symbol: "pint", Plural: "pints", Kind: Volume, System: Imperial, Factor: 473.176}
+	quart      = Unit{Symbol: "quart", Plural: "quarts", Kind: Volume, System: Imperial, Factor: 946.353}
+	gallon     = Unit{Symbol: "gallon", Plural: "gallons", Kind: Volume, System: Imperial, Factor: 3785.41}
+)
+
+// spellings maps lowercase singular spellings to their unit. "T" and "t"
+// are matched case-sensitively by Lookup.
+var spellings = map[string]Unit{
+	"g": gram, "gr": gram, "gram": gram, "gramme": gram,
+	"kg": kilogram, "kilo": kilogram, "kilogram": kilogram, "kilogramme": kilogram,
+	"mg": milligram, "milligram": milligram, "milligramme": milligram,
+	"oz": ounce, "ounce": ounce,
+	"lb": pound, "pound": pound,
+	"ml": millilitre, "milliliter": millilitre, "millilitre": millilitre,
+	"cl": centilitre, "centiliter": centilitre, "centilitre": centilitre,
+	"dl": decilitre, "deciliter": decilitre, "decilitre": decilitre,
+	"l": litre, "liter": litre, "litre": litre,
+	"tsp": teaspoon, "teaspoon": teaspoon,
+	"tbsp": tablespoon, "tbs": tablespoon, "tbl": tablespoon, "tablespoon": tablespoon,
+	"fl oz": fluidOunce, "fluid ounce": fluidOunce,
+	"c": cup, "cup": cup,
+	"pt": pint, "pint": pint,
+	"qt": quart, "quart": quart,
+	"gal": gallon, "gallon": gallon,
+}
+
+// Lookup finds the unit for a spelling such as "Tablespoons", "tbsp." or
+// "T". Case is ignored except for "T" (tablespoon) and "t" (teaspoon).
+func Lookup(spelling string) (Unit, bool) {
+	spelling = strings.TrimSuffix(strings.TrimSpace(spelling), ".")
+	switch spelling {
+	case "T":
+		return tablespoon, true
+	case "t":
+		return teaspoon, true
+	}
+	name := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(spelling, ".", " "))), " ")
+	if u, ok := spellings[name]; ok {
+		return u, true
+	}
+	for _, suffix := range []string{"es", "s"} {
+		if u, ok := spellings[strings.TrimSuffix(name, suffix)]; ok && strings.HasSuffix(name, suffix) {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Canonical returns the canonical spelling of unit for amount, or unit
+// trimmed when it is not a known measuring unit.
+func Canonical(unit string, amount float64) string {
+	if u, ok := Lookup(unit); ok {
+		return u.Label(amount)
+	}
+	return strings.TrimSpace(unit)
+}
+
+// Convert converts amount from one unit to another. Converting between
+// mass and volume needs the ingredient's density in grams per millilitre;
+// it fails when gramsPerML is 0.
+func Convert(amount float64, from, to Unit, gramsPerML float64) (float64, bool) {
+	base := amount * from.Factor
+	switch {
+	case from.Kind == to.Kind:
+	case gramsPerML <= 0:
+		return 0, false
+	case from.Kind == Volume:
+		base *= gramsPerML
+	default:
+		base /= gramsPerML
+	}
+	return base / to.Factor, true
+}
+
+// Grams converts amount of unit to grams, using the ingredient's density
+// for volumes.
+func Grams(amount float64, unit Unit, gramsPerML float64) (float64, bool) {
+	return Convert(amount, unit, gram, gramsPerML)
+}
+
+// ToSystem converts amount of unit into the most readable unit of the same
+// kind in system. Amounts already in system, and spoons, are returned as
+// they are.
+func ToSystem(amount float64, unit Unit, system string) (float64, Unit) {
+	if unit.System == "" || unit.System == system {
+		return amount, unit
+	}
+	base := amount * unit.Factor
+	target := readable(base, unit.Kind, system)
+	return base / target.Factor, target
+}
+
+// readable returns the unit of kind in system that states base, in grams or
+// millilitres, most readably.
+func readable(base float64, kind Kind, system string) Unit {
+	switch {
+	case system == Metric && kind == Mass:
+		return pick(base, gram, kilogram)
+	case system == Metric:
+		return pick(base, millilitre, litre)
+	case kind == Mass:
+		return pick(base, ounce, pound)
+	case base >= cup.Factor/4:
+		return cup
+	default:
+		return pick(base, teaspoon, tablespoon)
+	}
+}
+
+// pick returns the larger unit when base amounts to at least one of it.
+func pick(base float64, smaller, larger Unit) Unit {
+	if base >= larger.Factor {
+		return larger
+	}
+	return smaller
+}
+
+// ConvertAmount converts a written amount, such as "1 1/2" or "2-3", of a
+// unit into system, formatted for that system: fractions for imperial and
+// decimals for metric. Given the ingredient's density in grams per
+// millilitre, volumes are weighed for metric and weights are measured out
+// in cups and spoons for imperial, the way each system's cooks measure;
+// with a gramsPerML of 0 the kind of unit is kept. It returns false when
+// the amount is not a number or the unit is not a known measuring unit,
+// leaving both to be kept as written.
+func ConvertAmount(amount, unit, system string, gramsPerML float64) (string, string, bool) {
+	u, ok := Lookup(unit)
+	if !ok {
+		return "", "", false
+	}
+	q, err := quantity.Parse(amount)
+	if err != nil {
+		return "", "", false
+	}
+
+	// Both ends of a range are stated in the unit that suits the larger.
+	max, target := ToSystem(q.Max, u, system)
+	if kind := measuredKind(system); target != u && kind != u.Kind && gramsPerML > 0 {
+		reference := gram
+		if kind == Volume {
+			reference = millilitre
+		}
+		base, _ := Convert(q.Max, u, reference, gramsPerML)
+		target = readable(base, kind, system)
+		max, _ = Convert(q.Max, u, target, gramsPerML)
+	}
+	min, _ := Convert(q.Min, u, target, gramsPerML)
+	if target == u {
+		return amount, u.Label(q.Max), true
+	}
+	format := quantity.Format
+	if target.System == Metric {
+		format = quantity.FormatDecimal
+	}
+	text := format(min)
+	if q.IsRange() && format(max) != text {
+		text += "-" + format(max)
+	}
+	return text, target.Label(max), true
+}
+
+// measuredKind returns the kind of unit cooks in system measure
+// ingredients in when they know their density: metric cooks weigh them
+// and imperial cooks use cups and spoons.
+func measuredKind(system string) Kind {
+	if system == Metric {
+		return Mass
+	}
+	return Volume
+}
+
+// Temperature scales.
+const (
+	Celsius    = "C"
+	Fahrenheit = "F"
+)
+
+// ValidTemperatureScale reports whether scale is Celsius or Fahrenheit.
+func ValidTemperatureScale(scale string) bool {
+	return scale == Celsius || scale == Fahrenheit
+}
+
+// TemperatureScale returns the scale a system states temperatures in.
+func TemperatureScale(system string) string {
+	if system == Imperial {
+		return Fahrenheit
+	}
+	return Celsius
+}
+
+// ConvertTemperature converts degrees between scales, rounded to the
+// nearest 5 degrees the way oven dials are marked.
+func ConvertTemperature(degrees int, from, to string) int {
+	if from == to || !ValidTemperatureScale(from) || !ValidTemperatureScale(to) {
+		return degrees
+	}
+	converted := float64(degrees-32) * 5 / 9
+	if to == Fahrenheit {
+		converted = float64(degrees)*9/5 + 32
+	}
+	return int(math.Round(converted/5) * 5)
+}
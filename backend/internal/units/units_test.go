@@ -0,0 +1,143 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		spelling string
+		want     string
+		ok       bool
+	}{
+		{"tbsp", "tbsp", true},
+		{"Tablespoons", "tbsp", true},
+		{"T", "tbsp", true},
+		{"Tbsp.", "tbsp", true},
+		{"t", "tsp", true},
+		{"teaspoons", "tsp", true},
+		{"Cups", "cup", true},
+		{"c.", "cup", true},
+		{"lbs", "lb", true},
+		{"ounces", "oz", true},
+		{"fl. oz.", "fl oz", true},
+		{"grams", "g", true},
+		{"Litres", "l", true},
+		{"cloves", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		u, ok := Lookup(tt.spelling)
+		if ok != tt.ok || u.Symbol != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q, %v", tt.spelling, u.Symbol, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		unit   string
+		amount float64
+		want   string
+	}{
+		{"cups", 1, "cup"},
+		{"cup", 1.5, "cups"},
+		{"Tablespoons", 3, "tbsp"},
+		{" large ", 2, "large"},
+	}
+	for _, tt := range tests {
+		if got := Canonical(tt.unit, tt.amount); got != tt.want {
+			t.Errorf("Canonical(%q, %v) = %q, want %q", tt.unit, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	if got, ok := Convert(2, pound, kilogram, 0); !ok || math.Abs(got-0.907184) > 1e-6 {
+		t.Errorf("Convert(2 lb, kg) = %v, %v", got, ok)
+	}
+	if got, ok := Convert(1, cup, gram, 0.53); !ok || math.Abs(got-125.39) > 0.01 {
+		t.Errorf("Convert(1 cup, g, 0.53) = %v, %v, want 125.39", got, ok)
+	}
+	if got, ok := Convert(100, gram, millilitre, 0.5); !ok || got != 200 {
+		t.Errorf("Convert(100 g, ml, 0.5) = %v, %v, want 200", got, ok)
+	}
+	if _, ok := Convert(1, cup, gram, 0); ok {
+		t.Error("Convert(1 cup, g) without a density succeeded")
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	tests := []struct {
+		amount, unit, system string
+		want                 string
+		ok                   bool
+	}{
+		{"500", "g", Imperial, "1 1/8 lb", true},
+		{"200", "g", Imperial, "7 oz", true},
+		{"800", "ml", Imperial, "3 3/8 cups", true},
+		{"30", "ml", Imperial, "2 tbsp", true},
+		{"5", "ml", Imperial, "1 tsp", true},
+		{"1 1/2", "cups", Metric, "355 ml", true},
+		{"5", "cups", Metric, "1.2 l", true},
+		{"8", "oz", Metric, "225 g", true},
+		{"2", "tbsp", Metric, "2 tbsp", true},
+		{"2", "cups", Imperial, "2 cups", true},
+		{"to taste", "g", Imperial, "", false},
+		{"3", "cloves", Metric, "", false},
+	}
+	for _, tt := range tests {
+		amount, unit, ok := ConvertAmount(tt.amount, tt.unit, tt.system, 0)
+		got := ""
+		if ok {
+			got = amount + " " + unit
+		}
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ConvertAmount(%q, %q, %s) = %q, %v, want %q, %v", tt.amount, tt.unit, tt.system, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestConvertAmount_Density(t *testing.T) {
+	tests := []struct {
+		amount, unit, system string
+		gramsPerML           float64
+		want                 string
+	}{
+		{"1", "cup", Metric, 0.53, "125 g"},
+		{"2-3", "cups", Metric, 0.53, "250-375 g"},
+		{"2", "quarts", Metric, 1.03, "1.9 kg"},
+		{"125", "g", Imperial, 0.53, "1 cup"},
+		{"30", "g", Imperial, 1, "2 tbsp"},
+		{"2", "tbsp", Metric, 0.53, "2 tbsp"},
+		{"1", "cup", Imperial, 0.53, "1 cup"},
+		{"100", "ml", Metric, 1.03, "100 ml"},
+	}
+	for _, tt := range tests {
+		amount, unit, ok := ConvertAmount(tt.amount, tt.unit, tt.system, tt.gramsPerML)
+		if got := amount + " " + unit; !ok || got != tt.want {
+			t.Errorf("ConvertAmount(%q, %q, %s, %v) = %q, %v, want %q", tt.amount, tt.unit, tt.system, tt.gramsPerML, got, ok, tt.want)
+		}
+	}
+}
+
+func TestConvertTemperature(t *testing.T) {
+	tests := []struct {
+		degrees  int
+		from, to string
+		want     int
+	}{
+		{350, Fahrenheit, Celsius, 175},
+		{425, Fahrenheit, Celsius, 220},
+		{180, Celsius, Fahrenheit, 355},
+		{200, Celsius, Fahrenheit, 390},
+		{200, Celsius, Celsius, 200},
+		{200, "", Celsius, 200},
+	}
+	for _, tt := range tests {
+		if got := ConvertTemperature(tt.degrees, tt.from, tt.to); got != tt.want {
+			t.Errorf("ConvertTemperature(%d, %q, %q) = %d, want %d", tt.degrees, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_recipes_search;
+DROP INDEX IF EXISTS idx_ratings_user_id;
+DROP INDEX IF EXISTS idx_ratings_recipe_id;
+DROP INDEX IF EXISTS idx_instructions_recipe_id;
+DROP INDEX IF EXISTS idx_ingredients_recipe_id;
+DROP INDEX IF EXISTS idx_recipes_created_at;
+DROP INDEX IF EXISTS idx_recipes_difficulty;
+DROP INDEX IF EXISTS idx_recipes_cuisine;
+DROP INDEX IF EXISTS idx_recipes_category;
+
+DROP TABLE IF EXISTS nutrition_info;
+DROP TABLE IF EXISTS ratings;
+DROP TABLE IF EXISTS collection_recipes;
+DROP TABLE IF EXISTS recipe_collections;
+DROP TABLE IF EXISTS recipe_tags;
+DROP TABLE IF EXISTS instructions;
+DROP TABLE IF EXISTS ingredients;
+DROP TABLE IF EXISTS recipes;
+DROP TABLE IF EXISTS users;
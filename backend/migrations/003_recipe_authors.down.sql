@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+
+DROP INDEX IF EXISTS idx_recipes_author_id;
+ALTER TABLE recipes DROP COLUMN IF EXISTS author_id;
@@ -0,0 +1,6 @@
+-- Recipes created before authorship was recorded have no author and can
+-- only be changed by admins.
+ALTER TABLE recipes ADD COLUMN author_id UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX idx_recipes_author_id ON recipes(author_id);
+
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_recipe_tags_search;
+DROP INDEX IF EXISTS idx_ingredients_search;
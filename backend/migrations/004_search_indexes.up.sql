@@ -0,0 +1,4 @@
+-- Full-text search also matches ingredient names and tags; these indexes
+-- back the per-term subqueries alongside idx_recipes_search.
+CREATE INDEX idx_ingredients_search ON ingredients USING gin(to_tsvector('english', name));
+CREATE INDEX idx_recipe_tags_search ON recipe_tags USING gin(to_tsvector('english', tag));
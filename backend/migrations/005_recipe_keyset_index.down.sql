@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_recipes_created_at_id;
@@ -0,0 +1,3 @@
+-- Backs keyset pagination over the newest/oldest orders, which break ties
+-- on id.
+CREATE INDEX idx_recipes_created_at_id ON recipes(created_at, id);
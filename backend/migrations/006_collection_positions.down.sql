@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_recipe_collections_user_id;
+DROP INDEX IF EXISTS idx_collection_recipes_recipe_id;
+ALTER TABLE collection_recipes DROP COLUMN IF EXISTS position;
@@ -0,0 +1,11 @@
+-- Collections keep their recipes in an explicit, 1-based order.
+ALTER TABLE collection_recipes ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+UPDATE collection_recipes c SET position = n.position
+FROM (
+    SELECT collection_id, recipe_id, ROW_NUMBER() OVER (PARTITION BY collection_id ORDER BY recipe_id) AS position
+    FROM collection_recipes
+) n
+WHERE c.collection_id = n.collection_id AND c.recipe_id = n.recipe_id;
+
+CREATE INDEX idx_collection_recipes_recipe_id ON collection_recipes(recipe_id);
+CREATE INDEX idx_recipe_collections_user_id ON recipe_collections(user_id);
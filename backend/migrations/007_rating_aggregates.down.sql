@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_ratings_recipe_updated_at;
+DROP INDEX IF EXISTS idx_recipes_rating;
+ALTER TABLE ratings DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE recipes DROP COLUMN IF EXISTS rating_average;
+ALTER TABLE recipes DROP COLUMN IF EXISTS rating_count;
@@ -0,0 +1,13 @@
+-- Each recipe caches its rating count and average so that listings never
+-- aggregate ratings per row. Both are rewritten whenever a rating changes.
+ALTER TABLE recipes ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE recipes ADD COLUMN rating_average DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE ratings ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+UPDATE ratings SET updated_at = created_at;
+
+UPDATE recipes r SET rating_count = a.count, rating_average = a.sum::float8 / a.count
+FROM (SELECT recipe_id, COUNT(*) AS count, SUM(score) AS sum FROM ratings GROUP BY recipe_id) a
+WHERE r.id = a.recipe_id;
+
+CREATE INDEX idx_recipes_rating ON recipes(rating_average DESC, rating_count DESC, created_at DESC, id DESC);
+CREATE INDEX idx_ratings_recipe_updated_at ON ratings(recipe_id, updated_at DESC, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE nutrition_info DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE nutrition_info DROP COLUMN IF EXISTS unmatched;
+ALTER TABLE nutrition_info DROP COLUMN IF EXISTS source;
@@ -0,0 +1,5 @@
+-- Nutrition facts are either entered by a recipe's author or computed from
+-- its ingredients. Rows that predate this are author-entered.
+ALTER TABLE nutrition_info ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'computed'));
+ALTER TABLE nutrition_info ADD COLUMN unmatched TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE nutrition_info ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS preferred_units;
+ALTER TABLE instructions DROP COLUMN IF EXISTS temperature_unit;
@@ -0,0 +1,8 @@
+-- Instruction temperatures record their scale. Existing temperatures were
+-- written without one; oven settings above 260 can only be Fahrenheit, and
+-- anything lower is taken to be Celsius.
+ALTER TABLE instructions ADD COLUMN temperature_unit CHAR(1) CHECK (temperature_unit IN ('C', 'F'));
+UPDATE instructions SET temperature_unit = CASE WHEN temperature > 260 THEN 'F' ELSE 'C' END WHERE temperature > 0;
+
+-- The measuring system a user reads recipes in; empty shows them as written.
+ALTER TABLE users ADD COLUMN preferred_units VARCHAR(10) NOT NULL DEFAULT '' CHECK (preferred_units IN ('', 'metric', 'imperial'));
@@ -0,0 +1 @@
+ALTER TABLE instructions DROP COLUMN IF EXISTS image_urls;
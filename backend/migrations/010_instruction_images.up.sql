@@ -0,0 +1,2 @@
+-- Photos of a step, as URLs of uploaded images in display order.
+ALTER TABLE instructions ADD COLUMN image_urls TEXT[] NOT NULL DEFAULT '{}';
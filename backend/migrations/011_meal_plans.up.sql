@@ -0,0 +1,15 @@
+-- Meal plans assign recipes to a meal slot of a day. A servings of 0 keeps
+-- the recipe's own servings.
+CREATE TABLE meal_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    plan_date DATE NOT NULL,
+    slot VARCHAR(20) NOT NULL CHECK (slot IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    recipe_id UUID NOT NULL REFERENCES recipes(id) ON DELETE CASCADE,
+    servings INTEGER NOT NULL DEFAULT 0 CHECK (servings >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, plan_date, slot, recipe_id)
+);
+
+CREATE INDEX idx_meal_plans_recipe_id ON meal_plans(recipe_id);
@@ -0,0 +1,24 @@
+-- Shopping lists are generated from recipes and meal plans and keep the
+-- merged ingredients with their check-off state.
+CREATE TABLE shopping_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_shopping_lists_user_id ON shopping_lists(user_id);
+
+CREATE TABLE shopping_list_items (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    list_id UUID NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    amount VARCHAR(50) NOT NULL DEFAULT '',
+    unit VARCHAR(50) NOT NULL DEFAULT '',
+    aisle VARCHAR(50) NOT NULL DEFAULT '',
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL
+);
+
+CREATE INDEX idx_shopping_list_items_list_id ON shopping_list_items(list_id, position);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_ingredients_name_phrases;
+ALTER TABLE ingredients DROP COLUMN IF EXISTS name_phrases;
+DROP FUNCTION IF EXISTS ingredient_name_phrases(TEXT);
+DROP TABLE IF EXISTS pantry_items;
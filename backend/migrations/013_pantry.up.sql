@@ -0,0 +1,39 @@
+-- A user's pantry is the ingredients they have on hand, keyed by name as
+-- models.NormalizeIngredientName writes it.
+CREATE TABLE pantry_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, name)
+);
+
+-- ingredient_name_phrases mirrors models.IngredientPhrases: every run of
+-- consecutive words in a name that is lowercased, split on anything but
+-- letters and digits, and put in the singular a word at a time.
+CREATE FUNCTION ingredient_name_phrases(name TEXT) RETURNS TEXT[]
+LANGUAGE SQL IMMUTABLE PARALLEL SAFE AS $$
+    WITH normalized AS (
+        SELECT ARRAY(
+            SELECT CASE
+                WHEN length(w) > 4 AND w LIKE '%ies' THEN left(w, -3) || 'y'
+                WHEN w ~ '(oes|ches|shes|sses|xes)$' THEN left(w, -2)
+                WHEN length(w) > 3 AND w LIKE '%s' AND w NOT LIKE '%ss' THEN left(w, -1)
+                ELSE w
+            END
+            FROM regexp_split_to_table(lower(name), '[^[:alnum:]]+') WITH ORDINALITY AS t(w, n)
+            WHERE w <> ''
+            ORDER BY n
+        ) AS words
+    )
+    SELECT ARRAY(
+        SELECT array_to_string(words[i:j], ' ')
+        FROM generate_series(1, cardinality(words)) AS i, generate_series(i, cardinality(words)) AS j
+        ORDER BY i, j
+    )
+    FROM normalized
+$$;
+
+-- Pantry searches match pantry names against these phrases; the index
+-- finds the recipes using any of them without scanning every ingredient.
+ALTER TABLE ingredients ADD COLUMN name_phrases TEXT[] GENERATED ALWAYS AS (ingredient_name_phrases(name)) STORED;
+CREATE INDEX idx_ingredients_name_phrases ON ingredients USING gin(name_phrases);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_ingredients_catalog_id;
+ALTER TABLE ingredients DROP COLUMN IF EXISTS catalog_match, DROP COLUMN IF EXISTS catalog_id;
+DROP TABLE IF EXISTS ingredient_catalog_terms;
+DROP TABLE IF EXISTS ingredient_catalog;
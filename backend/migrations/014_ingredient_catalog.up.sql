@@ -0,0 +1,30 @@
+-- The ingredient catalog holds canonical ingredients that recipes'
+-- free-text ingredients are linked to, with the plural and synonyms they
+-- are also written as.
+CREATE TABLE ingredient_catalog (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    plural VARCHAR(255) NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL DEFAULT '',
+    synonyms TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Every normalized name, plural and synonym of a catalog entry, as
+-- models.CatalogIngredient.Terms returns them. A term names one entry.
+CREATE TABLE ingredient_catalog_terms (
+    term VARCHAR(255) PRIMARY KEY,
+    catalog_id UUID NOT NULL REFERENCES ingredient_catalog(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_ingredient_catalog_terms_catalog_id ON ingredient_catalog_terms(catalog_id);
+
+-- catalog_match is 'auto' for links the matcher made, 'manual' for links
+-- made by hand, including ingredients left unlinked by hand, and NULL for
+-- ingredients the matcher found no entry for.
+ALTER TABLE ingredients
+    ADD COLUMN catalog_id UUID REFERENCES ingredient_catalog(id) ON DELETE SET NULL,
+    ADD COLUMN catalog_match VARCHAR(10) CHECK (catalog_match IN ('auto', 'manual'));
+
+CREATE INDEX idx_ingredients_catalog_id ON ingredients(catalog_id);
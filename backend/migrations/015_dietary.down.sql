@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS avoid_allergens, DROP COLUMN IF EXISTS diets;
+DROP INDEX IF EXISTS idx_recipes_allergens;
+DROP INDEX IF EXISTS idx_recipes_diets;
+ALTER TABLE recipes DROP COLUMN IF EXISTS dietary_complete, DROP COLUMN IF EXISTS allergens, DROP COLUMN IF EXISTS diets;
+ALTER TABLE ingredient_catalog DROP COLUMN IF EXISTS diets, DROP COLUMN IF EXISTS allergens;
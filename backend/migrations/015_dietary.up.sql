@@ -0,0 +1,28 @@
+-- Catalog entries are tagged with the allergens they contain and the diets
+-- they fit, as listed in models.Allergens and models.Diets.
+ALTER TABLE ingredient_catalog
+    ADD COLUMN allergens TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN diets TEXT[] NOT NULL DEFAULT '{}';
+
+-- A recipe's dietary labels are derived from its ingredients' catalog
+-- entries and kept up to date by the store; see models.Dietary.
+-- dietary_complete is set when the recipe has ingredients and all of them
+-- are linked.
+ALTER TABLE recipes
+    ADD COLUMN diets TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN allergens TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN dietary_complete BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_recipes_diets ON recipes USING GIN (diets);
+CREATE INDEX idx_recipes_allergens ON recipes USING GIN (allergens);
+
+-- No entry is tagged yet, so existing recipes only need their completeness.
+UPDATE recipes r SET dietary_complete = TRUE
+WHERE EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id)
+    AND NOT EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id AND i.catalog_id IS NULL);
+
+-- The diets a user follows and the allergens they avoid, applied to their
+-- recipe searches by default.
+ALTER TABLE users
+    ADD COLUMN diets TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN avoid_allergens TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,5 @@
+ALTER TABLE ingredient_catalog DROP COLUMN IF EXISTS allergens_reviewed;
+
+UPDATE recipes r SET dietary_complete = TRUE
+WHERE EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id)
+    AND NOT EXISTS (SELECT 1 FROM ingredients i WHERE i.recipe_id = r.id AND i.catalog_id IS NULL);
@@ -0,0 +1,7 @@
+-- An entry's allergens are only trusted once an admin has reviewed them, so
+-- that an entry nobody has tagged yet is not taken to be allergen-free.
+ALTER TABLE ingredient_catalog ADD COLUMN allergens_reviewed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- No entry has been reviewed yet, so no recipe can vouch for the allergens
+-- it lacks.
+UPDATE recipes SET dietary_complete = FALSE WHERE dietary_complete;
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations so they ship inside
+// the server binary.
+package migrations
+
+import "embed"
+
+// Files holds every NNN_name.up.sql and NNN_name.down.sql migration.
+//
+//go:embed *.sql
+var Files embed.FS